@@ -0,0 +1,79 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package announcements lets everyone who cares know about new Messages no matter
+// where they were created from, whether that's the API or one of the chat bridges.
+package announcements
+
+import (
+	"context"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	log "github.com/sirupsen/logrus"
+)
+
+// Store defines the data the Announcer needs.
+type Store interface {
+	GetCharactersInChannel(context.Context, int) (characters.CharacterCollection, error)
+}
+
+// Announcer tells the owners of mentioned Characters about new Messages and sends
+// out the message.created Event. A nil Announcer is valid and announces nothing.
+type Announcer struct {
+	store      Store
+	notifier   *notifications.Notifier
+	dispatcher *webhooks.Dispatcher
+}
+
+// NewAnnouncer creates an Announcer. Either the notifier or the dispatcher can be
+// nil if they aren't being used.
+func NewAnnouncer(store Store, notifier *notifications.Notifier, dispatcher *webhooks.Dispatcher) *Announcer {
+	return &Announcer{
+		store:      store,
+		notifier:   notifier,
+		dispatcher: dispatcher,
+	}
+}
+
+// MessageCreated announces the Message the author just created in the Channel.
+// The Message was already created so failures here are only logged.
+func (a *Announcer) MessageCreated(ctx context.Context, channel *channels.Channel, author *characters.Character, message *messages.Message) {
+	if a == nil {
+		return
+	}
+
+	a.notifyMentions(ctx, channel, author, message)
+	a.dispatcher.Dispatch(channel.ID, webhooks.MessageCreatedEvent, message)
+}
+
+// notifyMentions lets the owners of any Characters mentioned in the Message know
+// about it.
+func (a *Announcer) notifyMentions(ctx context.Context, channel *channels.Channel, author *characters.Character, message *messages.Message) {
+	if a.notifier == nil {
+		return
+	}
+
+	charactersInChannel, err := a.store.GetCharactersInChannel(ctx, channel.ID)
+	if err != nil {
+		log.WithError(err).WithField("channelID", channel.ID).Error("Failed to look up characters to check for mentions.")
+		return
+	}
+
+	for _, mentioned := range notifications.FindMentions(message.Content, charactersInChannel) {
+		// Users don't need to hear about mentioning themselves
+		if mentioned.UserID == author.UserID {
+			continue
+		}
+
+		a.notifier.Notify(notifications.Notification{
+			Kind:        notifications.MentionKind,
+			UserID:      mentioned.UserID,
+			ChannelID:   channel.ID,
+			ChannelName: channel.Name,
+			Text:        message.Content,
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package apierrors defines the JSON error envelope that every error response
+// uses along with the mapping between sentinel errors, status codes, and the
+// stable error codes that clients can rely on.
+package apierrors
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/bots"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+)
+
+// Error codes that aren't tied to a sentinel error.
+const (
+	BadRequestCode       = "bad_request"
+	UnauthorizedCode     = "unauthorized"
+	ForbiddenCode        = "forbidden"
+	NotFoundCode         = "not_found"
+	ValidationFailedCode = "validation_failed"
+	InternalErrorCode    = "internal_error"
+)
+
+// Errors for requests whose conditional headers don't hold.
+var (
+	// ErrPreconditionFailed is for an If-Match that doesn't match the current version
+	ErrPreconditionFailed = fmt.Errorf("the resource has changed since it was retrieved")
+
+	// ErrPreconditionRequired is for a change without an If-Match when one is required
+	ErrPreconditionRequired = fmt.Errorf("the If-Match header is required")
+)
+
+// ErrRestoreWindowExpired is for restoring something that was deleted longer ago
+// than the retention window.
+var ErrRestoreWindowExpired = fmt.Errorf("the window to restore this has passed")
+
+// internalErrorMessage is all clients see for server errors so internals don't leak.
+const internalErrorMessage = "internal server error"
+
+// Response is the body of every error response.
+type Response struct {
+	Error Error `json:"Error"`
+}
+
+// Error describes what went wrong.
+type Error struct {
+	Code      string       `json:"Code"`
+	Message   string       `json:"Message"`
+	RequestID string       `json:"RequestID"`
+	Fields    []FieldError `json:"Fields,omitempty"`
+}
+
+// FieldError describes a problem with a single field in the request body.
+type FieldError struct {
+	Field   string `json:"Field"`
+	Message string `json:"Message"`
+}
+
+// FieldErrors is an error made up of problems with individual fields.
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, f := range fe {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return strings.Join(msgs, ", ")
+}
+
+// mapping is the status code and error code to use for a sentinel error.
+type mapping struct {
+	status int
+	code   string
+}
+
+var sentinels = map[error]mapping{
+	bans.ErrBanNotFound:                    {http.StatusNotFound, "ban_not_found"},
+	bans.ErrBanNotActive:                   {http.StatusConflict, "ban_not_active"},
+	bans.ErrBannedFromChannel:              {http.StatusForbidden, "banned_from_channel"},
+	bots.ErrBotNotFound:                    {http.StatusNotFound, "bot_not_found"},
+	bots.ErrNotBotOwner:                    {http.StatusForbidden, "not_bot_owner"},
+	channels.ErrChannelNotFound:            {http.StatusNotFound, "channel_not_found"},
+	characters.ErrCharacterNotFound:        {http.StatusNotFound, "character_not_found"},
+	impersonations.ErrNotImpersonating:     {http.StatusConflict, "not_impersonating"},
+	impersonations.ErrAlreadyImpersonating: {http.StatusConflict, "already_impersonating"},
+	impersonations.ErrReadOnly:             {http.StatusForbidden, "impersonation_read_only"},
+	messages.ErrMessageNotFound:            {http.StatusNotFound, "message_not_found"},
+	ratelimit.ErrRateLimited:               {http.StatusTooManyRequests, "rate_limited"},
+	reports.ErrReportNotFound:              {http.StatusNotFound, "report_not_found"},
+	reports.ErrReportClosed:                {http.StatusConflict, "report_closed"},
+	tokens.ErrTokenNotFound:                {http.StatusNotFound, "token_not_found"},
+	users.ErrUserNotFound:                  {http.StatusNotFound, "user_not_found"},
+	users.ErrUserIsAdmin:                   {http.StatusForbidden, "user_is_admin"},
+	webhooks.ErrWebhookNotFound:            {http.StatusNotFound, "webhook_not_found"},
+	webhooks.ErrUnknownEvent:               {http.StatusBadRequest, "unknown_webhook_event"},
+	ErrPreconditionFailed:                  {http.StatusPreconditionFailed, "etag_mismatch"},
+	ErrPreconditionRequired:                {http.StatusPreconditionRequired, "if_match_required"},
+	ErrRestoreWindowExpired:                {http.StatusGone, "restore_window_expired"},
+}
+
+// lookup finds the mapping for a sentinel error. Errors that can't be map keys,
+// such as FieldErrors, are never sentinels.
+func lookup(err error) (mapping, bool) {
+	if err == nil || !reflect.TypeOf(err).Comparable() {
+		return mapping{}, false
+	}
+	m, ok := sentinels[err]
+	return m, ok
+}
+
+// StatusFor is the status code to respond with for the error. Sentinel errors
+// have their own status codes, validation failures are bad requests, and
+// anything else is a server error.
+func StatusFor(err error) int {
+	if m, ok := lookup(err); ok {
+		return m.status
+	}
+	if _, ok := err.(FieldErrors); ok {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// FromCode finds the sentinel error for the error code so clients can get back
+// the same errors the API uses. Returns nil if there isn't one.
+func FromCode(code string) error {
+	for err, m := range sentinels {
+		if m.code == code {
+			return err
+		}
+	}
+	return nil
+}
+
+// New creates the Error for the response status and the error the handler
+// aborted with, which can be nil. Server errors never include the details.
+func New(status int, err error, requestID string) Error {
+	apiErr := Error{
+		Code:      codeForStatus(status),
+		Message:   strings.ToLower(http.StatusText(status)),
+		RequestID: requestID,
+	}
+
+	if status >= http.StatusInternalServerError {
+		apiErr.Code = InternalErrorCode
+		apiErr.Message = internalErrorMessage
+		return apiErr
+	}
+
+	if err == nil {
+		return apiErr
+	}
+
+	apiErr.Message = err.Error()
+	if m, ok := lookup(err); ok {
+		apiErr.Code = m.code
+	}
+	if fields, ok := err.(FieldErrors); ok {
+		apiErr.Code = ValidationFailedCode
+		apiErr.Message = "request body failed validation"
+		apiErr.Fields = fields
+	}
+
+	return apiErr
+}
+
+// codeForStatus is the error code to use when there isn't a more specific one.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequestCode
+	case http.StatusUnauthorized:
+		return UnauthorizedCode
+	case http.StatusForbidden:
+		return ForbiddenCode
+	case http.StatusNotFound:
+		return NotFoundCode
+	}
+
+	if status >= http.StatusInternalServerError {
+		return InternalErrorCode
+	}
+	return strings.Replace(strings.ToLower(http.StatusText(status)), " ", "_", -1)
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TargetType is the kind of thing an Entry is about.
+type TargetType string
+
+// The kinds of things actions are recorded for.
+const (
+	ChannelTarget   TargetType = "channel"
+	MessageTarget   TargetType = "message"
+	CharacterTarget TargetType = "character"
+	UserTarget      TargetType = "user"
+	BanTarget       TargetType = "ban"
+	ReportTarget    TargetType = "report"
+
+	ImpersonationTarget TargetType = "impersonation"
+)
+
+// AllTargetTypes are all of the kinds of things actions are recorded for.
+var AllTargetTypes = []TargetType{
+	ChannelTarget,
+	MessageTarget,
+	CharacterTarget,
+	UserTarget,
+	BanTarget,
+	ReportTarget,
+	ImpersonationTarget,
+}
+
+// Action is what was done to the target. They're named the same way as the
+// Webhook events.
+type Action string
+
+// The Actions that are recorded.
+const (
+	ChannelUpdated    Action = "channel.updated"
+	ChannelDeleted    Action = "channel.deleted"
+	ChannelRestored   Action = "channel.restored"
+	MessageUpdated    Action = "message.updated"
+	MessageDeleted    Action = "message.deleted"
+	MessageRestored   Action = "message.restored"
+	CharacterUpdated  Action = "character.updated"
+	CharacterDeleted  Action = "character.deleted"
+	CharacterRestored Action = "character.restored"
+	UserUpdated       Action = "user.updated"
+	UserDeleted       Action = "user.deleted"
+	BanCreated        Action = "ban.created"
+	BanLifted         Action = "ban.lifted"
+	ReportAssigned    Action = "report.assigned"
+	ReportResolved    Action = "report.resolved"
+	ReportDismissed   Action = "report.dismissed"
+
+	ImpersonationStarted Action = "impersonation.started"
+	ImpersonationEnded   Action = "impersonation.ended"
+	// ImpersonationRequest is recorded for every request made while impersonating.
+	ImpersonationRequest Action = "impersonation.request"
+)
+
+// Entry is a record of a User changing something that wasn't only theirs to
+// change, like an admin updating another User or a Channel owner deleting the
+// Channel. Before and After are JSON snapshots of the target on either side of
+// the change and are left out when there's nothing on that side. ImpersonatingID
+// is the User the actor was impersonating at the time, if any. Entries are never
+// changed or deleted once they're created.
+type Entry struct {
+	ID              int             `json:"ID" db:"id"`
+	ActorID         int             `json:"ActorID" db:"actor_id"`
+	ImpersonatingID int             `json:"ImpersonatingID" db:"impersonating_id"`
+	Action          Action          `json:"Action" db:"action"`
+	TargetType      TargetType      `json:"TargetType" db:"target_type"`
+	TargetID        int             `json:"TargetID" db:"target_id"`
+	Before          json.RawMessage `json:"Before,omitempty" db:"before"`
+	After           json.RawMessage `json:"After,omitempty" db:"after"`
+	CreatedOn       time.Time       `json:"CreatedOn" db:"created_on"`
+}
+
+// EntryCollection is a collection of Entries.
+type EntryCollection []*Entry
+
+// Filter limits which Entries are retrieved. Empty fields don't filter anything.
+// Since and Until bound when the Entries were created and Limit caps how many
+// come back.
+type Filter struct {
+	ActorID    int
+	Action     Action
+	TargetType TargetType
+	TargetID   int
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+}
+
+// IsValidTargetType determines if actions are recorded for the TargetType.
+func IsValidTargetType(t TargetType) bool {
+	for _, valid := range AllTargetTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
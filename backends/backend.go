@@ -3,13 +3,24 @@
 package backends
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/bots"
 	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
 	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/stats"
+	"github.com/andrew-boutin/dndtextapi/tokens"
 	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
 
 	"github.com/andrew-boutin/dndtextapi/backends/postgresql"
 	"github.com/andrew-boutin/dndtextapi/channels"
@@ -18,45 +29,118 @@ import (
 	_ "github.com/lib/pq"
 )
 
-// Backend defines the functionality expected of a backend.
+// Backend defines the functionality expected of a backend. Updates and deletes
+// that take a time only change the row if it was last updated at that time, and
+// return apierrors.ErrPreconditionFailed if it wasn't. A nil time changes it
+// regardless.
 type Backend interface {
+	// Lifecycle functionality
+	HealthCheck(context.Context) error
+	Close() error
+
 	// Channels functionality
-	GetChannel(int) (*channels.Channel, error)
-	GetChannelsOwnedByUser(int) (channels.ChannelCollection, error)
-	GetChannelsUserHasCharacterIn(int, *bool) (channels.ChannelCollection, error)
-	GetAllChannels(*bool) (channels.ChannelCollection, error)
-	CreateChannel(*channels.Channel, int) (*channels.Channel, error)
-	DeleteChannel(int) error
-	UpdateChannel(int, *channels.Channel) (*channels.Channel, error)
+	GetChannel(context.Context, int) (*channels.Channel, error)
+	GetChannelsOwnedByUser(context.Context, int) (channels.ChannelCollection, error)
+	GetChannelsUserHasCharacterIn(context.Context, int, *bool) (channels.ChannelCollection, error)
+	GetAllChannels(context.Context, *bool) (channels.ChannelCollection, error)
+	GetChannels(context.Context, channels.Filter) (channels.ChannelCollection, error)
+	CreateChannel(context.Context, *channels.Channel, int) (*channels.Channel, error)
+	DeleteChannel(context.Context, int, *time.Time) error
+	UpdateChannel(context.Context, int, *channels.Channel, *time.Time) (*channels.Channel, error)
+	GetChannelByBotChannel(context.Context, int, string) (*channels.Channel, error)
+	GetChannelsForBot(context.Context, int) (channels.ChannelCollection, error)
+	RemoveBotFromChannels(context.Context, int) error
+	GetDeletedChannel(context.Context, int) (*channels.Channel, error)
+	GetDeletedChannels(context.Context) (channels.ChannelCollection, error)
+	RestoreChannel(context.Context, int) (*channels.Channel, error)
 
 	// Messages functionality
-	GetMessagesInChannel(int, *bool) (messages.MessageCollection, error)
-	GetMessage(int) (*messages.Message, error)
-	CreateMessage(*messages.Message) (*messages.Message, error)
-	DeleteMessage(int) error
-	UpdateMessage(int, *messages.Message) (*messages.Message, error)
-	DeleteMessagesFromUser(int) error
-	DeleteMessagesFromChannel(int) error
-	DeleteMessagesFromCharacter(int) error
+	GetMessagesInChannel(context.Context, int, *bool, *messages.Page) (messages.MessageCollection, error)
+	GetMessage(context.Context, int) (*messages.Message, error)
+	CreateMessage(context.Context, *messages.Message) (*messages.Message, error)
+	DeleteMessage(context.Context, int, *time.Time) error
+	UpdateMessage(context.Context, int, *messages.Message, *time.Time) (*messages.Message, error)
+	GetDeletedMessage(context.Context, int) (*messages.Message, error)
+	GetDeletedMessagesInChannel(context.Context, int) (messages.MessageCollection, error)
+	RestoreMessage(context.Context, int) (*messages.Message, error)
 
 	// Users functionality
-	UpdateUser(int, *users.User) (*users.User, error)
-	DeleteUser(int) error
-	GetUserByEmail(string) (*users.User, error)
-	GetUserByID(int) (*users.User, error)
-	CreateUser(*users.GoogleUser) (*users.User, error)
-	GetAllUsers() (users.UserCollection, error)
-	UpdateUserLastLogin(*users.User) (*users.User, error)
+	UpdateUser(context.Context, int, *users.User, *time.Time) (*users.User, error)
+	DeleteUser(context.Context, int, *time.Time) error
+	GetUserByEmail(context.Context, string) (*users.User, error)
+	GetUserByID(context.Context, int) (*users.User, error)
+	CreateUser(context.Context, *users.GoogleUser) (*users.User, error)
+	GetUsers(context.Context, users.Filter) (users.UserCollection, error)
+	UpdateUserLastLogin(context.Context, *users.User) (*users.User, error)
 
 	// Characters functionality
-	DoesUserHaveCharacterInChannel(int, int) (bool, error)
-	GetCharactersInChannel(channelID int) (characters.CharacterCollection, error)
-	GetCharacter(int) (*characters.Character, error)
-	CreateCharacter(*characters.Character) (*characters.Character, error)
-	DeleteCharacter(int) error
-	UpdateCharacter(int, *characters.Character) (*characters.Character, error)
-	DeleteCharactersFromUser(int) error
-	DeleteCharactersFromChannel(int) error
+	DoesUserHaveCharacterInChannel(context.Context, int, int) (bool, error)
+	GetCharactersInChannel(context.Context, int) (characters.CharacterCollection, error)
+	GetCharacter(context.Context, int) (*characters.Character, error)
+	CreateCharacter(context.Context, *characters.Character) (*characters.Character, error)
+	DeleteCharacter(context.Context, int, *time.Time) error
+	UpdateCharacter(context.Context, int, *characters.Character, *time.Time) (*characters.Character, error)
+	GetCharacterByBotUsername(context.Context, int, string) (*characters.Character, error)
+	GetDeletedCharacter(context.Context, int) (*characters.Character, error)
+	GetDeletedCharactersInChannel(context.Context, int) (characters.CharacterCollection, error)
+	RestoreCharacter(context.Context, int) (*characters.Character, error)
+
+	// Retention functionality
+	PurgeDeleted(context.Context, time.Time) error
+
+	// Notifications functionality
+	GetNotificationPreferences(context.Context, int) (*notifications.Preferences, error)
+	UpdateNotificationPreferences(context.Context, int, *notifications.Preferences, *time.Time) (*notifications.Preferences, error)
+
+	// Webhooks functionality
+	GetWebhooksInChannel(context.Context, int) (webhooks.WebhookCollection, error)
+	GetWebhook(context.Context, int) (*webhooks.Webhook, error)
+	CreateWebhook(context.Context, *webhooks.Webhook) (*webhooks.Webhook, error)
+	UpdateWebhook(context.Context, int, *webhooks.Webhook, *time.Time) (*webhooks.Webhook, error)
+	DeleteWebhook(context.Context, int, *time.Time) error
+	GetWebhookDeliveries(context.Context, int) (webhooks.DeliveryCollection, error)
+	CreateWebhookDelivery(context.Context, *webhooks.Delivery) (*webhooks.Delivery, error)
+	RecordWebhookSuccess(context.Context, int) error
+	RecordWebhookFailure(context.Context, int, int) error
+
+	// Bots functionality
+	GetAllBots(context.Context) (bots.BotCollection, error)
+	GetBot(context.Context, int) (*bots.Bot, error)
+	CreateBot(context.Context, *bots.Bot) (*bots.Bot, error)
+	DeleteBot(context.Context, int, *time.Time) error
+
+	// Tokens functionality
+	GetTokensForUser(context.Context, int) (tokens.TokenCollection, error)
+	GetToken(context.Context, int) (*tokens.Token, error)
+	GetTokenByHash(context.Context, string) (*tokens.Token, error)
+	CreateToken(context.Context, *tokens.Token) (*tokens.Token, error)
+	DeleteToken(context.Context, int) error
+
+	// Bans functionality
+	GetBansForUser(context.Context, int) (bans.BanCollection, error)
+	GetBansInChannel(context.Context, int) (bans.BanCollection, error)
+	GetBan(context.Context, int) (*bans.Ban, error)
+	CreateBan(context.Context, *bans.Ban) (*bans.Ban, error)
+	LiftBan(context.Context, int, int) (*bans.Ban, error)
+	IsUserBannedFromChannel(context.Context, int, int) (bool, error)
+
+	// Reports functionality
+	GetReports(context.Context, reports.Filter) (reports.ReportCollection, error)
+	GetReport(context.Context, int) (*reports.Report, error)
+	CreateReport(context.Context, *reports.Report) (*reports.Report, error)
+	UpdateReport(context.Context, int, *reports.Report, *time.Time) (*reports.Report, error)
+
+	// Audit functionality
+	GetAuditEntries(context.Context, audit.Filter) (audit.EntryCollection, error)
+	CreateAuditEntry(context.Context, *audit.Entry) (*audit.Entry, error)
+
+	// Impersonation functionality
+	GetActiveImpersonation(context.Context, int) (*impersonations.Impersonation, error)
+	CreateImpersonation(context.Context, *impersonations.Impersonation) (*impersonations.Impersonation, error)
+	EndImpersonation(context.Context, int) (*impersonations.Impersonation, error)
+
+	// Stats functionality
+	GetStats(context.Context, stats.Options) (*stats.Stats, error)
 }
 
 // InitBackend initializes whatever backend matches the provided
@@ -64,7 +148,7 @@ type Backend interface {
 func InitBackend(backendConfig configs.BackendConfiguration) (backendDB Backend, err error) {
 	switch backendConfig.Type {
 	case "postgres":
-		backendDB, err = postgresql.MakePostgresqlBackend(backendConfig.User, backendConfig.PW, backendConfig.DBName)
+		backendDB, err = postgresql.MakePostgresqlBackend(backendConfig)
 		if err != nil {
 			log.WithError(err).Error("Failed to initialize postgresql backend.")
 		}
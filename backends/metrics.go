@@ -0,0 +1,473 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package backends
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/bots"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/metrics"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/stats"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+)
+
+// metricsBackend is a Backend that records how long every call to the Backend it
+// wraps takes and how many of them fail. Messages and Channels are counted here so
+// the ones the bridges create are included.
+type metricsBackend struct {
+	backend Backend
+}
+
+// WithMetrics wraps the Backend so every call is recorded in the metrics.
+func WithMetrics(backend Backend) Backend {
+	return &metricsBackend{backend: backend}
+}
+
+// observe records the call to the method that started at the time. Errors with
+// their own status, like something not being found, are expected so they aren't
+// counted as failures.
+func (mb *metricsBackend) observe(method string, start time.Time, err *error) {
+	metrics.BackendCallDuration.Observe(time.Since(start).Seconds(), method)
+	if *err != nil && apierrors.StatusFor(*err) >= http.StatusInternalServerError {
+		metrics.BackendErrors.Inc(method)
+	}
+}
+
+// Lifecycle functionality isn't timed so health checks don't drown out real calls
+
+func (mb *metricsBackend) HealthCheck(ctx context.Context) error {
+	return mb.backend.HealthCheck(ctx)
+}
+
+func (mb *metricsBackend) Close() error {
+	return mb.backend.Close()
+}
+
+// Channels functionality
+
+func (mb *metricsBackend) GetChannel(ctx context.Context, id int) (result *channels.Channel, err error) {
+	defer mb.observe("GetChannel", time.Now(), &err)
+	return mb.backend.GetChannel(ctx, id)
+}
+
+func (mb *metricsBackend) GetChannelsOwnedByUser(ctx context.Context, userID int) (result channels.ChannelCollection, err error) {
+	defer mb.observe("GetChannelsOwnedByUser", time.Now(), &err)
+	return mb.backend.GetChannelsOwnedByUser(ctx, userID)
+}
+
+func (mb *metricsBackend) GetChannelsUserHasCharacterIn(ctx context.Context, userID int, isPrivate *bool) (result channels.ChannelCollection, err error) {
+	defer mb.observe("GetChannelsUserHasCharacterIn", time.Now(), &err)
+	return mb.backend.GetChannelsUserHasCharacterIn(ctx, userID, isPrivate)
+}
+
+func (mb *metricsBackend) GetAllChannels(ctx context.Context, isPrivate *bool) (result channels.ChannelCollection, err error) {
+	defer mb.observe("GetAllChannels", time.Now(), &err)
+	return mb.backend.GetAllChannels(ctx, isPrivate)
+}
+
+func (mb *metricsBackend) GetChannels(ctx context.Context, filter channels.Filter) (result channels.ChannelCollection, err error) {
+	defer mb.observe("GetChannels", time.Now(), &err)
+	return mb.backend.GetChannels(ctx, filter)
+}
+
+func (mb *metricsBackend) CreateChannel(ctx context.Context, c *channels.Channel, userID int) (result *channels.Channel, err error) {
+	defer mb.observe("CreateChannel", time.Now(), &err)
+	result, err = mb.backend.CreateChannel(ctx, c, userID)
+	if err == nil {
+		metrics.ChannelsCreated.Inc()
+	}
+	return
+}
+
+func (mb *metricsBackend) DeleteChannel(ctx context.Context, id int, expected *time.Time) (err error) {
+	defer mb.observe("DeleteChannel", time.Now(), &err)
+	return mb.backend.DeleteChannel(ctx, id, expected)
+}
+
+func (mb *metricsBackend) UpdateChannel(ctx context.Context, id int, c *channels.Channel, expected *time.Time) (result *channels.Channel, err error) {
+	defer mb.observe("UpdateChannel", time.Now(), &err)
+	return mb.backend.UpdateChannel(ctx, id, c, expected)
+}
+
+func (mb *metricsBackend) GetChannelByBotChannel(ctx context.Context, botID int, botChannel string) (result *channels.Channel, err error) {
+	defer mb.observe("GetChannelByBotChannel", time.Now(), &err)
+	return mb.backend.GetChannelByBotChannel(ctx, botID, botChannel)
+}
+
+func (mb *metricsBackend) GetChannelsForBot(ctx context.Context, botID int) (result channels.ChannelCollection, err error) {
+	defer mb.observe("GetChannelsForBot", time.Now(), &err)
+	return mb.backend.GetChannelsForBot(ctx, botID)
+}
+
+func (mb *metricsBackend) RemoveBotFromChannels(ctx context.Context, botID int) (err error) {
+	defer mb.observe("RemoveBotFromChannels", time.Now(), &err)
+	return mb.backend.RemoveBotFromChannels(ctx, botID)
+}
+
+func (mb *metricsBackend) GetDeletedChannel(ctx context.Context, id int) (result *channels.Channel, err error) {
+	defer mb.observe("GetDeletedChannel", time.Now(), &err)
+	return mb.backend.GetDeletedChannel(ctx, id)
+}
+
+func (mb *metricsBackend) GetDeletedChannels(ctx context.Context) (result channels.ChannelCollection, err error) {
+	defer mb.observe("GetDeletedChannels", time.Now(), &err)
+	return mb.backend.GetDeletedChannels(ctx)
+}
+
+func (mb *metricsBackend) RestoreChannel(ctx context.Context, id int) (result *channels.Channel, err error) {
+	defer mb.observe("RestoreChannel", time.Now(), &err)
+	return mb.backend.RestoreChannel(ctx, id)
+}
+
+// Messages functionality
+
+func (mb *metricsBackend) GetMessagesInChannel(ctx context.Context, channelID int, onlyStory *bool, page *messages.Page) (result messages.MessageCollection, err error) {
+	defer mb.observe("GetMessagesInChannel", time.Now(), &err)
+	return mb.backend.GetMessagesInChannel(ctx, channelID, onlyStory, page)
+}
+
+func (mb *metricsBackend) GetMessage(ctx context.Context, id int) (result *messages.Message, err error) {
+	defer mb.observe("GetMessage", time.Now(), &err)
+	return mb.backend.GetMessage(ctx, id)
+}
+
+func (mb *metricsBackend) CreateMessage(ctx context.Context, m *messages.Message) (result *messages.Message, err error) {
+	defer mb.observe("CreateMessage", time.Now(), &err)
+	result, err = mb.backend.CreateMessage(ctx, m)
+	if err == nil {
+		metrics.MessagesCreated.Inc()
+	}
+	return
+}
+
+func (mb *metricsBackend) DeleteMessage(ctx context.Context, id int, expected *time.Time) (err error) {
+	defer mb.observe("DeleteMessage", time.Now(), &err)
+	return mb.backend.DeleteMessage(ctx, id, expected)
+}
+
+func (mb *metricsBackend) UpdateMessage(ctx context.Context, id int, m *messages.Message, expected *time.Time) (result *messages.Message, err error) {
+	defer mb.observe("UpdateMessage", time.Now(), &err)
+	return mb.backend.UpdateMessage(ctx, id, m, expected)
+}
+
+func (mb *metricsBackend) GetDeletedMessage(ctx context.Context, id int) (result *messages.Message, err error) {
+	defer mb.observe("GetDeletedMessage", time.Now(), &err)
+	return mb.backend.GetDeletedMessage(ctx, id)
+}
+
+func (mb *metricsBackend) GetDeletedMessagesInChannel(ctx context.Context, channelID int) (result messages.MessageCollection, err error) {
+	defer mb.observe("GetDeletedMessagesInChannel", time.Now(), &err)
+	return mb.backend.GetDeletedMessagesInChannel(ctx, channelID)
+}
+
+func (mb *metricsBackend) RestoreMessage(ctx context.Context, id int) (result *messages.Message, err error) {
+	defer mb.observe("RestoreMessage", time.Now(), &err)
+	return mb.backend.RestoreMessage(ctx, id)
+}
+
+// Users functionality
+
+func (mb *metricsBackend) UpdateUser(ctx context.Context, id int, u *users.User, expected *time.Time) (result *users.User, err error) {
+	defer mb.observe("UpdateUser", time.Now(), &err)
+	return mb.backend.UpdateUser(ctx, id, u, expected)
+}
+
+func (mb *metricsBackend) DeleteUser(ctx context.Context, userID int, expected *time.Time) (err error) {
+	defer mb.observe("DeleteUser", time.Now(), &err)
+	return mb.backend.DeleteUser(ctx, userID, expected)
+}
+
+func (mb *metricsBackend) GetUserByEmail(ctx context.Context, email string) (result *users.User, err error) {
+	defer mb.observe("GetUserByEmail", time.Now(), &err)
+	return mb.backend.GetUserByEmail(ctx, email)
+}
+
+func (mb *metricsBackend) GetUserByID(ctx context.Context, id int) (result *users.User, err error) {
+	defer mb.observe("GetUserByID", time.Now(), &err)
+	return mb.backend.GetUserByID(ctx, id)
+}
+
+func (mb *metricsBackend) CreateUser(ctx context.Context, gu *users.GoogleUser) (result *users.User, err error) {
+	defer mb.observe("CreateUser", time.Now(), &err)
+	return mb.backend.CreateUser(ctx, gu)
+}
+
+func (mb *metricsBackend) GetUsers(ctx context.Context, filter users.Filter) (result users.UserCollection, err error) {
+	defer mb.observe("GetUsers", time.Now(), &err)
+	return mb.backend.GetUsers(ctx, filter)
+}
+
+func (mb *metricsBackend) UpdateUserLastLogin(ctx context.Context, u *users.User) (result *users.User, err error) {
+	defer mb.observe("UpdateUserLastLogin", time.Now(), &err)
+	return mb.backend.UpdateUserLastLogin(ctx, u)
+}
+
+// Characters functionality
+
+func (mb *metricsBackend) DoesUserHaveCharacterInChannel(ctx context.Context, userID, channelID int) (result bool, err error) {
+	defer mb.observe("DoesUserHaveCharacterInChannel", time.Now(), &err)
+	return mb.backend.DoesUserHaveCharacterInChannel(ctx, userID, channelID)
+}
+
+func (mb *metricsBackend) GetCharactersInChannel(ctx context.Context, channelID int) (result characters.CharacterCollection, err error) {
+	defer mb.observe("GetCharactersInChannel", time.Now(), &err)
+	return mb.backend.GetCharactersInChannel(ctx, channelID)
+}
+
+func (mb *metricsBackend) GetCharacter(ctx context.Context, id int) (result *characters.Character, err error) {
+	defer mb.observe("GetCharacter", time.Now(), &err)
+	return mb.backend.GetCharacter(ctx, id)
+}
+
+func (mb *metricsBackend) CreateCharacter(ctx context.Context, c *characters.Character) (result *characters.Character, err error) {
+	defer mb.observe("CreateCharacter", time.Now(), &err)
+	return mb.backend.CreateCharacter(ctx, c)
+}
+
+func (mb *metricsBackend) DeleteCharacter(ctx context.Context, characterID int, expected *time.Time) (err error) {
+	defer mb.observe("DeleteCharacter", time.Now(), &err)
+	return mb.backend.DeleteCharacter(ctx, characterID, expected)
+}
+
+func (mb *metricsBackend) UpdateCharacter(ctx context.Context, id int, c *characters.Character, expected *time.Time) (result *characters.Character, err error) {
+	defer mb.observe("UpdateCharacter", time.Now(), &err)
+	return mb.backend.UpdateCharacter(ctx, id, c, expected)
+}
+
+func (mb *metricsBackend) GetCharacterByBotUsername(ctx context.Context, channelID int, botUsername string) (result *characters.Character, err error) {
+	defer mb.observe("GetCharacterByBotUsername", time.Now(), &err)
+	return mb.backend.GetCharacterByBotUsername(ctx, channelID, botUsername)
+}
+
+func (mb *metricsBackend) GetDeletedCharacter(ctx context.Context, id int) (result *characters.Character, err error) {
+	defer mb.observe("GetDeletedCharacter", time.Now(), &err)
+	return mb.backend.GetDeletedCharacter(ctx, id)
+}
+
+func (mb *metricsBackend) GetDeletedCharactersInChannel(ctx context.Context, channelID int) (result characters.CharacterCollection, err error) {
+	defer mb.observe("GetDeletedCharactersInChannel", time.Now(), &err)
+	return mb.backend.GetDeletedCharactersInChannel(ctx, channelID)
+}
+
+func (mb *metricsBackend) RestoreCharacter(ctx context.Context, characterID int) (result *characters.Character, err error) {
+	defer mb.observe("RestoreCharacter", time.Now(), &err)
+	return mb.backend.RestoreCharacter(ctx, characterID)
+}
+
+// Retention functionality
+
+func (mb *metricsBackend) PurgeDeleted(ctx context.Context, before time.Time) (err error) {
+	defer mb.observe("PurgeDeleted", time.Now(), &err)
+	return mb.backend.PurgeDeleted(ctx, before)
+}
+
+// Notifications functionality
+
+func (mb *metricsBackend) GetNotificationPreferences(ctx context.Context, userID int) (result *notifications.Preferences, err error) {
+	defer mb.observe("GetNotificationPreferences", time.Now(), &err)
+	return mb.backend.GetNotificationPreferences(ctx, userID)
+}
+
+func (mb *metricsBackend) UpdateNotificationPreferences(ctx context.Context, userID int, p *notifications.Preferences, expected *time.Time) (result *notifications.Preferences, err error) {
+	defer mb.observe("UpdateNotificationPreferences", time.Now(), &err)
+	return mb.backend.UpdateNotificationPreferences(ctx, userID, p, expected)
+}
+
+// Webhooks functionality
+
+func (mb *metricsBackend) GetWebhooksInChannel(ctx context.Context, channelID int) (result webhooks.WebhookCollection, err error) {
+	defer mb.observe("GetWebhooksInChannel", time.Now(), &err)
+	return mb.backend.GetWebhooksInChannel(ctx, channelID)
+}
+
+func (mb *metricsBackend) GetWebhook(ctx context.Context, id int) (result *webhooks.Webhook, err error) {
+	defer mb.observe("GetWebhook", time.Now(), &err)
+	return mb.backend.GetWebhook(ctx, id)
+}
+
+func (mb *metricsBackend) CreateWebhook(ctx context.Context, w *webhooks.Webhook) (result *webhooks.Webhook, err error) {
+	defer mb.observe("CreateWebhook", time.Now(), &err)
+	return mb.backend.CreateWebhook(ctx, w)
+}
+
+func (mb *metricsBackend) UpdateWebhook(ctx context.Context, id int, w *webhooks.Webhook, expected *time.Time) (result *webhooks.Webhook, err error) {
+	defer mb.observe("UpdateWebhook", time.Now(), &err)
+	return mb.backend.UpdateWebhook(ctx, id, w, expected)
+}
+
+func (mb *metricsBackend) DeleteWebhook(ctx context.Context, id int, expected *time.Time) (err error) {
+	defer mb.observe("DeleteWebhook", time.Now(), &err)
+	return mb.backend.DeleteWebhook(ctx, id, expected)
+}
+
+func (mb *metricsBackend) GetWebhookDeliveries(ctx context.Context, webhookID int) (result webhooks.DeliveryCollection, err error) {
+	defer mb.observe("GetWebhookDeliveries", time.Now(), &err)
+	return mb.backend.GetWebhookDeliveries(ctx, webhookID)
+}
+
+func (mb *metricsBackend) CreateWebhookDelivery(ctx context.Context, d *webhooks.Delivery) (result *webhooks.Delivery, err error) {
+	defer mb.observe("CreateWebhookDelivery", time.Now(), &err)
+	return mb.backend.CreateWebhookDelivery(ctx, d)
+}
+
+func (mb *metricsBackend) RecordWebhookSuccess(ctx context.Context, id int) (err error) {
+	defer mb.observe("RecordWebhookSuccess", time.Now(), &err)
+	return mb.backend.RecordWebhookSuccess(ctx, id)
+}
+
+func (mb *metricsBackend) RecordWebhookFailure(ctx context.Context, id, disableAfter int) (err error) {
+	defer mb.observe("RecordWebhookFailure", time.Now(), &err)
+	return mb.backend.RecordWebhookFailure(ctx, id, disableAfter)
+}
+
+// Bots functionality
+
+func (mb *metricsBackend) GetAllBots(ctx context.Context) (result bots.BotCollection, err error) {
+	defer mb.observe("GetAllBots", time.Now(), &err)
+	return mb.backend.GetAllBots(ctx)
+}
+
+func (mb *metricsBackend) GetBot(ctx context.Context, id int) (result *bots.Bot, err error) {
+	defer mb.observe("GetBot", time.Now(), &err)
+	return mb.backend.GetBot(ctx, id)
+}
+
+func (mb *metricsBackend) CreateBot(ctx context.Context, b *bots.Bot) (result *bots.Bot, err error) {
+	defer mb.observe("CreateBot", time.Now(), &err)
+	return mb.backend.CreateBot(ctx, b)
+}
+
+func (mb *metricsBackend) DeleteBot(ctx context.Context, id int, expected *time.Time) (err error) {
+	defer mb.observe("DeleteBot", time.Now(), &err)
+	return mb.backend.DeleteBot(ctx, id, expected)
+}
+
+// Tokens functionality
+
+func (mb *metricsBackend) GetTokensForUser(ctx context.Context, userID int) (result tokens.TokenCollection, err error) {
+	defer mb.observe("GetTokensForUser", time.Now(), &err)
+	return mb.backend.GetTokensForUser(ctx, userID)
+}
+
+func (mb *metricsBackend) GetToken(ctx context.Context, id int) (result *tokens.Token, err error) {
+	defer mb.observe("GetToken", time.Now(), &err)
+	return mb.backend.GetToken(ctx, id)
+}
+
+func (mb *metricsBackend) GetTokenByHash(ctx context.Context, hash string) (result *tokens.Token, err error) {
+	defer mb.observe("GetTokenByHash", time.Now(), &err)
+	return mb.backend.GetTokenByHash(ctx, hash)
+}
+
+func (mb *metricsBackend) CreateToken(ctx context.Context, t *tokens.Token) (result *tokens.Token, err error) {
+	defer mb.observe("CreateToken", time.Now(), &err)
+	return mb.backend.CreateToken(ctx, t)
+}
+
+func (mb *metricsBackend) DeleteToken(ctx context.Context, id int) (err error) {
+	defer mb.observe("DeleteToken", time.Now(), &err)
+	return mb.backend.DeleteToken(ctx, id)
+}
+
+// Bans functionality
+
+func (mb *metricsBackend) GetBansForUser(ctx context.Context, userID int) (result bans.BanCollection, err error) {
+	defer mb.observe("GetBansForUser", time.Now(), &err)
+	return mb.backend.GetBansForUser(ctx, userID)
+}
+
+func (mb *metricsBackend) GetBansInChannel(ctx context.Context, channelID int) (result bans.BanCollection, err error) {
+	defer mb.observe("GetBansInChannel", time.Now(), &err)
+	return mb.backend.GetBansInChannel(ctx, channelID)
+}
+
+func (mb *metricsBackend) GetBan(ctx context.Context, id int) (result *bans.Ban, err error) {
+	defer mb.observe("GetBan", time.Now(), &err)
+	return mb.backend.GetBan(ctx, id)
+}
+
+func (mb *metricsBackend) CreateBan(ctx context.Context, b *bans.Ban) (result *bans.Ban, err error) {
+	defer mb.observe("CreateBan", time.Now(), &err)
+	return mb.backend.CreateBan(ctx, b)
+}
+
+func (mb *metricsBackend) LiftBan(ctx context.Context, id, liftedBy int) (result *bans.Ban, err error) {
+	defer mb.observe("LiftBan", time.Now(), &err)
+	return mb.backend.LiftBan(ctx, id, liftedBy)
+}
+
+func (mb *metricsBackend) IsUserBannedFromChannel(ctx context.Context, userID, channelID int) (result bool, err error) {
+	defer mb.observe("IsUserBannedFromChannel", time.Now(), &err)
+	return mb.backend.IsUserBannedFromChannel(ctx, userID, channelID)
+}
+
+// Reports functionality
+
+func (mb *metricsBackend) GetReports(ctx context.Context, filter reports.Filter) (result reports.ReportCollection, err error) {
+	defer mb.observe("GetReports", time.Now(), &err)
+	return mb.backend.GetReports(ctx, filter)
+}
+
+func (mb *metricsBackend) GetReport(ctx context.Context, id int) (result *reports.Report, err error) {
+	defer mb.observe("GetReport", time.Now(), &err)
+	return mb.backend.GetReport(ctx, id)
+}
+
+func (mb *metricsBackend) CreateReport(ctx context.Context, r *reports.Report) (result *reports.Report, err error) {
+	defer mb.observe("CreateReport", time.Now(), &err)
+	return mb.backend.CreateReport(ctx, r)
+}
+
+func (mb *metricsBackend) UpdateReport(ctx context.Context, id int, r *reports.Report, expected *time.Time) (result *reports.Report, err error) {
+	defer mb.observe("UpdateReport", time.Now(), &err)
+	return mb.backend.UpdateReport(ctx, id, r, expected)
+}
+
+// Audit functionality
+
+func (mb *metricsBackend) GetAuditEntries(ctx context.Context, filter audit.Filter) (result audit.EntryCollection, err error) {
+	defer mb.observe("GetAuditEntries", time.Now(), &err)
+	return mb.backend.GetAuditEntries(ctx, filter)
+}
+
+func (mb *metricsBackend) CreateAuditEntry(ctx context.Context, e *audit.Entry) (result *audit.Entry, err error) {
+	defer mb.observe("CreateAuditEntry", time.Now(), &err)
+	return mb.backend.CreateAuditEntry(ctx, e)
+}
+
+// Impersonation functionality
+
+func (mb *metricsBackend) GetActiveImpersonation(ctx context.Context, adminID int) (result *impersonations.Impersonation, err error) {
+	defer mb.observe("GetActiveImpersonation", time.Now(), &err)
+	return mb.backend.GetActiveImpersonation(ctx, adminID)
+}
+
+func (mb *metricsBackend) CreateImpersonation(ctx context.Context, i *impersonations.Impersonation) (result *impersonations.Impersonation, err error) {
+	defer mb.observe("CreateImpersonation", time.Now(), &err)
+	return mb.backend.CreateImpersonation(ctx, i)
+}
+
+func (mb *metricsBackend) EndImpersonation(ctx context.Context, id int) (result *impersonations.Impersonation, err error) {
+	defer mb.observe("EndImpersonation", time.Now(), &err)
+	return mb.backend.EndImpersonation(ctx, id)
+}
+
+// Stats functionality
+
+func (mb *metricsBackend) GetStats(ctx context.Context, opts stats.Options) (result *stats.Stats, err error) {
+	defer mb.observe("GetStats", time.Now(), &err)
+	return mb.backend.GetStats(ctx, opts)
+}
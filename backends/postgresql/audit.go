@@ -0,0 +1,126 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/audit"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	auditTable     = "audit_log"
+	auditReturning = "RETURNING id, actor_id, impersonating_id, action, target_type, target_id, before, after, created_on"
+)
+
+var auditColumns = []string{
+	"id",
+	"actor_id",
+	"impersonating_id",
+	"action",
+	"target_type",
+	"target_id",
+	"before",
+	"after",
+	"created_on",
+}
+
+func init() {
+	// Add the audit table name in front of the columms to avoid ambigious references.
+	for i, col := range auditColumns {
+		auditColumns[i] = fmt.Sprintf("%s.%s", auditTable, col)
+	}
+}
+
+// GetAuditEntries retrieves the audit Entries matching the filter, newest first.
+func (backend Backend) GetAuditEntries(ctx context.Context, filter audit.Filter) (audit.EntryCollection, error) {
+	builder := PSQLBuilder().
+		Select(auditColumns...).
+		From(auditTable).
+		OrderBy("audit_log.created_on DESC", "audit_log.id DESC")
+
+	if filter.ActorID != 0 {
+		builder = builder.Where(sq.Eq{"audit_log.actor_id": filter.ActorID})
+	}
+	if filter.Action != "" {
+		builder = builder.Where(sq.Eq{"audit_log.action": filter.Action})
+	}
+	if filter.TargetType != "" {
+		builder = builder.Where(sq.Eq{"audit_log.target_type": filter.TargetType})
+	}
+	if filter.TargetID != 0 {
+		builder = builder.Where(sq.Eq{"audit_log.target_id": filter.TargetID})
+	}
+	if filter.Since != nil {
+		builder = builder.Where(sq.GtOrEq{"audit_log.created_on": filter.Since.UTC()})
+	}
+	if filter.Until != nil {
+		builder = builder.Where(sq.Lt{"audit_log.created_on": filter.Until.UTC()})
+	}
+	if filter.Limit > 0 {
+		builder = builder.Limit(uint64(filter.Limit))
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get audit entries query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get audit entries query.")
+		return nil, err
+	}
+
+	outEntries := make(audit.EntryCollection, 0)
+	for rows.Next() {
+		var entry audit.Entry
+		err = rows.StructScan(&entry)
+		if err != nil {
+			log.WithError(err).Error("Failed to load entry from get audit entries query.")
+			return nil, err
+		}
+
+		outEntries = append(outEntries, &entry)
+	}
+
+	return outEntries, nil
+}
+
+// CreateAuditEntry adds the Entry to the audit log. The log is append only so
+// there's no way to change or remove it afterwards.
+func (backend Backend) CreateAuditEntry(ctx context.Context, e *audit.Entry) (*audit.Entry, error) {
+	kvs := map[string]interface{}{
+		"actor_id":         e.ActorID,
+		"impersonating_id": e.ImpersonatingID,
+		"action":           e.Action,
+		"target_type":      e.TargetType,
+		"target_id":        e.TargetID,
+		"before":           snapshotValue(e.Before),
+		"after":            snapshotValue(e.After),
+	}
+
+	newEntry := &audit.Entry{}
+	err := backend.createSingle(ctx, auditTable, auditReturning, kvs, newEntry)
+	if err != nil {
+		log.WithError(err).Error("Issue with create audit entry sql.")
+		return nil, err
+	}
+
+	return newEntry, nil
+}
+
+// snapshotValue converts the snapshot into something that can be stored in a jsonb
+// column. The driver sends raw bytes as bytea so it has to be a string, and missing
+// snapshots are stored as NULL.
+func snapshotValue(snapshot json.RawMessage) interface{} {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	return string(snapshot)
+}
@@ -3,23 +3,56 @@
 package postgresql
 
 import (
+	"context"
 	sqlP "database/sql"
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
-	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/configs"
 	log "github.com/sirupsen/logrus"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 const (
 	schemaFilePath    = "./backends/postgresql/schema.sql"
 	functionsFilePath = "./backends/postgresql/functions.sql"
+
+	// uniqueViolationCode is the Postgres error code for breaking a unique constraint
+	uniqueViolationCode = "23505"
 )
 
+// uniqueFields are the request fields to blame when each unique constraint is
+// broken so duplicates are reported as validation failures.
+var uniqueFields = map[string]apierrors.FieldError{
+	"users_username_key":                {Field: "Username", Message: "is already taken"},
+	"users_email_key":                   {Field: "Email", Message: "is already taken"},
+	"channels_name_key":                 {Field: "Name", Message: "is already taken"},
+	"channels_bot_id_bot_channel_idx":   {Field: "BotChannel", Message: "is already linked to another Channel for the Bot"},
+	"characters_name_channel_id_key":    {Field: "Name", Message: "is already taken in the Channel"},
+	"characters_user_id_channel_id_key": {Field: "UserID", Message: "already has a Character in the Channel"},
+}
+
+// fieldErrorsFor converts a broken unique constraint into the FieldErrors for it.
+// Any other error is returned as is.
+func fieldErrorsFor(err error) error {
+	pqErr, ok := err.(*pq.Error)
+	if !ok || pqErr.Code != uniqueViolationCode {
+		return err
+	}
+
+	field, ok := uniqueFields[pqErr.Constraint]
+	if !ok {
+		return err
+	}
+	return apierrors.FieldErrors{field}
+}
+
 // Backend contains all of the data specific to a Postgres backend
 type Backend struct {
 	db *sqlx.DB
@@ -28,16 +61,17 @@ type Backend struct {
 // MakePostgresqlBackend creates a Postgresql backend with connection to the
 // actual DB, verifies the connection, and initializes the schema if it
 // isn't already populated.
-func MakePostgresqlBackend(user, password, dbname string) (b Backend, err error) {
-	dbinfo := fmt.Sprintf("host=db user=%s password=%s dbname=%s sslmode=disable",
-		user, password, dbname)
-	db, err := sqlx.Open("postgres", dbinfo)
+func MakePostgresqlBackend(c configs.BackendConfiguration) (b Backend, err error) {
+	db, err := sqlx.Open("postgres", connectionString(c))
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to database.")
 		return b, err
 	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
 
-	err = RunHealthCheck(db)
+	err = RunHealthCheck(context.Background(), db)
 	if err != nil {
 		log.WithError(err).Error("Error during db health check.")
 		return b, err
@@ -60,6 +94,26 @@ func MakePostgresqlBackend(user, password, dbname string) (b Backend, err error)
 	return Backend{db: db}, nil
 }
 
+// connectionString builds the lib/pq connection string for the config. Values are
+// quoted so passwords can have spaces and quotes in them.
+func connectionString(c configs.BackendConfiguration) string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("host='%s' port=%d user='%s' password='%s' dbname='%s' sslmode='%s'",
+		quote.Replace(c.Host), c.Port, quote.Replace(c.User), quote.Replace(c.PW),
+		quote.Replace(c.DBName), quote.Replace(c.SSLMode))
+}
+
+// HealthCheck returns an error if the database can't be reached.
+func (backend Backend) HealthCheck(ctx context.Context) error {
+	return RunHealthCheck(ctx, backend.db)
+}
+
+// Close closes the connections to the database. Nothing should be using the
+// Backend afterwards.
+func (backend Backend) Close() error {
+	return backend.db.Close()
+}
+
 // PSQLBuilder retruns a squirrel SQL builder that uses
 // placeholders in the format that Postgresql expects.
 func PSQLBuilder() sq.StatementBuilderType {
@@ -68,8 +122,8 @@ func PSQLBuilder() sq.StatementBuilderType {
 
 // RunHealthCheck returns an error if there is an issue
 // connecting to the database.
-func RunHealthCheck(db *sqlx.DB) error {
-	err := db.Ping()
+func RunHealthCheck(ctx context.Context, db *sqlx.DB) error {
+	err := db.PingContext(ctx)
 	if err != nil {
 		log.WithError(err).Error("DB health check failed.")
 	}
@@ -114,20 +168,24 @@ func initSchema(db *sqlx.DB) error {
 // getSingle retrieves a single row from the given table matching the given id. The columns
 // retrieved are also determined from the input. The resulting record is loaded into the
 // input object, not returned, so a pointer should be passed in for persistance. If no record
-// exists then the return flag will be false.
-func (backend Backend) getSingle(id int, tableName string, cols []string, obj interface{}) (wasFound bool, err error) {
-	sql, args, err := PSQLBuilder().
+// exists then the return flag will be false. Any filters further limit which row matches.
+func (backend Backend) getSingle(ctx context.Context, id int, tableName string, cols []string, obj interface{}, filters ...sq.Sqlizer) (wasFound bool, err error) {
+	builder := PSQLBuilder().
 		Select(cols...).
 		From(tableName).
-		Where(sq.Eq{"id": id}).
-		ToSql()
+		Where(sq.Eq{"id": id})
+	for _, filter := range filters {
+		builder = builder.Where(filter)
+	}
+
+	sql, args, err := builder.ToSql()
 	if err != nil {
 		log.WithError(err).Error("Issue building query for get single.")
 		return false, err
 	}
 
 	// This could be an error from the thing not existing which is not unexpected
-	err = backend.db.Get(obj, sql, args...)
+	err = backend.db.GetContext(ctx, obj, sql, args...)
 	if err != nil {
 		if err == sqlP.ErrNoRows {
 			return false, nil
@@ -141,45 +199,54 @@ func (backend Backend) getSingle(id int, tableName string, cols []string, obj in
 // determines what columns should be updated to what values. The returning parameter dictates
 // what fields to retrieve after the update. The resulting record is loaded into the
 // input object, not returned, so a pointer should be passed in for persistance. If no record
-// exists then the return flag will be false.
-func (backend Backend) updateSingle(id int, tableName, returning string, setMap map[string]interface{}, obj interface{}) (wasFound bool, err error) {
-	sql, args, err := PSQLBuilder().
+// exists then the return flag will be false. Any filters further limit which row matches.
+func (backend Backend) updateSingle(ctx context.Context, id int, tableName, returning string, setMap map[string]interface{}, obj interface{}, filters ...sq.Sqlizer) (wasFound bool, err error) {
+	builder := PSQLBuilder().
 		Update(tableName).
 		SetMap(setMap).
 		Where(sq.Eq{"id": id}).
-		Suffix(returning).
-		ToSql()
+		Suffix(returning)
+	for _, filter := range filters {
+		builder = builder.Where(filter)
+	}
+
+	sql, args, err := builder.ToSql()
 	if err != nil {
 		log.WithError(err).Error("Failed to build query for update single.")
 		return false, err
 	}
 
 	// This could be an error from the thing not existing which is not unexpected
-	err = backend.db.QueryRowx(sql, args...).StructScan(obj)
+	err = backend.db.QueryRowxContext(ctx, sql, args...).StructScan(obj)
 	if err != nil {
 		if err == sqlP.ErrNoRows {
 			return false, nil
 		}
-		return false, err
+		return false, fieldErrorsFor(err)
 	}
 	return true, err
 }
 
 // deleteSingle deletes a single row from the given table matching the given id. If no record
-// exists then the return flag will be false.
-func (backend Backend) deleteSingle(id int, tableName string) (wasFound bool, err error) {
-	sql, args, err := PSQLBuilder().
+// exists then the return flag will be false. Any filters further limit which row matches.
+func (backend Backend) deleteSingle(ctx context.Context, id int, tableName string, filters ...sq.Sqlizer) (wasFound bool, err error) {
+	builder := PSQLBuilder().
 		Delete(tableName).
-		Where(sq.Eq{"id": id}).
-		ToSql()
+		Where(sq.Eq{"id": id})
+	for _, filter := range filters {
+		builder = builder.Where(filter)
+	}
+
+	sql, args, err := builder.ToSql()
 	if err != nil {
 		log.WithError(err).Error("Failed to build query for delete single.")
 		return false, err
 	}
 
-	result, err := backend.db.Exec(sql, args...)
+	result, err := backend.db.ExecContext(ctx, sql, args...)
 	if err != nil {
 		log.WithError(err).Error("Failed to execute delete single query.")
+		return false, err
 	}
 
 	numRowsAffected, err := result.RowsAffected()
@@ -188,18 +255,33 @@ func (backend Backend) deleteSingle(id int, tableName string) (wasFound bool, er
 		return false, err
 	}
 
-	if numRowsAffected <= 0 {
-		return false, channels.ErrChannelNotFound
+	return numRowsAffected > 0, nil
+}
+
+// atVersion adds a filter to the filters so only the row last updated at the
+// expected time matches. Nothing is added when there isn't an expected time.
+func atVersion(expected *time.Time, filters ...sq.Sqlizer) []sq.Sqlizer {
+	if expected != nil {
+		filters = append(filters, sq.Eq{"last_updated": *expected})
 	}
+	return filters
+}
 
-	return true, err
+// notFoundAt is the error for an update or delete that didn't match a row. When a
+// version was expected the row was there when it was looked up so it has been
+// changed or deleted since.
+func notFoundAt(expected *time.Time, notFound error) error {
+	if expected != nil {
+		return apierrors.ErrPreconditionFailed
+	}
+	return notFound
 }
 
 // createSingle creates a single row in the given table. The input map determines what columns
 // should be set to what values. The returning parameter dictates what fields to retrieve after
 // the create. The resulting record is loaded into the input object, not returned, so a pointer
 // should be passed in for persistance.
-func (backend Backend) createSingle(tableName, returning string, kvs map[string]interface{}, obj interface{}) (err error) {
+func (backend Backend) createSingle(ctx context.Context, tableName, returning string, kvs map[string]interface{}, obj interface{}) (err error) {
 	cols := make([]string, len(kvs))
 	vals := make([]interface{}, len(kvs))
 	i := 0
@@ -220,30 +302,56 @@ func (backend Backend) createSingle(tableName, returning string, kvs map[string]
 		return err
 	}
 
-	err = backend.db.QueryRowx(sql, args...).StructScan(obj)
+	err = backend.db.QueryRowxContext(ctx, sql, args...).StructScan(obj)
 	if err != nil {
 		log.WithError(err).Error("Issue running create single sql.")
-		return err
+		return fieldErrorsFor(err)
 	}
 
 	return
 }
 
-// deleteMultiple deletes all rows from the input table where the given column
-// matches the input id.
-func (backend Backend) deleteMultiple(table, col string, id int) error {
-	sql, args, err := PSQLBuilder().
-		Delete(table).
-		Where(sq.Eq{col: id}).
-		ToSql()
+// inTransaction runs fn in a transaction that's committed if fn succeeds and rolled
+// back otherwise. Postgres gives every statement in a transaction the same now().
+func (backend Backend) inTransaction(ctx context.Context, fn func(*sqlx.Tx) error) error {
+	tx, err := backend.db.BeginTxx(ctx, nil)
 	if err != nil {
-		log.WithError(err).Error("Failed to build delete multiple query.")
+		log.WithError(err).Error("Failed to begin transaction.")
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.WithError(rollbackErr).Error("Failed to roll back transaction.")
+		}
 		return err
 	}
 
-	_, err = backend.db.Exec(sql, args...)
+	err = tx.Commit()
 	if err != nil {
-		log.WithError(err).Error("Failed to execute delete multiple query.")
+		log.WithError(err).Error("Failed to commit transaction.")
 	}
 	return err
 }
+
+// sortAndPage orders the query by the column, with the ID breaking ties so pages
+// are stable, and skips offset rows before taking at most limit. A limit of 0
+// takes every row.
+func sortAndPage(builder sq.SelectBuilder, table, col string, descending bool, limit, offset int) sq.SelectBuilder {
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+	builder = builder.OrderBy(
+		fmt.Sprintf("%s.%s %s", table, col, direction),
+		fmt.Sprintf("%s.id %s", table, direction),
+	)
+
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+	if offset > 0 {
+		builder = builder.Offset(uint64(offset))
+	}
+	return builder
+}
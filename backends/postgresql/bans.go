@@ -0,0 +1,180 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	sqlP "database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	bansTable     = "bans"
+	bansReturning = "RETURNING id, user_id, channel_id, reason, issued_by, starts_on, expires_on, lifted_by, lifted_on, " + isActiveColumn + ", created_on, last_updated"
+
+	// activeBanCondition is true for Bans that are in effect right now. Expired
+	// Bans stop matching on their own so nothing has to lift them.
+	activeBanCondition = "bans.lifted_on IS NULL AND bans.starts_on <= now() AND (bans.expires_on IS NULL OR bans.expires_on > now())"
+
+	isActiveColumn = "(" + activeBanCondition + ") AS is_active"
+)
+
+var banColumns = []string{
+	"id",
+	"user_id",
+	"channel_id",
+	"reason",
+	"issued_by",
+	"starts_on",
+	"expires_on",
+	"lifted_by",
+	"lifted_on",
+	"created_on",
+	"last_updated",
+}
+
+func init() {
+	// Add the Ban table name in front of the columms to avoid ambigious references.
+	for i, col := range banColumns {
+		banColumns[i] = fmt.Sprintf("%s.%s", bansTable, col)
+	}
+	banColumns = append(banColumns, isActiveColumn)
+}
+
+// GetBansForUser retrieves every Ban the User has ever had, site wide and in
+// Channels, newest first.
+func (backend Backend) GetBansForUser(ctx context.Context, userID int) (bans.BanCollection, error) {
+	return backend.getBans(ctx, sq.Eq{"bans.user_id": userID})
+}
+
+// GetBansInChannel retrieves every Ban there has ever been from the Channel,
+// newest first.
+func (backend Backend) GetBansInChannel(ctx context.Context, channelID int) (bans.BanCollection, error) {
+	return backend.getBans(ctx, sq.Eq{"bans.channel_id": channelID})
+}
+
+// getBans retrieves the Bans matching where, newest first.
+func (backend Backend) getBans(ctx context.Context, where sq.Sqlizer) (bans.BanCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(banColumns...).
+		From(bansTable).
+		Where(where).
+		OrderBy("bans.starts_on DESC", "bans.id DESC").
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get bans query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get bans query.")
+		return nil, err
+	}
+
+	outBans := make(bans.BanCollection, 0)
+	for rows.Next() {
+		var ban bans.Ban
+		err = rows.StructScan(&ban)
+		if err != nil {
+			log.WithError(err).Error("Failed to load ban from get bans query.")
+			return nil, err
+		}
+
+		outBans = append(outBans, &ban)
+	}
+
+	return outBans, nil
+}
+
+// GetBan retrieves the Ban matching the given ID.
+func (backend Backend) GetBan(ctx context.Context, id int) (*bans.Ban, error) {
+	ban := &bans.Ban{}
+	wasFound, err := backend.getSingle(ctx, id, bansTable, banColumns, ban)
+	if err != nil {
+		log.WithError(err).Error("Query issue for get ban.")
+		return nil, err
+	} else if !wasFound {
+		return nil, bans.ErrBanNotFound
+	}
+
+	return ban, nil
+}
+
+// CreateBan creates a new Ban using the provided data. Bans without a start time
+// start right away. Times are stored in UTC.
+func (backend Backend) CreateBan(ctx context.Context, b *bans.Ban) (*bans.Ban, error) {
+	kvs := map[string]interface{}{
+		"user_id":    b.UserID,
+		"channel_id": b.ChannelID,
+		"reason":     b.Reason,
+		"issued_by":  b.IssuedBy,
+	}
+	if !b.StartsOn.IsZero() {
+		kvs["starts_on"] = b.StartsOn.UTC()
+	}
+	if b.ExpiresOn != nil {
+		kvs["expires_on"] = b.ExpiresOn.UTC()
+	}
+
+	newBan := &bans.Ban{}
+	err := backend.createSingle(ctx, bansTable, bansReturning, kvs, newBan)
+	if err != nil {
+		log.WithError(err).Error("Issue with create ban sql.")
+		return nil, err
+	}
+
+	return newBan, nil
+}
+
+// LiftBan ends the Ban matching the given ID early. The Ban is kept for the
+// history. Bans that already ended can't be lifted and are ErrBanNotActive.
+func (backend Backend) LiftBan(ctx context.Context, id, liftedBy int) (*bans.Ban, error) {
+	setMap := map[string]interface{}{
+		"lifted_by": liftedBy,
+		"lifted_on": sq.Expr("now()"),
+	}
+
+	liftedBan := &bans.Ban{}
+	wasFound, err := backend.updateSingle(ctx, id, bansTable, bansReturning, setMap, liftedBan, sq.Expr(activeBanCondition))
+	if err != nil {
+		log.WithError(err).Error("Issue with query for lift ban.")
+		return nil, err
+	} else if !wasFound {
+		return nil, bans.ErrBanNotActive
+	}
+
+	return liftedBan, nil
+}
+
+// IsUserBannedFromChannel determines if the User has a Ban in effect for the
+// Channel. Site wide Bans aren't included since those Users can't log in.
+func (backend Backend) IsUserBannedFromChannel(ctx context.Context, userID, channelID int) (bool, error) {
+	sql, args, err := PSQLBuilder().
+		Select("1").
+		From(bansTable).
+		Where(sq.Eq{"bans.user_id": userID, "bans.channel_id": channelID}).
+		Where(activeBanCondition).
+		Limit(1).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build is user banned from channel query.")
+		return false, err
+	}
+
+	var found int
+	err = backend.db.QueryRowxContext(ctx, sql, args...).Scan(&found)
+	if err != nil {
+		if err == sqlP.ErrNoRows {
+			return false, nil
+		}
+		log.WithError(err).Error("Failed to execute is user banned from channel query.")
+		return false, err
+	}
+
+	return true, nil
+}
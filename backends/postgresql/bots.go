@@ -0,0 +1,98 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/bots"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	botsTable     = "bots"
+	botsReturning = "RETURNING id, workspace, owner_id, created_on, last_updated"
+)
+
+var botColumns = []string{
+	"id",
+	"workspace",
+	"owner_id",
+	"created_on",
+	"last_updated",
+}
+
+// GetAllBots retrieves all of the Bots.
+func (backend Backend) GetAllBots(ctx context.Context) (bots.BotCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(botColumns...).
+		From(botsTable).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get all bots query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get all bots query.")
+		return nil, err
+	}
+
+	outBots := make(bots.BotCollection, 0)
+	for rows.Next() {
+		var bot bots.Bot
+		err = rows.StructScan(&bot)
+		if err != nil {
+			log.WithError(err).Error("Failed to load bot from get all bots query.")
+			return nil, err
+		}
+
+		outBots = append(outBots, &bot)
+	}
+
+	return outBots, nil
+}
+
+// GetBot retrieves the Bot matching the given ID.
+func (backend Backend) GetBot(ctx context.Context, id int) (*bots.Bot, error) {
+	bot := &bots.Bot{}
+	wasFound, err := backend.getSingle(ctx, id, botsTable, botColumns, bot)
+	if err != nil {
+		log.WithError(err).Error("Query issue for get bot.")
+		return nil, err
+	} else if !wasFound {
+		return nil, bots.ErrBotNotFound
+	}
+
+	return bot, nil
+}
+
+// CreateBot creates a new Bot using the provided data.
+func (backend Backend) CreateBot(ctx context.Context, b *bots.Bot) (*bots.Bot, error) {
+	kvs := map[string]interface{}{
+		"workspace": b.Workspace,
+		"owner_id":  b.OwnerID,
+	}
+
+	newBot := &bots.Bot{}
+	err := backend.createSingle(ctx, botsTable, botsReturning, kvs, newBot)
+	if err != nil {
+		log.WithError(err).Error("Issue with create bot sql.")
+		return nil, err
+	}
+
+	return newBot, nil
+}
+
+// DeleteBot deletes the Bot matching the given ID.
+func (backend Backend) DeleteBot(ctx context.Context, id int, expected *time.Time) error {
+	wasFound, err := backend.deleteSingle(ctx, id, botsTable, atVersion(expected)...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute delete bot query.")
+	} else if !wasFound {
+		return notFoundAt(expected, bots.ErrBotNotFound)
+	}
+	return err
+}
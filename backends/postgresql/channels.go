@@ -3,16 +3,20 @@
 package postgresql
 
 import (
+	"context"
+	sqlP "database/sql"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	channelsTable     = "channels"
-	channelsReturning = "RETURNING id, name, description, topic, owner_id, is_private, dm_id, created_on, last_updated"
+	channelsReturning = "RETURNING id, name, description, topic, owner_id, is_private, dm_id, bot_id, bot_channel, created_on, last_updated, deleted_on"
 )
 
 var channelColumns = []string{
@@ -23,8 +27,26 @@ var channelColumns = []string{
 	"owner_id",
 	"is_private",
 	"dm_id",
+	"bot_id",
+	"bot_channel",
 	"created_on",
 	"last_updated",
+	"deleted_on",
+}
+
+// Deleted Channels are kept until they're purged so they can be restored. Every
+// query other than the ones for restoring leaves them out.
+var (
+	liveChannel    = sq.Eq{channelsTable + ".deleted_on": nil}
+	deletedChannel = sq.NotEq{channelsTable + ".deleted_on": nil}
+)
+
+// channelSortColumns are the columns Channels are ordered by for each SortField.
+var channelSortColumns = map[channels.SortField]string{
+	channels.SortByID:          "id",
+	channels.SortByName:        "name",
+	channels.SortByCreatedOn:   "created_on",
+	channels.SortByLastUpdated: "last_updated",
 }
 
 func init() {
@@ -35,9 +57,9 @@ func init() {
 }
 
 // GetChannel retrieves the channel corresponding to the given id.
-func (backend Backend) GetChannel(id int) (*channels.Channel, error) {
+func (backend Backend) GetChannel(ctx context.Context, id int) (*channels.Channel, error) {
 	channel := &channels.Channel{}
-	wasFound, err := backend.getSingle(id, channelsTable, channelColumns, channel)
+	wasFound, err := backend.getSingle(ctx, id, channelsTable, channelColumns, channel, liveChannel)
 	if err != nil {
 		log.WithError(err).Error("Query issue for get channel.")
 		return nil, err
@@ -50,26 +72,28 @@ func (backend Backend) GetChannel(id int) (*channels.Channel, error) {
 
 // GetChannelsOwnedByUser retrieves all of the Channels where the provided User ID
 // is the owner of the Channel.
-func (backend Backend) GetChannelsOwnedByUser(userID int) (channels.ChannelCollection, error) {
+func (backend Backend) GetChannelsOwnedByUser(ctx context.Context, userID int) (channels.ChannelCollection, error) {
 	sql, args, err := PSQLBuilder().
 		Select(channelColumns...).
 		From(channelsTable).
 		Where(sq.Eq{"owner_id": userID}).
+		Where(liveChannel).
 		ToSql()
 	if err != nil {
 		log.WithError(err).Error("Failed to build query for get channels owned by user.")
 		return nil, err
 	}
 
-	return backend.runMultiChannelQuery(sql, args)
+	return backend.runMultiChannelQuery(ctx, sql, args)
 }
 
 // GetAllChannels returns a list of all Channels if the isPrivate flag is nil. If the flag is set then only
 // private Channels are returned. If the flag is not set then only public Channels are returned.
-func (backend Backend) GetAllChannels(isPrivate *bool) (channels.ChannelCollection, error) {
+func (backend Backend) GetAllChannels(ctx context.Context, isPrivate *bool) (channels.ChannelCollection, error) {
 	builder := PSQLBuilder().
 		Select(channelColumns...).
-		From(channelsTable)
+		From(channelsTable).
+		Where(liveChannel)
 
 	if isPrivate != nil {
 		builder = builder.Where(sq.Eq{"is_private": *isPrivate})
@@ -81,17 +105,48 @@ func (backend Backend) GetAllChannels(isPrivate *bool) (channels.ChannelCollecti
 		return nil, err
 	}
 
-	return backend.runMultiChannelQuery(sql, args)
+	return backend.runMultiChannelQuery(ctx, sql, args)
+}
+
+// GetChannels retrieves the Channels matching the filter in the order it asks for.
+func (backend Backend) GetChannels(ctx context.Context, filter channels.Filter) (channels.ChannelCollection, error) {
+	builder := PSQLBuilder().
+		Select(channelColumns...).
+		From(channelsTable).
+		Where(liveChannel)
+
+	if filter.IsPrivate != nil {
+		builder = builder.Where(sq.Eq{"channels.is_private": *filter.IsPrivate})
+	}
+	if filter.OwnerID != 0 {
+		builder = builder.Where(sq.Eq{"channels.owner_id": filter.OwnerID})
+	}
+
+	sortCol, ok := channelSortColumns[filter.Sort]
+	if !ok {
+		sortCol = channelSortColumns[channels.SortByID]
+	}
+	builder = sortAndPage(builder, channelsTable, sortCol, filter.Descending, filter.Limit, filter.Offset)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build query for get channels.")
+		return nil, err
+	}
+
+	return backend.runMultiChannelQuery(ctx, sql, args)
 }
 
 // GetChannelsUserHasCharacterIn finds all of the Channels that the given User has at least one Character in.
-func (backend Backend) GetChannelsUserHasCharacterIn(userID int, isPrivate *bool) (channels.ChannelCollection, error) {
+func (backend Backend) GetChannelsUserHasCharacterIn(ctx context.Context, userID int, isPrivate *bool) (channels.ChannelCollection, error) {
 	builder := PSQLBuilder().
 		Select(channelColumns...).
 		Distinct().
 		From(channelsTable).
 		Join(fmt.Sprintf("%s ON %s.%s = %s.%s", charactersTable, charactersTable, "channel_id", channelsTable, "id")).
-		Where(sq.Eq{"user_id": userID})
+		Where(sq.Eq{"user_id": userID}).
+		Where(liveChannel).
+		Where(liveCharacter)
 
 	if isPrivate != nil {
 		builder = builder.Where(sq.Eq{"is_private": *isPrivate})
@@ -103,11 +158,76 @@ func (backend Backend) GetChannelsUserHasCharacterIn(userID int, isPrivate *bool
 		return nil, err
 	}
 
-	return backend.runMultiChannelQuery(sql, args)
+	return backend.runMultiChannelQuery(ctx, sql, args)
 }
 
-func (backend Backend) runMultiChannelQuery(sql string, args []interface{}) (channels.ChannelCollection, error) {
-	rows, err := backend.db.Queryx(sql, args...)
+// GetChannelByBotChannel retrieves the Channel that the Bot is linked to through
+// the given bot channel.
+func (backend Backend) GetChannelByBotChannel(ctx context.Context, botID int, botChannel string) (*channels.Channel, error) {
+	sql, args, err := PSQLBuilder().
+		Select(channelColumns...).
+		From(channelsTable).
+		Where(sq.Eq{"bot_id": botID}).
+		Where(sq.Eq{"bot_channel": botChannel}).
+		Where(liveChannel).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build query for get channel by bot channel.")
+		return nil, err
+	}
+
+	channel := &channels.Channel{}
+	err = backend.db.GetContext(ctx, channel, sql, args...)
+	if err != nil {
+		if err == sqlP.ErrNoRows {
+			return nil, channels.ErrChannelNotFound
+		}
+		log.WithError(err).Error("Issue executing get channel by bot channel query.")
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+// GetChannelsForBot retrieves every Channel that the Bot is linked to.
+func (backend Backend) GetChannelsForBot(ctx context.Context, botID int) (channels.ChannelCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(channelColumns...).
+		From(channelsTable).
+		Where(sq.Eq{"bot_id": botID}).
+		Where(sq.NotEq{"bot_channel": ""}).
+		Where(liveChannel).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build query for get channels for bot.")
+		return nil, err
+	}
+
+	return backend.runMultiChannelQuery(ctx, sql, args)
+}
+
+// RemoveBotFromChannels unlinks the Bot from every Channel it's linked to.
+func (backend Backend) RemoveBotFromChannels(ctx context.Context, botID int) error {
+	sql, args, err := PSQLBuilder().
+		Update(channelsTable).
+		Set("bot_id", 0).
+		Set("bot_channel", "").
+		Where(sq.Eq{"bot_id": botID}).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build remove bot from channels query.")
+		return err
+	}
+
+	_, err = backend.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute remove bot from channels query.")
+	}
+	return err
+}
+
+func (backend Backend) runMultiChannelQuery(ctx context.Context, sql string, args []interface{}) (channels.ChannelCollection, error) {
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
 	if err != nil {
 		log.WithError(err).Error("Issue executing query for multiple channels.")
 		return nil, err
@@ -131,7 +251,7 @@ func (backend Backend) runMultiChannelQuery(sql string, args []interface{}) (cha
 // CreateChannel creates a new channel using the provided channel info
 // and returns the result from the database.
 // TODO: What is userID for?
-func (backend Backend) CreateChannel(c *channels.Channel, userID int) (*channels.Channel, error) {
+func (backend Backend) CreateChannel(ctx context.Context, c *channels.Channel, userID int) (*channels.Channel, error) {
 	// TODO: Don't require description, default isprivate to false
 	kvs := map[string]interface{}{
 		"name":        c.Name,
@@ -140,10 +260,12 @@ func (backend Backend) CreateChannel(c *channels.Channel, userID int) (*channels
 		"owner_id":    c.OwnerID,
 		"is_private":  c.IsPrivate,
 		"dm_id":       c.DMID,
+		"bot_id":      c.BotID,
+		"bot_channel": c.BotChannel,
 	}
 
 	newChannel := &channels.Channel{}
-	err := backend.createSingle(channelsTable, channelsReturning, kvs, newChannel)
+	err := backend.createSingle(ctx, channelsTable, channelsReturning, kvs, newChannel)
 	if err != nil {
 		log.WithError(err).Error("Issue with create channel sql.")
 		return nil, err
@@ -152,20 +274,92 @@ func (backend Backend) CreateChannel(c *channels.Channel, userID int) (*channels
 	return newChannel, nil
 }
 
-// DeleteChannel deletes the channel that corresponds to the given ID.
-func (backend Backend) DeleteChannel(id int) error {
-	wasFound, err := backend.deleteSingle(id, channelsTable)
+// DeleteChannel marks the channel that corresponds to the given ID as deleted along
+// with its Characters and Messages. They all get the same deleted time so
+// restoring the Channel brings back exactly what was deleted with it.
+func (backend Backend) DeleteChannel(ctx context.Context, id int, expected *time.Time) error {
+	return backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		err := markDeleted(ctx, tx, channelsTable, sq.And(atVersion(expected, sq.Eq{"id": id})))
+		if err == sqlP.ErrNoRows {
+			return notFoundAt(expected, channels.ErrChannelNotFound)
+		} else if err != nil {
+			log.WithError(err).Error("Failed to execute delete channel query.")
+			return err
+		}
+
+		for _, table := range []string{charactersTable, messagesTable} {
+			err = markDeleted(ctx, tx, table, sq.Eq{"channel_id": id})
+			if err != nil && err != sqlP.ErrNoRows {
+				log.WithError(err).WithField("table", table).Error("Failed to delete from channel.")
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetDeletedChannel retrieves the deleted channel corresponding to the given id.
+func (backend Backend) GetDeletedChannel(ctx context.Context, id int) (*channels.Channel, error) {
+	channel := &channels.Channel{}
+	wasFound, err := backend.getSingle(ctx, id, channelsTable, channelColumns, channel, deletedChannel)
 	if err != nil {
-		log.WithError(err).Error("Failed to execute delete channel query.")
+		log.WithError(err).Error("Query issue for get deleted channel.")
+		return nil, err
 	} else if !wasFound {
-		return channels.ErrChannelNotFound
+		return nil, channels.ErrChannelNotFound
 	}
-	return err
+
+	return channel, nil
+}
+
+// GetDeletedChannels retrieves every Channel that has been deleted but not purged
+// yet, most recently deleted first.
+func (backend Backend) GetDeletedChannels(ctx context.Context) (channels.ChannelCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(channelColumns...).
+		From(channelsTable).
+		Where(deletedChannel).
+		OrderBy("deleted_on DESC").
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build query for get deleted channels.")
+		return nil, err
+	}
+
+	return backend.runMultiChannelQuery(ctx, sql, args)
+}
+
+// RestoreChannel undoes deleting the channel that corresponds to the given ID along
+// with the Characters and Messages that were deleted with it.
+func (backend Backend) RestoreChannel(ctx context.Context, id int) (*channels.Channel, error) {
+	restoredChannel := &channels.Channel{}
+	err := backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		for _, table := range []string{charactersTable, messagesTable} {
+			err := restoreDeletedWith(ctx, tx, table, "channel_id", channelsTable, id)
+			if err != nil {
+				log.WithError(err).WithField("table", table).Error("Failed to restore from channel.")
+				return err
+			}
+		}
+
+		err := unmarkDeleted(ctx, tx, channelsTable, channelsReturning, sq.Eq{"id": id}, restoredChannel)
+		if err == sqlP.ErrNoRows {
+			return channels.ErrChannelNotFound
+		} else if err != nil {
+			log.WithError(err).Error("Failed to execute restore channel query.")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restoredChannel, nil
 }
 
 // UpdateChannel updates the channel matching the given ID using the data
 // provided in the input channel. Returns the channel data from the database.
-func (backend Backend) UpdateChannel(id int, c *channels.Channel) (*channels.Channel, error) {
+func (backend Backend) UpdateChannel(ctx context.Context, id int, c *channels.Channel, expected *time.Time) (*channels.Channel, error) {
 	setMap := map[string]interface{}{
 		"name":        c.Name,
 		"description": c.Description,
@@ -173,15 +367,17 @@ func (backend Backend) UpdateChannel(id int, c *channels.Channel) (*channels.Cha
 		"owner_id":    c.OwnerID,
 		"is_private":  c.IsPrivate,
 		"dm_id":       c.DMID,
+		"bot_id":      c.BotID,
+		"bot_channel": c.BotChannel,
 	}
 
 	updatedChannel := &channels.Channel{}
-	wasFound, err := backend.updateSingle(id, channelsTable, channelsReturning, setMap, updatedChannel)
+	wasFound, err := backend.updateSingle(ctx, id, channelsTable, channelsReturning, setMap, updatedChannel, atVersion(expected, liveChannel)...)
 	if err != nil {
 		log.WithError(err).Error("Issue with query for update channel.")
 		return nil, err
 	} else if !wasFound {
-		return nil, channels.ErrChannelNotFound
+		return nil, notFoundAt(expected, channels.ErrChannelNotFound)
 	}
 
 	return updatedChannel, nil
@@ -3,10 +3,13 @@
 package postgresql
 
 import (
+	"context"
 	sqlP "database/sql"
 	"fmt"
+	"time"
 
 	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 
 	sq "github.com/Masterminds/squirrel"
@@ -16,7 +19,7 @@ const (
 	charactersTable = "characters"
 
 	// TODO: Figure out how to use characterColumns... instead - maybe init func w/ string join
-	charactersReturning = "RETURNING id, user_id, channel_id, name, description, created_on, last_updated"
+	charactersReturning = "RETURNING id, user_id, channel_id, name, description, bot_username, created_on, last_updated, deleted_on"
 )
 
 var characterColumns = []string{
@@ -25,10 +28,18 @@ var characterColumns = []string{
 	"channel_id",
 	"name",
 	"description",
+	"bot_username",
 	"created_on",
 	"last_updated",
+	"deleted_on",
 }
 
+// Deleted Characters are left out the same as deleted Channels.
+var (
+	liveCharacter    = sq.Eq{charactersTable + ".deleted_on": nil}
+	deletedCharacter = sq.NotEq{charactersTable + ".deleted_on": nil}
+)
+
 func init() {
 	// Add the Character table name in front of the columms to avoid ambigious references.
 	for i, col := range characterColumns {
@@ -38,12 +49,13 @@ func init() {
 
 // DoesUserHaveCharacterInChannel determines if the given User has a Character in the given
 // Channel.
-func (backend Backend) DoesUserHaveCharacterInChannel(userID, channelID int) (bool, error) {
+func (backend Backend) DoesUserHaveCharacterInChannel(ctx context.Context, userID, channelID int) (bool, error) {
 	sql, args, err := PSQLBuilder().
 		Select("1").
 		From(charactersTable).
 		Where(sq.Eq{"channel_id": channelID}).
 		Where(sq.Eq{"user_id": userID}).
+		Where(liveCharacter).
 		ToSql()
 	if err != nil {
 		log.WithError(err).Error("Failed to build query for does user have character in channel.")
@@ -55,7 +67,7 @@ func (backend Backend) DoesUserHaveCharacterInChannel(userID, channelID int) (bo
 	sql = fmt.Sprintf("select exists(%s)", sql)
 
 	var exists bool
-	err = backend.db.QueryRow(sql, args...).Scan(&exists)
+	err = backend.db.QueryRowContext(ctx, sql, args...).Scan(&exists)
 	if err != nil && err != sqlP.ErrNoRows {
 		log.WithError(err).Error("Issue executing query for does user have character in channel.")
 		return false, err
@@ -65,19 +77,43 @@ func (backend Backend) DoesUserHaveCharacterInChannel(userID, channelID int) (bo
 }
 
 // GetCharactersInChannel retrieves all of the Characters in the given Channel.
-func (backend Backend) GetCharactersInChannel(channelID int) (characters.CharacterCollection, error) {
+func (backend Backend) GetCharactersInChannel(ctx context.Context, channelID int) (characters.CharacterCollection, error) {
 	sql, args, err := PSQLBuilder().
 		Select(characterColumns...).
 		From(charactersTable).
-		Where(sq.Eq{"channel_id": channelID}).ToSql()
+		Where(sq.Eq{"channel_id": channelID}).
+		Where(liveCharacter).
+		ToSql()
 	if err != nil {
 		log.WithError(err).Error("Failed to build query for get characters in channel.")
 		return nil, err
 	}
 
-	rows, err := backend.db.Queryx(sql, args...)
+	return backend.runMultiCharacterQuery(ctx, sql, args)
+}
+
+// GetDeletedCharactersInChannel retrieves the Characters in the given Channel that
+// have been deleted but not purged yet, most recently deleted first.
+func (backend Backend) GetDeletedCharactersInChannel(ctx context.Context, channelID int) (characters.CharacterCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(characterColumns...).
+		From(charactersTable).
+		Where(sq.Eq{"channel_id": channelID}).
+		Where(deletedCharacter).
+		OrderBy("deleted_on DESC").
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build query for get deleted characters in channel.")
+		return nil, err
+	}
+
+	return backend.runMultiCharacterQuery(ctx, sql, args)
+}
+
+func (backend Backend) runMultiCharacterQuery(ctx context.Context, sql string, args []interface{}) (characters.CharacterCollection, error) {
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
 	if err != nil {
-		log.WithError(err).Error("Issue executing query for get characters in channel.")
+		log.WithError(err).Error("Issue executing query for multiple characters.")
 		return nil, err
 	}
 
@@ -86,7 +122,7 @@ func (backend Backend) GetCharactersInChannel(channelID int) (characters.Charact
 		var char characters.Character
 		err = rows.StructScan(&char)
 		if err != nil {
-			log.WithError(err).Error("Issue loading character for get characters in channel.")
+			log.WithError(err).Error("Issue loading character during multiple character query.")
 			return nil, err
 		}
 
@@ -97,14 +133,14 @@ func (backend Backend) GetCharactersInChannel(channelID int) (characters.Charact
 }
 
 // CreateCharacter creates a new Character in the given Channel with the given data.
-func (backend Backend) CreateCharacter(c *characters.Character) (*characters.Character, error) {
+func (backend Backend) CreateCharacter(ctx context.Context, c *characters.Character) (*characters.Character, error) {
 	kvs := map[string]interface{}{
 		"user_id":    c.UserID,
 		"channel_id": c.ChannelID,
 	}
 
 	newChar := &characters.Character{}
-	err := backend.createSingle(charactersTable, charactersReturning, kvs, newChar)
+	err := backend.createSingle(ctx, charactersTable, charactersReturning, kvs, newChar)
 	if err != nil {
 		log.WithError(err).Error("Issue with create character sql.")
 		return nil, err
@@ -115,39 +151,78 @@ func (backend Backend) CreateCharacter(c *characters.Character) (*characters.Cha
 
 // UpdateCharacter updates the Character matching the input ID using the data from
 // the input Character.
-func (backend Backend) UpdateCharacter(id int, c *characters.Character) (*characters.Character, error) {
+func (backend Backend) UpdateCharacter(ctx context.Context, id int, c *characters.Character, expected *time.Time) (*characters.Character, error) {
 	setMap := map[string]interface{}{
-		"name":        c.Name,
-		"description": c.Description,
+		"name":         c.Name,
+		"description":  c.Description,
+		"bot_username": c.BotUsername,
 	}
 
 	updatedCharacter := &characters.Character{}
-	wasFound, err := backend.updateSingle(id, channelsTable, channelsReturning, setMap, updatedCharacter)
+	wasFound, err := backend.updateSingle(ctx, id, charactersTable, charactersReturning, setMap, updatedCharacter, atVersion(expected, liveCharacter)...)
 	if err != nil {
 		log.WithError(err).Error("Issue with query for update character.")
 		return nil, err
 	} else if !wasFound {
-		return nil, characters.ErrCharacterNotFound
+		return nil, notFoundAt(expected, characters.ErrCharacterNotFound)
 	}
 
 	return updatedCharacter, nil
 }
 
-// DeleteCharacter deletes the Character matching the input ID.
-func (backend Backend) DeleteCharacter(characterID int) error {
-	wasFound, err := backend.deleteSingle(characterID, charactersTable)
+// DeleteCharacter marks the Character matching the input ID as deleted along with
+// its Messages so restoring the Character brings them back too.
+func (backend Backend) DeleteCharacter(ctx context.Context, characterID int, expected *time.Time) error {
+	return backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		err := markDeleted(ctx, tx, charactersTable, sq.And(atVersion(expected, sq.Eq{"id": characterID})))
+		if err == sqlP.ErrNoRows {
+			return notFoundAt(expected, characters.ErrCharacterNotFound)
+		} else if err != nil {
+			log.WithError(err).Error("Failed to execute delete character query.")
+			return err
+		}
+
+		err = markDeleted(ctx, tx, messagesTable, sq.Eq{"character_id": characterID})
+		if err != nil && err != sqlP.ErrNoRows {
+			log.WithError(err).Error("Failed to delete messages from character.")
+			return err
+		}
+		return nil
+	})
+}
+
+// RestoreCharacter undoes deleting the Character matching the input ID along with
+// the Messages that were deleted with it. Characters in a deleted Channel can only
+// come back by restoring the Channel.
+func (backend Backend) RestoreCharacter(ctx context.Context, characterID int) (*characters.Character, error) {
+	restoredCharacter := &characters.Character{}
+	err := backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		err := restoreDeletedWith(ctx, tx, messagesTable, "character_id", charactersTable, characterID)
+		if err != nil {
+			log.WithError(err).Error("Failed to restore messages from character.")
+			return err
+		}
+
+		inLiveChannel := sq.Expr(fmt.Sprintf("channel_id IN (SELECT id FROM %s WHERE deleted_on IS NULL)", channelsTable))
+		err = unmarkDeleted(ctx, tx, charactersTable, charactersReturning, sq.And{sq.Eq{"id": characterID}, inLiveChannel}, restoredCharacter)
+		if err == sqlP.ErrNoRows {
+			return characters.ErrCharacterNotFound
+		} else if err != nil {
+			log.WithError(err).Error("Failed to execute restore character query.")
+		}
+		return err
+	})
 	if err != nil {
-		log.WithError(err).Error("Failed to execute delete user query.")
-	} else if !wasFound {
-		return characters.ErrCharacterNotFound
+		return nil, err
 	}
-	return err
+
+	return restoredCharacter, nil
 }
 
 // GetCharacter retrieves a single Character by ID.
-func (backend Backend) GetCharacter(id int) (*characters.Character, error) {
+func (backend Backend) GetCharacter(ctx context.Context, id int) (*characters.Character, error) {
 	char := &characters.Character{}
-	wasFound, err := backend.getSingle(id, channelsTable, characterColumns, char)
+	wasFound, err := backend.getSingle(ctx, id, charactersTable, characterColumns, char, liveCharacter)
 	if err != nil {
 		log.WithError(err).Error("Query issue for get character.")
 		return nil, err
@@ -158,22 +233,63 @@ func (backend Backend) GetCharacter(id int) (*characters.Character, error) {
 	return char, nil
 }
 
-// DeleteCharactersFromUser deletes all of the Characters for the given
-// User.
-func (backend Backend) DeleteCharactersFromUser(userID int) error {
-	err := backend.deleteMultiple(channelsTable, "user_id", userID)
+// GetDeletedCharacter retrieves a single deleted Character by ID.
+func (backend Backend) GetDeletedCharacter(ctx context.Context, id int) (*characters.Character, error) {
+	char := &characters.Character{}
+	wasFound, err := backend.getSingle(ctx, id, charactersTable, characterColumns, char, deletedCharacter)
 	if err != nil {
-		log.WithError(err).Error("Issue with delete characters from user query.")
+		log.WithError(err).Error("Query issue for get deleted character.")
+		return nil, err
+	} else if !wasFound {
+		return nil, characters.ErrCharacterNotFound
 	}
-	return err
+
+	return char, nil
 }
 
-// DeleteCharactersFromChannel deletes all of the Characters for the given
-// Channel.
-func (backend Backend) DeleteCharactersFromChannel(channelID int) error {
-	err := backend.deleteMultiple(charactersTable, "channel_id", channelID)
+// GetCharacterByBotUsername retrieves the Character in the given Channel that has
+// been linked to the bot username.
+func (backend Backend) GetCharacterByBotUsername(ctx context.Context, channelID int, botUsername string) (*characters.Character, error) {
+	sql, args, err := PSQLBuilder().
+		Select(characterColumns...).
+		From(charactersTable).
+		Where(sq.Eq{"channel_id": channelID}).
+		Where(sq.Eq{"bot_username": botUsername}).
+		Where(liveCharacter).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build query for get character by bot username.")
+		return nil, err
+	}
+
+	char := &characters.Character{}
+	err = backend.db.GetContext(ctx, char, sql, args...)
+	if err != nil {
+		if err == sqlP.ErrNoRows {
+			return nil, characters.ErrCharacterNotFound
+		}
+		log.WithError(err).Error("Issue executing get character by bot username query.")
+		return nil, err
+	}
+
+	return char, nil
+}
+
+// deleteCharactersFromUser permanently deletes all of the Characters for the given
+// User, including deleted ones. Deleting a User can't be undone so neither can this.
+func deleteCharactersFromUser(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	sql, args, err := PSQLBuilder().
+		Delete(charactersTable).
+		Where(sq.Eq{"user_id": userID}).
+		ToSql()
 	if err != nil {
-		log.WithError(err).Error("Issue with delete characters from channel query.")
+		log.WithError(err).Error("Failed to build delete characters from user query.")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Issue with delete characters from user query.")
 	}
 	return err
 }
@@ -0,0 +1,130 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	sqlP "database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	log "github.com/sirupsen/logrus"
+)
+
+// markDeleted sets the deleted time on every row in the table matching where that
+// isn't already deleted. If there aren't any then the error is sqlP.ErrNoRows.
+func markDeleted(ctx context.Context, tx *sqlx.Tx, table string, where sq.Sqlizer) error {
+	sql, args, err := PSQLBuilder().
+		Update(table).
+		Set("deleted_on", sq.Expr("now()")).
+		Where(where).
+		Where(sq.Eq{"deleted_on": nil}).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build mark deleted query.")
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+
+	numRowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.WithError(err).Error("Failed to determine how many rows were affected by mark deleted query.")
+		return err
+	}
+
+	if numRowsAffected <= 0 {
+		return sqlP.ErrNoRows
+	}
+	return nil
+}
+
+// unmarkDeleted clears the deleted time of the row in the table matching where and
+// loads the restored row into obj. If the row isn't deleted then the error is
+// sqlP.ErrNoRows, and if a live row has taken one of its unique values then the
+// error is the FieldErrors for it.
+func unmarkDeleted(ctx context.Context, tx *sqlx.Tx, table, returning string, where sq.Sqlizer, obj interface{}) error {
+	sql, args, err := PSQLBuilder().
+		Update(table).
+		Set("deleted_on", nil).
+		Where(where).
+		Where(sq.NotEq{"deleted_on": nil}).
+		Suffix(returning).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build unmark deleted query.")
+		return err
+	}
+
+	return fieldErrorsFor(tx.QueryRowxContext(ctx, sql, args...).StructScan(obj))
+}
+
+// restoreDeletedWith clears the deleted time of the rows in the table whose col is
+// the parent's ID and that were deleted at the same time as the parent. This has
+// to happen before the parent itself is restored. Rows that would break a unique
+// value a live row has taken since result in the FieldErrors for it.
+func restoreDeletedWith(ctx context.Context, tx *sqlx.Tx, table, col, parentTable string, parentID int) error {
+	sql, args, err := PSQLBuilder().
+		Update(table).
+		Set("deleted_on", nil).
+		Where(sq.Eq{col: parentID}).
+		Where(fmt.Sprintf("deleted_on = (SELECT deleted_on FROM %s WHERE id = ?)", parentTable), parentID).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build restore deleted with query.")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	return fieldErrorsFor(err)
+}
+
+// PurgeDeleted permanently deletes every Channel, Character, and Message that was
+// deleted before the given time. Webhooks and Bans for purged Channels go with them. Things
+// are never deleted before whatever they belong to so nothing is left pointing at a
+// purged row.
+func (backend Backend) PurgeDeleted(ctx context.Context, before time.Time) error {
+	purgedChannels := fmt.Sprintf("SELECT id FROM %s WHERE deleted_on < ?", channelsTable)
+	purgedWebhooks := fmt.Sprintf("SELECT id FROM %s WHERE channel_id IN (%s)", webhooksTable, purgedChannels)
+
+	deletes := []struct {
+		table string
+		where sq.Sqlizer
+	}{
+		{messagesTable, sq.Lt{"deleted_on": before}},
+		{charactersTable, sq.Lt{"deleted_on": before}},
+		{webhookDeliveriesTable, sq.Expr(fmt.Sprintf("webhook_id IN (%s)", purgedWebhooks), before)},
+		{webhooksTable, sq.Expr(fmt.Sprintf("channel_id IN (%s)", purgedChannels), before)},
+		{bansTable, sq.Expr(fmt.Sprintf("channel_id IN (%s)", purgedChannels), before)},
+		{channelsTable, sq.Lt{"deleted_on": before}},
+	}
+
+	return backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		for _, d := range deletes {
+			sql, args, err := PSQLBuilder().
+				Delete(d.table).
+				Where(d.where).
+				ToSql()
+			if err != nil {
+				log.WithError(err).Error("Failed to build purge deleted query.")
+				return err
+			}
+
+			result, err := tx.ExecContext(ctx, sql, args...)
+			if err != nil {
+				log.WithError(err).WithField("table", d.table).Error("Failed to execute purge deleted query.")
+				return err
+			}
+
+			if numRowsAffected, err := result.RowsAffected(); err == nil && numRowsAffected > 0 {
+				log.WithField("table", d.table).WithField("rows", numRowsAffected).Info("Purged deleted rows.")
+			}
+		}
+		return nil
+	})
+}
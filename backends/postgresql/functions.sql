@@ -14,4 +14,17 @@ BEGIN
     RETURN NEW;
 END;
 $BODY$
-LANGUAGE plpgsql;
\ No newline at end of file
+LANGUAGE plpgsql;
+
+-- Provides a function for tables that can only be added to, like the audit log. Used
+-- in triggers that run before updates and deletes.
+
+CREATE OR REPLACE FUNCTION prevent_change()
+  RETURNS trigger
+AS
+$BODY$
+BEGIN
+    RAISE EXCEPTION '% is append only', TG_TABLE_NAME;
+END;
+$BODY$
+LANGUAGE plpgsql;
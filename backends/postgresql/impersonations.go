@@ -0,0 +1,121 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	impersonationsTable     = "impersonations"
+	impersonationsReturning = "RETURNING id, admin_id, user_id, reason, allow_writes, starts_on, expires_on, ended_on, " + isImpersonatingColumn + ", created_on, last_updated"
+
+	// activeImpersonationCondition is true for Impersonations that are in effect
+	// right now. Expired Impersonations stop matching on their own.
+	activeImpersonationCondition = "impersonations.ended_on IS NULL AND impersonations.expires_on > now()"
+
+	isImpersonatingColumn = "(" + activeImpersonationCondition + ") AS is_active"
+)
+
+var impersonationColumns = []string{
+	"id",
+	"admin_id",
+	"user_id",
+	"reason",
+	"allow_writes",
+	"starts_on",
+	"expires_on",
+	"ended_on",
+	"created_on",
+	"last_updated",
+}
+
+func init() {
+	// Add the Impersonation table name in front of the columms to avoid ambigious references.
+	for i, col := range impersonationColumns {
+		impersonationColumns[i] = fmt.Sprintf("%s.%s", impersonationsTable, col)
+	}
+	impersonationColumns = append(impersonationColumns, isImpersonatingColumn)
+}
+
+// GetActiveImpersonation retrieves the Impersonation the admin has in effect, or
+// ErrNotImpersonating if there isn't one.
+func (backend Backend) GetActiveImpersonation(ctx context.Context, adminID int) (*impersonations.Impersonation, error) {
+	sql, args, err := PSQLBuilder().
+		Select(impersonationColumns...).
+		From(impersonationsTable).
+		Where(sq.Eq{"impersonations.admin_id": adminID}).
+		Where(activeImpersonationCondition).
+		OrderBy("impersonations.id DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get active impersonation query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get active impersonation query.")
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, impersonations.ErrNotImpersonating
+	}
+
+	impersonation := &impersonations.Impersonation{}
+	err = rows.StructScan(impersonation)
+	if err != nil {
+		log.WithError(err).Error("Failed to load impersonation from get active impersonation query.")
+		return nil, err
+	}
+
+	return impersonation, nil
+}
+
+// CreateImpersonation starts a new Impersonation using the provided data. Times
+// are stored in UTC.
+func (backend Backend) CreateImpersonation(ctx context.Context, i *impersonations.Impersonation) (*impersonations.Impersonation, error) {
+	kvs := map[string]interface{}{
+		"admin_id":     i.AdminID,
+		"user_id":      i.UserID,
+		"reason":       i.Reason,
+		"allow_writes": i.AllowWrites,
+		"expires_on":   i.ExpiresOn.UTC(),
+	}
+
+	newImpersonation := &impersonations.Impersonation{}
+	err := backend.createSingle(ctx, impersonationsTable, impersonationsReturning, kvs, newImpersonation)
+	if err != nil {
+		log.WithError(err).Error("Issue with create impersonation sql.")
+		return nil, err
+	}
+
+	return newImpersonation, nil
+}
+
+// EndImpersonation ends the Impersonation matching the given ID. Impersonations
+// that already ended are ErrNotImpersonating.
+func (backend Backend) EndImpersonation(ctx context.Context, id int) (*impersonations.Impersonation, error) {
+	setMap := map[string]interface{}{
+		"ended_on": sq.Expr("now()"),
+	}
+
+	endedImpersonation := &impersonations.Impersonation{}
+	wasFound, err := backend.updateSingle(ctx, id, impersonationsTable, impersonationsReturning, setMap, endedImpersonation, sq.Expr(activeImpersonationCondition))
+	if err != nil {
+		log.WithError(err).Error("Issue with query for end impersonation.")
+		return nil, err
+	} else if !wasFound {
+		return nil, impersonations.ErrNotImpersonating
+	}
+
+	return endedImpersonation, nil
+}
@@ -3,16 +3,20 @@
 package postgresql
 
 import (
+	"context"
+	sqlP "database/sql"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	messagesTable     = "messages"
-	messagesReturning = "RETURNING id, character_id, channel_id, content, is_story, created_on, last_updated"
+	messagesReturning = "RETURNING id, character_id, channel_id, content, is_story, created_on, last_updated, deleted_on"
 )
 
 var messageColumns = []string{
@@ -23,8 +27,15 @@ var messageColumns = []string{
 	"is_story",
 	"created_on",
 	"last_updated",
+	"deleted_on",
 }
 
+// Deleted Messages are left out the same as deleted Channels.
+var (
+	liveMessage    = sq.Eq{messagesTable + ".deleted_on": nil}
+	deletedMessage = sq.NotEq{messagesTable + ".deleted_on": nil}
+)
+
 func init() {
 	// Add the Message table name in front of the columms to avoid ambigious references.
 	for i, col := range messageColumns {
@@ -35,26 +46,60 @@ func init() {
 // GetMessagesInChannel retrieves all of the Messages in the database
 // for the given Channel by ID. If onlyStory is nil then both msgType are returned.
 // If onlyStory is set then only story Messages are returned. Otherwise only meta
-// Messages are retrieved.
-func (backend Backend) GetMessagesInChannel(channelID int, onlyStory *bool) (messages.MessageCollection, error) {
+// Messages are retrieved. Messages are ordered oldest first and page can be used
+// to only get some of them.
+func (backend Backend) GetMessagesInChannel(ctx context.Context, channelID int, onlyStory *bool, page *messages.Page) (messages.MessageCollection, error) {
 	builder := PSQLBuilder().
 		Select(messageColumns...).
 		From(messagesTable).
-		Where(sq.Eq{"channel_id": channelID})
+		Where(sq.Eq{"channel_id": channelID}).
+		Where(liveMessage).
+		OrderBy("id")
 
 	if onlyStory != nil {
 		builder = builder.Where(sq.Eq{"is_story": *onlyStory})
 	}
 
+	if page != nil {
+		if page.AfterID > 0 {
+			builder = builder.Where(sq.Gt{"id": page.AfterID})
+		}
+		if page.Limit > 0 {
+			builder = builder.Limit(uint64(page.Limit))
+		}
+	}
+
 	sql, args, err := builder.ToSql()
 	if err != nil {
 		log.WithError(err).Error("Failed tobuild get messages in channel query.")
 		return nil, err
 	}
 
-	rows, err := backend.db.Queryx(sql, args...)
+	return backend.runMultiMessageQuery(ctx, sql, args)
+}
+
+// GetDeletedMessagesInChannel retrieves the Messages in the given Channel that have
+// been deleted but not purged yet, most recently deleted first.
+func (backend Backend) GetDeletedMessagesInChannel(ctx context.Context, channelID int) (messages.MessageCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(messageColumns...).
+		From(messagesTable).
+		Where(sq.Eq{"channel_id": channelID}).
+		Where(deletedMessage).
+		OrderBy("deleted_on DESC", "id").
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get deleted messages in channel query.")
+		return nil, err
+	}
+
+	return backend.runMultiMessageQuery(ctx, sql, args)
+}
+
+func (backend Backend) runMultiMessageQuery(ctx context.Context, sql string, args []interface{}) (messages.MessageCollection, error) {
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
 	if err != nil {
-		log.WithError(err).Error("Failed to execute get messages in channel query.")
+		log.WithError(err).Error("Failed to execute query for multiple messages.")
 		return nil, err
 	}
 
@@ -63,7 +108,7 @@ func (backend Backend) GetMessagesInChannel(channelID int, onlyStory *bool) (mes
 		var message messages.Message
 		err = rows.StructScan(&message)
 		if err != nil {
-			log.WithError(err).Error("Failed to load message from get messages in channel query.")
+			log.WithError(err).Error("Failed to load message during multiple message query.")
 			return nil, err
 		}
 
@@ -74,15 +119,16 @@ func (backend Backend) GetMessagesInChannel(channelID int, onlyStory *bool) (mes
 }
 
 // CreateMessage creates a new Message in the database using the provided data.
-func (backend Backend) CreateMessage(m *messages.Message) (*messages.Message, error) {
+func (backend Backend) CreateMessage(ctx context.Context, m *messages.Message) (*messages.Message, error) {
 	kvs := map[string]interface{}{
 		"character_id": m.CharacterID,
 		"channel_id":   m.ChannelID,
 		"content":      m.Content,
+		"is_story":     m.IsStory,
 	}
 
 	newMessage := &messages.Message{}
-	err := backend.createSingle(messagesTable, messagesReturning, kvs, newMessage)
+	err := backend.createSingle(ctx, messagesTable, messagesReturning, kvs, newMessage)
 	if err != nil {
 		log.WithError(err).Error("Issue with create message sql.")
 		return nil, err
@@ -93,9 +139,9 @@ func (backend Backend) CreateMessage(m *messages.Message) (*messages.Message, er
 
 // GetMessage retrieves the Message from the database that matches the
 // given ID.
-func (backend Backend) GetMessage(id int) (*messages.Message, error) {
+func (backend Backend) GetMessage(ctx context.Context, id int) (*messages.Message, error) {
 	message := &messages.Message{}
-	wasFound, err := backend.getSingle(id, messagesTable, messageColumns, message)
+	wasFound, err := backend.getSingle(ctx, id, messagesTable, messageColumns, message, liveMessage)
 	if err != nil {
 		return nil, err
 	} else if !wasFound {
@@ -105,75 +151,94 @@ func (backend Backend) GetMessage(id int) (*messages.Message, error) {
 	return message, nil
 }
 
-// DeleteMessagesFromChannel deletes all of the Messages in the database
-// that have their Channel match the given Channel ID.
-func (backend Backend) DeleteMessagesFromChannel(channelID int) error {
-	err := backend.deleteMultiple(messagesTable, "channel_id", channelID)
+// GetDeletedMessage retrieves the deleted Message from the database that matches
+// the given ID.
+func (backend Backend) GetDeletedMessage(ctx context.Context, id int) (*messages.Message, error) {
+	message := &messages.Message{}
+	wasFound, err := backend.getSingle(ctx, id, messagesTable, messageColumns, message, deletedMessage)
 	if err != nil {
-		log.WithError(err).Error("Issue with delete messages from character query.")
+		return nil, err
+	} else if !wasFound {
+		return nil, messages.ErrMessageNotFound
 	}
-	return err
+
+	return message, nil
 }
 
-// DeleteMessage deletes the Message in the database that matches
-// the given ID.
-func (backend Backend) DeleteMessage(id int) error {
-	wasFound, err := backend.deleteSingle(id, messagesTable)
+// DeleteMessage marks the Message in the database that matches the given ID as
+// deleted.
+func (backend Backend) DeleteMessage(ctx context.Context, id int, expected *time.Time) error {
+	return backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		err := markDeleted(ctx, tx, messagesTable, sq.And(atVersion(expected, sq.Eq{"id": id})))
+		if err == sqlP.ErrNoRows {
+			return notFoundAt(expected, messages.ErrMessageNotFound)
+		} else if err != nil {
+			log.WithError(err).Error("Failed to execute delete message query.")
+		}
+		return err
+	})
+}
+
+// RestoreMessage undoes deleting the Message in the database that matches the
+// given ID. Messages from a deleted Character can only come back by restoring the
+// Character.
+func (backend Backend) RestoreMessage(ctx context.Context, id int) (*messages.Message, error) {
+	restoredMessage := &messages.Message{}
+	err := backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		fromLiveCharacter := sq.Expr(fmt.Sprintf("character_id IN (SELECT id FROM %s WHERE deleted_on IS NULL)", charactersTable))
+		err := unmarkDeleted(ctx, tx, messagesTable, messagesReturning, sq.And{sq.Eq{"id": id}, fromLiveCharacter}, restoredMessage)
+		if err == sqlP.ErrNoRows {
+			return messages.ErrMessageNotFound
+		} else if err != nil {
+			log.WithError(err).Error("Failed to execute restore message query.")
+		}
+		return err
+	})
 	if err != nil {
-		log.WithError(err).Error("Failed to execute delete message query.")
-	} else if !wasFound {
-		return messages.ErrMessageNotFound
+		return nil, err
 	}
-	return err
+
+	return restoredMessage, nil
 }
 
 // UpdateMessage updates the Message in the database matching the input ID
 // with the data from the given Message.
-func (backend Backend) UpdateMessage(id int, m *messages.Message) (*messages.Message, error) {
+func (backend Backend) UpdateMessage(ctx context.Context, id int, m *messages.Message, expected *time.Time) (*messages.Message, error) {
 	setMap := map[string]interface{}{
 		"content": m.Content,
 	}
 
 	updatedMessage := &messages.Message{}
-	wasFound, err := backend.updateSingle(id, messagesTable, messagesReturning, setMap, updatedMessage)
+	wasFound, err := backend.updateSingle(ctx, id, messagesTable, messagesReturning, setMap, updatedMessage, atVersion(expected, liveMessage)...)
 	if err != nil {
 		log.WithError(err).Error("Issue with query for update message.")
 		return nil, err
 	} else if !wasFound {
-		return nil, messages.ErrMessageNotFound
+		return nil, notFoundAt(expected, messages.ErrMessageNotFound)
 	}
 
 	return updatedMessage, nil
 }
 
-// DeleteMessagesFromUser deletes all of the messages that were from the input
-// User. This means that the Messages are from a Character that is the User's.
-func (backend Backend) DeleteMessagesFromUser(userID int) error {
-	findMessagesQuery := fmt.Sprintf("SELECT messages.id FROM messages INNER JOIN "+
-		"characters characters.id ON messages.character_id WHERE characters.user_id = %d", userID)
+// deleteMessagesFromUser permanently deletes all of the messages that were from the
+// input User, including deleted ones. This means that the Messages are from a
+// Character that is the User's.
+func deleteMessagesFromUser(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	findMessagesQuery := fmt.Sprintf("SELECT messages.id FROM %s INNER JOIN "+
+		"%s ON characters.id = messages.character_id WHERE characters.user_id = ?", messagesTable, charactersTable)
 
 	sql, args, err := PSQLBuilder().
 		Delete(messagesTable).
-		Where(fmt.Sprintf("id IN (%s)", findMessagesQuery)).
+		Where(fmt.Sprintf("id IN (%s)", findMessagesQuery), userID).
 		ToSql()
 	if err != nil {
 		log.WithError(err).Error("Failed to build delete messages from user sql.")
 		return err
 	}
 
-	_, err = backend.db.Exec(sql, args...)
+	_, err = tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		log.WithError(err).Error("Failed to execute delete messages from user query.")
 	}
 	return err
 }
-
-// DeleteMessagesFromCharacter deletes all of the messages that match the input
-// Character ID.
-func (backend Backend) DeleteMessagesFromCharacter(characterID int) error {
-	err := backend.deleteMultiple(messagesTable, "character_id", characterID)
-	if err != nil {
-		log.WithError(err).Error("Issue with delete messages from character query.")
-	}
-	return err
-}
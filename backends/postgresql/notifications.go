@@ -0,0 +1,90 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	sqlP "database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	notificationPreferencesTable     = "notification_preferences"
+	notificationPreferencesReturning = "RETURNING user_id, mentions, invitations, turns, created_on, last_updated"
+)
+
+var notificationPreferencesColumns = []string{
+	"user_id",
+	"mentions",
+	"invitations",
+	"turns",
+	"created_on",
+	"last_updated",
+}
+
+// GetNotificationPreferences retrieves the notification Preferences for the given
+// User. Users that haven't saved any Preferences get the defaults.
+func (backend Backend) GetNotificationPreferences(ctx context.Context, userID int) (*notifications.Preferences, error) {
+	sql, args, err := PSQLBuilder().
+		Select(notificationPreferencesColumns...).
+		From(notificationPreferencesTable).
+		Where(sq.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get notification preferences query.")
+		return nil, err
+	}
+
+	prefs := &notifications.Preferences{}
+	err = backend.db.GetContext(ctx, prefs, sql, args...)
+	if err != nil {
+		if err == sqlP.ErrNoRows {
+			return notifications.DefaultPreferences(userID), nil
+		}
+		log.WithError(err).Error("Issue executing get notification preferences query.")
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// UpdateNotificationPreferences saves the notification Preferences for the given
+// User creating them if they don't exist yet. The expected time only applies when
+// they already exist.
+func (backend Backend) UpdateNotificationPreferences(ctx context.Context, userID int, p *notifications.Preferences, expected *time.Time) (*notifications.Preferences, error) {
+	onConflict := "ON CONFLICT (user_id) DO UPDATE SET mentions = EXCLUDED.mentions, " +
+		"invitations = EXCLUDED.invitations, turns = EXCLUDED.turns"
+	onConflictArgs := []interface{}{}
+	if expected != nil {
+		onConflict += fmt.Sprintf(" WHERE %s.last_updated = ?", notificationPreferencesTable)
+		onConflictArgs = append(onConflictArgs, *expected)
+	}
+
+	sql, args, err := PSQLBuilder().
+		Insert(notificationPreferencesTable).
+		Columns("user_id", "mentions", "invitations", "turns").
+		Values(userID, p.Mentions, p.Invitations, p.Turns).
+		Suffix(onConflict+" "+notificationPreferencesReturning, onConflictArgs...).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build update notification preferences query.")
+		return nil, err
+	}
+
+	updatedPrefs := &notifications.Preferences{}
+	err = backend.db.QueryRowxContext(ctx, sql, args...).StructScan(updatedPrefs)
+	if err == sqlP.ErrNoRows {
+		// Only skipped when they were updated since the expected time
+		return nil, notFoundAt(expected, err)
+	} else if err != nil {
+		log.WithError(err).Error("Failed to execute update notification preferences query.")
+		return nil, err
+	}
+
+	return updatedPrefs, nil
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	reportsTable     = "reports"
+	reportsReturning = "RETURNING id, reporter_id, target_type, target_id, target_user_id, reason, status, assignee_id, action, resolution, closed_by, closed_on, created_on, last_updated"
+)
+
+var reportColumns = []string{
+	"id",
+	"reporter_id",
+	"target_type",
+	"target_id",
+	"target_user_id",
+	"reason",
+	"status",
+	"assignee_id",
+	"action",
+	"resolution",
+	"closed_by",
+	"closed_on",
+	"created_on",
+	"last_updated",
+}
+
+func init() {
+	// Add the Report table name in front of the columms to avoid ambigious references.
+	for i, col := range reportColumns {
+		reportColumns[i] = fmt.Sprintf("%s.%s", reportsTable, col)
+	}
+}
+
+// openReport limits updates to Reports that haven't been closed yet.
+var openReport = sq.Eq{"reports.status": reports.OpenStatus}
+
+// GetReports retrieves the Reports matching the filter, oldest first so the queue
+// is worked through in order.
+func (backend Backend) GetReports(ctx context.Context, filter reports.Filter) (reports.ReportCollection, error) {
+	builder := PSQLBuilder().
+		Select(reportColumns...).
+		From(reportsTable).
+		OrderBy("reports.created_on ASC", "reports.id ASC")
+
+	if filter.Status != "" {
+		builder = builder.Where(sq.Eq{"reports.status": filter.Status})
+	}
+	if filter.TargetType != "" {
+		builder = builder.Where(sq.Eq{"reports.target_type": filter.TargetType})
+	}
+	if filter.AssigneeID != nil {
+		builder = builder.Where(sq.Eq{"reports.assignee_id": *filter.AssigneeID})
+	}
+	if filter.ReporterID != 0 {
+		builder = builder.Where(sq.Eq{"reports.reporter_id": filter.ReporterID})
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get reports query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get reports query.")
+		return nil, err
+	}
+
+	outReports := make(reports.ReportCollection, 0)
+	for rows.Next() {
+		var report reports.Report
+		err = rows.StructScan(&report)
+		if err != nil {
+			log.WithError(err).Error("Failed to load report from get reports query.")
+			return nil, err
+		}
+
+		outReports = append(outReports, &report)
+	}
+
+	return outReports, nil
+}
+
+// GetReport retrieves the Report matching the given ID.
+func (backend Backend) GetReport(ctx context.Context, id int) (*reports.Report, error) {
+	report := &reports.Report{}
+	wasFound, err := backend.getSingle(ctx, id, reportsTable, reportColumns, report)
+	if err != nil {
+		log.WithError(err).Error("Query issue for get report.")
+		return nil, err
+	} else if !wasFound {
+		return nil, reports.ErrReportNotFound
+	}
+
+	return report, nil
+}
+
+// CreateReport creates a new open Report using the provided data.
+func (backend Backend) CreateReport(ctx context.Context, r *reports.Report) (*reports.Report, error) {
+	kvs := map[string]interface{}{
+		"reporter_id":    r.ReporterID,
+		"target_type":    r.TargetType,
+		"target_id":      r.TargetID,
+		"target_user_id": r.TargetUserID,
+		"reason":         r.Reason,
+	}
+
+	newReport := &reports.Report{}
+	err := backend.createSingle(ctx, reportsTable, reportsReturning, kvs, newReport)
+	if err != nil {
+		log.WithError(err).Error("Issue with create report sql.")
+		return nil, err
+	}
+
+	return newReport, nil
+}
+
+// UpdateReport updates the triage fields of the Report matching the given ID.
+// Closing the Report records when it happened. Only open Reports can be updated
+// so one that was closed in the meantime is ErrReportClosed.
+func (backend Backend) UpdateReport(ctx context.Context, id int, r *reports.Report, expected *time.Time) (*reports.Report, error) {
+	setMap := map[string]interface{}{
+		"status":      r.Status,
+		"assignee_id": r.AssigneeID,
+		"action":      r.Action,
+		"resolution":  r.Resolution,
+		"closed_by":   r.ClosedBy,
+	}
+	if r.Status != reports.OpenStatus {
+		setMap["closed_on"] = sq.Expr("now()")
+	}
+
+	updatedReport := &reports.Report{}
+	wasFound, err := backend.updateSingle(ctx, id, reportsTable, reportsReturning, setMap, updatedReport, atVersion(expected, openReport)...)
+	if err != nil {
+		log.WithError(err).Error("Issue with query for update report.")
+		return nil, err
+	} else if !wasFound {
+		return nil, notFoundAt(expected, reports.ErrReportClosed)
+	}
+
+	return updatedReport, nil
+}
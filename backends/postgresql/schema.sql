@@ -3,7 +3,6 @@
 CREATE TABLE users (
     id bigserial primary key,
     is_admin bool NOT NULL default false,
-    is_banned bool NOT NULL default false,
     username varchar(30) UNIQUE NOT NULL,
     email varchar(30) UNIQUE NOT NULL,
     bio varchar(200) NOT NULL default '',
@@ -12,30 +11,52 @@ CREATE TABLE users (
     last_updated timestamp default current_timestamp
 );
 
+CREATE TABLE bots (
+    id bigserial primary key,
+    owner_id bigint NOT NULL references users(id),
+    workspace varchar(200) NOT NULL,
+    created_on timestamp default current_timestamp,
+    last_updated timestamp default current_timestamp
+);
+
 CREATE TABLE channels (
     id bigserial primary key,
     owner_id bigserial references users(id),
     dm_id bigserial references users(id),
-    name varchar(30) UNIQUE NOT NULL,
+    name varchar(30) NOT NULL,
     description text NOT NULL default '',
     topic text NOT NULL default '',
     is_private boolean NOT NULL default false,
+    bot_id bigint NOT NULL default 0,
+    bot_channel varchar(80) NOT NULL default '',
     created_on timestamp default current_timestamp,
-    last_updated timestamp default current_timestamp
+    last_updated timestamp default current_timestamp,
+    deleted_on timestamp
 );
 
+-- Unique values only have to be unique among the rows that haven't been deleted
+-- so deleted names and links can be used again before they're purged.
+CREATE UNIQUE INDEX channels_name_key ON channels (name) WHERE deleted_on IS NULL;
+
+-- Each channel in a chat platform can only be bridged to one Channel. Channels
+-- that aren't bridged all have the blank bot fields so they're left out.
+CREATE UNIQUE INDEX channels_bot_id_bot_channel_idx ON channels (bot_id, bot_channel) WHERE bot_id <> 0 AND deleted_on IS NULL;
+
 CREATE TABLE characters (
     id bigserial primary key,
     user_id bigserial references users(id),
     channel_id bigserial references channels(id),
     name varchar(30) NOT NULL default '',
     description text NOT NULL default '',
+    bot_username varchar(80) NOT NULL default '',
     created_on timestamp default current_timestamp,
     last_updated timestamp default current_timestamp,
-    UNIQUE (name, channel_id),
-    UNIQUE (user_id, channel_id)
+    deleted_on timestamp
 );
 
+CREATE UNIQUE INDEX characters_name_channel_id_key ON characters (name, channel_id) WHERE deleted_on IS NULL;
+CREATE UNIQUE INDEX characters_user_id_channel_id_key ON characters (user_id, channel_id) WHERE deleted_on IS NULL;
+
 CREATE TABLE messages (
     id bigserial primary key,
     character_id bigserial references characters(id),
@@ -43,22 +64,154 @@ CREATE TABLE messages (
     content varchar(200) NOT NULL,
     is_story boolean NOT NULL,
     created_on timestamp default current_timestamp,
+    last_updated timestamp default current_timestamp,
+    deleted_on timestamp
+);
+
+-- Speeds up counting each Channel's Messages for the admin stats
+CREATE INDEX messages_channel_id_created_on_idx ON messages (channel_id, created_on);
+
+CREATE TABLE notification_preferences (
+    user_id bigint primary key references users(id) ON DELETE CASCADE,
+    mentions boolean NOT NULL default true,
+    invitations boolean NOT NULL default true,
+    turns boolean NOT NULL default true,
+    created_on timestamp default current_timestamp,
+    last_updated timestamp default current_timestamp
+);
+
+CREATE TABLE tokens (
+    id bigserial primary key,
+    user_id bigint NOT NULL references users(id) ON DELETE CASCADE,
+    name varchar(80) NOT NULL,
+    token_hash char(64) NOT NULL UNIQUE,
+    created_on timestamp default current_timestamp,
+    last_updated timestamp default current_timestamp
+);
+
+CREATE TABLE webhooks (
+    id bigserial primary key,
+    channel_id bigint NOT NULL references channels(id),
+    url varchar(500) NOT NULL,
+    secret varchar(64) NOT NULL,
+    events text[] NOT NULL default '{}',
+    is_disabled boolean NOT NULL default false,
+    failure_count integer NOT NULL default 0,
+    created_on timestamp default current_timestamp,
+    last_updated timestamp default current_timestamp
+);
+
+CREATE TABLE webhook_deliveries (
+    id bigserial primary key,
+    webhook_id bigint NOT NULL references webhooks(id) ON DELETE CASCADE,
+    event varchar(30) NOT NULL,
+    payload text NOT NULL,
+    attempt integer NOT NULL,
+    status_code integer NOT NULL default 0,
+    error text NOT NULL default '',
+    succeeded boolean NOT NULL,
+    created_on timestamp default current_timestamp
+);
+
+CREATE TABLE bans (
+    id bigserial primary key,
+    user_id bigint NOT NULL references users(id) ON DELETE CASCADE,
+    channel_id bigint NOT NULL default 0,
+    reason varchar(500) NOT NULL default '',
+    issued_by bigint NOT NULL,
+    starts_on timestamp NOT NULL default current_timestamp,
+    expires_on timestamp,
+    lifted_by bigint NOT NULL default 0,
+    lifted_on timestamp,
+    created_on timestamp default current_timestamp,
+    last_updated timestamp default current_timestamp
+);
+
+CREATE INDEX bans_user_id_idx ON bans (user_id, channel_id);
+
+CREATE TABLE reports (
+    id bigserial primary key,
+    reporter_id bigint NOT NULL references users(id) ON DELETE CASCADE,
+    target_type varchar(20) NOT NULL,
+    target_id bigint NOT NULL,
+    target_user_id bigint NOT NULL default 0,
+    reason varchar(500) NOT NULL,
+    status varchar(20) NOT NULL default 'open',
+    assignee_id bigint NOT NULL default 0,
+    action varchar(20) NOT NULL default '',
+    resolution varchar(500) NOT NULL default '',
+    closed_by bigint NOT NULL default 0,
+    closed_on timestamp,
+    created_on timestamp default current_timestamp,
     last_updated timestamp default current_timestamp
 );
 
+CREATE TABLE impersonations (
+    id bigserial primary key,
+    admin_id bigint NOT NULL references users(id) ON DELETE CASCADE,
+    user_id bigint NOT NULL references users(id) ON DELETE CASCADE,
+    reason varchar(500) NOT NULL default '',
+    allow_writes bool NOT NULL default false,
+    starts_on timestamp NOT NULL default current_timestamp,
+    expires_on timestamp NOT NULL,
+    ended_on timestamp,
+    created_on timestamp default current_timestamp,
+    last_updated timestamp default current_timestamp
+);
+
+CREATE INDEX impersonations_admin_id_idx ON impersonations (admin_id);
+
+-- Audit Entries outlive the Users and things they're about so there are no references
+CREATE TABLE audit_log (
+    id bigserial primary key,
+    actor_id bigint NOT NULL,
+    impersonating_id bigint NOT NULL default 0,
+    action varchar(30) NOT NULL,
+    target_type varchar(20) NOT NULL,
+    target_id bigint NOT NULL,
+    before jsonb,
+    after jsonb,
+    created_on timestamp default current_timestamp
+);
+
+CREATE INDEX audit_log_created_on_idx ON audit_log (created_on);
+CREATE INDEX audit_log_target_idx ON audit_log (target_type, target_id);
+
 -- Use function provided from functions.sql to handle updating lastmodified timestamps on updates
 CREATE TRIGGER users_updated_at_modtime BEFORE UPDATE ON users FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
+CREATE TRIGGER bots_updated_at_modtime BEFORE UPDATE ON bots FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
 CREATE TRIGGER channels_updated_at_modtime BEFORE UPDATE ON channels FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
 CREATE TRIGGER characters_updated_at_modtime BEFORE UPDATE ON characters FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
 CREATE TRIGGER messages_updated_at_modtime BEFORE UPDATE ON messages FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
+CREATE TRIGGER notification_preferences_updated_at_modtime BEFORE UPDATE ON notification_preferences FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
+CREATE TRIGGER tokens_updated_at_modtime BEFORE UPDATE ON tokens FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
+-- Delivery failures being counted isn't a change to the webhook itself so it doesn't change its ETag
+CREATE TRIGGER webhooks_updated_at_modtime BEFORE UPDATE ON webhooks FOR EACH ROW
+    WHEN ((OLD.url, OLD.secret, OLD.events, OLD.is_disabled) IS DISTINCT FROM (NEW.url, NEW.secret, NEW.events, NEW.is_disabled))
+    EXECUTE PROCEDURE update_lastupdated_column();
+CREATE TRIGGER bans_updated_at_modtime BEFORE UPDATE ON bans FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
+CREATE TRIGGER reports_updated_at_modtime BEFORE UPDATE ON reports FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
+CREATE TRIGGER impersonations_updated_at_modtime BEFORE UPDATE ON impersonations FOR EACH ROW EXECUTE PROCEDURE update_lastupdated_column();
+
+-- Use function provided from functions.sql to keep the audit log from being changed
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log FOR EACH ROW EXECUTE PROCEDURE prevent_change();
 
 -- Sample data
 INSERT INTO users
-(username, email, is_admin, is_banned) VALUES
-('andrew.w.boutin@gmail.com', 'andrew.w.boutin@gmail.com', true, false),
-('banneduser', 'banneduser@fake.com', false, true),
-('adminuser', 'adminuser@fake.com', true, false),
-('regularuser', 'regularuser@fake.com', false, false);
+(username, email, is_admin) VALUES
+('andrew.w.boutin@gmail.com', 'andrew.w.boutin@gmail.com', true),
+('banneduser', 'banneduser@fake.com', false),
+('adminuser', 'adminuser@fake.com', true),
+('regularuser', 'regularuser@fake.com', false);
+
+INSERT INTO bans
+(user_id, reason, issued_by) VALUES
+(2, 'sample ban', 1);
+
+INSERT INTO bots
+(owner_id, workspace) VALUES
+(1, 'dndtext'),
+(1, 'dndtext-discord');
 
 INSERT INTO channels
 (owner_id, dm_id, name, description, topic, is_private) VALUES
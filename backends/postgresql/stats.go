@@ -0,0 +1,207 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/stats"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetStats works out the Stats with aggregate queries so nothing has to be loaded
+// row by row.
+func (backend Backend) GetStats(ctx context.Context, opts stats.Options) (*stats.Stats, error) {
+	now := time.Now().UTC()
+	out := &stats.Stats{GeneratedOn: now}
+
+	err := backend.getActiveUsers(ctx, now, &out.ActiveUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	err = backend.getChannelCounts(ctx, &out.Channels)
+	if err != nil {
+		return nil, err
+	}
+
+	out.ChannelsPerWeek, err = backend.getChannelsPerWeek(ctx, now, opts.Weeks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every live Channel, most Messages first
+	out.MessagesPerChannel, err = backend.getChannelActivity(ctx, channelActivityBuilder(time.Time{}).
+		OrderBy("messages DESC", "channels.id ASC"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Only Messages from the activity window count towards the top Channels
+	out.TopChannels, err = backend.getChannelActivity(ctx, channelActivityBuilder(now.Add(-stats.ActivityWindow)).
+		Having("count(messages.id) > 0").
+		OrderBy("messages DESC", "channels.id ASC").
+		Limit(uint64(opts.Top)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Dormant Channels are old enough to have had a Message by now but haven't
+	// had one in a while, longest dormant first
+	dormantSince := now.Add(-stats.DormantAfter)
+	out.DormantChannels, err = backend.getChannelActivity(ctx, channelActivityBuilder(time.Time{}).
+		Where(sq.Lt{"channels.created_on": dormantSince}).
+		Having(sq.Or{
+			sq.Expr("max(messages.created_on) IS NULL"),
+			sq.Expr("max(messages.created_on) < ?", dormantSince),
+		}).
+		OrderBy("last_message_on ASC NULLS FIRST", "channels.id ASC"))
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// getActiveUsers counts the Users that logged in over the last day, week, and 30
+// days in one pass over the Users.
+func (backend Backend) getActiveUsers(ctx context.Context, now time.Time, out *stats.ActiveUsers) error {
+	sql, args, err := PSQLBuilder().
+		Select().
+		Column(sq.Expr("count(*) FILTER (WHERE users.last_login >= ?) AS day", now.AddDate(0, 0, -1))).
+		Column(sq.Expr("count(*) FILTER (WHERE users.last_login >= ?) AS week", now.AddDate(0, 0, -7))).
+		Column(sq.Expr("count(*) FILTER (WHERE users.last_login >= ?) AS month", now.AddDate(0, 0, -30))).
+		From(usersTable).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build active users query.")
+		return err
+	}
+
+	err = backend.db.QueryRowxContext(ctx, sql, args...).StructScan(out)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute active users query.")
+	}
+	return err
+}
+
+// getChannelCounts counts the public and private Channels.
+func (backend Backend) getChannelCounts(ctx context.Context, out *stats.ChannelCounts) error {
+	sql, args, err := PSQLBuilder().
+		Select(
+			"count(*) FILTER (WHERE NOT channels.is_private) AS public",
+			"count(*) FILTER (WHERE channels.is_private) AS private",
+		).
+		From(channelsTable).
+		Where(liveChannel).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build channel counts query.")
+		return err
+	}
+
+	err = backend.db.QueryRowxContext(ctx, sql, args...).StructScan(out)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute channel counts query.")
+	}
+	return err
+}
+
+// getChannelsPerWeek counts the Channels created in each of the last weeks,
+// including this one. Weeks without any are still included with a count of 0.
+func (backend Backend) getChannelsPerWeek(ctx context.Context, now time.Time, weeks int) ([]*stats.WeekCount, error) {
+	thisWeek := stats.StartOfWeek(now)
+	firstWeek := thisWeek.AddDate(0, 0, -7*(weeks-1))
+
+	sql, args, err := PSQLBuilder().
+		Select("weeks.week_of", "count(channels.id) AS channels").
+		From("generate_series(?::timestamp, ?::timestamp, interval '1 week') AS weeks(week_of)").
+		LeftJoin(channelsTable + " ON date_trunc('week', channels.created_on) = weeks.week_of").
+		GroupBy("weeks.week_of").
+		OrderBy("weeks.week_of ASC").
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build channels per week query.")
+		return nil, err
+	}
+	// The placeholders in From don't take arguments through the builder and come
+	// before any others
+	args = append([]interface{}{firstWeek, thisWeek}, args...)
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute channels per week query.")
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]*stats.WeekCount, 0, weeks)
+	for rows.Next() {
+		var count stats.WeekCount
+		err = rows.StructScan(&count)
+		if err != nil {
+			log.WithError(err).Error("Failed to load week from channels per week query.")
+			return nil, err
+		}
+
+		counts = append(counts, &count)
+	}
+
+	return counts, nil
+}
+
+// channelActivityBuilder builds the query for the Message count and latest Message
+// of each live Channel. Only Messages posted at or after since count unless it's
+// the zero time.
+func channelActivityBuilder(since time.Time) sq.SelectBuilder {
+	join := messagesTable + " ON messages.channel_id = channels.id AND messages.deleted_on IS NULL"
+	joinArgs := []interface{}{}
+	if !since.IsZero() {
+		join += " AND messages.created_on >= ?"
+		joinArgs = append(joinArgs, since)
+	}
+
+	return PSQLBuilder().
+		Select(
+			"channels.id AS channel_id",
+			"channels.name",
+			"count(messages.id) AS messages",
+			"max(messages.created_on) AS last_message_on",
+		).
+		From(channelsTable).
+		LeftJoin(join, joinArgs...).
+		Where(liveChannel).
+		GroupBy("channels.id")
+}
+
+// getChannelActivity runs the query built from channelActivityBuilder.
+func (backend Backend) getChannelActivity(ctx context.Context, builder sq.SelectBuilder) ([]*stats.ChannelActivity, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build channel activity query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute channel activity query.")
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := make([]*stats.ChannelActivity, 0)
+	for rows.Next() {
+		var channel stats.ChannelActivity
+		err = rows.StructScan(&channel)
+		if err != nil {
+			log.WithError(err).Error("Failed to load channel from channel activity query.")
+			return nil, err
+		}
+
+		activity = append(activity, &channel)
+	}
+
+	return activity, nil
+}
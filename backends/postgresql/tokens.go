@@ -0,0 +1,130 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	sqlP "database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	tokensTable     = "tokens"
+	tokensReturning = "RETURNING id, user_id, name, token_hash, created_on, last_updated"
+)
+
+var tokenColumns = []string{
+	"id",
+	"user_id",
+	"name",
+	"token_hash",
+	"created_on",
+	"last_updated",
+}
+
+// GetTokensForUser retrieves all of the Tokens the User has created.
+func (backend Backend) GetTokensForUser(ctx context.Context, userID int) (tokens.TokenCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(tokenColumns...).
+		From(tokensTable).
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get tokens for user query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get tokens for user query.")
+		return nil, err
+	}
+
+	outTokens := make(tokens.TokenCollection, 0)
+	for rows.Next() {
+		var token tokens.Token
+		err = rows.StructScan(&token)
+		if err != nil {
+			log.WithError(err).Error("Failed to load token from get tokens for user query.")
+			return nil, err
+		}
+
+		outTokens = append(outTokens, &token)
+	}
+
+	return outTokens, nil
+}
+
+// GetToken retrieves the Token matching the given ID.
+func (backend Backend) GetToken(ctx context.Context, id int) (*tokens.Token, error) {
+	token := &tokens.Token{}
+	wasFound, err := backend.getSingle(ctx, id, tokensTable, tokenColumns, token)
+	if err != nil {
+		log.WithError(err).Error("Query issue for get token.")
+		return nil, err
+	} else if !wasFound {
+		return nil, tokens.ErrTokenNotFound
+	}
+
+	return token, nil
+}
+
+// GetTokenByHash retrieves the Token matching the hash of its value.
+func (backend Backend) GetTokenByHash(ctx context.Context, hash string) (*tokens.Token, error) {
+	sql, args, err := PSQLBuilder().
+		Select(tokenColumns...).
+		From(tokensTable).
+		Where(sq.Eq{"token_hash": hash}).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get token by hash query.")
+		return nil, err
+	}
+
+	token := &tokens.Token{}
+	err = backend.db.GetContext(ctx, token, sql, args...)
+	if err != nil {
+		if err == sqlP.ErrNoRows {
+			return nil, tokens.ErrTokenNotFound
+		}
+		log.WithError(err).Error("Issue executing get token by hash query.")
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// CreateToken creates a new Token using the provided data. The value isn't
+// stored so it's copied over to the returned Token.
+func (backend Backend) CreateToken(ctx context.Context, t *tokens.Token) (*tokens.Token, error) {
+	kvs := map[string]interface{}{
+		"user_id":    t.UserID,
+		"name":       t.Name,
+		"token_hash": t.Hash,
+	}
+
+	newToken := &tokens.Token{}
+	err := backend.createSingle(ctx, tokensTable, tokensReturning, kvs, newToken)
+	if err != nil {
+		log.WithError(err).Error("Issue with create token sql.")
+		return nil, err
+	}
+
+	newToken.Value = t.Value
+	return newToken, nil
+}
+
+// DeleteToken deletes the Token matching the given ID.
+func (backend Backend) DeleteToken(ctx context.Context, id int) error {
+	wasFound, err := backend.deleteSingle(ctx, id, tokensTable)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute delete token query.")
+	} else if !wasFound {
+		return tokens.ErrTokenNotFound
+	}
+	return err
+}
@@ -3,6 +3,7 @@
 package postgresql
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -10,21 +11,34 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	usersTable     = "users"
-	usersReturning = "RETURNING id, username, email, bio, is_admin, is_banned, last_login, created_on, last_updated"
+	usersReturning = "RETURNING id, username, email, bio, is_admin, " + isBannedColumn + ", last_login, created_on, last_updated"
+
+	// isBannedCondition works out whether the User is banned from their site wide
+	// Bans so Bans that expire are lifted without anything having to happen.
+	isBannedCondition = "EXISTS (SELECT 1 FROM " + bansTable + " WHERE bans.user_id = users.id AND bans.channel_id = 0 AND " + activeBanCondition + ")"
+	isBannedColumn    = isBannedCondition + " AS is_banned"
 )
 
+// userSortColumns are the columns Users are ordered by for each SortField.
+var userSortColumns = map[users.SortField]string{
+	users.SortByID:        "id",
+	users.SortByUsername:  "username",
+	users.SortByLastLogin: "last_login",
+	users.SortByCreatedOn: "created_on",
+}
+
 var userColumns = []string{
 	"id",
 	"username",
 	"email",
 	"bio",
 	"is_admin",
-	"is_banned",
 	"last_login",
 	"created_on",
 	"last_updated",
@@ -35,26 +49,52 @@ func init() {
 	for i, col := range userColumns {
 		userColumns[i] = fmt.Sprintf("%s.%s", usersTable, col)
 	}
+	userColumns = append(userColumns, isBannedColumn)
 }
 
-// GetAllUsers retrieves all Users from the database - including their User.IsAdmin flag.
-func (backend Backend) GetAllUsers() (users.UserCollection, error) {
-	sql, args, err := PSQLBuilder().
+// GetUsers retrieves the Users matching the filter from the database - including
+// their User.IsAdmin flag - in the order it asks for.
+func (backend Backend) GetUsers(ctx context.Context, filter users.Filter) (users.UserCollection, error) {
+	builder := PSQLBuilder().
 		Select(userColumns...).
-		From(usersTable).
-		ToSql()
+		From(usersTable)
+
+	if filter.IsAdmin != nil {
+		builder = builder.Where(sq.Eq{"users.is_admin": *filter.IsAdmin})
+	}
+	if filter.IsBanned != nil {
+		if *filter.IsBanned {
+			builder = builder.Where(isBannedCondition)
+		} else {
+			builder = builder.Where("NOT " + isBannedCondition)
+		}
+	}
+	if filter.LoggedInSince != nil {
+		builder = builder.Where(sq.GtOrEq{"users.last_login": filter.LoggedInSince.UTC()})
+	}
+	if filter.LoggedInBefore != nil {
+		builder = builder.Where(sq.Lt{"users.last_login": filter.LoggedInBefore.UTC()})
+	}
+
+	sortCol, ok := userSortColumns[filter.Sort]
+	if !ok {
+		sortCol = userSortColumns[users.SortByID]
+	}
+	builder = sortAndPage(builder, usersTable, sortCol, filter.Descending, filter.Limit, filter.Offset)
+
+	sql, args, err := builder.ToSql()
 	if err != nil {
-		log.WithError(err).Error("Failed to create get all users sql.")
+		log.WithError(err).Error("Failed to create get users sql.")
 		return nil, err
 	}
 
-	return backend.runMultiUsersQuery(sql, args)
+	return backend.runMultiUsersQuery(ctx, sql, args)
 }
 
 // runMultiUsersQuery runs the given query with arguments to retrieve a User
 // collection.
-func (backend Backend) runMultiUsersQuery(sql string, args []interface{}) (users.UserCollection, error) {
-	rows, err := backend.db.Queryx(sql, args...)
+func (backend Backend) runMultiUsersQuery(ctx context.Context, sql string, args []interface{}) (users.UserCollection, error) {
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
 	if err != nil {
 		log.WithError(err).Error("Failed to execute multi user query.")
 		return nil, err
@@ -76,39 +116,69 @@ func (backend Backend) runMultiUsersQuery(sql string, args []interface{}) (users
 }
 
 // UpdateUser updates the given User with the given User data.
-func (backend Backend) UpdateUser(id int, u *users.User) (*users.User, error) {
+func (backend Backend) UpdateUser(ctx context.Context, id int, u *users.User, expected *time.Time) (*users.User, error) {
 	setMap := map[string]interface{}{
 		"username": u.Username,
 		"bio":      u.Bio,
 	}
 
 	updatedUser := &users.User{}
-	wasFound, err := backend.updateSingle(id, usersTable, usersReturning, setMap, updatedUser)
+	wasFound, err := backend.updateSingle(ctx, id, usersTable, usersReturning, setMap, updatedUser, atVersion(expected)...)
 	if err != nil {
 		log.WithError(err).Error("Issue with query for update user.")
 		return nil, err
 	} else if !wasFound {
-		return nil, users.ErrUserNotFound
+		return nil, notFoundAt(expected, users.ErrUserNotFound)
 	}
 
 	return updatedUser, nil
 }
 
-// DeleteUser removes a User from the Users table.
-func (backend Backend) DeleteUser(userID int) error {
-	wasFound, err := backend.deleteSingle(userID, usersTable)
-	if err != nil {
-		log.WithError(err).Error("Failed to execute delete user query.")
-	} else if !wasFound {
-		return users.ErrUserNotFound
-	}
-	return err
+// DeleteUser permanently deletes a User along with their Messages and Characters,
+// including deleted ones. It's all one transaction so none of it is deleted unless
+// the User is.
+func (backend Backend) DeleteUser(ctx context.Context, userID int, expected *time.Time) error {
+	return backend.inTransaction(ctx, func(tx *sqlx.Tx) error {
+		err := deleteMessagesFromUser(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = deleteCharactersFromUser(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		sql, args, err := PSQLBuilder().
+			Delete(usersTable).
+			Where(sq.And(atVersion(expected, sq.Eq{"id": userID}))).
+			ToSql()
+		if err != nil {
+			log.WithError(err).Error("Failed to build delete user query.")
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			log.WithError(err).Error("Failed to execute delete user query.")
+			return err
+		}
+
+		numRowsAffected, err := result.RowsAffected()
+		if err != nil {
+			log.WithError(err).Error("Failed to determine how many rows were affected by delete user query.")
+			return err
+		} else if numRowsAffected <= 0 {
+			return notFoundAt(expected, users.ErrUserNotFound)
+		}
+		return nil
+	})
 }
 
 // GetUserByID retrieves a User by using the given id.
-func (backend Backend) GetUserByID(id int) (*users.User, error) {
+func (backend Backend) GetUserByID(ctx context.Context, id int) (*users.User, error) {
 	user := &users.User{}
-	wasFound, err := backend.getSingle(id, usersTable, userColumns, user)
+	wasFound, err := backend.getSingle(ctx, id, usersTable, userColumns, user)
 	if err != nil {
 		log.WithError(err).Error("Query issue for get user.")
 		return nil, err
@@ -120,7 +190,7 @@ func (backend Backend) GetUserByID(id int) (*users.User, error) {
 }
 
 // GetUserByEmail retrieves a User by using the given email address.
-func (backend Backend) GetUserByEmail(email string) (*users.User, error) {
+func (backend Backend) GetUserByEmail(ctx context.Context, email string) (*users.User, error) {
 	sql, args, err := PSQLBuilder().
 		Select(userColumns...).
 		From(usersTable).
@@ -132,7 +202,7 @@ func (backend Backend) GetUserByEmail(email string) (*users.User, error) {
 	}
 
 	user := &users.User{}
-	err = backend.db.Get(user, sql, args...)
+	err = backend.db.GetContext(ctx, user, sql, args...)
 	if err != nil {
 		if err == sqlP.ErrNoRows {
 			return nil, users.ErrUserNotFound
@@ -145,14 +215,14 @@ func (backend Backend) GetUserByEmail(email string) (*users.User, error) {
 }
 
 // CreateUser creates a new User in the database using the provided data.
-func (backend Backend) CreateUser(gu *users.GoogleUser) (*users.User, error) {
+func (backend Backend) CreateUser(ctx context.Context, gu *users.GoogleUser) (*users.User, error) {
 	kvs := map[string]interface{}{
 		"username": gu.Email,
 		"email":    gu.Email,
 	}
 
 	newUser := &users.User{}
-	err := backend.createSingle(usersTable, usersReturning, kvs, newUser)
+	err := backend.createSingle(ctx, usersTable, usersReturning, kvs, newUser)
 	if err != nil {
 		log.WithError(err).Error("Issue with create user sql.")
 		return nil, err
@@ -162,7 +232,7 @@ func (backend Backend) CreateUser(gu *users.GoogleUser) (*users.User, error) {
 }
 
 // UpdateUserLastLogin sets the passed in User's last login time to now.
-func (backend Backend) UpdateUserLastLogin(u *users.User) (*users.User, error) {
+func (backend Backend) UpdateUserLastLogin(ctx context.Context, u *users.User) (*users.User, error) {
 	setMap := map[string]interface{}{
 		"last_login": time.Now(),
 	}
@@ -178,7 +248,7 @@ func (backend Backend) UpdateUserLastLogin(u *users.User) (*users.User, error) {
 	}
 
 	updatedUser := &users.User{}
-	err = backend.db.QueryRowx(sql, args...).StructScan(updatedUser)
+	err = backend.db.QueryRowxContext(ctx, sql, args...).StructScan(updatedUser)
 	if err != nil {
 		log.WithError(err).Error("Failed to execute update user last login query.")
 		return nil, err
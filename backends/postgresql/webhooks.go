@@ -0,0 +1,247 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	webhooksTable     = "webhooks"
+	webhooksReturning = "RETURNING id, channel_id, url, secret, events, is_disabled, failure_count, created_on, last_updated"
+
+	webhookDeliveriesTable     = "webhook_deliveries"
+	webhookDeliveriesReturning = "RETURNING id, webhook_id, event, payload, attempt, status_code, error, succeeded, created_on"
+)
+
+var webhookColumns = []string{
+	"id",
+	"channel_id",
+	"url",
+	"secret",
+	"events",
+	"is_disabled",
+	"failure_count",
+	"created_on",
+	"last_updated",
+}
+
+var webhookDeliveryColumns = []string{
+	"id",
+	"webhook_id",
+	"event",
+	"payload",
+	"attempt",
+	"status_code",
+	"error",
+	"succeeded",
+	"created_on",
+}
+
+// GetWebhooksInChannel retrieves all of the Webhooks registered for the given Channel.
+func (backend Backend) GetWebhooksInChannel(ctx context.Context, channelID int) (webhooks.WebhookCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(webhookColumns...).
+		From(webhooksTable).
+		Where(sq.Eq{"channel_id": channelID}).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get webhooks in channel query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get webhooks in channel query.")
+		return nil, err
+	}
+	defer rows.Close()
+
+	outWebhooks := make(webhooks.WebhookCollection, 0)
+	for rows.Next() {
+		var webhook webhooks.Webhook
+		err = rows.StructScan(&webhook)
+		if err != nil {
+			log.WithError(err).Error("Failed to load webhook from get webhooks in channel query.")
+			return nil, err
+		}
+
+		outWebhooks = append(outWebhooks, &webhook)
+	}
+
+	return outWebhooks, nil
+}
+
+// GetWebhook retrieves the Webhook matching the given ID.
+func (backend Backend) GetWebhook(ctx context.Context, id int) (*webhooks.Webhook, error) {
+	webhook := &webhooks.Webhook{}
+	wasFound, err := backend.getSingle(ctx, id, webhooksTable, webhookColumns, webhook)
+	if err != nil {
+		log.WithError(err).Error("Query issue for get webhook.")
+		return nil, err
+	} else if !wasFound {
+		return nil, webhooks.ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+// CreateWebhook creates a new Webhook using the provided data.
+func (backend Backend) CreateWebhook(ctx context.Context, w *webhooks.Webhook) (*webhooks.Webhook, error) {
+	kvs := map[string]interface{}{
+		"channel_id": w.ChannelID,
+		"url":        w.URL,
+		"secret":     w.Secret,
+		"events":     w.Events,
+	}
+
+	newWebhook := &webhooks.Webhook{}
+	err := backend.createSingle(ctx, webhooksTable, webhooksReturning, kvs, newWebhook)
+	if err != nil {
+		log.WithError(err).Error("Issue with create webhook sql.")
+		return nil, err
+	}
+
+	return newWebhook, nil
+}
+
+// UpdateWebhook updates the Webhook matching the given ID. Re-enabling a
+// disabled Webhook also clears its failures.
+func (backend Backend) UpdateWebhook(ctx context.Context, id int, w *webhooks.Webhook, expected *time.Time) (*webhooks.Webhook, error) {
+	setMap := map[string]interface{}{
+		"url":         w.URL,
+		"events":      w.Events,
+		"is_disabled": w.IsDisabled,
+	}
+	if !w.IsDisabled {
+		setMap["failure_count"] = 0
+	}
+
+	updatedWebhook := &webhooks.Webhook{}
+	wasFound, err := backend.updateSingle(ctx, id, webhooksTable, webhooksReturning, setMap, updatedWebhook, atVersion(expected)...)
+	if err != nil {
+		log.WithError(err).Error("Issue with query for update webhook.")
+		return nil, err
+	} else if !wasFound {
+		return nil, notFoundAt(expected, webhooks.ErrWebhookNotFound)
+	}
+
+	return updatedWebhook, nil
+}
+
+// DeleteWebhook deletes the Webhook matching the given ID. Its deliveries are
+// deleted along with it by the database.
+func (backend Backend) DeleteWebhook(ctx context.Context, id int, expected *time.Time) error {
+	wasFound, err := backend.deleteSingle(ctx, id, webhooksTable, atVersion(expected)...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute delete webhook query.")
+	} else if !wasFound {
+		return notFoundAt(expected, webhooks.ErrWebhookNotFound)
+	}
+	return err
+}
+
+// GetWebhookDeliveries retrieves the delivery log for the given Webhook with the
+// most recent deliveries first.
+func (backend Backend) GetWebhookDeliveries(ctx context.Context, webhookID int) (webhooks.DeliveryCollection, error) {
+	sql, args, err := PSQLBuilder().
+		Select(webhookDeliveryColumns...).
+		From(webhookDeliveriesTable).
+		Where(sq.Eq{"webhook_id": webhookID}).
+		OrderBy("created_on DESC", "id DESC").
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build get webhook deliveries query.")
+		return nil, err
+	}
+
+	rows, err := backend.db.QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute get webhook deliveries query.")
+		return nil, err
+	}
+	defer rows.Close()
+
+	outDeliveries := make(webhooks.DeliveryCollection, 0)
+	for rows.Next() {
+		var delivery webhooks.Delivery
+		err = rows.StructScan(&delivery)
+		if err != nil {
+			log.WithError(err).Error("Failed to load delivery from get webhook deliveries query.")
+			return nil, err
+		}
+
+		outDeliveries = append(outDeliveries, &delivery)
+	}
+
+	return outDeliveries, nil
+}
+
+// CreateWebhookDelivery records a single delivery attempt.
+func (backend Backend) CreateWebhookDelivery(ctx context.Context, d *webhooks.Delivery) (*webhooks.Delivery, error) {
+	kvs := map[string]interface{}{
+		"webhook_id":  d.WebhookID,
+		"event":       d.Event,
+		"payload":     d.Payload,
+		"attempt":     d.Attempt,
+		"status_code": d.StatusCode,
+		"error":       d.Error,
+		"succeeded":   d.Succeeded,
+	}
+
+	newDelivery := &webhooks.Delivery{}
+	err := backend.createSingle(ctx, webhookDeliveriesTable, webhookDeliveriesReturning, kvs, newDelivery)
+	if err != nil {
+		log.WithError(err).Error("Issue with create webhook delivery sql.")
+		return nil, err
+	}
+
+	return newDelivery, nil
+}
+
+// RecordWebhookSuccess clears the consecutive failures for the Webhook. Only
+// changing the failures doesn't change its LastUpdated.
+func (backend Backend) RecordWebhookSuccess(ctx context.Context, id int) error {
+	sql, args, err := PSQLBuilder().
+		Update(webhooksTable).
+		Set("failure_count", 0).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build record webhook success query.")
+		return err
+	}
+
+	_, err = backend.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute record webhook success query.")
+	}
+	return err
+}
+
+// RecordWebhookFailure adds a failure to the Webhook and disables it once it has
+// failed disableAfter times in a row. Only changing the failures doesn't change
+// its LastUpdated.
+func (backend Backend) RecordWebhookFailure(ctx context.Context, id, disableAfter int) error {
+	sql, args, err := PSQLBuilder().
+		Update(webhooksTable).
+		Set("failure_count", sq.Expr("failure_count + 1")).
+		Set("is_disabled", sq.Expr("is_disabled OR failure_count + 1 >= ?", disableAfter)).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		log.WithError(err).Error("Failed to build record webhook failure query.")
+		return err
+	}
+
+	_, err = backend.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("Failed to execute record webhook failure query.")
+	}
+	return err
+}
@@ -0,0 +1,545 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package backends
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/bots"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/stats"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/andrew-boutin/dndtextapi/tracing"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+)
+
+// tracingBackend is a Backend that records a span for every call to the Backend it
+// wraps. The spans are children of the span in the context, which is the request's
+// span for calls made by handlers.
+type tracingBackend struct {
+	backend Backend
+	tracer  *tracing.Tracer
+}
+
+// WithTracing wraps the Backend so every call is traced.
+func WithTracing(backend Backend, tracer *tracing.Tracer) Backend {
+	return &tracingBackend{backend: backend, tracer: tracer}
+}
+
+// finish ends the span for the call. Errors with their own status, like something
+// not being found, are expected so they don't mark the span as failed.
+func (tb *tracingBackend) finish(span *tracing.Span, err *error) {
+	if *err != nil {
+		if apierrors.StatusFor(*err) >= http.StatusInternalServerError {
+			span.RecordError(*err)
+		} else {
+			span.SetAttribute("backend.result", (*err).Error())
+		}
+	}
+	span.Finish()
+}
+
+// Lifecycle functionality isn't traced so health checks don't drown out real calls
+
+func (tb *tracingBackend) HealthCheck(ctx context.Context) error {
+	return tb.backend.HealthCheck(ctx)
+}
+
+func (tb *tracingBackend) Close() error {
+	return tb.backend.Close()
+}
+
+// Channels functionality
+
+func (tb *tracingBackend) GetChannel(ctx context.Context, id int) (result *channels.Channel, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetChannel(ctx, id)
+}
+
+func (tb *tracingBackend) GetChannelsOwnedByUser(ctx context.Context, userID int) (result channels.ChannelCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetChannelsOwnedByUser", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetChannelsOwnedByUser(ctx, userID)
+}
+
+func (tb *tracingBackend) GetChannelsUserHasCharacterIn(ctx context.Context, userID int, isPrivate *bool) (result channels.ChannelCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetChannelsUserHasCharacterIn", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetChannelsUserHasCharacterIn(ctx, userID, isPrivate)
+}
+
+func (tb *tracingBackend) GetAllChannels(ctx context.Context, isPrivate *bool) (result channels.ChannelCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetAllChannels", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetAllChannels(ctx, isPrivate)
+}
+
+func (tb *tracingBackend) GetChannels(ctx context.Context, filter channels.Filter) (result channels.ChannelCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetChannels", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetChannels(ctx, filter)
+}
+
+func (tb *tracingBackend) CreateChannel(ctx context.Context, c *channels.Channel, userID int) (result *channels.Channel, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateChannel(ctx, c, userID)
+}
+
+func (tb *tracingBackend) DeleteChannel(ctx context.Context, id int, expected *time.Time) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DeleteChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DeleteChannel(ctx, id, expected)
+}
+
+func (tb *tracingBackend) UpdateChannel(ctx context.Context, id int, c *channels.Channel, expected *time.Time) (result *channels.Channel, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateChannel(ctx, id, c, expected)
+}
+
+func (tb *tracingBackend) GetChannelByBotChannel(ctx context.Context, botID int, botChannel string) (result *channels.Channel, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetChannelByBotChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetChannelByBotChannel(ctx, botID, botChannel)
+}
+
+func (tb *tracingBackend) GetChannelsForBot(ctx context.Context, botID int) (result channels.ChannelCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetChannelsForBot", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetChannelsForBot(ctx, botID)
+}
+
+func (tb *tracingBackend) RemoveBotFromChannels(ctx context.Context, botID int) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.RemoveBotFromChannels", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.RemoveBotFromChannels(ctx, botID)
+}
+
+func (tb *tracingBackend) GetDeletedChannel(ctx context.Context, id int) (result *channels.Channel, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetDeletedChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetDeletedChannel(ctx, id)
+}
+
+func (tb *tracingBackend) GetDeletedChannels(ctx context.Context) (result channels.ChannelCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetDeletedChannels", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetDeletedChannels(ctx)
+}
+
+func (tb *tracingBackend) RestoreChannel(ctx context.Context, id int) (result *channels.Channel, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.RestoreChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.RestoreChannel(ctx, id)
+}
+
+// Messages functionality
+
+func (tb *tracingBackend) GetMessagesInChannel(ctx context.Context, channelID int, onlyStory *bool, page *messages.Page) (result messages.MessageCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetMessagesInChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetMessagesInChannel(ctx, channelID, onlyStory, page)
+}
+
+func (tb *tracingBackend) GetMessage(ctx context.Context, id int) (result *messages.Message, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetMessage", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetMessage(ctx, id)
+}
+
+func (tb *tracingBackend) CreateMessage(ctx context.Context, m *messages.Message) (result *messages.Message, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateMessage", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateMessage(ctx, m)
+}
+
+func (tb *tracingBackend) DeleteMessage(ctx context.Context, id int, expected *time.Time) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DeleteMessage", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DeleteMessage(ctx, id, expected)
+}
+
+func (tb *tracingBackend) UpdateMessage(ctx context.Context, id int, m *messages.Message, expected *time.Time) (result *messages.Message, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateMessage", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateMessage(ctx, id, m, expected)
+}
+
+func (tb *tracingBackend) GetDeletedMessage(ctx context.Context, id int) (result *messages.Message, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetDeletedMessage", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetDeletedMessage(ctx, id)
+}
+
+func (tb *tracingBackend) GetDeletedMessagesInChannel(ctx context.Context, channelID int) (result messages.MessageCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetDeletedMessagesInChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetDeletedMessagesInChannel(ctx, channelID)
+}
+
+func (tb *tracingBackend) RestoreMessage(ctx context.Context, id int) (result *messages.Message, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.RestoreMessage", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.RestoreMessage(ctx, id)
+}
+
+// Users functionality
+
+func (tb *tracingBackend) UpdateUser(ctx context.Context, id int, u *users.User, expected *time.Time) (result *users.User, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateUser", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateUser(ctx, id, u, expected)
+}
+
+func (tb *tracingBackend) DeleteUser(ctx context.Context, userID int, expected *time.Time) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DeleteUser", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DeleteUser(ctx, userID, expected)
+}
+
+func (tb *tracingBackend) GetUserByEmail(ctx context.Context, email string) (result *users.User, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetUserByEmail", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetUserByEmail(ctx, email)
+}
+
+func (tb *tracingBackend) GetUserByID(ctx context.Context, id int) (result *users.User, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetUserByID", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetUserByID(ctx, id)
+}
+
+func (tb *tracingBackend) CreateUser(ctx context.Context, gu *users.GoogleUser) (result *users.User, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateUser", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateUser(ctx, gu)
+}
+
+func (tb *tracingBackend) GetUsers(ctx context.Context, filter users.Filter) (result users.UserCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetUsers", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetUsers(ctx, filter)
+}
+
+func (tb *tracingBackend) UpdateUserLastLogin(ctx context.Context, u *users.User) (result *users.User, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateUserLastLogin", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateUserLastLogin(ctx, u)
+}
+
+// Characters functionality
+
+func (tb *tracingBackend) DoesUserHaveCharacterInChannel(ctx context.Context, userID, channelID int) (result bool, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DoesUserHaveCharacterInChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DoesUserHaveCharacterInChannel(ctx, userID, channelID)
+}
+
+func (tb *tracingBackend) GetCharactersInChannel(ctx context.Context, channelID int) (result characters.CharacterCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetCharactersInChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetCharactersInChannel(ctx, channelID)
+}
+
+func (tb *tracingBackend) GetCharacter(ctx context.Context, id int) (result *characters.Character, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetCharacter", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetCharacter(ctx, id)
+}
+
+func (tb *tracingBackend) CreateCharacter(ctx context.Context, c *characters.Character) (result *characters.Character, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateCharacter", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateCharacter(ctx, c)
+}
+
+func (tb *tracingBackend) DeleteCharacter(ctx context.Context, characterID int, expected *time.Time) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DeleteCharacter", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DeleteCharacter(ctx, characterID, expected)
+}
+
+func (tb *tracingBackend) UpdateCharacter(ctx context.Context, id int, c *characters.Character, expected *time.Time) (result *characters.Character, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateCharacter", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateCharacter(ctx, id, c, expected)
+}
+
+func (tb *tracingBackend) GetCharacterByBotUsername(ctx context.Context, channelID int, botUsername string) (result *characters.Character, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetCharacterByBotUsername", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetCharacterByBotUsername(ctx, channelID, botUsername)
+}
+
+func (tb *tracingBackend) GetDeletedCharacter(ctx context.Context, id int) (result *characters.Character, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetDeletedCharacter", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetDeletedCharacter(ctx, id)
+}
+
+func (tb *tracingBackend) GetDeletedCharactersInChannel(ctx context.Context, channelID int) (result characters.CharacterCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetDeletedCharactersInChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetDeletedCharactersInChannel(ctx, channelID)
+}
+
+func (tb *tracingBackend) RestoreCharacter(ctx context.Context, characterID int) (result *characters.Character, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.RestoreCharacter", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.RestoreCharacter(ctx, characterID)
+}
+
+// Retention functionality
+
+func (tb *tracingBackend) PurgeDeleted(ctx context.Context, before time.Time) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.PurgeDeleted", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.PurgeDeleted(ctx, before)
+}
+
+// Notifications functionality
+
+func (tb *tracingBackend) GetNotificationPreferences(ctx context.Context, userID int) (result *notifications.Preferences, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetNotificationPreferences", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetNotificationPreferences(ctx, userID)
+}
+
+func (tb *tracingBackend) UpdateNotificationPreferences(ctx context.Context, userID int, p *notifications.Preferences, expected *time.Time) (result *notifications.Preferences, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateNotificationPreferences", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateNotificationPreferences(ctx, userID, p, expected)
+}
+
+// Webhooks functionality
+
+func (tb *tracingBackend) GetWebhooksInChannel(ctx context.Context, channelID int) (result webhooks.WebhookCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetWebhooksInChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetWebhooksInChannel(ctx, channelID)
+}
+
+func (tb *tracingBackend) GetWebhook(ctx context.Context, id int) (result *webhooks.Webhook, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetWebhook", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetWebhook(ctx, id)
+}
+
+func (tb *tracingBackend) CreateWebhook(ctx context.Context, w *webhooks.Webhook) (result *webhooks.Webhook, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateWebhook", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateWebhook(ctx, w)
+}
+
+func (tb *tracingBackend) UpdateWebhook(ctx context.Context, id int, w *webhooks.Webhook, expected *time.Time) (result *webhooks.Webhook, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateWebhook", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateWebhook(ctx, id, w, expected)
+}
+
+func (tb *tracingBackend) DeleteWebhook(ctx context.Context, id int, expected *time.Time) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DeleteWebhook", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DeleteWebhook(ctx, id, expected)
+}
+
+func (tb *tracingBackend) GetWebhookDeliveries(ctx context.Context, webhookID int) (result webhooks.DeliveryCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetWebhookDeliveries", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetWebhookDeliveries(ctx, webhookID)
+}
+
+func (tb *tracingBackend) CreateWebhookDelivery(ctx context.Context, d *webhooks.Delivery) (result *webhooks.Delivery, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateWebhookDelivery", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateWebhookDelivery(ctx, d)
+}
+
+func (tb *tracingBackend) RecordWebhookSuccess(ctx context.Context, id int) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.RecordWebhookSuccess", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.RecordWebhookSuccess(ctx, id)
+}
+
+func (tb *tracingBackend) RecordWebhookFailure(ctx context.Context, id, disableAfter int) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.RecordWebhookFailure", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.RecordWebhookFailure(ctx, id, disableAfter)
+}
+
+// Bots functionality
+
+func (tb *tracingBackend) GetAllBots(ctx context.Context) (result bots.BotCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetAllBots", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetAllBots(ctx)
+}
+
+func (tb *tracingBackend) GetBot(ctx context.Context, id int) (result *bots.Bot, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetBot", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetBot(ctx, id)
+}
+
+func (tb *tracingBackend) CreateBot(ctx context.Context, b *bots.Bot) (result *bots.Bot, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateBot", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateBot(ctx, b)
+}
+
+func (tb *tracingBackend) DeleteBot(ctx context.Context, id int, expected *time.Time) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DeleteBot", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DeleteBot(ctx, id, expected)
+}
+
+// Tokens functionality
+
+func (tb *tracingBackend) GetTokensForUser(ctx context.Context, userID int) (result tokens.TokenCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetTokensForUser", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetTokensForUser(ctx, userID)
+}
+
+func (tb *tracingBackend) GetToken(ctx context.Context, id int) (result *tokens.Token, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetToken", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetToken(ctx, id)
+}
+
+func (tb *tracingBackend) GetTokenByHash(ctx context.Context, hash string) (result *tokens.Token, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetTokenByHash", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetTokenByHash(ctx, hash)
+}
+
+func (tb *tracingBackend) CreateToken(ctx context.Context, t *tokens.Token) (result *tokens.Token, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateToken", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateToken(ctx, t)
+}
+
+func (tb *tracingBackend) DeleteToken(ctx context.Context, id int) (err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.DeleteToken", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.DeleteToken(ctx, id)
+}
+
+// Bans functionality
+
+func (tb *tracingBackend) GetBansForUser(ctx context.Context, userID int) (result bans.BanCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetBansForUser", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetBansForUser(ctx, userID)
+}
+
+func (tb *tracingBackend) GetBansInChannel(ctx context.Context, channelID int) (result bans.BanCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetBansInChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetBansInChannel(ctx, channelID)
+}
+
+func (tb *tracingBackend) GetBan(ctx context.Context, id int) (result *bans.Ban, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetBan", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetBan(ctx, id)
+}
+
+func (tb *tracingBackend) CreateBan(ctx context.Context, b *bans.Ban) (result *bans.Ban, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateBan", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateBan(ctx, b)
+}
+
+func (tb *tracingBackend) LiftBan(ctx context.Context, id, liftedBy int) (result *bans.Ban, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.LiftBan", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.LiftBan(ctx, id, liftedBy)
+}
+
+func (tb *tracingBackend) IsUserBannedFromChannel(ctx context.Context, userID, channelID int) (result bool, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.IsUserBannedFromChannel", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.IsUserBannedFromChannel(ctx, userID, channelID)
+}
+
+// Reports functionality
+
+func (tb *tracingBackend) GetReports(ctx context.Context, filter reports.Filter) (result reports.ReportCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetReports", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetReports(ctx, filter)
+}
+
+func (tb *tracingBackend) GetReport(ctx context.Context, id int) (result *reports.Report, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetReport", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetReport(ctx, id)
+}
+
+func (tb *tracingBackend) CreateReport(ctx context.Context, r *reports.Report) (result *reports.Report, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateReport", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateReport(ctx, r)
+}
+
+func (tb *tracingBackend) UpdateReport(ctx context.Context, id int, r *reports.Report, expected *time.Time) (result *reports.Report, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.UpdateReport", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.UpdateReport(ctx, id, r, expected)
+}
+
+// Audit functionality
+
+func (tb *tracingBackend) GetAuditEntries(ctx context.Context, filter audit.Filter) (result audit.EntryCollection, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetAuditEntries", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetAuditEntries(ctx, filter)
+}
+
+func (tb *tracingBackend) CreateAuditEntry(ctx context.Context, e *audit.Entry) (result *audit.Entry, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateAuditEntry", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateAuditEntry(ctx, e)
+}
+
+// Impersonation functionality
+
+func (tb *tracingBackend) GetActiveImpersonation(ctx context.Context, adminID int) (result *impersonations.Impersonation, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetActiveImpersonation", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetActiveImpersonation(ctx, adminID)
+}
+
+func (tb *tracingBackend) CreateImpersonation(ctx context.Context, i *impersonations.Impersonation) (result *impersonations.Impersonation, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.CreateImpersonation", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.CreateImpersonation(ctx, i)
+}
+
+func (tb *tracingBackend) EndImpersonation(ctx context.Context, id int) (result *impersonations.Impersonation, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.EndImpersonation", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.EndImpersonation(ctx, id)
+}
+
+// Stats functionality
+
+func (tb *tracingBackend) GetStats(ctx context.Context, opts stats.Options) (result *stats.Stats, err error) {
+	ctx, span := tb.tracer.StartSpan(ctx, "Backend.GetStats", tracing.KindClient)
+	defer tb.finish(span, &err)
+	return tb.backend.GetStats(ctx, opts)
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package bans
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrBanNotFound is the error to use when the Ban is not found.
+var ErrBanNotFound = fmt.Errorf("ban not found")
+
+// ErrBanNotActive is the error to use when lifting a Ban that was already lifted
+// or has expired.
+var ErrBanNotActive = fmt.Errorf("ban is no longer active")
+
+// ErrBannedFromChannel is the error to use when a User that's banned from a
+// Channel tries to use it.
+var ErrBannedFromChannel = fmt.Errorf("user is banned from this channel")
+
+// SiteWide is the ChannelID of Bans that keep the User out of everything rather
+// than a single Channel.
+const SiteWide = 0
+
+// Ban keeps a User out of the site, or just one Channel, from StartsOn until
+// ExpiresOn. Bans without an ExpiresOn last until they're lifted. Bans are kept
+// after they end so there's a history of them. IsActive is whether the Ban is in
+// effect at the time it was retrieved.
+type Ban struct {
+	ID          int        `json:"ID" db:"id"`
+	UserID      int        `json:"UserID" db:"user_id"`
+	ChannelID   int        `json:"ChannelID" db:"channel_id"`
+	Reason      string     `json:"Reason" db:"reason"`
+	IssuedBy    int        `json:"IssuedBy" db:"issued_by"`
+	StartsOn    time.Time  `json:"StartsOn" db:"starts_on"`
+	ExpiresOn   *time.Time `json:"ExpiresOn,omitempty" db:"expires_on"`
+	LiftedBy    int        `json:"LiftedBy" db:"lifted_by"`
+	LiftedOn    *time.Time `json:"LiftedOn,omitempty" db:"lifted_on"`
+	IsActive    bool       `json:"IsActive" db:"is_active"`
+	CreatedOn   time.Time  `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time  `json:"LastUpdated" db:"last_updated"`
+}
+
+// BanCollection is a collection of Bans.
+type BanCollection []*Ban
@@ -0,0 +1,28 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package bots
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrBotNotFound is the error to use when the Bot is not found.
+var ErrBotNotFound = fmt.Errorf("bot not found")
+
+// ErrNotBotOwner is the error to use when a User links a Channel to a Bot they
+// don't own.
+var ErrNotBotOwner = fmt.Errorf("only the bot's owner can link channels to it")
+
+// Bot is a chat app bot, such as a Slack bot, that can be linked to Channels
+// so gameplay can happen in another messaging platform.
+type Bot struct {
+	ID          int       `json:"ID" db:"id"`
+	Workspace   string    `json:"Workspace" db:"workspace"`
+	OwnerID     int       `json:"OwnerID" db:"owner_id"`
+	CreatedOn   time.Time `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time `json:"LastUpdated" db:"last_updated"`
+}
+
+// BotCollection is a collection of Bots.
+type BotCollection []*Bot
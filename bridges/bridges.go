@@ -0,0 +1,192 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package bridges holds the functionality shared by the chat app bridges that
+// let gameplay happen in other messaging platforms. A bridge is linked to a
+// Channel through Channel.BotID and Channel.BotChannel and a platform user is
+// linked to a Character through Character.BotUsername.
+package bridges
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/andrew-boutin/dndtextapi/announcements"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/users"
+)
+
+// maxContentLength matches the messages.content column limit.
+const maxContentLength = 200
+
+// Errors used by the bridges.
+var (
+	// ErrNotLinked is the error to use when the platform channel isn't linked
+	// to any Channel for the bot.
+	ErrNotLinked = fmt.Errorf("platform channel not linked to a channel")
+
+	// ErrNotRegistered is the error to use when the platform user doesn't have
+	// a Character in the linked Channel.
+	ErrNotRegistered = fmt.Errorf("platform user not registered with a character")
+
+	// ErrUserBanned is the error to use when the User behind the Character is banned,
+	// either site wide or from the Channel.
+	ErrUserBanned = fmt.Errorf("user is banned")
+
+	// ErrEmptyContent is the error to use when there's nothing left to post.
+	ErrEmptyContent = fmt.Errorf("message content is empty")
+
+	// ErrContentTooLong is the error to use when the content won't fit in a Message.
+	ErrContentTooLong = fmt.Errorf("message content is too long")
+)
+
+// Store defines the data the bridges need.
+type Store interface {
+	GetChannel(context.Context, int) (*channels.Channel, error)
+	GetChannelByBotChannel(context.Context, int, string) (*channels.Channel, error)
+	GetCharacter(context.Context, int) (*characters.Character, error)
+	GetCharacterByBotUsername(context.Context, int, string) (*characters.Character, error)
+	GetUserByID(context.Context, int) (*users.User, error)
+	IsUserBannedFromChannel(context.Context, int, int) (bool, error)
+	CreateMessage(context.Context, *messages.Message) (*messages.Message, error)
+}
+
+// ParseContent determines if the platform text is a story or meta Message. Text
+// starting with the meta prefix is meta and everything else is story.
+func ParseContent(text, metaPrefix string) (content string, isStory bool) {
+	text = strings.TrimSpace(text)
+	if metaPrefix != "" && strings.HasPrefix(text, metaPrefix) {
+		return strings.TrimSpace(strings.TrimPrefix(text, metaPrefix)), false
+	}
+	return text, true
+}
+
+// Poster posts Messages from a platform and announces them the same as Messages
+// created through the API. It remembers which Messages it posted so the bridge
+// doesn't relay them back to the platform they came from.
+type Poster struct {
+	store     Store
+	announcer *announcements.Announcer
+
+	mu           sync.Mutex
+	fromPlatform map[int]bool
+}
+
+// NewPoster creates a Poster that announces Messages with the Announcer.
+func NewPoster(store Store, announcer *announcements.Announcer) *Poster {
+	return &Poster{
+		store:        store,
+		announcer:    announcer,
+		fromPlatform: make(map[int]bool),
+	}
+}
+
+// Post creates a Message from text sent by a platform user in a platform channel.
+// The platform channel determines the Channel and the platform user determines which
+// Character the Message is from.
+func (p *Poster) Post(ctx context.Context, botID int, platformChannel, platformUser, text, metaPrefix string) (*messages.Message, error) {
+	channel, err := p.store.GetChannelByBotChannel(ctx, botID, platformChannel)
+	if err != nil {
+		if err == channels.ErrChannelNotFound {
+			return nil, ErrNotLinked
+		}
+		return nil, err
+	}
+
+	char, err := p.store.GetCharacterByBotUsername(ctx, channel.ID, platformUser)
+	if err != nil {
+		if err == characters.ErrCharacterNotFound {
+			return nil, ErrNotRegistered
+		}
+		return nil, err
+	}
+
+	user, err := p.store.GetUserByID(ctx, char.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsBanned {
+		return nil, ErrUserBanned
+	}
+
+	// Admins aren't kept out of Channels, the same as through the API
+	if !user.IsAdmin {
+		isBanned, err := p.store.IsUserBannedFromChannel(ctx, user.ID, channel.ID)
+		if err != nil {
+			return nil, err
+		}
+		if isBanned {
+			return nil, ErrUserBanned
+		}
+	}
+
+	content, isStory := ParseContent(text, metaPrefix)
+	if content == "" {
+		return nil, ErrEmptyContent
+	}
+	if len(content) > maxContentLength {
+		return nil, ErrContentTooLong
+	}
+
+	message, err := p.store.CreateMessage(ctx, &messages.Message{
+		Content:     content,
+		CharacterID: char.ID,
+		ChannelID:   channel.ID,
+		IsStory:     isStory,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only announced Messages get relayed so there's nothing to skip otherwise
+	if p.announcer != nil {
+		p.mu.Lock()
+		p.fromPlatform[message.ID] = true
+		p.mu.Unlock()
+	}
+
+	p.announcer.MessageCreated(ctx, channel, char, message)
+	return message, nil
+}
+
+// FromPlatform determines if the Message was posted from the platform, in which
+// case it shouldn't be relayed back to it. Each Message is only reported once.
+func (p *Poster) FromPlatform(messageID int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.fromPlatform[messageID] {
+		return false
+	}
+	delete(p.fromPlatform, messageID)
+	return true
+}
+
+// FormatForPlatform figures out where a Message should be mirrored to for the bot and
+// what the text should be. If the Message's Channel isn't linked to the bot then the
+// returned platform channel is empty.
+func FormatForPlatform(ctx context.Context, store Store, botID int, message *messages.Message, metaPrefix string) (platformChannel, text string, err error) {
+	channel, err := store.GetChannel(ctx, message.ChannelID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if channel.BotID != botID || channel.BotChannel == "" {
+		return "", "", nil
+	}
+
+	char, err := store.GetCharacter(ctx, message.CharacterID)
+	if err != nil {
+		return "", "", err
+	}
+
+	text = fmt.Sprintf("%s: %s", char.Name, message.Content)
+	if !message.IsStory {
+		text = fmt.Sprintf("%s %s", metaPrefix, text)
+	}
+	return channel.BotChannel, text, nil
+}
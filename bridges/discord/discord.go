@@ -0,0 +1,298 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package discord bridges Channels with Discord channels. Linked Discord channels
+// are polled through the Discord REST API for new messages which become Messages,
+// and Messages created through the API are mirrored back to Discord.
+//
+// Polling is the design rather than a stand in for the Discord gateway. The gateway
+// needs a websocket held open with heartbeats, resuming, and reconnecting, while
+// polling only needs plain HTTP and works against a fake Discord server. The cost is
+// that messages show up as much as a poll interval late, each linked Discord channel
+// takes a request every interval, and messages sent while the server is down aren't
+// picked up since the first poll after starting only remembers the newest message.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/announcements"
+	"github.com/andrew-boutin/dndtextapi/bridges"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	log "github.com/sirupsen/logrus"
+)
+
+// pollLimit is the most messages Discord will return in a single request.
+const pollLimit = 100
+
+// Store defines the data the Discord bridge needs.
+type Store interface {
+	bridges.Store
+	GetChannelsForBot(context.Context, int) (channels.ChannelCollection, error)
+}
+
+// Author is the Discord user who sent a message.
+type Author struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Bot      bool   `json:"bot"`
+}
+
+// Message is the part of a Discord message the bridge cares about.
+type Message struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	Content   string `json:"content"`
+	Author    Author `json:"author"`
+	WebhookID string `json:"webhook_id"`
+}
+
+// createMessageRequest is the body used to send a Discord message.
+type createMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// Bridge moves Messages between Discord and the API for a single Bot.
+type Bridge struct {
+	store        Store
+	poster       *bridges.Poster
+	botID        int
+	token        string
+	apiURL       string
+	pollInterval time.Duration
+	metaPrefix   string
+	client       *http.Client
+
+	// lastSeen is the newest Discord message id handled per Discord channel
+	mu       sync.Mutex
+	lastSeen map[string]string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBridge creates a Discord Bridge using the Discord configuration. Messages from
+// Discord are announced with the Announcer.
+func NewBridge(store Store, announcer *announcements.Announcer, c configs.DiscordConfiguration) *Bridge {
+	return &Bridge{
+		store:        store,
+		poster:       bridges.NewPoster(store, announcer),
+		botID:        c.BotID,
+		token:        c.Token,
+		apiURL:       c.APIURL,
+		pollInterval: c.PollInterval,
+		metaPrefix:   c.MetaPrefix,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		lastSeen:     make(map[string]string),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start begins polling the linked Discord channels in the background.
+func (b *Bridge) Start() {
+	go func() {
+		defer close(b.done)
+		ticker := time.NewTicker(b.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				b.Poll()
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops polling.
+func (b *Bridge) Stop() {
+	close(b.stop)
+	<-b.done
+}
+
+// Poll checks every Discord channel linked to the Bot for new messages and posts
+// them to the API. The first time a Discord channel is seen only its newest message
+// is remembered so old history doesn't get replayed.
+func (b *Bridge) Poll() {
+	ctx := context.Background()
+	linkedChannels, err := b.store.GetChannelsForBot(ctx, b.botID)
+	if err != nil {
+		log.WithError(err).Error("Failed to look up channels linked to discord bot.")
+		return
+	}
+
+	for _, channel := range linkedChannels {
+		err = b.pollChannel(ctx, channel.BotChannel)
+		if err != nil {
+			log.WithError(err).WithField("discordChannel", channel.BotChannel).Error("Failed to poll discord channel.")
+		}
+	}
+}
+
+// pollChannel handles any new messages in a single Discord channel.
+func (b *Bridge) pollChannel(ctx context.Context, discordChannel string) error {
+	b.mu.Lock()
+	after, seen := b.lastSeen[discordChannel]
+	b.mu.Unlock()
+
+	newMessages, err := b.getMessages(discordChannel, after)
+	if err != nil {
+		return err
+	}
+
+	// Discord returns the newest messages first
+	sort.Slice(newMessages, func(i, j int) bool {
+		return snowflakeLess(newMessages[i].ID, newMessages[j].ID)
+	})
+
+	for _, message := range newMessages {
+		if seen {
+			b.handleMessage(ctx, message)
+		}
+		after = message.ID
+	}
+
+	b.mu.Lock()
+	b.lastSeen[discordChannel] = after
+	b.mu.Unlock()
+	return nil
+}
+
+// handleMessage posts a Discord message to the API. Problems with the message
+// are reported back in the Discord channel.
+func (b *Bridge) handleMessage(ctx context.Context, message Message) {
+	// Ignore anything from bots and webhooks which includes our own relays
+	if message.Author.Bot || message.WebhookID != "" {
+		return
+	}
+
+	_, err := b.poster.Post(ctx, b.botID, message.ChannelID, message.Author.ID, message.Content, b.metaPrefix)
+	switch err {
+	case nil:
+	case bridges.ErrNotLinked, bridges.ErrNotRegistered:
+		// Not everyone in the Discord channel has to be playing
+	case bridges.ErrEmptyContent, bridges.ErrContentTooLong, bridges.ErrUserBanned:
+		b.reportError(message.ChannelID, fmt.Sprintf("Message not sent: %s.", err))
+	default:
+		log.WithError(err).WithField("discordChannel", message.ChannelID).Error("Failed to post discord message.")
+		b.reportError(message.ChannelID, "Message not sent: something went wrong talking to the API.")
+	}
+}
+
+// reportError lets the Discord channel know about a problem.
+func (b *Bridge) reportError(discordChannel, text string) {
+	err := b.PostMessage(discordChannel, text)
+	if err != nil {
+		log.WithError(err).WithField("discordChannel", discordChannel).Error("Failed to report error to discord.")
+	}
+}
+
+// Relay mirrors Messages created through the API to the linked Discord channel. It's
+// meant to be added as a webhooks.Listener.
+func (b *Bridge) Relay(channelID int, event webhooks.Event, data interface{}) {
+	if event != webhooks.MessageCreatedEvent {
+		return
+	}
+
+	message, ok := data.(*messages.Message)
+	if !ok || b.poster.FromPlatform(message.ID) {
+		return
+	}
+
+	discordChannel, text, err := bridges.FormatForPlatform(context.Background(), b.store, b.botID, message, b.metaPrefix)
+	if err != nil {
+		log.WithError(err).WithField("messageID", message.ID).Error("Failed to format message for discord.")
+		return
+	}
+
+	if discordChannel == "" {
+		return
+	}
+
+	err = b.PostMessage(discordChannel, text)
+	if err != nil {
+		log.WithError(err).WithField("messageID", message.ID).Error("Failed to relay message to discord.")
+	}
+}
+
+// getMessages retrieves the messages in the Discord channel newer than the given
+// message id. An empty id gets the newest message.
+func (b *Bridge) getMessages(discordChannel, after string) ([]Message, error) {
+	query := url.Values{}
+	if after != "" {
+		query.Set("after", after)
+		query.Set("limit", strconv.Itoa(pollLimit))
+	} else {
+		query.Set("limit", "1")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/channels/%s/messages?%s", b.apiURL, discordChannel, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bot "+b.token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from discord", resp.StatusCode)
+	}
+
+	out := make([]Message, 0)
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	return out, err
+}
+
+// PostMessage sends text to the Discord channel.
+func (b *Bridge) PostMessage(discordChannel, text string) error {
+	body, err := json.Marshal(createMessageRequest{Content: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/channels/%s/messages", b.apiURL, discordChannel), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+b.token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d from discord", resp.StatusCode)
+	}
+	return nil
+}
+
+// snowflakeLess compares two Discord ids which are ordered by when they were created.
+func snowflakeLess(a, b string) bool {
+	aInt, aErr := strconv.ParseUint(a, 10, 64)
+	bInt, bErr := strconv.ParseUint(b, 10, 64)
+	if aErr != nil || bErr != nil {
+		return a < b
+	}
+	return aInt < bInt
+}
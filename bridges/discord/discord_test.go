@@ -0,0 +1,165 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	created []*messages.Message
+}
+
+func (s *fakeStore) GetChannel(ctx context.Context, id int) (*channels.Channel, error) {
+	return &channels.Channel{ID: id, BotID: 2, BotChannel: "100"}, nil
+}
+
+func (s *fakeStore) GetChannelsForBot(ctx context.Context, botID int) (channels.ChannelCollection, error) {
+	return channels.ChannelCollection{{ID: 1, BotID: botID, BotChannel: "100"}}, nil
+}
+
+func (s *fakeStore) GetChannelByBotChannel(ctx context.Context, botID int, botChannel string) (*channels.Channel, error) {
+	if botID == 2 && botChannel == "100" {
+		return &channels.Channel{ID: 1, BotID: 2, BotChannel: "100"}, nil
+	}
+	return nil, channels.ErrChannelNotFound
+}
+
+func (s *fakeStore) GetCharacter(ctx context.Context, id int) (*characters.Character, error) {
+	return &characters.Character{ID: id, ChannelID: 1, UserID: 1, Name: "Gandalf"}, nil
+}
+
+func (s *fakeStore) GetCharacterByBotUsername(ctx context.Context, channelID int, botUsername string) (*characters.Character, error) {
+	if botUsername == "500" {
+		return &characters.Character{ID: 1, ChannelID: channelID, UserID: 1, Name: "Gandalf", BotUsername: "500"}, nil
+	}
+	return nil, characters.ErrCharacterNotFound
+}
+
+func (s *fakeStore) GetUserByID(ctx context.Context, id int) (*users.User, error) {
+	return &users.User{ID: id}, nil
+}
+
+func (s *fakeStore) IsUserBannedFromChannel(ctx context.Context, userID, channelID int) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeStore) CreateMessage(ctx context.Context, m *messages.Message) (*messages.Message, error) {
+	s.created = append(s.created, m)
+	return m, nil
+}
+
+// fakeDiscord stands in for the Discord REST API for a single channel.
+type fakeDiscord struct {
+	mu       sync.Mutex
+	messages []Message
+	posted   []string
+}
+
+func (d *fakeDiscord) add(message Message) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Discord lists the newest messages first
+	d.messages = append([]Message{message}, d.messages...)
+}
+
+func (d *fakeDiscord) server(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bot token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/channels/100/messages", r.URL.Path)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if r.Method == http.MethodPost {
+			req := createMessageRequest{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+			d.posted = append(d.posted, req.Content)
+			w.Write([]byte(`{}`))
+			return
+		}
+
+		out := make([]Message, 0)
+		after := r.URL.Query().Get("after")
+		for _, message := range d.messages {
+			if after == "" || snowflakeLess(after, message.ID) {
+				out = append(out, message)
+			}
+		}
+		if after == "" && len(out) > 1 {
+			out = out[:1]
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+}
+
+func newTestBridge(store *fakeStore, apiURL string) *Bridge {
+	return NewBridge(store, nil, configs.DiscordConfiguration{
+		BotID:      2,
+		Token:      "token",
+		APIURL:     apiURL,
+		MetaPrefix: "//",
+	})
+}
+
+func TestPoll(t *testing.T) {
+	discord := &fakeDiscord{}
+	discord.add(Message{ID: "1", ChannelID: "100", Content: "old history", Author: Author{ID: "500"}})
+	server := discord.server(t)
+	defer server.Close()
+
+	store := &fakeStore{}
+	bridge := newTestBridge(store, server.URL)
+
+	// The first poll only remembers where the channel is at
+	bridge.Poll()
+	assert.Equal(t, 0, len(store.created))
+
+	discord.add(Message{ID: "2", ChannelID: "100", Content: "I cast a spell", Author: Author{ID: "500"}})
+	discord.add(Message{ID: "3", ChannelID: "100", Content: "// brb", Author: Author{ID: "500"}})
+	discord.add(Message{ID: "4", ChannelID: "100", Content: "hello", Author: Author{ID: "501"}})
+	discord.add(Message{ID: "5", ChannelID: "100", Content: "Gandalf: relayed", Author: Author{ID: "900", Bot: true}})
+	discord.add(Message{ID: "6", ChannelID: "100", Content: "//", Author: Author{ID: "500"}})
+	bridge.Poll()
+
+	assert.Equal(t, 2, len(store.created))
+	assert.Equal(t, "I cast a spell", store.created[0].Content)
+	assert.True(t, store.created[0].IsStory)
+	assert.Equal(t, "brb", store.created[1].Content)
+	assert.False(t, store.created[1].IsStory)
+
+	// The empty message is reported back to discord
+	assert.Equal(t, 1, len(discord.posted))
+	assert.True(t, strings.HasPrefix(discord.posted[0], "Message not sent"))
+
+	// Nothing new means nothing happens
+	bridge.Poll()
+	assert.Equal(t, 2, len(store.created))
+}
+
+func TestRelay(t *testing.T) {
+	discord := &fakeDiscord{}
+	server := discord.server(t)
+	defer server.Close()
+
+	bridge := newTestBridge(&fakeStore{}, server.URL)
+	bridge.Relay(1, webhooks.MessageCreatedEvent, &messages.Message{ID: 1, ChannelID: 1, CharacterID: 1, Content: "hello", IsStory: true})
+	bridge.Relay(1, webhooks.MessageCreatedEvent, &messages.Message{ID: 2, ChannelID: 1, CharacterID: 1, Content: "brb", IsStory: false})
+	bridge.Relay(1, webhooks.MessageDeletedEvent, &messages.Message{ID: 3, ChannelID: 1, CharacterID: 1, Content: "ignored"})
+
+	assert.Equal(t, []string{"Gandalf: hello", "// Gandalf: brb"}, discord.posted)
+}
@@ -0,0 +1,294 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package slack bridges Channels with Slack channels. Slack Events API payloads
+// become Messages and Messages created through the API are relayed back to Slack
+// using chat.postMessage.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/announcements"
+	"github.com/andrew-boutin/dndtextapi/bridges"
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	log "github.com/sirupsen/logrus"
+)
+
+// Headers Slack uses to sign requests.
+const (
+	// TimestampHeader holds when Slack sent the request.
+	TimestampHeader = "X-Slack-Request-Timestamp"
+
+	// SignatureHeader holds the signature of the request.
+	SignatureHeader = "X-Slack-Signature"
+)
+
+const (
+	signatureVersion = "v0"
+
+	// maxRequestAge is how far off a signed request's timestamp can be before it's
+	// considered a replay
+	maxRequestAge = 5 * time.Minute
+
+	// eventMemory is how long handled event ids are remembered so Slack's retries,
+	// which are spread out over a few minutes, aren't posted again
+	eventMemory = time.Hour
+
+	// Slack Events API payload types
+	urlVerificationType = "url_verification"
+	eventCallbackType   = "event_callback"
+	messageEventType    = "message"
+)
+
+// Errors used by the Slack bridge.
+var (
+	// ErrInvalidSignature is the error to use when a request wasn't signed by Slack.
+	ErrInvalidSignature = fmt.Errorf("invalid slack request signature")
+
+	// ErrStaleRequest is the error to use when a request is too old.
+	ErrStaleRequest = fmt.Errorf("stale slack request")
+)
+
+// EventPayload is the outer payload Slack sends to the Events API endpoint.
+type EventPayload struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	TeamID    string `json:"team_id"`
+	EventID   string `json:"event_id"`
+	Event     Event  `json:"event"`
+}
+
+// Event is the inner event of an event callback.
+type Event struct {
+	Type    string `json:"type"`
+	SubType string `json:"subtype"`
+	Channel string `json:"channel"`
+	User    string `json:"user"`
+	BotID   string `json:"bot_id"`
+	Text    string `json:"text"`
+	TS      string `json:"ts"`
+}
+
+// postMessageRequest is the body of a chat.postMessage call.
+type postMessageRequest struct {
+	Channel string `json:"channel"`
+	Text    string `json:"text"`
+}
+
+// postMessageResponse is the part of the chat.postMessage response we care about.
+type postMessageResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// Bridge moves Messages between Slack and the API for a single Bot.
+type Bridge struct {
+	store         bridges.Store
+	poster        *bridges.Poster
+	botID         int
+	signingSecret string
+	token         string
+	apiURL        string
+	metaPrefix    string
+	client        *http.Client
+
+	// handledEvents are when each recently handled event id was first seen
+	mu            sync.Mutex
+	handledEvents map[string]time.Time
+}
+
+// NewBridge creates a Slack Bridge using the Slack configuration. Messages from Slack
+// are announced with the Announcer.
+func NewBridge(store bridges.Store, announcer *announcements.Announcer, c configs.SlackConfiguration) *Bridge {
+	return &Bridge{
+		store:         store,
+		poster:        bridges.NewPoster(store, announcer),
+		botID:         c.BotID,
+		signingSecret: c.SigningSecret,
+		token:         c.Token,
+		apiURL:        c.APIURL,
+		metaPrefix:    c.MetaPrefix,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		handledEvents: make(map[string]time.Time),
+	}
+}
+
+// Sign creates the signature Slack would send for the body at the given timestamp.
+func Sign(signingSecret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	fmt.Fprintf(mac, "%s:%s:%s", signatureVersion, timestamp, body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequest makes sure the request body was signed by Slack recently. Timestamps
+// too far in the future are rejected the same as ones too far in the past.
+func (b *Bridge) VerifyRequest(timestamp, signature string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if age < 0 {
+		age = -age
+	}
+	if age > maxRequestAge {
+		return ErrStaleRequest
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(b.signingSecret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// HandleEvent processes an Events API payload. URL verification requests return the
+// challenge that has to be echoed back to Slack. Messages from registered Slack users
+// in linked Slack channels are posted to the API. Slack retries events it doesn't
+// think were received so events that were already handled are skipped.
+func (b *Bridge) HandleEvent(ctx context.Context, body []byte) (challenge string, err error) {
+	payload := EventPayload{}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		return "", err
+	}
+
+	switch payload.Type {
+	case urlVerificationType:
+		return payload.Challenge, nil
+	case eventCallbackType:
+		if b.firstDelivery(payload.EventID, time.Now()) {
+			b.handleMessage(ctx, payload.Event)
+		}
+	}
+	return "", nil
+}
+
+// handleMessage posts a Slack message to the API. Slack only cares that we received
+// the event so problems are logged and reported back in the Slack channel.
+func (b *Bridge) handleMessage(ctx context.Context, event Event) {
+	// Ignore edits, joins, etc. and anything from bots which includes our own relays
+	if event.Type != messageEventType || event.SubType != "" || event.BotID != "" {
+		return
+	}
+
+	_, err := b.poster.Post(ctx, b.botID, event.Channel, event.User, event.Text, b.metaPrefix)
+	switch err {
+	case nil:
+	case bridges.ErrNotLinked, bridges.ErrNotRegistered:
+		// Not everyone in the Slack channel has to be playing
+	case bridges.ErrEmptyContent, bridges.ErrContentTooLong, bridges.ErrUserBanned:
+		b.reportError(event.Channel, fmt.Sprintf("Message not sent: %s.", err))
+	default:
+		log.WithError(err).WithField("slackChannel", event.Channel).Error("Failed to post slack message.")
+		b.reportError(event.Channel, "Message not sent: something went wrong talking to the API.")
+	}
+}
+
+// firstDelivery records the event id and determines if it's the first time it's been
+// seen. Events without an id are always handled.
+func (b *Bridge) firstDelivery(eventID string, now time.Time) bool {
+	if eventID == "" {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, seen := range b.handledEvents {
+		if now.Sub(seen) > eventMemory {
+			delete(b.handledEvents, id)
+		}
+	}
+
+	if _, ok := b.handledEvents[eventID]; ok {
+		return false
+	}
+	b.handledEvents[eventID] = now
+	return true
+}
+
+// reportError lets the Slack channel know about a problem.
+func (b *Bridge) reportError(slackChannel, text string) {
+	err := b.PostMessage(slackChannel, text)
+	if err != nil {
+		log.WithError(err).WithField("slackChannel", slackChannel).Error("Failed to report error to slack.")
+	}
+}
+
+// Relay mirrors Messages created through the API to the linked Slack channel. It's
+// meant to be added as a webhooks.Listener.
+func (b *Bridge) Relay(channelID int, event webhooks.Event, data interface{}) {
+	if event != webhooks.MessageCreatedEvent {
+		return
+	}
+
+	message, ok := data.(*messages.Message)
+	if !ok || b.poster.FromPlatform(message.ID) {
+		return
+	}
+
+	slackChannel, text, err := bridges.FormatForPlatform(context.Background(), b.store, b.botID, message, b.metaPrefix)
+	if err != nil {
+		log.WithError(err).WithField("messageID", message.ID).Error("Failed to format message for slack.")
+		return
+	}
+
+	if slackChannel == "" {
+		return
+	}
+
+	err = b.PostMessage(slackChannel, text)
+	if err != nil {
+		log.WithError(err).WithField("messageID", message.ID).Error("Failed to relay message to slack.")
+	}
+}
+
+// PostMessage sends text to the Slack channel using chat.postMessage.
+func (b *Bridge) PostMessage(slackChannel, text string) error {
+	body, err := json.Marshal(postMessageRequest{Channel: slackChannel, Text: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.apiURL+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+b.token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from slack", resp.StatusCode)
+	}
+
+	// Slack reports most failures in the body with a 200
+	out := postMessageResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	if err != nil {
+		return err
+	}
+
+	if !out.OK {
+		return fmt.Errorf("slack error %s", out.Error)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/announcements"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	created []*messages.Message
+}
+
+func (s *fakeStore) GetChannel(ctx context.Context, id int) (*channels.Channel, error) {
+	return &channels.Channel{ID: id, BotID: 1, BotChannel: "C1"}, nil
+}
+
+func (s *fakeStore) GetChannelByBotChannel(ctx context.Context, botID int, botChannel string) (*channels.Channel, error) {
+	if botID == 1 && botChannel == "C1" {
+		return &channels.Channel{ID: 1, BotID: 1, BotChannel: "C1"}, nil
+	}
+	return nil, channels.ErrChannelNotFound
+}
+
+func (s *fakeStore) GetCharacter(ctx context.Context, id int) (*characters.Character, error) {
+	return &characters.Character{ID: id, ChannelID: 1, UserID: 1, Name: "Gandalf"}, nil
+}
+
+func (s *fakeStore) GetCharacterByBotUsername(ctx context.Context, channelID int, botUsername string) (*characters.Character, error) {
+	if botUsername == "U1" {
+		return &characters.Character{ID: 1, ChannelID: channelID, UserID: 1, Name: "Gandalf", BotUsername: "U1"}, nil
+	}
+	return nil, characters.ErrCharacterNotFound
+}
+
+func (s *fakeStore) GetUserByID(ctx context.Context, id int) (*users.User, error) {
+	return &users.User{ID: id}, nil
+}
+
+func (s *fakeStore) IsUserBannedFromChannel(ctx context.Context, userID, channelID int) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeStore) CreateMessage(ctx context.Context, m *messages.Message) (*messages.Message, error) {
+	s.created = append(s.created, m)
+	return m, nil
+}
+
+// mockSlack stands in for the Slack Web API and keeps track of posted messages.
+func mockSlack(t *testing.T, posted *[]postMessageRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		req := postMessageRequest{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		*posted = append(*posted, req)
+
+		w.Write([]byte(`{"ok":true}`))
+	}))
+}
+
+func newTestBridge(store *fakeStore, apiURL string) *Bridge {
+	return NewBridge(store, nil, configs.SlackConfiguration{
+		BotID:         1,
+		SigningSecret: "secret",
+		Token:         "token",
+		APIURL:        apiURL,
+		MetaPrefix:    "//",
+	})
+}
+
+func TestVerifyRequest(t *testing.T) {
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	oldTS := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	futureTS := strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10)
+	body := []byte(`{"type":"event_callback"}`)
+
+	testIO := []struct {
+		desc        string
+		timestamp   string
+		signature   string
+		expectedErr error
+	}{
+		{
+			desc:        "Valid signature",
+			timestamp:   ts,
+			signature:   Sign("secret", ts, body),
+			expectedErr: nil,
+		},
+		{
+			desc:        "Wrong secret",
+			timestamp:   ts,
+			signature:   Sign("other", ts, body),
+			expectedErr: ErrInvalidSignature,
+		},
+		{
+			desc:        "Old request",
+			timestamp:   oldTS,
+			signature:   Sign("secret", oldTS, body),
+			expectedErr: ErrStaleRequest,
+		},
+		{
+			desc:        "Future request",
+			timestamp:   futureTS,
+			signature:   Sign("secret", futureTS, body),
+			expectedErr: ErrStaleRequest,
+		},
+		{
+			desc:        "Missing timestamp",
+			timestamp:   "",
+			signature:   Sign("secret", "", body),
+			expectedErr: ErrInvalidSignature,
+		},
+	}
+
+	bridge := newTestBridge(&fakeStore{}, "")
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expectedErr, bridge.VerifyRequest(test.timestamp, test.signature, body, now))
+		})
+	}
+}
+
+func TestHandleEvent(t *testing.T) {
+	testIO := []struct {
+		desc              string
+		body              string
+		deliveries        int
+		expectedChallenge string
+		expectedContent   []string
+		expectedIsStory   []bool
+		expectedPosts     int
+	}{
+		{
+			desc:              "URL verification echoes the challenge",
+			body:              `{"type":"url_verification","challenge":"abc"}`,
+			expectedChallenge: "abc",
+		},
+		{
+			desc:            "Story message from a registered user",
+			body:            `{"type":"event_callback","event":{"type":"message","channel":"C1","user":"U1","text":"I cast a spell"}}`,
+			expectedContent: []string{"I cast a spell"},
+			expectedIsStory: []bool{true},
+		},
+		{
+			desc:            "Meta message from a registered user",
+			body:            `{"type":"event_callback","event":{"type":"message","channel":"C1","user":"U1","text":"// brb"}}`,
+			expectedContent: []string{"brb"},
+			expectedIsStory: []bool{false},
+		},
+		{
+			desc: "Unregistered users are ignored",
+			body: `{"type":"event_callback","event":{"type":"message","channel":"C1","user":"U2","text":"hello"}}`,
+		},
+		{
+			desc: "Unlinked channels are ignored",
+			body: `{"type":"event_callback","event":{"type":"message","channel":"C2","user":"U1","text":"hello"}}`,
+		},
+		{
+			desc: "Bot messages are ignored",
+			body: `{"type":"event_callback","event":{"type":"message","channel":"C1","user":"U1","bot_id":"B1","text":"hello"}}`,
+		},
+		{
+			desc:          "Empty messages are reported back to slack",
+			body:          `{"type":"event_callback","event":{"type":"message","channel":"C1","user":"U1","text":"//"}}`,
+			expectedPosts: 1,
+		},
+		{
+			desc:            "Retries of an event are only posted once",
+			body:            `{"type":"event_callback","event_id":"Ev1","event":{"type":"message","channel":"C1","user":"U1","text":"I cast a spell"}}`,
+			deliveries:      3,
+			expectedContent: []string{"I cast a spell"},
+			expectedIsStory: []bool{true},
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			posted := []postMessageRequest{}
+			server := mockSlack(t, &posted)
+			defer server.Close()
+
+			store := &fakeStore{}
+			bridge := newTestBridge(store, server.URL)
+
+			for i := 0; i < test.deliveries || i == 0; i++ {
+				challenge, err := bridge.HandleEvent(context.Background(), []byte(test.body))
+				assert.Nil(t, err)
+				assert.Equal(t, test.expectedChallenge, challenge)
+			}
+
+			assert.Equal(t, len(test.expectedContent), len(store.created))
+			for i, message := range store.created {
+				assert.Equal(t, test.expectedContent[i], message.Content)
+				assert.Equal(t, test.expectedIsStory[i], message.IsStory)
+				assert.Equal(t, 1, message.ChannelID)
+				assert.Equal(t, 1, message.CharacterID)
+			}
+			assert.Equal(t, test.expectedPosts, len(posted))
+		})
+	}
+}
+
+func TestRelay(t *testing.T) {
+	posted := []postMessageRequest{}
+	server := mockSlack(t, &posted)
+	defer server.Close()
+
+	bridge := newTestBridge(&fakeStore{}, server.URL)
+	bridge.Relay(1, webhooks.MessageCreatedEvent, &messages.Message{ID: 1, ChannelID: 1, CharacterID: 1, Content: "hello", IsStory: true})
+	bridge.Relay(1, webhooks.MessageCreatedEvent, &messages.Message{ID: 2, ChannelID: 1, CharacterID: 1, Content: "brb", IsStory: false})
+	bridge.Relay(1, webhooks.MessageDeletedEvent, &messages.Message{ID: 3, ChannelID: 1, CharacterID: 1, Content: "ignored"})
+
+	assert.Equal(t, []postMessageRequest{
+		{Channel: "C1", Text: "Gandalf: hello"},
+		{Channel: "C1", Text: "// Gandalf: brb"},
+	}, posted)
+}
+
+func TestRelaySkipsMessagesFromSlack(t *testing.T) {
+	posted := []postMessageRequest{}
+	server := mockSlack(t, &posted)
+	defer server.Close()
+
+	store := &fakeStore{}
+	bridge := NewBridge(store, announcements.NewAnnouncer(nil, nil, nil), configs.SlackConfiguration{
+		BotID:         1,
+		SigningSecret: "secret",
+		Token:         "token",
+		APIURL:        server.URL,
+	})
+
+	_, err := bridge.HandleEvent(context.Background(), []byte(`{"type":"event_callback","event":{"type":"message","channel":"C1","user":"U1","text":"hello"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(store.created))
+
+	bridge.Relay(1, webhooks.MessageCreatedEvent, store.created[0])
+	assert.Equal(t, 0, len(posted))
+}
@@ -0,0 +1,18 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package bulk
+
+import "github.com/andrew-boutin/dndtextapi/apierrors"
+
+// Result is how doing something to one of the IDs in a bulk request went. Status
+// is what the request for just that ID would have responded with and Error is
+// only there when it failed.
+type Result struct {
+	ID     int              `json:"ID"`
+	Status int              `json:"Status"`
+	Error  *apierrors.Error `json:"Error,omitempty"`
+}
+
+// ResultCollection is a collection of Results in the same order as the IDs in the
+// request.
+type ResultCollection []*Result
@@ -12,16 +12,59 @@ var ErrChannelNotFound = fmt.Errorf("channel not found")
 
 // Channel contains all of the data for a channel
 type Channel struct {
-	Name        string    `json:"Name" db:"name"`
-	Description string    `json:"Description" db:"description"`
-	Topic       string    `json:"Topic" db:"topic"`
-	ID          int       `json:"ID" db:"id"`
-	OwnerID     int       `json:"OwnerID" db:"owner_id"`
-	IsPrivate   bool      `json:"IsPrivate" db:"is_private"`
-	CreatedOn   time.Time `json:"CreatedOn" db:"created_on"`
-	LastUpdated time.Time `json:"LastUpdated" db:"last_updated"`
-	DMID        int       `json:"DMID" db:"dm_id"`
+	Name        string     `json:"Name" db:"name"`
+	Description string     `json:"Description" db:"description"`
+	Topic       string     `json:"Topic" db:"topic"`
+	ID          int        `json:"ID" db:"id"`
+	OwnerID     int        `json:"OwnerID" db:"owner_id"`
+	IsPrivate   bool       `json:"IsPrivate" db:"is_private"`
+	CreatedOn   time.Time  `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time  `json:"LastUpdated" db:"last_updated"`
+	DeletedOn   *time.Time `json:"DeletedOn,omitempty" db:"deleted_on"`
+	DMID        int        `json:"DMID" db:"dm_id"`
+	BotID       int        `json:"BotID" db:"bot_id"`
+	BotChannel  string     `json:"BotChannel" db:"bot_channel"`
 }
 
 // ChannelCollection is a collection of channels
 type ChannelCollection []*Channel
+
+// SortField is what Channels can be listed in order of.
+type SortField string
+
+// The SortFields Channels can be listed by.
+const (
+	SortByID          SortField = "id"
+	SortByName        SortField = "name"
+	SortByCreatedOn   SortField = "createdOn"
+	SortByLastUpdated SortField = "lastUpdated"
+)
+
+// AllSortFields are all of the SortFields Channels can be listed by.
+var AllSortFields = []SortField{
+	SortByID,
+	SortByName,
+	SortByCreatedOn,
+	SortByLastUpdated,
+}
+
+// Filter limits which Channels are retrieved and what order they're in. Empty
+// fields don't filter anything and Channels are in order of ID by default.
+type Filter struct {
+	IsPrivate  *bool
+	OwnerID    int
+	Sort       SortField
+	Descending bool
+	Limit      int
+	Offset     int
+}
+
+// IsValidSortField determines if Channels can be listed by the SortField.
+func IsValidSortField(s SortField) bool {
+	for _, known := range AllSortFields {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
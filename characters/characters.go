@@ -12,13 +12,15 @@ var ErrCharacterNotFound = fmt.Errorf("character not found")
 
 // Character holds all of the information that makes up a Character.
 type Character struct {
-	ID          int       `json:"ID" db:"id"`
-	ChannelID   int       `json:"ChannelID" db:"channel_id"`
-	UserID      int       `json:"UserID" db:"user_id"`
-	Name        string    `json:"Name" db:"name"`
-	Description string    `json:"Description" db:"description"`
-	CreatedOn   time.Time `json:"CreatedOn" db:"created_on"`
-	LastUpdated time.Time `json:"LastUpdated" db:"last_updated"`
+	ID          int        `json:"ID" db:"id"`
+	ChannelID   int        `json:"ChannelID" db:"channel_id"`
+	UserID      int        `json:"UserID" db:"user_id"`
+	Name        string     `json:"Name" db:"name"`
+	Description string     `json:"Description" db:"description"`
+	BotUsername string     `json:"BotUsername" db:"bot_username"`
+	CreatedOn   time.Time  `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time  `json:"LastUpdated" db:"last_updated"`
+	DeletedOn   *time.Time `json:"DeletedOn,omitempty" db:"deleted_on"`
 }
 
 // CharacterCollection is a collection of Characters
@@ -0,0 +1,367 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/bulk"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/stats"
+	"github.com/andrew-boutin/dndtextapi/users"
+)
+
+// The admin routes require that the authenticated User is an admin.
+
+// AdminGetChannels retrieves the Channels matching the filter in the order it
+// asks for.
+func (c *Client) AdminGetChannels(filter channels.Filter) (channels.ChannelCollection, error) {
+	query := listQuery(string(filter.Sort), filter.Descending, filter.Limit, filter.Offset)
+	if filter.IsPrivate != nil {
+		query.Set("private", strconv.FormatBool(*filter.IsPrivate))
+	}
+	if filter.OwnerID != 0 {
+		query.Set("owner", strconv.Itoa(filter.OwnerID))
+	}
+
+	out := channels.ChannelCollection{}
+	err := c.do(http.MethodGet, "/admin/channels", query, nil, &out, nil)
+	return out, err
+}
+
+// AdminGetChannel retrieves any Channel.
+func (c *Client) AdminGetChannel(id int) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodGet, adminPath("channels", id), nil, nil, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// AdminUpdateChannel updates any Channel.
+func (c *Client) AdminUpdateChannel(id int, channel *requests.Channel) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodPut, adminPath("channels", id), nil, channel, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// AdminDeleteChannel deletes any Channel.
+func (c *Client) AdminDeleteChannel(id int) error {
+	return c.do(http.MethodDelete, adminPath("channels", id), nil, nil, nil, channels.ErrChannelNotFound)
+}
+
+// AdminGetMessages retrieves a Page of Messages from any Channel.
+func (c *Client) AdminGetMessages(channelID int, page *messages.Page) (messages.MessageCollection, error) {
+	out := messages.MessageCollection{}
+	err := c.do(http.MethodGet, adminPath("channels", channelID)+"/messages", pageQuery(nil, page), nil, &out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// AdminGetMessage retrieves any Message.
+func (c *Client) AdminGetMessage(id int) (*messages.Message, error) {
+	out := &messages.Message{}
+	err := c.do(http.MethodGet, adminPath("messages", id), nil, nil, out, messages.ErrMessageNotFound)
+	return out, err
+}
+
+// AdminUpdateMessage updates any Message.
+func (c *Client) AdminUpdateMessage(id int, message *requests.UpdateMessage) (*messages.Message, error) {
+	out := &messages.Message{}
+	err := c.do(http.MethodPut, adminPath("messages", id), nil, message, out, messages.ErrMessageNotFound)
+	return out, err
+}
+
+// AdminDeleteMessage deletes any Message.
+func (c *Client) AdminDeleteMessage(id int) error {
+	return c.do(http.MethodDelete, adminPath("messages", id), nil, nil, nil, messages.ErrMessageNotFound)
+}
+
+// AdminGetUsers retrieves the Users matching the filter in the order it asks for.
+func (c *Client) AdminGetUsers(filter users.Filter) (users.UserCollection, error) {
+	query := listQuery(string(filter.Sort), filter.Descending, filter.Limit, filter.Offset)
+	if filter.IsAdmin != nil {
+		query.Set("admin", strconv.FormatBool(*filter.IsAdmin))
+	}
+	if filter.IsBanned != nil {
+		query.Set("banned", strconv.FormatBool(*filter.IsBanned))
+	}
+	if filter.LoggedInSince != nil {
+		query.Set("loggedInSince", filter.LoggedInSince.Format(time.RFC3339))
+	}
+	if filter.LoggedInBefore != nil {
+		query.Set("loggedInBefore", filter.LoggedInBefore.Format(time.RFC3339))
+	}
+
+	out := users.UserCollection{}
+	err := c.do(http.MethodGet, "/admin/users", query, nil, &out, nil)
+	return out, err
+}
+
+// AdminGetUser retrieves any User.
+func (c *Client) AdminGetUser(id int) (*users.User, error) {
+	out := &users.User{}
+	err := c.do(http.MethodGet, adminPath("users", id), nil, nil, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// AdminUpdateUser updates any User that isn't an admin.
+func (c *Client) AdminUpdateUser(id int, user *requests.UpdateUser) (*users.User, error) {
+	out := &users.User{}
+	err := c.do(http.MethodPut, adminPath("users", id), nil, user, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// AdminDeleteUser deletes any User.
+func (c *Client) AdminDeleteUser(id int) error {
+	return c.do(http.MethodDelete, adminPath("users", id), nil, nil, nil, users.ErrUserNotFound)
+}
+
+// AdminGetUserBans retrieves the Ban history for any User.
+func (c *Client) AdminGetUserBans(id int) (bans.BanCollection, error) {
+	out := bans.BanCollection{}
+	err := c.do(http.MethodGet, adminPath("users", id)+"/bans", nil, nil, &out, users.ErrUserNotFound)
+	return out, err
+}
+
+// AdminBanUser bans any User that isn't an admin from the whole site.
+func (c *Client) AdminBanUser(id int, ban *requests.CreateBan) (*bans.Ban, error) {
+	out := &bans.Ban{}
+	err := c.do(http.MethodPost, adminPath("users", id)+"/bans", nil, ban, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// AdminLiftBan lifts any Ban early.
+func (c *Client) AdminLiftBan(id int) (*bans.Ban, error) {
+	out := &bans.Ban{}
+	err := c.do(http.MethodPost, adminPath("bans", id)+"/lift", nil, nil, out, bans.ErrBanNotFound)
+	return out, err
+}
+
+// AdminStartImpersonation starts impersonating the User matching the id. Requests
+// are made as the User until EndImpersonation is called or it expires.
+func (c *Client) AdminStartImpersonation(id int, req *requests.StartImpersonation) (*impersonations.Impersonation, error) {
+	out := &impersonations.Impersonation{}
+	err := c.do(http.MethodPost, adminPath("users", id)+"/impersonate", nil, req, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// AdminGetCharacters retrieves the Characters in any Channel.
+func (c *Client) AdminGetCharacters(channelID int) (characters.CharacterCollection, error) {
+	out := characters.CharacterCollection{}
+	err := c.do(http.MethodGet, adminPath("channels", channelID)+"/characters", nil, nil, &out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// AdminGetCharacter retrieves any Character.
+func (c *Client) AdminGetCharacter(id int) (*characters.Character, error) {
+	out := &characters.Character{}
+	err := c.do(http.MethodGet, adminPath("characters", id), nil, nil, out, characters.ErrCharacterNotFound)
+	return out, err
+}
+
+// AdminUpdateCharacter updates any Character.
+func (c *Client) AdminUpdateCharacter(id int, character *requests.UpdateCharacter) (*characters.Character, error) {
+	out := &characters.Character{}
+	err := c.do(http.MethodPut, adminPath("characters", id), nil, character, out, characters.ErrCharacterNotFound)
+	return out, err
+}
+
+// AdminDeleteCharacter deletes any Character.
+func (c *Client) AdminDeleteCharacter(id int) error {
+	return c.do(http.MethodDelete, adminPath("characters", id), nil, nil, nil, characters.ErrCharacterNotFound)
+}
+
+// AdminGetDeletedChannels retrieves the deleted Channels that haven't been purged.
+func (c *Client) AdminGetDeletedChannels() (channels.ChannelCollection, error) {
+	out := channels.ChannelCollection{}
+	err := c.do(http.MethodGet, "/admin/deleted/channels", nil, nil, &out, nil)
+	return out, err
+}
+
+// AdminGetDeletedCharacters retrieves the deleted Characters in any Channel.
+func (c *Client) AdminGetDeletedCharacters(channelID int) (characters.CharacterCollection, error) {
+	out := characters.CharacterCollection{}
+	err := c.do(http.MethodGet, adminPath("deleted/channels", channelID)+"/characters", nil, nil, &out, nil)
+	return out, err
+}
+
+// AdminGetDeletedMessages retrieves the deleted Messages in any Channel.
+func (c *Client) AdminGetDeletedMessages(channelID int) (messages.MessageCollection, error) {
+	out := messages.MessageCollection{}
+	err := c.do(http.MethodGet, adminPath("deleted/channels", channelID)+"/messages", nil, nil, &out, nil)
+	return out, err
+}
+
+// AdminRestoreChannel brings back any deleted Channel that hasn't been purged.
+func (c *Client) AdminRestoreChannel(id int) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodPost, adminPath("channels", id)+"/restore", nil, nil, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// AdminRestoreCharacter brings back any deleted Character that hasn't been purged.
+func (c *Client) AdminRestoreCharacter(id int) (*characters.Character, error) {
+	out := &characters.Character{}
+	err := c.do(http.MethodPost, adminPath("characters", id)+"/restore", nil, nil, out, characters.ErrCharacterNotFound)
+	return out, err
+}
+
+// AdminRestoreMessage brings back any deleted Message that hasn't been purged.
+func (c *Client) AdminRestoreMessage(id int) (*messages.Message, error) {
+	out := &messages.Message{}
+	err := c.do(http.MethodPost, adminPath("messages", id)+"/restore", nil, nil, out, messages.ErrMessageNotFound)
+	return out, err
+}
+
+// AdminGetReports retrieves the Reports matching the filter.
+func (c *Client) AdminGetReports(filter reports.Filter) (reports.ReportCollection, error) {
+	query := url.Values{}
+	if filter.Status != "" {
+		query.Set("status", string(filter.Status))
+	}
+	if filter.TargetType != "" {
+		query.Set("targetType", string(filter.TargetType))
+	}
+	if filter.AssigneeID != nil {
+		query.Set("assignee", strconv.Itoa(*filter.AssigneeID))
+	}
+
+	out := reports.ReportCollection{}
+	err := c.do(http.MethodGet, "/admin/reports", query, nil, &out, nil)
+	return out, err
+}
+
+// AdminGetReport retrieves any Report.
+func (c *Client) AdminGetReport(id int) (*reports.Report, error) {
+	out := &reports.Report{}
+	err := c.do(http.MethodGet, adminPath("reports", id), nil, nil, out, reports.ErrReportNotFound)
+	return out, err
+}
+
+// AdminAssignReport assigns an open Report to an admin.
+func (c *Client) AdminAssignReport(id int, assign *requests.AssignReport) (*reports.Report, error) {
+	out := &reports.Report{}
+	err := c.do(http.MethodPost, adminPath("reports", id)+"/assign", nil, assign, out, reports.ErrReportNotFound)
+	return out, err
+}
+
+// AdminResolveReport resolves an open Report, taking the action against what was
+// reported.
+func (c *Client) AdminResolveReport(id int, resolve *requests.ResolveReport) (*reports.Report, error) {
+	out := &reports.Report{}
+	err := c.do(http.MethodPost, adminPath("reports", id)+"/resolve", nil, resolve, out, reports.ErrReportNotFound)
+	return out, err
+}
+
+// AdminDismissReport dismisses an open Report.
+func (c *Client) AdminDismissReport(id int, dismiss *requests.DismissReport) (*reports.Report, error) {
+	out := &reports.Report{}
+	err := c.do(http.MethodPost, adminPath("reports", id)+"/dismiss", nil, dismiss, out, reports.ErrReportNotFound)
+	return out, err
+}
+
+func adminPath(resource string, id int) string {
+	return fmt.Sprintf("/admin/%s/%d", resource, id)
+}
+
+// AdminGetAuditLog retrieves the audit log Entries matching the filter, newest
+// first.
+func (c *Client) AdminGetAuditLog(filter audit.Filter) (audit.EntryCollection, error) {
+	query := url.Values{}
+	if filter.ActorID != 0 {
+		query.Set("actor", strconv.Itoa(filter.ActorID))
+	}
+	if filter.Action != "" {
+		query.Set("action", string(filter.Action))
+	}
+	if filter.TargetType != "" {
+		query.Set("targetType", string(filter.TargetType))
+	}
+	if filter.TargetID != 0 {
+		query.Set("targetID", strconv.Itoa(filter.TargetID))
+	}
+	if filter.Since != nil {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		query.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	out := audit.EntryCollection{}
+	err := c.do(http.MethodGet, "/admin/audit", query, nil, &out, nil)
+	return out, err
+}
+
+// AdminGetStats retrieves the usage stats covering the options. Options left at
+// zero get the defaults.
+func (c *Client) AdminGetStats(opts stats.Options) (*stats.Stats, error) {
+	query := url.Values{}
+	if opts.Weeks > 0 {
+		query.Set("weeks", strconv.Itoa(opts.Weeks))
+	}
+	if opts.Top > 0 {
+		query.Set("top", strconv.Itoa(opts.Top))
+	}
+
+	out := &stats.Stats{}
+	err := c.do(http.MethodGet, "/admin/stats", query, nil, out, nil)
+	return out, err
+}
+
+// AdminBulkBanUsers bans each of the Users matching the IDs from the whole site.
+func (c *Client) AdminBulkBanUsers(req *requests.BulkBan) (bulk.ResultCollection, error) {
+	out := bulk.ResultCollection{}
+	err := c.do(http.MethodPost, "/admin/bulk/users/ban", nil, req, &out, nil)
+	return out, err
+}
+
+// AdminBulkDeleteUsers deletes each of the Users matching the IDs.
+func (c *Client) AdminBulkDeleteUsers(ids []int) (bulk.ResultCollection, error) {
+	out := bulk.ResultCollection{}
+	err := c.do(http.MethodPost, "/admin/bulk/users/delete", nil, &requests.BulkIDs{IDs: ids}, &out, nil)
+	return out, err
+}
+
+// AdminBulkDeleteChannels deletes each of the Channels matching the IDs.
+func (c *Client) AdminBulkDeleteChannels(ids []int) (bulk.ResultCollection, error) {
+	out := bulk.ResultCollection{}
+	err := c.do(http.MethodPost, "/admin/bulk/channels/delete", nil, &requests.BulkIDs{IDs: ids}, &out, nil)
+	return out, err
+}
+
+// AdminBulkDeleteMessages deletes each of the Messages matching the IDs.
+func (c *Client) AdminBulkDeleteMessages(ids []int) (bulk.ResultCollection, error) {
+	out := bulk.ResultCollection{}
+	err := c.do(http.MethodPost, "/admin/bulk/messages/delete", nil, &requests.BulkIDs{IDs: ids}, &out, nil)
+	return out, err
+}
+
+// listQuery builds the query shared by the admin lists for ordering and paging.
+func listQuery(sort string, descending bool, limit, offset int) url.Values {
+	query := url.Values{}
+	if sort != "" {
+		query.Set("sort", sort)
+	}
+	if descending {
+		query.Set("order", "desc")
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	return query
+}
@@ -0,0 +1,33 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/requests"
+)
+
+// GetChannelBans retrieves the Ban history for the Channel.
+func (c *Client) GetChannelBans(channelID int) (bans.BanCollection, error) {
+	out := bans.BanCollection{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/channels/%d/bans", channelID), nil, nil, &out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// CreateChannelBan bans a User from the Channel.
+func (c *Client) CreateChannelBan(channelID int, ban *requests.CreateChannelBan) (*bans.Ban, error) {
+	out := &bans.Ban{}
+	err := c.do(http.MethodPost, fmt.Sprintf("/channels/%d/bans", channelID), nil, ban, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// LiftChannelBan lifts the Ban matching the id in the Channel early.
+func (c *Client) LiftChannelBan(channelID, id int) (*bans.Ban, error) {
+	out := &bans.Ban{}
+	err := c.do(http.MethodPost, fmt.Sprintf("/channels/%d/bans/%d/lift", channelID, id), nil, nil, out, bans.ErrBanNotFound)
+	return out, err
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/bots"
+	"github.com/andrew-boutin/dndtextapi/requests"
+)
+
+// GetBots retrieves all of the Bots.
+func (c *Client) GetBots() (bots.BotCollection, error) {
+	out := bots.BotCollection{}
+	err := c.do(http.MethodGet, "/bots", nil, nil, &out, nil)
+	return out, err
+}
+
+// CreateBot creates a new Bot owned by the User.
+func (c *Client) CreateBot(bot *requests.CreateBot) (*bots.Bot, error) {
+	out := &bots.Bot{}
+	err := c.do(http.MethodPost, "/bots", nil, bot, out, nil)
+	return out, err
+}
+
+// GetBot retrieves the Bot matching the id.
+func (c *Client) GetBot(id int) (*bots.Bot, error) {
+	out := &bots.Bot{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/bots/%d", id), nil, nil, out, bots.ErrBotNotFound)
+	return out, err
+}
+
+// DeleteBot deletes the Bot matching the id.
+func (c *Client) DeleteBot(id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/bots/%d", id), nil, nil, nil, bots.ErrBotNotFound)
+}
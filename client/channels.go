@@ -0,0 +1,69 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/requests"
+)
+
+// Channel levels used to filter GetChannels.
+const (
+	OwnerLevel  = "owner"
+	MemberLevel = "member"
+)
+
+// GetChannels retrieves the Channels the User owns, is a member of, or both when
+// level is blank.
+func (c *Client) GetChannels(level string) (channels.ChannelCollection, error) {
+	query := url.Values{}
+	if level != "" {
+		query.Set("level", level)
+	}
+
+	out := channels.ChannelCollection{}
+	err := c.do(http.MethodGet, "/channels", query, nil, &out, nil)
+	return out, err
+}
+
+// CreateChannel creates a new Channel owned by the User.
+func (c *Client) CreateChannel(channel *requests.Channel) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodPost, "/channels", nil, channel, out, nil)
+	return out, err
+}
+
+// GetChannel retrieves the Channel matching the id.
+func (c *Client) GetChannel(id int) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodGet, channelPath(id), nil, nil, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// UpdateChannel updates the Channel matching the id.
+func (c *Client) UpdateChannel(id int, channel *requests.Channel) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodPut, channelPath(id), nil, channel, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// DeleteChannel deletes the Channel matching the id.
+func (c *Client) DeleteChannel(id int) error {
+	return c.do(http.MethodDelete, channelPath(id), nil, nil, nil, channels.ErrChannelNotFound)
+}
+
+// RestoreChannel brings back the deleted Channel matching the id along with
+// everything deleted with it.
+func (c *Client) RestoreChannel(id int) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodPost, channelPath(id)+"/restore", nil, nil, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+func channelPath(id int) string {
+	return fmt.Sprintf("/channels/%d", id)
+}
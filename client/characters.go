@@ -0,0 +1,62 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/requests"
+)
+
+// GetCharacters retrieves the Characters in the Channel.
+func (c *Client) GetCharacters(channelID int) (characters.CharacterCollection, error) {
+	out := characters.CharacterCollection{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/channels/%d/characters", channelID), nil, nil, &out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// CreateCharacter invites a User to the Channel by creating a Character for them.
+func (c *Client) CreateCharacter(channelID int, character *requests.CreateCharacter) (*characters.Character, error) {
+	out := &characters.Character{}
+	err := c.do(http.MethodPost, fmt.Sprintf("/channels/%d/characters", channelID), nil, character, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// GetCharacter retrieves the Character matching the id from the Channel.
+func (c *Client) GetCharacter(channelID, id int) (*characters.Character, error) {
+	out := &characters.Character{}
+	err := c.do(http.MethodGet, characterPath(channelID, id), nil, nil, out, characters.ErrCharacterNotFound)
+	return out, err
+}
+
+// UpdateCharacter updates the Character matching the id in the Channel.
+func (c *Client) UpdateCharacter(channelID, id int, character *requests.UpdateCharacter) (*characters.Character, error) {
+	out := &characters.Character{}
+	err := c.do(http.MethodPut, characterPath(channelID, id), nil, character, out, characters.ErrCharacterNotFound)
+	return out, err
+}
+
+// DeleteCharacter deletes the Character matching the id in the Channel.
+func (c *Client) DeleteCharacter(channelID, id int) error {
+	return c.do(http.MethodDelete, characterPath(channelID, id), nil, nil, nil, characters.ErrCharacterNotFound)
+}
+
+// NotifyCharacterTurn lets the Character's User know that it's their turn.
+func (c *Client) NotifyCharacterTurn(channelID, id int) error {
+	return c.do(http.MethodPost, characterPath(channelID, id)+"/turn", nil, nil, nil, characters.ErrCharacterNotFound)
+}
+
+// RestoreCharacter brings back the deleted Character matching the id in the
+// Channel along with the Messages deleted with it.
+func (c *Client) RestoreCharacter(channelID, id int) (*characters.Character, error) {
+	out := &characters.Character{}
+	err := c.do(http.MethodPost, characterPath(channelID, id)+"/restore", nil, nil, out, characters.ErrCharacterNotFound)
+	return out, err
+}
+
+func characterPath(channelID, id int) string {
+	return fmt.Sprintf("/channels/%d/characters/%d", channelID, id)
+}
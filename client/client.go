@@ -0,0 +1,216 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package client is a Go client for the API. It wraps every route with typed
+// methods that send and receive the model structs, and maps error responses
+// back to the sentinel errors used by the API such as channels.ErrChannelNotFound.
+// Validation failures come back as apierrors.FieldErrors.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/messages"
+)
+
+const (
+	// SessionCookieName is the name of the cookie holding an API session.
+	SessionCookieName = "dndtextapisession"
+
+	// DefaultPageSize is how many Messages are requested at a time when paging
+	// through all of the Messages in a Channel.
+	DefaultPageSize = 100
+)
+
+// Errors returned for error responses that don't map to a more specific
+// sentinel error.
+var (
+	// ErrBadRequest is returned when the API rejects the request data.
+	ErrBadRequest = fmt.Errorf("bad request")
+
+	// ErrUnauthorized is returned when the client isn't authenticated.
+	ErrUnauthorized = fmt.Errorf("not authenticated")
+
+	// ErrForbidden is returned when the authenticated User isn't allowed to
+	// make the request.
+	ErrForbidden = fmt.Errorf("access denied")
+
+	// ErrNotFound is returned when a route isn't found.
+	ErrNotFound = fmt.Errorf("not found")
+)
+
+// StatusError is returned for error responses without a sentinel error.
+type StatusError struct {
+	StatusCode int
+	Message    string
+	RequestID  string
+}
+
+func (e *StatusError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("unexpected status code %d: %s (request id %s)", e.StatusCode, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Message)
+}
+
+// Client makes requests to the API as a single User, or anonymously.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	authorize  func(*http.Request)
+}
+
+// NewAnonymousClient creates a Client that can only use the public routes.
+func NewAnonymousClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		authorize:  func(*http.Request) {},
+	}
+}
+
+// NewTokenClient creates a Client that authenticates using an API Token.
+func NewTokenClient(baseURL, token string) *Client {
+	c := NewAnonymousClient(baseURL)
+	c.authorize = func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c
+}
+
+// NewSessionClient creates a Client that authenticates using the value of the
+// session cookie set after logging in through the browser.
+func NewSessionClient(baseURL, session string) *Client {
+	c := NewAnonymousClient(baseURL)
+	c.authorize = func(req *http.Request) {
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: session})
+	}
+	return c
+}
+
+// SetHTTPClient replaces the http.Client used to make requests.
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// do sends the request and decodes the response body into out when out isn't nil.
+// A 404 response becomes notFound so each route can report what wasn't found.
+func (c *Client) do(method, path string, query url.Values, in, out interface{}, notFound error) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp, notFound)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// responseError maps an error response to an error. The error code in the
+// error envelope is used to find the sentinel error when there is one.
+func responseError(resp *http.Response, notFound error) error {
+	b, _ := ioutil.ReadAll(resp.Body)
+
+	envelope := apierrors.Response{}
+	if json.Unmarshal(b, &envelope) == nil && envelope.Error.Code != "" {
+		if err := apierrors.FromCode(envelope.Error.Code); err != nil {
+			return err
+		}
+		if envelope.Error.Code == apierrors.ValidationFailedCode {
+			return apierrors.FieldErrors(envelope.Error.Fields)
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		if notFound != nil {
+			return notFound
+		}
+		return ErrNotFound
+	}
+
+	if envelope.Error.Code != "" {
+		return &StatusError{StatusCode: resp.StatusCode, Message: envelope.Error.Message, RequestID: envelope.Error.RequestID}
+	}
+	return &StatusError{StatusCode: resp.StatusCode, Message: string(b)}
+}
+
+// pageQuery adds the Page to the query parameters.
+func pageQuery(query url.Values, page *messages.Page) url.Values {
+	if query == nil {
+		query = url.Values{}
+	}
+	if page == nil {
+		return query
+	}
+	if page.AfterID > 0 {
+		query.Set("after", strconv.Itoa(page.AfterID))
+	}
+	if page.Limit > 0 {
+		query.Set("limit", strconv.Itoa(page.Limit))
+	}
+	return query
+}
+
+// allPages keeps requesting pages of Messages using get until a page comes back
+// that isn't full.
+func allPages(get func(*messages.Page) (messages.MessageCollection, error)) (messages.MessageCollection, error) {
+	all := make(messages.MessageCollection, 0)
+	page := &messages.Page{Limit: DefaultPageSize}
+	for {
+		msgs, err := get(page)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, msgs...)
+		if len(msgs) < page.Limit {
+			return all, nil
+		}
+		page.AfterID = msgs[len(msgs)-1].ID
+	}
+}
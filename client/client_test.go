@@ -0,0 +1,158 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthentication(t *testing.T) {
+	testIO := []struct {
+		desc           string
+		client         func(string) *Client
+		expectedHeader string
+		expectedCookie string
+	}{
+		{
+			desc:           "Token",
+			client:         func(u string) *Client { return NewTokenClient(u, "abc") },
+			expectedHeader: "Bearer abc",
+		},
+		{
+			desc:           "Session",
+			client:         func(u string) *Client { return NewSessionClient(u, "xyz") },
+			expectedCookie: "xyz",
+		},
+		{
+			desc:   "Anonymous",
+			client: NewAnonymousClient,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.expectedHeader, r.Header.Get("Authorization"))
+
+				cookie, err := r.Cookie(SessionCookieName)
+				if test.expectedCookie == "" {
+					assert.Equal(t, http.ErrNoCookie, err)
+				} else {
+					assert.Equal(t, test.expectedCookie, cookie.Value)
+				}
+
+				w.Write([]byte(`{"ID":1,"Name":"my channel"}`))
+			}))
+			defer server.Close()
+
+			channel, err := test.client(server.URL).GetChannel(1)
+			assert.Nil(t, err)
+			assert.Equal(t, "my channel", channel.Name)
+		})
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	testIO := []struct {
+		desc        string
+		status      int
+		body        string
+		expectedErr error
+	}{
+		{
+			desc:        "Error code maps to the sentinel error",
+			status:      http.StatusNotFound,
+			body:        `{"Error":{"Code":"user_not_found","Message":"user not found","RequestID":"abc"}}`,
+			expectedErr: users.ErrUserNotFound,
+		},
+		{
+			desc:        "Validation failures include the fields",
+			status:      http.StatusBadRequest,
+			body:        `{"Error":{"Code":"validation_failed","Message":"request body failed validation","RequestID":"abc","Fields":[{"Field":"Name","Message":"is required"}]}}`,
+			expectedErr: apierrors.FieldErrors{{Field: "Name", Message: "is required"}},
+		},
+		{
+			desc:        "Server errors include the request id",
+			status:      http.StatusInternalServerError,
+			body:        `{"Error":{"Code":"internal_error","Message":"internal server error","RequestID":"abc"}}`,
+			expectedErr: &StatusError{StatusCode: http.StatusInternalServerError, Message: "internal server error", RequestID: "abc"},
+		},
+		{
+			desc:        "Not found uses the route's sentinel error",
+			status:      http.StatusNotFound,
+			expectedErr: channels.ErrChannelNotFound,
+		},
+		{
+			desc:        "Bad request",
+			status:      http.StatusBadRequest,
+			expectedErr: ErrBadRequest,
+		},
+		{
+			desc:        "Unauthorized",
+			status:      http.StatusUnauthorized,
+			expectedErr: ErrUnauthorized,
+		},
+		{
+			desc:        "Forbidden",
+			status:      http.StatusForbidden,
+			expectedErr: ErrForbidden,
+		},
+		{
+			desc:        "Anything else",
+			status:      http.StatusInternalServerError,
+			body:        "oops",
+			expectedErr: &StatusError{StatusCode: http.StatusInternalServerError, Message: "oops"},
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, err := NewTokenClient(server.URL, "abc").GetChannel(1)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func TestGetAllMessages(t *testing.T) {
+	// 250 Messages should take three pages
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/channels/1/messages", r.URL.Path)
+		assert.Equal(t, StoryMsgType, r.URL.Query().Get("msgType"))
+
+		after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		assert.Equal(t, DefaultPageSize, limit)
+
+		out := messages.MessageCollection{}
+		for id := after + 1; id <= 250 && len(out) < limit; id++ {
+			out = append(out, &messages.Message{ID: id, Content: fmt.Sprintf("message %d", id)})
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+
+	allMessages, err := NewTokenClient(server.URL, "abc").GetAllMessages(1, StoryMsgType)
+	assert.Nil(t, err)
+	assert.Equal(t, 250, len(allMessages))
+	assert.Equal(t, 250, allMessages[249].ID)
+	assert.Equal(t, 3, requests)
+}
@@ -0,0 +1,17 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+)
+
+// EndImpersonation ends the admin's Impersonation so requests are made as
+// themselves again.
+func (c *Client) EndImpersonation() (*impersonations.Impersonation, error) {
+	out := &impersonations.Impersonation{}
+	err := c.do(http.MethodPost, "/impersonation/end", nil, nil, out, impersonations.ErrNotImpersonating)
+	return out, err
+}
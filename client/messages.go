@@ -0,0 +1,76 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/requests"
+)
+
+// Message types used to filter GetMessages.
+const (
+	StoryMsgType = "story"
+	MetaMsgType  = "meta"
+)
+
+// GetMessages retrieves a Page of Messages from the Channel. The msgType can be
+// blank to get both story and meta Messages and page can be nil to get all of them.
+func (c *Client) GetMessages(channelID int, msgType string, page *messages.Page) (messages.MessageCollection, error) {
+	query := url.Values{}
+	if msgType != "" {
+		query.Set("msgType", msgType)
+	}
+
+	out := messages.MessageCollection{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/channels/%d/messages", channelID), pageQuery(query, page), nil, &out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// GetAllMessages retrieves every Message from the Channel a Page at a time.
+func (c *Client) GetAllMessages(channelID int, msgType string) (messages.MessageCollection, error) {
+	return allPages(func(page *messages.Page) (messages.MessageCollection, error) {
+		return c.GetMessages(channelID, msgType, page)
+	})
+}
+
+// CreateMessage creates a new Message in the Channel.
+func (c *Client) CreateMessage(channelID int, message *requests.CreateMessage) (*messages.Message, error) {
+	out := &messages.Message{}
+	err := c.do(http.MethodPost, fmt.Sprintf("/channels/%d/messages", channelID), nil, message, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// GetMessage retrieves the Message matching the id from the Channel.
+func (c *Client) GetMessage(channelID, id int) (*messages.Message, error) {
+	out := &messages.Message{}
+	err := c.do(http.MethodGet, messagePath(channelID, id), nil, nil, out, messages.ErrMessageNotFound)
+	return out, err
+}
+
+// UpdateMessage updates the Message matching the id in the Channel.
+func (c *Client) UpdateMessage(channelID, id int, message *requests.UpdateMessage) (*messages.Message, error) {
+	out := &messages.Message{}
+	err := c.do(http.MethodPut, messagePath(channelID, id), nil, message, out, messages.ErrMessageNotFound)
+	return out, err
+}
+
+// DeleteMessage deletes the Message matching the id in the Channel.
+func (c *Client) DeleteMessage(channelID, id int) error {
+	return c.do(http.MethodDelete, messagePath(channelID, id), nil, nil, nil, messages.ErrMessageNotFound)
+}
+
+// RestoreMessage brings back the deleted Message matching the id in the Channel.
+func (c *Client) RestoreMessage(channelID, id int) (*messages.Message, error) {
+	out := &messages.Message{}
+	err := c.do(http.MethodPost, messagePath(channelID, id)+"/restore", nil, nil, out, messages.ErrMessageNotFound)
+	return out, err
+}
+
+func messagePath(channelID, id int) string {
+	return fmt.Sprintf("/channels/%d/messages/%d", channelID, id)
+}
@@ -0,0 +1,40 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/messages"
+)
+
+// GetPublicChannels retrieves all of the public Channels. No authentication is needed.
+func (c *Client) GetPublicChannels() (channels.ChannelCollection, error) {
+	out := channels.ChannelCollection{}
+	err := c.do(http.MethodGet, "/public/channels", nil, nil, &out, nil)
+	return out, err
+}
+
+// GetPublicChannel retrieves the public Channel matching the id.
+func (c *Client) GetPublicChannel(id int) (*channels.Channel, error) {
+	out := &channels.Channel{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/public/channels/%d", id), nil, nil, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// GetPublicMessages retrieves a Page of story Messages from the public Channel.
+func (c *Client) GetPublicMessages(channelID int, page *messages.Page) (messages.MessageCollection, error) {
+	out := messages.MessageCollection{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/public/channels/%d/messages", channelID), pageQuery(nil, page), nil, &out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// GetAllPublicMessages retrieves every story Message from the public Channel a
+// Page at a time.
+func (c *Client) GetAllPublicMessages(channelID int) (messages.MessageCollection, error) {
+	return allPages(func(page *messages.Page) (messages.MessageCollection, error) {
+		return c.GetPublicMessages(channelID, page)
+	})
+}
@@ -0,0 +1,24 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/requests"
+)
+
+// GetReports retrieves the Reports the User made.
+func (c *Client) GetReports() (reports.ReportCollection, error) {
+	out := reports.ReportCollection{}
+	err := c.do(http.MethodGet, "/reports", nil, nil, &out, nil)
+	return out, err
+}
+
+// CreateReport reports a Message, Character, Channel, or User to the admins.
+func (c *Client) CreateReport(report *requests.CreateReport) (*reports.Report, error) {
+	out := &reports.Report{}
+	err := c.do(http.MethodPost, "/reports", nil, report, out, nil)
+	return out, err
+}
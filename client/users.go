@@ -0,0 +1,71 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/andrew-boutin/dndtextapi/users"
+)
+
+// GetUser retrieves the User matching the id. Only the public profile fields are
+// filled in for other Users and the admin only fields are never filled in.
+func (c *Client) GetUser(id int) (*users.User, error) {
+	out := &users.User{}
+	err := c.do(http.MethodGet, userPath(id), nil, nil, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// UpdateUser updates the User matching the id.
+func (c *Client) UpdateUser(id int, user *requests.UpdateUser) (*users.User, error) {
+	out := &users.User{}
+	err := c.do(http.MethodPut, userPath(id), nil, user, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// DeleteUser deletes the User matching the id.
+func (c *Client) DeleteUser(id int) error {
+	return c.do(http.MethodDelete, userPath(id), nil, nil, nil, users.ErrUserNotFound)
+}
+
+// GetNotificationPreferences retrieves the User's notification Preferences.
+func (c *Client) GetNotificationPreferences(userID int) (*notifications.Preferences, error) {
+	out := &notifications.Preferences{}
+	err := c.do(http.MethodGet, userPath(userID)+"/notifications", nil, nil, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// UpdateNotificationPreferences updates the User's notification Preferences.
+func (c *Client) UpdateNotificationPreferences(userID int, prefs *requests.NotificationPreferences) (*notifications.Preferences, error) {
+	out := &notifications.Preferences{}
+	err := c.do(http.MethodPut, userPath(userID)+"/notifications", nil, prefs, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// GetTokens retrieves the User's API Tokens.
+func (c *Client) GetTokens(userID int) (tokens.TokenCollection, error) {
+	out := tokens.TokenCollection{}
+	err := c.do(http.MethodGet, userPath(userID)+"/tokens", nil, nil, &out, users.ErrUserNotFound)
+	return out, err
+}
+
+// CreateToken creates a new API Token for the User. The returned Token is the
+// only one that has its Value filled in.
+func (c *Client) CreateToken(userID int, name string) (*tokens.Token, error) {
+	out := &tokens.Token{}
+	err := c.do(http.MethodPost, userPath(userID)+"/tokens", nil, &requests.CreateToken{Name: name}, out, users.ErrUserNotFound)
+	return out, err
+}
+
+// DeleteToken revokes the User's API Token matching the id.
+func (c *Client) DeleteToken(userID, id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("%s/tokens/%d", userPath(userID), id), nil, nil, nil, tokens.ErrTokenNotFound)
+}
+
+func userPath(id int) string {
+	return fmt.Sprintf("/users/%d", id)
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+)
+
+// GetWebhooks retrieves the Webhooks registered for the Channel.
+func (c *Client) GetWebhooks(channelID int) (webhooks.WebhookCollection, error) {
+	out := webhooks.WebhookCollection{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/channels/%d/webhooks", channelID), nil, nil, &out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// CreateWebhook registers a new Webhook for the Channel.
+func (c *Client) CreateWebhook(channelID int, webhook *requests.CreateWebhook) (*webhooks.Webhook, error) {
+	out := &webhooks.Webhook{}
+	err := c.do(http.MethodPost, fmt.Sprintf("/channels/%d/webhooks", channelID), nil, webhook, out, channels.ErrChannelNotFound)
+	return out, err
+}
+
+// GetWebhook retrieves the Webhook matching the id from the Channel.
+func (c *Client) GetWebhook(channelID, id int) (*webhooks.Webhook, error) {
+	out := &webhooks.Webhook{}
+	err := c.do(http.MethodGet, webhookPath(channelID, id), nil, nil, out, webhooks.ErrWebhookNotFound)
+	return out, err
+}
+
+// UpdateWebhook updates the Webhook matching the id in the Channel.
+func (c *Client) UpdateWebhook(channelID, id int, webhook *requests.UpdateWebhook) (*webhooks.Webhook, error) {
+	out := &webhooks.Webhook{}
+	err := c.do(http.MethodPut, webhookPath(channelID, id), nil, webhook, out, webhooks.ErrWebhookNotFound)
+	return out, err
+}
+
+// DeleteWebhook deletes the Webhook matching the id in the Channel.
+func (c *Client) DeleteWebhook(channelID, id int) error {
+	return c.do(http.MethodDelete, webhookPath(channelID, id), nil, nil, nil, webhooks.ErrWebhookNotFound)
+}
+
+// GetWebhookDeliveries retrieves the recent delivery attempts for the Webhook.
+func (c *Client) GetWebhookDeliveries(channelID, id int) (webhooks.DeliveryCollection, error) {
+	out := webhooks.DeliveryCollection{}
+	err := c.do(http.MethodGet, webhookPath(channelID, id)+"/deliveries", nil, nil, &out, webhooks.ErrWebhookNotFound)
+	return out, err
+}
+
+func webhookPath(channelID, id int) string {
+	return fmt.Sprintf("/channels/%d/webhooks/%d", channelID, id)
+}
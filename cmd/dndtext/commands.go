@@ -0,0 +1,344 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/client"
+	"github.com/andrew-boutin/dndtextapi/dice"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/requests"
+)
+
+// errChannelRequired is the error to use when the -channel flag is missing.
+var errChannelRequired = fmt.Errorf("-channel is required")
+
+// runLogin verifies the token works and saves it.
+func runLogin(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8080", "API URL")
+	token := fs.String("token", "", "API token created with POST /users/:id/tokens")
+	fs.Parse(args)
+
+	if *token == "" {
+		return fmt.Errorf("-token is required")
+	}
+
+	cfg := &config{URL: strings.TrimRight(*url, "/"), Token: *token}
+	_, err := client.NewTokenClient(cfg.URL, cfg.Token).GetChannels("")
+	if err != nil {
+		return fmt.Errorf("token didn't work: %s", err)
+	}
+
+	err = saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in to %s\n", cfg.URL)
+	return nil
+}
+
+// runChannels lists the Channels the User owns or is a member of.
+func runChannels(args []string) error {
+	fs := flag.NewFlagSet("channels", flag.ExitOnError)
+	level := fs.String("level", "", "Only show Channels you're an `owner` or `member` of")
+	fs.Parse(args)
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	userChannels, err := c.GetChannels(*level)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPRIVATE\tTOPIC")
+	for _, channel := range userChannels {
+		fmt.Fprintf(w, "%d\t%s\t%t\t%s\n", channel.ID, channel.Name, channel.IsPrivate, channel.Topic)
+	}
+	return w.Flush()
+}
+
+// runTail prints the most recent Messages in the Channel and then keeps polling
+// for new ones.
+func runTail(args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	channelID := fs.Int("channel", 0, "Channel ID")
+	count := fs.Int("n", 10, "Number of existing Messages to show")
+	interval := fs.Duration("interval", 2*time.Second, "How often to check for new Messages")
+	fs.Parse(args)
+
+	if *channelID == 0 {
+		return errChannelRequired
+	}
+	if *count < 0 {
+		return fmt.Errorf("-n can't be negative")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	names, err := characterNames(c, *channelID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.GetAllMessages(*channelID, "")
+	if err != nil {
+		return err
+	}
+
+	page := &messages.Page{Limit: client.DefaultPageSize}
+	if len(existing) > 0 {
+		page.AfterID = existing[len(existing)-1].ID
+	}
+	if len(existing) > *count {
+		existing = existing[len(existing)-*count:]
+	}
+	for _, message := range existing {
+		printMessage(os.Stdout, names, message)
+	}
+
+	for range time.Tick(*interval) {
+		newMessages, err := c.GetMessages(*channelID, "", page)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range newMessages {
+			// Characters can join while tailing
+			if _, ok := names[message.CharacterID]; !ok {
+				names, err = characterNames(c, *channelID)
+				if err != nil {
+					return err
+				}
+			}
+
+			printMessage(os.Stdout, names, message)
+			page.AfterID = message.ID
+		}
+	}
+	return nil
+}
+
+// runPost posts a Message as one of the User's Characters.
+func runPost(args []string) error {
+	fs := flag.NewFlagSet("post", flag.ExitOnError)
+	channelID := fs.Int("channel", 0, "Channel ID")
+	as := fs.String("as", "", "Name of your Character to post as")
+	meta := fs.Bool("meta", false, "Post a meta Message instead of a story Message")
+	fs.Parse(args)
+
+	if *channelID == 0 {
+		return errChannelRequired
+	}
+
+	content := strings.Join(fs.Args(), " ")
+	if content == "" {
+		return fmt.Errorf("nothing to post")
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = post(c, *channelID, *as, content, !*meta)
+	return err
+}
+
+// runRoll rolls dice and posts the result as a story Message when a Channel is given.
+func runRoll(args []string) error {
+	fs := flag.NewFlagSet("roll", flag.ExitOnError)
+	channelID := fs.Int("channel", 0, "Channel ID to post the roll to")
+	as := fs.String("as", "", "Name of your Character to post as")
+	fs.Parse(args)
+
+	result, err := dice.Roll(strings.Join(fs.Args(), ""), rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(result)
+	if *channelID == 0 {
+		return nil
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = post(c, *channelID, *as, "rolls "+result.String(), true)
+	return err
+}
+
+// campaign is the JSON export of a Channel.
+type campaign struct {
+	Channel    *channels.Channel
+	Characters characters.CharacterCollection
+	Messages   messages.MessageCollection
+}
+
+// runExport writes out the Channel, its Characters, and its Messages.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	channelID := fs.Int("channel", 0, "Channel ID")
+	format := fs.String("format", "markdown", "Export format `markdown` or `json`")
+	meta := fs.Bool("meta", false, "Include meta Messages instead of only the story")
+	out := fs.String("o", "", "File to write to instead of stdout")
+	fs.Parse(args)
+
+	if *channelID == 0 {
+		return errChannelRequired
+	}
+	if *format != "markdown" && *format != "json" {
+		return fmt.Errorf("unknown format %s", *format)
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	channel, err := c.GetChannel(*channelID)
+	if err != nil {
+		return err
+	}
+
+	channelCharacters, err := c.GetCharacters(*channelID)
+	if err != nil {
+		return err
+	}
+
+	msgType := client.StoryMsgType
+	if *meta {
+		msgType = ""
+	}
+	allMessages, err := c.GetAllMessages(*channelID, msgType)
+	if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(campaign{Channel: channel, Characters: channelCharacters, Messages: allMessages})
+	}
+
+	writeMarkdown(w, channel, channelCharacters, allMessages)
+	return nil
+}
+
+// writeMarkdown writes the campaign as a markdown document.
+func writeMarkdown(w io.Writer, channel *channels.Channel, channelCharacters characters.CharacterCollection, allMessages messages.MessageCollection) {
+	names := make(map[int]string, len(channelCharacters))
+	fmt.Fprintf(w, "# %s\n\n", channel.Name)
+	if channel.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", channel.Description)
+	}
+
+	fmt.Fprintf(w, "## Characters\n\n")
+	for _, character := range channelCharacters {
+		names[character.ID] = character.Name
+		if character.Name == "" {
+			continue
+		}
+		fmt.Fprintf(w, "- **%s** %s\n", character.Name, character.Description)
+	}
+
+	fmt.Fprintf(w, "\n## Story\n\n")
+	for _, message := range allMessages {
+		if message.IsStory {
+			fmt.Fprintf(w, "**%s**: %s\n\n", nameOrUnknown(names, message.CharacterID), message.Content)
+		} else {
+			fmt.Fprintf(w, "> _%s: %s_\n\n", nameOrUnknown(names, message.CharacterID), message.Content)
+		}
+	}
+}
+
+// post posts the content as the named Character. The API makes sure the
+// Character belongs to the User.
+func post(c *client.Client, channelID int, name, content string, isStory bool) (*messages.Message, error) {
+	channelCharacters, err := c.GetCharacters(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var character *characters.Character
+	for _, ch := range channelCharacters {
+		if strings.EqualFold(ch.Name, name) {
+			character = ch
+			break
+		}
+	}
+	if character == nil {
+		return nil, fmt.Errorf("no character named %q in channel %d", name, channelID)
+	}
+
+	return c.CreateMessage(channelID, &requests.CreateMessage{
+		CharacterID: character.ID,
+		Content:     content,
+		IsStory:     isStory,
+	})
+}
+
+// characterNames looks up the Character names in the Channel by id.
+func characterNames(c *client.Client, channelID int) (map[int]string, error) {
+	channelCharacters, err := c.GetCharacters(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string, len(channelCharacters))
+	for _, character := range channelCharacters {
+		names[character.ID] = character.Name
+	}
+	return names, nil
+}
+
+// printMessage prints a Message with meta Messages marked.
+func printMessage(w io.Writer, names map[int]string, message *messages.Message) {
+	prefix := ""
+	if !message.IsStory {
+		prefix = "(meta) "
+	}
+	fmt.Fprintf(w, "%s %s%s: %s\n", message.CreatedOn.Local().Format("15:04"), prefix, nameOrUnknown(names, message.CharacterID), message.Content)
+}
+
+func nameOrUnknown(names map[int]string, characterID int) string {
+	if name, ok := names[characterID]; ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("character %d", characterID)
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/andrew-boutin/dndtextapi/client"
+)
+
+const (
+	configFileName = ".dndtext.json"
+
+	// Environment variables that take precedence over the saved config
+	urlEnv   = "DNDTEXT_URL"
+	tokenEnv = "DNDTEXT_TOKEN"
+)
+
+// errNotLoggedIn is the error to use when there's no saved token.
+var errNotLoggedIn = fmt.Errorf("not logged in - run `dndtext login` or set %s", tokenEnv)
+
+// config is what `dndtext login` saves.
+type config struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// configPath is where the config is saved in the User's home directory.
+func configPath() string {
+	return filepath.Join(os.Getenv("HOME"), configFileName)
+}
+
+// loadConfig reads the saved config and applies any environment overrides.
+func loadConfig() (*config, error) {
+	cfg := &config{}
+
+	b, err := ioutil.ReadFile(configPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		err = json.Unmarshal(b, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("bad config file %s: %s", configPath(), err)
+		}
+	}
+
+	if url := os.Getenv(urlEnv); url != "" {
+		cfg.URL = url
+	}
+	if token := os.Getenv(tokenEnv); token != "" {
+		cfg.Token = token
+	}
+
+	return cfg, nil
+}
+
+// saveConfig writes the config where only the User can read it since it holds the token.
+func saveConfig(cfg *config) error {
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath(), b, 0600)
+}
+
+// newClient creates an API client using the saved token.
+func newClient() (*client.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Token == "" || cfg.URL == "" {
+		return nil, errNotLoggedIn
+	}
+
+	return client.NewTokenClient(cfg.URL, cfg.Token), nil
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// dndtext is a command-line client for the API so players and DMs can run
+// sessions from a terminal and admins can script tasks.
+//
+// Usage:
+//
+//	dndtext login -url http://localhost:8080 -token <token>
+//	dndtext channels [-level owner|member]
+//	dndtext tail -channel 1 [-n 10] [-interval 2s]
+//	dndtext post -channel 1 -as Gandalf [-meta] You shall not pass!
+//	dndtext roll [-channel 1 -as Gandalf] 1d20+5
+//	dndtext export -channel 1 [-format markdown|json] [-meta] [-o campaign.md]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a subcommand that gets the remaining command line arguments.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"login", "Save the API URL and token to use", runLogin},
+	{"channels", "List your Channels", runChannels},
+	{"tail", "Follow the Messages in a Channel", runTail},
+	{"post", "Post a story or meta Message as one of your Characters", runPost},
+	{"roll", "Roll dice and optionally post the result to a Channel", runRoll},
+	{"export", "Export a Channel's campaign as markdown or JSON", runExport},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			err := cmd.run(os.Args[2:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "dndtext %s: %s\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: dndtext <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run `dndtext <command> -h` for the command's flags.")
+}
@@ -16,4 +16,11 @@ type AuthenticationConfiguration struct {
 
 	// Secret is the client secret
 	Secret string
+
+	// RedirectURL is where Google sends Users back to after they log in. It has to
+	// point at the `/callback` route.
+	RedirectURL string
+
+	// SessionSecret signs the session cookies. Changing it logs everyone out.
+	SessionSecret string
 }
@@ -2,11 +2,27 @@
 
 package configs
 
+import "time"
+
 // BackendConfiguration holds the backend configuration data
 // that matches the config file.
 type BackendConfiguration struct {
 	Type   string
+	Host   string
+	Port   int
 	User   string
 	PW     string
 	DBName string
+
+	// SSLMode is passed to Postgres as is such as `disable` or `verify-full`
+	SSLMode string
+
+	// MaxOpenConns is the most connections in the pool, or unlimited if it's 0
+	MaxOpenConns int
+
+	// MaxIdleConns is the most connections kept around while idle
+	MaxIdleConns int
+
+	// ConnMaxLifetime is how long a connection is reused, or forever if it's 0
+	ConnMaxLifetime time.Duration
 }
@@ -4,47 +4,223 @@ package configs
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
-	log "github.com/sirupsen/logrus"
-
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-const defaultEnv = "int"
+const (
+	defaultEnv = "int"
+
+	// envPrefix is the prefix for every environment variable. Nested keys are
+	// joined with underscores so `backend.host` is DNDTEXTAPI_BACKEND_HOST.
+	envPrefix = "DNDTEXTAPI"
+
+	// secretFileSuffix is added to the environment variable of a secret to read it
+	// from a file instead, such as DNDTEXTAPI_BACKEND_PW_FILE.
+	secretFileSuffix = "_FILE"
+)
+
+// ErrHelp is returned by LoadConfig when the help flag was passed.
+var ErrHelp = pflag.ErrHelp
+
+// secretKeys are the settings that can be read from files so they don't have to
+// be in the config file or the environment.
+var secretKeys = []string{
+	"backend.pw",
+	"authentication.secret",
+	"authentication.sessionsecret",
+	"slack.signingsecret",
+	"slack.token",
+	"discord.token",
+}
 
 // Configuration is the top level configuration data from
 // the config file.
 type Configuration struct {
+	Server         ServerConfiguration
 	Backend        BackendConfiguration
 	Authentication AuthenticationConfiguration
+	Notifications  NotificationsConfiguration
+	Webhooks       WebhooksConfiguration
+	Retention      RetentionConfiguration
+	Slack          SlackConfiguration
+	Discord        DiscordConfiguration
+	RateLimit      RateLimitConfiguration
+	Metrics        MetricsConfiguration
+	Tracing        TracingConfiguration
+	Logging        LoggingConfiguration
 }
 
-// LoadConfig loads the config file into the configuration
-// objects and returns the top level configuration.
-func LoadConfig() (configuration Configuration) {
-	// TODO: What about pflags?
-	// Use the DNDTEXTAPI_ENV variable to determine which config file to load up
-	viper.SetEnvPrefix("DNDTEXTAPI")
-	viper.BindEnv("ENV")
-	env := viper.Get("ENV")
-
-	// Default to the integration environment config since that works out of the box
-	if env == nil {
+// LoadConfig builds the configuration from, in order of precedence, the command
+// line flags in args, environment variables, the config file, and defaults. The
+// config file is `config-<env>.yml` where env comes from the `--env` flag or
+// DNDTEXTAPI_ENV, unless a file is given with `--config`. The configuration is
+// validated before it's returned.
+func LoadConfig(args []string) (configuration Configuration, err error) {
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	fs := newFlagSet()
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+	if err = v.BindPFlags(fs); err != nil {
+		return
+	}
+
+	// Use the environment to determine which config file to load up, defaulting to
+	// the integration environment config since that works out of the box
+	env := v.GetString("env")
+	if env == "" {
 		env = defaultEnv
 	}
 
-	// Load the config file and use it to populate the config structs
-	viper.SetConfigName(fmt.Sprintf("config-%s", env))
-	viper.AddConfigPath(".")
-	if err := viper.ReadInConfig(); err != nil {
-		log.WithError(err).Fatal("Error reading config file.")
-		os.Exit(-1)
+	configFile := v.GetString("config")
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+	} else {
+		v.SetConfigName(fmt.Sprintf("config-%s", env))
+		v.AddConfigPath(".")
+	}
+
+	// The config file is optional when one isn't asked for since everything can
+	// come from the environment
+	if err = v.ReadInConfig(); err != nil {
+		if _, notFound := err.(viper.ConfigFileNotFoundError); !notFound || configFile != "" {
+			return configuration, fmt.Errorf("reading config file: %s", err)
+		}
+	}
+
+	if err = readSecretFiles(v); err != nil {
+		return
 	}
-	err := viper.Unmarshal(&configuration)
-	if err != nil {
-		log.WithError(err).Fatal("Unable to decode into struct.")
-		os.Exit(-1)
+
+	if err = v.Unmarshal(&configuration); err != nil {
+		return configuration, fmt.Errorf("decoding config: %s", err)
 	}
+
+	err = configuration.Validate()
 	return
 }
+
+// newFlagSet defines the command line flags. The flag names match the config keys
+// and their defaults are the defaults for the settings.
+func newFlagSet() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("dndtextapi", pflag.ContinueOnError)
+
+	fs.String("env", "", "Environment whose config file to load, config-<env>.yml (default \""+defaultEnv+"\")")
+	fs.String("config", "", "Path to the config file, overrides --env")
+
+	fs.String("server.address", ":8080", "Address to listen on")
+	fs.Duration("server.readtimeout", 10*time.Second, "Longest time to read a request")
+	fs.Duration("server.writetimeout", 30*time.Second, "Longest time to write a response")
+	fs.Duration("server.idletimeout", 60*time.Second, "Longest time to keep an idle connection open")
+	fs.Duration("server.shutdowntimeout", 30*time.Second, "How long in-flight requests get to finish when shutting down")
+	fs.StringSlice("server.corsorigins", nil, "Origins allowed to make cross-origin requests")
+	fs.Bool("server.requireifmatch", false, "Require an If-Match header on updates and deletes")
+
+	fs.String("backend.type", "postgres", "Backend to store data in")
+	fs.String("backend.host", "db", "Database host")
+	fs.Int("backend.port", 5432, "Database port")
+	fs.String("backend.user", "", "Database user")
+	fs.String("backend.pw", "", "Database password, prefer DNDTEXTAPI_BACKEND_PW_FILE")
+	fs.String("backend.dbname", "", "Database name")
+	fs.String("backend.sslmode", "disable", "Postgres sslmode")
+	fs.Int("backend.maxopenconns", 0, "Most open database connections, 0 for unlimited")
+	fs.Int("backend.maxidleconns", 2, "Most idle database connections")
+	fs.Duration("backend.connmaxlifetime", 0, "How long a database connection is reused, 0 for forever")
+
+	fs.String("authentication.accounts", "https://www.googleapis.com", "Base URL to look up User profiles from")
+	fs.String("authentication.oauth2", "https://accounts.google.com", "Base URL of the OAuth2 provider")
+	fs.String("authentication.id", "", "OAuth2 client id")
+	fs.String("authentication.secret", "", "OAuth2 client secret, prefer DNDTEXTAPI_AUTHENTICATION_SECRET_FILE")
+	fs.String("authentication.redirecturl", "http://localhost:8080/callback", "URL Google sends Users back to after logging in")
+	fs.String("authentication.sessionsecret", "", "Secret that signs session cookies, prefer DNDTEXTAPI_AUTHENTICATION_SESSIONSECRET_FILE")
+
+	fs.Bool("notifications.enabled", false, "Send email notifications")
+	fs.String("notifications.host", "", "SMTP server host")
+	fs.Int("notifications.port", 25, "SMTP server port")
+	fs.String("notifications.username", "", "SMTP username, no auth is used if it's blank")
+	fs.String("notifications.password", "", "SMTP password")
+	fs.String("notifications.from", "", "Address notification emails are sent from")
+	fs.Duration("notifications.digestinterval", 15*time.Minute, "How often pending notifications are sent")
+
+	fs.Int("webhooks.workers", 4, "How many webhook deliveries can be in flight at once")
+	fs.Duration("webhooks.timeout", 10*time.Second, "Longest time to wait on a webhook delivery attempt")
+	fs.Int("webhooks.maxattempts", 5, "How many times a webhook delivery is tried")
+	fs.Duration("webhooks.initialbackoff", time.Second, "Wait before the first webhook retry, doubled each retry")
+	fs.Int("webhooks.disableafter", 10, "How many failed deliveries in a row disable a webhook")
+	fs.Bool("webhooks.allowprivate", false, "Allow webhooks to be sent to loopback and private addresses")
+
+	fs.Duration("retention.window", 30*24*time.Hour, "How long deleted Channels, Characters, and Messages can be restored")
+	fs.Duration("retention.purgeinterval", time.Hour, "How often to purge deleted things that can't be restored anymore")
+
+	fs.Bool("slack.enabled", false, "Run the Slack bridge")
+	fs.Int("slack.botid", 0, "ID of the Bot the Slack bridge runs as")
+	fs.String("slack.signingsecret", "", "Slack app signing secret, prefer DNDTEXTAPI_SLACK_SIGNINGSECRET_FILE")
+	fs.String("slack.token", "", "Slack bot token, prefer DNDTEXTAPI_SLACK_TOKEN_FILE")
+	fs.String("slack.apiurl", "https://slack.com/api", "Slack Web API base URL")
+	fs.String("slack.metaprefix", "//", "Prefix that marks a Slack message as meta")
+
+	fs.Bool("discord.enabled", false, "Run the Discord bridge")
+	fs.Int("discord.botid", 0, "ID of the Bot the Discord bridge runs as")
+	fs.String("discord.token", "", "Discord bot token, prefer DNDTEXTAPI_DISCORD_TOKEN_FILE")
+	fs.String("discord.apiurl", "https://discord.com/api/v10", "Discord REST API base URL")
+	fs.Duration("discord.pollinterval", 5*time.Second, "How often linked Discord channels are checked for new messages")
+	fs.String("discord.metaprefix", "//", "Prefix that marks a Discord message as meta")
+
+	fs.Bool("ratelimit.enabled", false, "Rate limit requests")
+	fs.StringSlice("ratelimit.trustedproxies", nil, "IPs or CIDRs of proxies whose X-Forwarded-For header is trusted")
+	addRateLimitFlags(fs, "anonymous", 60, "anonymous routes per IP")
+	addRateLimitFlags(fs, "authenticated", 600, "authenticated routes per User or Token")
+	addRateLimitFlags(fs, "admin", 1200, "admin routes per admin")
+	addRateLimitFlags(fs, "messages", 60, "creating Messages per User or Token")
+
+	fs.Bool("metrics.enabled", false, "Serve Prometheus metrics")
+	fs.Duration("metrics.sessionwindow", 15*time.Minute, "How recently a User has to have made a request to count as active")
+
+	fs.Bool("tracing.enabled", false, "Trace requests and backend calls")
+	fs.String("tracing.servicename", "dndtextapi", "Service name spans are reported under")
+	fs.String("tracing.exporter", "stdout", "Where spans go, stdout or otlp")
+	fs.String("tracing.endpoint", "", "OTLP/HTTP traces URL of the collector")
+	fs.Duration("tracing.flushinterval", 5*time.Second, "How often finished spans are exported")
+
+	fs.String("logging.level", "info", "Lowest level to log")
+	fs.String("logging.format", "json", "Log format, json or text")
+
+	return fs
+}
+
+// addRateLimitFlags defines the flags for the rate limit of a route group. Burst
+// defaults to 0 which means the same as requests.
+func addRateLimitFlags(fs *pflag.FlagSet, group string, requests int, usage string) {
+	fs.Int("ratelimit."+group+".requests", requests, "Requests allowed to "+usage)
+	fs.Duration("ratelimit."+group+".per", time.Minute, "Period the "+group+" requests are allowed in")
+	fs.Int("ratelimit."+group+".burst", 0, "Requests that can be made at once to "+usage)
+}
+
+// readSecretFiles sets any secret that has its _FILE environment variable set to
+// the contents of that file.
+func readSecretFiles(v *viper.Viper) error {
+	for _, key := range secretKeys {
+		envVar := envPrefix + "_" + strings.ToUpper(strings.Replace(key, ".", "_", -1)) + secretFileSuffix
+		path := os.Getenv(envVar)
+		if path == "" {
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %s", envVar, err)
+		}
+		v.Set(key, strings.TrimSpace(string(contents)))
+	}
+	return nil
+}
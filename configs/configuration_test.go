@@ -0,0 +1,232 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+server:
+  address: ":9000"
+backend:
+  host: "filehost"
+  user: "postgres"
+  pw: "postgres"
+  dbname: "dndtext"
+authentication:
+  accounts: http://accounts.example.com
+  oauth2: http://oauth2.example.com
+  id: "clientid"
+  secret: "clientsecret"
+  sessionsecret: "0123456789abcdef0123456789abcdef"
+`
+
+func writeTestFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	configPath := writeTestFile(t, dir, "config.yml", testConfig)
+	secretPath := writeTestFile(t, dir, "pw", "from-file\n")
+
+	type testIO struct {
+		desc  string
+		args  []string
+		env   map[string]string
+		check func(t *testing.T, c Configuration)
+	}
+
+	tests := []testIO{
+		{
+			desc: "File and defaults",
+			args: []string{"--config", configPath},
+			check: func(t *testing.T, c Configuration) {
+				assert.Equal(t, ":9000", c.Server.Address)
+				assert.Equal(t, 30*time.Second, c.Server.ShutdownTimeout)
+				assert.Equal(t, "filehost", c.Backend.Host)
+				assert.Equal(t, 5432, c.Backend.Port)
+				assert.Equal(t, "disable", c.Backend.SSLMode)
+				assert.Equal(t, "http://localhost:8080/callback", c.Authentication.RedirectURL)
+				assert.Equal(t, 30*24*time.Hour, c.Retention.Window)
+			},
+		},
+		{
+			desc: "Environment overrides file",
+			args: []string{"--config", configPath},
+			env:  map[string]string{"DNDTEXTAPI_BACKEND_HOST": "envhost", "DNDTEXTAPI_SERVER_CORSORIGINS": "https://a.example.com,https://b.example.com"},
+			check: func(t *testing.T, c Configuration) {
+				assert.Equal(t, "envhost", c.Backend.Host)
+				assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, c.Server.CORSOrigins)
+			},
+		},
+		{
+			desc: "Flags override environment",
+			args: []string{"--config", configPath, "--backend.host", "flaghost", "--backend.maxopenconns", "10"},
+			env:  map[string]string{"DNDTEXTAPI_BACKEND_HOST": "envhost"},
+			check: func(t *testing.T, c Configuration) {
+				assert.Equal(t, "flaghost", c.Backend.Host)
+				assert.Equal(t, 10, c.Backend.MaxOpenConns)
+			},
+		},
+		{
+			desc: "Environment without a file",
+			env: map[string]string{
+				"DNDTEXTAPI_BACKEND_USER":                 "postgres",
+				"DNDTEXTAPI_BACKEND_DBNAME":               "dndtext",
+				"DNDTEXTAPI_AUTHENTICATION_ID":            "envid",
+				"DNDTEXTAPI_AUTHENTICATION_SECRET":        "envsecret",
+				"DNDTEXTAPI_AUTHENTICATION_SESSIONSECRET": "0123456789abcdef0123456789abcdef",
+				"DNDTEXTAPI_WEBHOOKS_WORKERS":             "8",
+				"DNDTEXTAPI_RATELIMIT_MESSAGES_BURST":     "5",
+				"DNDTEXTAPI_NOTIFICATIONS_DIGESTINTERVAL": "2m",
+			},
+			check: func(t *testing.T, c Configuration) {
+				assert.Equal(t, "envid", c.Authentication.ID)
+				assert.Equal(t, "envsecret", c.Authentication.Secret)
+				assert.Equal(t, "https://accounts.google.com", c.Authentication.Oauth2)
+				assert.Equal(t, 8, c.Webhooks.Workers)
+				assert.Equal(t, 5, c.Webhooks.MaxAttempts)
+				assert.Equal(t, 5, c.RateLimit.Messages.Burst)
+				assert.Equal(t, time.Minute, c.RateLimit.Messages.Per)
+				assert.Equal(t, 2*time.Minute, c.Notifications.DigestInterval)
+			},
+		},
+		{
+			desc: "Flags for every section",
+			args: []string{"--config", configPath, "--discord.pollinterval", "30s", "--ratelimit.admin.requests", "10", "--tracing.exporter", "otlp"},
+			check: func(t *testing.T, c Configuration) {
+				assert.Equal(t, 30*time.Second, c.Discord.PollInterval)
+				assert.Equal(t, 10, c.RateLimit.Admin.Requests)
+				assert.Equal(t, "otlp", c.Tracing.Exporter)
+			},
+		},
+		{
+			desc: "Secret from file",
+			args: []string{"--config", configPath},
+			env:  map[string]string{"DNDTEXTAPI_BACKEND_PW_FILE": secretPath},
+			check: func(t *testing.T, c Configuration) {
+				assert.Equal(t, "from-file", c.Backend.PW)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			for k, v := range test.env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+
+			c, err := LoadConfig(test.args)
+			assert.Nil(t, err)
+			test.check(t, c)
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	configPath := writeTestFile(t, dir, "config.yml", testConfig)
+
+	_, err = LoadConfig([]string{"--config", filepath.Join(dir, "missing.yml")})
+	assert.NotNil(t, err)
+
+	_, err = LoadConfig([]string{"--config", configPath, "--backend.port", "nope"})
+	assert.NotNil(t, err)
+
+	_, err = LoadConfig([]string{"--config", configPath, "--backend.sslmode", "sometimes", "--authentication.sessionsecret", "short"})
+	validationErr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Len(t, validationErr.Problems, 2)
+}
+
+func TestValidate(t *testing.T) {
+	valid := Configuration{
+		Server: ServerConfiguration{Address: ":8080", ShutdownTimeout: time.Second, CORSOrigins: []string{"*", "https://dndtext.example.com"}},
+		Backend: BackendConfiguration{
+			Type: "postgres", Host: "db", Port: 5432, User: "postgres", DBName: "dndtext", SSLMode: "verify-full",
+		},
+		Authentication: AuthenticationConfiguration{
+			Accounts: "http://accounts.example.com", Oauth2: "http://oauth2.example.com", ID: "id", Secret: "secret",
+			RedirectURL: "http://localhost:8080/callback", SessionSecret: "0123456789abcdef0123456789abcdef",
+		},
+		Notifications: NotificationsConfiguration{
+			Enabled: true, Host: "mailhog", Port: 1025, From: "notifications@dndtext.local", DigestInterval: time.Minute,
+		},
+		Webhooks:  WebhooksConfiguration{Workers: 2, Timeout: time.Second, MaxAttempts: 5},
+		Retention: RetentionConfiguration{Window: 24 * time.Hour, PurgeInterval: time.Hour},
+		Slack: SlackConfiguration{
+			Enabled: true, BotID: 1, SigningSecret: "signingsecret", Token: "token", APIURL: "https://slack.com/api",
+		},
+		Discord: DiscordConfiguration{
+			Enabled: true, BotID: 2, Token: "token", APIURL: "https://discord.com/api/v10", PollInterval: time.Second,
+		},
+		RateLimit: RateLimitConfiguration{
+			Enabled:        true,
+			Anonymous:      RateLimit{Requests: 60, Per: time.Minute},
+			Authenticated:  RateLimit{Requests: 600, Per: time.Minute, Burst: 100},
+			Admin:          RateLimit{Requests: 1200, Per: time.Minute},
+			Messages:       RateLimit{Requests: 60, Per: time.Minute},
+			TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"},
+		},
+	}
+	assert.Nil(t, valid.Validate())
+
+	type testIO struct {
+		desc   string
+		modify func(c *Configuration)
+	}
+
+	tests := []testIO{
+		{desc: "Missing address", modify: func(c *Configuration) { c.Server.Address = "" }},
+		{desc: "Bad CORS origin", modify: func(c *Configuration) { c.Server.CORSOrigins = []string{"dndtext.example.com"} }},
+		{desc: "Unknown backend", modify: func(c *Configuration) { c.Backend.Type = "mysql" }},
+		{desc: "Bad port", modify: func(c *Configuration) { c.Backend.Port = 70000 }},
+		{desc: "Negative pool size", modify: func(c *Configuration) { c.Backend.MaxOpenConns = -1 }},
+		{desc: "Relative redirect", modify: func(c *Configuration) { c.Authentication.RedirectURL = "/callback" }},
+		{desc: "No digest interval", modify: func(c *Configuration) { c.Notifications.DigestInterval = 0 }},
+		{desc: "No webhook workers", modify: func(c *Configuration) { c.Webhooks.Workers = 0 }},
+		{desc: "No webhook attempts", modify: func(c *Configuration) { c.Webhooks.MaxAttempts = 0 }},
+		{desc: "No webhook timeout", modify: func(c *Configuration) { c.Webhooks.Timeout = 0 }},
+		{desc: "No retention window", modify: func(c *Configuration) { c.Retention.Window = 0 }},
+		{desc: "No Slack signing secret", modify: func(c *Configuration) { c.Slack.SigningSecret = "" }},
+		{desc: "No Slack token", modify: func(c *Configuration) { c.Slack.Token = "" }},
+		{desc: "No Discord poll interval", modify: func(c *Configuration) { c.Discord.PollInterval = 0 }},
+		{desc: "No rate limit requests", modify: func(c *Configuration) { c.RateLimit.Messages.Requests = 0 }},
+		{desc: "No rate limit period", modify: func(c *Configuration) { c.RateLimit.Admin.Per = 0 }},
+		{desc: "Negative rate limit burst", modify: func(c *Configuration) { c.RateLimit.Anonymous.Burst = -1 }},
+		{desc: "Bad trusted proxy", modify: func(c *Configuration) { c.RateLimit.TrustedProxies = []string{"proxy.local"} }},
+		{desc: "No OTLP endpoint", modify: func(c *Configuration) {
+			c.Tracing = TracingConfiguration{Enabled: true, Exporter: "otlp"}
+		}},
+		{desc: "Unknown log format", modify: func(c *Configuration) { c.Logging.Format = "xml" }},
+		{desc: "Unknown trace exporter", modify: func(c *Configuration) { c.Tracing = TracingConfiguration{Enabled: true, Exporter: "zipkin"} }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			c := valid
+			test.modify(&c)
+			err := c.Validate()
+			validationErr, ok := err.(*ValidationError)
+			assert.True(t, ok)
+			assert.Len(t, validationErr.Problems, 1)
+		})
+	}
+}
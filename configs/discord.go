@@ -0,0 +1,27 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// DiscordConfiguration holds the Discord bridge configuration data
+// that matches the config file.
+type DiscordConfiguration struct {
+	// Enabled turns the Discord bridge on or off
+	Enabled bool
+
+	// BotID is the id of the Bot in the API that this bridge runs as
+	BotID int
+
+	// Token is the Discord bot token
+	Token string
+
+	// APIURL is the Discord REST API base URL - can point at a local fake
+	APIURL string
+
+	// PollInterval is how often linked Discord channels are checked for new messages
+	PollInterval time.Duration
+
+	// MetaPrefix marks a Discord message as a meta message instead of story
+	MetaPrefix string
+}
@@ -0,0 +1,13 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+// LoggingConfiguration holds the logging configuration data that matches the
+// config file.
+type LoggingConfiguration struct {
+	// Level is the lowest level logged such as `debug`, `info`, or `error`
+	Level string
+
+	// Format is either `json` or `text`
+	Format string
+}
@@ -0,0 +1,15 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// MetricsConfiguration holds the metrics configuration data that matches the
+// config file.
+type MetricsConfiguration struct {
+	Enabled bool
+
+	// SessionWindow is how recently a User has to have made a request to count as
+	// an active session
+	SessionWindow time.Duration
+}
@@ -0,0 +1,30 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// NotificationsConfiguration holds the outbound email notification
+// configuration data that matches the config file.
+type NotificationsConfiguration struct {
+	// Enabled turns the notification subsystem on or off
+	Enabled bool
+
+	// Host is the SMTP server host
+	Host string
+
+	// Port is the SMTP server port
+	Port int
+
+	// Username is the optional SMTP username - no auth is used if it's empty
+	Username string
+
+	// Password is the optional SMTP password
+	Password string
+
+	// From is the address notification emails are sent from
+	From string
+
+	// DigestInterval is how often pending notifications are batched up and sent
+	DigestInterval time.Duration
+}
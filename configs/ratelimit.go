@@ -0,0 +1,37 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// RateLimitConfiguration holds the rate limit configuration data that matches
+// the config file. Each route group has its own limit.
+type RateLimitConfiguration struct {
+	Enabled bool
+
+	// Anonymous applies to the routes that don't need authentication per IP
+	Anonymous RateLimit
+
+	// Authenticated applies to the routes that need authentication per User or Token
+	Authenticated RateLimit
+
+	// Admin applies to the /admin routes instead of Authenticated
+	Admin RateLimit
+
+	// Messages applies to creating Messages on top of Authenticated
+	Messages RateLimit
+
+	// TrustedProxies are the IPs or CIDRs of the proxies in front of the server. The
+	// X-Forwarded-For header is only used to identify anonymous callers when the
+	// request came through one of them, otherwise it could be forged
+	TrustedProxies []string
+}
+
+// RateLimit is how many requests are allowed in a period of time.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+
+	// Burst is how many requests can be made at once - defaults to Requests
+	Burst int
+}
@@ -0,0 +1,17 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// RetentionConfiguration holds how deleted Channels, Characters, and Messages are
+// kept around that matches the config file.
+type RetentionConfiguration struct {
+	// Window is how long something can be restored after it's deleted before it's
+	// purged for good
+	Window time.Duration
+
+	// PurgeInterval is how often to purge whatever has been deleted for longer
+	// than the window
+	PurgeInterval time.Duration
+}
@@ -0,0 +1,27 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// ServerConfiguration holds the HTTP server configuration data that matches the
+// config file.
+type ServerConfiguration struct {
+	// Address is the host and port to listen on such as `:8080`
+	Address string
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// ShutdownTimeout is how long in-flight requests get to finish when shutting down
+	ShutdownTimeout time.Duration
+
+	// CORSOrigins are the browser origins, such as `https://dndtext.example.com`,
+	// allowed to call the API. `*` allows any origin. It's empty to turn CORS off.
+	CORSOrigins []string
+
+	// RequireIfMatch makes updates and deletes fail without an If-Match header so
+	// clients can't skip the check for concurrent changes
+	RequireIfMatch bool
+}
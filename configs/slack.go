@@ -0,0 +1,25 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+// SlackConfiguration holds the Slack bridge configuration data
+// that matches the config file.
+type SlackConfiguration struct {
+	// Enabled turns the Slack bridge on or off
+	Enabled bool
+
+	// BotID is the id of the Bot in the API that this bridge runs as
+	BotID int
+
+	// SigningSecret is the Slack app signing secret used to verify event requests
+	SigningSecret string
+
+	// Token is the Slack bot token used to post messages
+	Token string
+
+	// APIURL is the Slack Web API base URL - can point at a local mock
+	APIURL string
+
+	// MetaPrefix marks a Slack message as a meta message instead of story
+	MetaPrefix string
+}
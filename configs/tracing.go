@@ -0,0 +1,21 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// TracingConfiguration holds the tracing configuration data that matches the
+// config file.
+type TracingConfiguration struct {
+	Enabled     bool
+	ServiceName string
+
+	// Exporter is where spans go - either `stdout` or `otlp`
+	Exporter string
+
+	// Endpoint is the OTLP/HTTP traces URL of the collector when exporting with `otlp`
+	Endpoint string
+
+	// FlushInterval is how often finished spans are exported
+	FlushInterval time.Duration
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// minSessionSecretLength is the shortest session secret allowed so the cookies
+// can't be forged by guessing it.
+const minSessionSecretLength = 32
+
+// sslModes are the sslmode values Postgres understands.
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// ValidationError lists everything wrong with a Configuration so it can all be
+// fixed at once.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// validator collects problems found with a Configuration.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) checkURL(key, value string) {
+	u, err := url.Parse(value)
+	v.check(err == nil && u.Scheme != "" && u.Host != "", "%s must be an absolute URL but is %q", key, value)
+}
+
+func (v *validator) checkRateLimit(key string, limit RateLimit) {
+	v.check(limit.Requests > 0, "%s.requests must be positive", key)
+	v.check(limit.Per > 0, "%s.per must be positive", key)
+	v.check(limit.Burst >= 0, "%s.burst can't be negative", key)
+}
+
+// Validate returns a *ValidationError describing every setting that's missing or
+// doesn't make sense.
+func (c Configuration) Validate() error {
+	v := &validator{}
+
+	v.check(c.Server.Address != "", "server.address is required")
+	v.check(c.Server.ReadTimeout >= 0, "server.readtimeout can't be negative")
+	v.check(c.Server.WriteTimeout >= 0, "server.writetimeout can't be negative")
+	v.check(c.Server.IdleTimeout >= 0, "server.idletimeout can't be negative")
+	v.check(c.Server.ShutdownTimeout > 0, "server.shutdowntimeout must be positive")
+	for _, origin := range c.Server.CORSOrigins {
+		if origin != "*" {
+			v.checkURL("server.corsorigins", origin)
+		}
+	}
+
+	v.check(c.Backend.Type == "postgres", "backend.type must be postgres but is %q", c.Backend.Type)
+	v.check(c.Backend.Host != "", "backend.host is required")
+	v.check(c.Backend.Port > 0 && c.Backend.Port < 65536, "backend.port must be between 1 and 65535 but is %d", c.Backend.Port)
+	v.check(c.Backend.User != "", "backend.user is required")
+	v.check(c.Backend.DBName != "", "backend.dbname is required")
+	v.check(sslModes[c.Backend.SSLMode], "backend.sslmode %q isn't a Postgres sslmode", c.Backend.SSLMode)
+	v.check(c.Backend.MaxOpenConns >= 0, "backend.maxopenconns can't be negative")
+	v.check(c.Backend.MaxIdleConns >= 0, "backend.maxidleconns can't be negative")
+	v.check(c.Backend.ConnMaxLifetime >= 0, "backend.connmaxlifetime can't be negative")
+
+	v.check(c.Authentication.ID != "", "authentication.id is required")
+	v.check(c.Authentication.Secret != "", "authentication.secret is required")
+	v.checkURL("authentication.accounts", c.Authentication.Accounts)
+	v.checkURL("authentication.oauth2", c.Authentication.Oauth2)
+	v.checkURL("authentication.redirecturl", c.Authentication.RedirectURL)
+	v.check(len(c.Authentication.SessionSecret) >= minSessionSecretLength,
+		"authentication.sessionsecret must be at least %d characters", minSessionSecretLength)
+
+	if c.Notifications.Enabled {
+		v.check(c.Notifications.Host != "", "notifications.host is required")
+		v.check(c.Notifications.Port > 0 && c.Notifications.Port < 65536,
+			"notifications.port must be between 1 and 65535 but is %d", c.Notifications.Port)
+		v.check(c.Notifications.From != "", "notifications.from is required")
+		v.check(c.Notifications.DigestInterval > 0, "notifications.digestinterval must be positive")
+	}
+
+	// Webhooks are always on
+	v.check(c.Webhooks.Workers > 0, "webhooks.workers must be positive")
+	v.check(c.Webhooks.MaxAttempts > 0, "webhooks.maxattempts must be positive")
+	v.check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	v.check(c.Webhooks.InitialBackoff >= 0, "webhooks.initialbackoff can't be negative")
+	v.check(c.Webhooks.DisableAfter >= 0, "webhooks.disableafter can't be negative")
+
+	v.check(c.Retention.Window > 0, "retention.window must be positive")
+	v.check(c.Retention.PurgeInterval > 0, "retention.purgeinterval must be positive")
+
+	if c.Slack.Enabled {
+		v.check(c.Slack.BotID > 0, "slack.botid is required")
+		v.check(c.Slack.SigningSecret != "", "slack.signingsecret is required")
+		v.check(c.Slack.Token != "", "slack.token is required")
+		v.checkURL("slack.apiurl", c.Slack.APIURL)
+	}
+
+	if c.Discord.Enabled {
+		v.check(c.Discord.BotID > 0, "discord.botid is required")
+		v.check(c.Discord.Token != "", "discord.token is required")
+		v.checkURL("discord.apiurl", c.Discord.APIURL)
+		v.check(c.Discord.PollInterval > 0, "discord.pollinterval must be positive")
+	}
+
+	if c.RateLimit.Enabled {
+		v.checkRateLimit("ratelimit.anonymous", c.RateLimit.Anonymous)
+		v.checkRateLimit("ratelimit.authenticated", c.RateLimit.Authenticated)
+		v.checkRateLimit("ratelimit.admin", c.RateLimit.Admin)
+		v.checkRateLimit("ratelimit.messages", c.RateLimit.Messages)
+		for _, proxy := range c.RateLimit.TrustedProxies {
+			_, _, err := net.ParseCIDR(proxy)
+			v.check(err == nil || net.ParseIP(proxy) != nil, "ratelimit.trustedproxies must be IPs or CIDRs but has %q", proxy)
+		}
+	}
+
+	if c.Tracing.Enabled {
+		v.check(c.Tracing.Exporter == "stdout" || c.Tracing.Exporter == "otlp",
+			"tracing.exporter must be stdout or otlp but is %q", c.Tracing.Exporter)
+		if c.Tracing.Exporter == "otlp" {
+			v.checkURL("tracing.endpoint", c.Tracing.Endpoint)
+		}
+	}
+
+	v.check(c.Logging.Format == "" || c.Logging.Format == "json" || c.Logging.Format == "text",
+		"logging.format must be json or text but is %q", c.Logging.Format)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
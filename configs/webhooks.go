@@ -0,0 +1,28 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package configs
+
+import "time"
+
+// WebhooksConfiguration holds the outgoing webhook configuration data
+// that matches the config file.
+type WebhooksConfiguration struct {
+	// Workers is how many deliveries can be in flight at once
+	Workers int
+
+	// Timeout is how long to wait on a single delivery attempt
+	Timeout time.Duration
+
+	// MaxAttempts is how many times a delivery is tried before giving up
+	MaxAttempts int
+
+	// InitialBackoff is how long to wait before the first retry - it doubles each retry
+	InitialBackoff time.Duration
+
+	// DisableAfter is how many failed deliveries in a row disable a webhook
+	DisableAfter int
+
+	// AllowPrivate lets webhooks be sent to loopback and private addresses, which
+	// is only meant for local development
+	AllowPrivate bool
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package dice rolls dice using standard dice notation such as `1d20+5`
+// or `2d6+1d4-1`.
+package dice
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Limits to keep rolls reasonable.
+const (
+	MaxDice  = 100
+	MaxSides = 1000
+)
+
+// ErrInvalidExpression is the error to use when the dice expression can't be parsed.
+var ErrInvalidExpression = fmt.Errorf("invalid dice expression")
+
+// Term is a group of identical dice, such as `2d6`, and what they rolled.
+type Term struct {
+	Count    int
+	Sides    int
+	Negative bool
+	Rolls    []int
+}
+
+// Result is the outcome of rolling a dice expression.
+type Result struct {
+	Expression string
+	Terms      []*Term
+	Modifier   int
+	Total      int
+}
+
+// String shows each die rolled along with the total, ex: `2d6+3: [4 5] +3 = 12`.
+func (r *Result) String() string {
+	parts := []string{}
+	for _, term := range r.Terms {
+		rolls := make([]string, len(term.Rolls))
+		for i, roll := range term.Rolls {
+			rolls[i] = strconv.Itoa(roll)
+		}
+
+		part := "[" + strings.Join(rolls, " ") + "]"
+		if term.Negative {
+			part = "-" + part
+		}
+		parts = append(parts, part)
+	}
+
+	if r.Modifier > 0 {
+		parts = append(parts, fmt.Sprintf("+%d", r.Modifier))
+	} else if r.Modifier < 0 {
+		parts = append(parts, strconv.Itoa(r.Modifier))
+	}
+
+	return fmt.Sprintf("%s: %s = %d", r.Expression, strings.Join(parts, " "), r.Total)
+}
+
+// Roll parses the dice expression and rolls it using rng.
+func Roll(expression string, rng *rand.Rand) (*Result, error) {
+	expr := strings.ToLower(strings.Replace(expression, " ", "", -1))
+	if expr == "" {
+		return nil, ErrInvalidExpression
+	}
+
+	result := &Result{Expression: expr}
+	for _, token := range splitTerms(expr) {
+		negative := strings.HasPrefix(token, "-")
+		token = strings.TrimLeft(token, "+-")
+
+		if !strings.Contains(token, "d") {
+			modifier, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, ErrInvalidExpression
+			}
+			if negative {
+				modifier = -modifier
+			}
+			result.Modifier += modifier
+			result.Total += modifier
+			continue
+		}
+
+		term, err := parseTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		term.Negative = negative
+
+		for i := 0; i < term.Count; i++ {
+			roll := rng.Intn(term.Sides) + 1
+			term.Rolls = append(term.Rolls, roll)
+			if negative {
+				result.Total -= roll
+			} else {
+				result.Total += roll
+			}
+		}
+		result.Terms = append(result.Terms, term)
+	}
+
+	return result, nil
+}
+
+// splitTerms splits the expression into signed terms, ex: `2d6-1` becomes `2d6` and `-1`.
+func splitTerms(expr string) []string {
+	terms := []string{}
+	start := 0
+	for i := 1; i < len(expr); i++ {
+		if expr[i] == '+' || expr[i] == '-' {
+			terms = append(terms, expr[start:i])
+			start = i
+		}
+	}
+	return append(terms, expr[start:])
+}
+
+// parseTerm parses a dice term such as `2d6` or `d20`.
+func parseTerm(token string) (*Term, error) {
+	parts := strings.Split(token, "d")
+	if len(parts) != 2 {
+		return nil, ErrInvalidExpression
+	}
+
+	count := 1
+	if parts[0] != "" {
+		var err error
+		count, err = strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, ErrInvalidExpression
+		}
+	}
+
+	sides, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, ErrInvalidExpression
+	}
+
+	if count < 1 || count > MaxDice || sides < 2 || sides > MaxSides {
+		return nil, ErrInvalidExpression
+	}
+
+	return &Term{Count: count, Sides: sides}, nil
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package dice
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoll(t *testing.T) {
+	testIO := []struct {
+		desc          string
+		expression    string
+		expectedTerms int
+		expectedMod   int
+		expectedErr   error
+	}{
+		{
+			desc:          "Single die",
+			expression:    "d20",
+			expectedTerms: 1,
+		},
+		{
+			desc:          "Dice with a modifier",
+			expression:    "2d6+3",
+			expectedTerms: 1,
+			expectedMod:   3,
+		},
+		{
+			desc:          "Multiple dice and modifiers",
+			expression:    "1d8 + 2d4 - 1 + 2",
+			expectedTerms: 2,
+			expectedMod:   1,
+		},
+		{
+			desc:        "Empty",
+			expression:  "",
+			expectedErr: ErrInvalidExpression,
+		},
+		{
+			desc:        "Garbage",
+			expression:  "2dx",
+			expectedErr: ErrInvalidExpression,
+		},
+		{
+			desc:        "Too many dice",
+			expression:  "1000d6",
+			expectedErr: ErrInvalidExpression,
+		},
+		{
+			desc:        "One sided die",
+			expression:  "1d1",
+			expectedErr: ErrInvalidExpression,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			result, err := Roll(test.expression, rand.New(rand.NewSource(1)))
+			assert.Equal(t, test.expectedErr, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, test.expectedTerms, len(result.Terms))
+			assert.Equal(t, test.expectedMod, result.Modifier)
+
+			total := result.Modifier
+			for _, term := range result.Terms {
+				assert.Equal(t, term.Count, len(term.Rolls))
+				for _, roll := range term.Rolls {
+					assert.True(t, roll >= 1 && roll <= term.Sides)
+					if term.Negative {
+						total -= roll
+					} else {
+						total += roll
+					}
+				}
+			}
+			assert.Equal(t, total, result.Total)
+		})
+	}
+}
+
+func TestResultString(t *testing.T) {
+	result := &Result{
+		Expression: "2d6-1d4+3",
+		Terms: []*Term{
+			{Count: 2, Sides: 6, Rolls: []int{4, 5}},
+			{Count: 1, Sides: 4, Negative: true, Rolls: []int{2}},
+		},
+		Modifier: 3,
+		Total:    10,
+	}
+	assert.Equal(t, "2d6-1d4+3: [4 5] -[2] +3 = 10", result.String())
+}
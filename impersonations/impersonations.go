@@ -0,0 +1,42 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package impersonations
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrNotImpersonating is the error to use when the admin doesn't have an
+// Impersonation in effect.
+var ErrNotImpersonating = fmt.Errorf("not impersonating anyone")
+
+// ErrAlreadyImpersonating is the error to use when the admin starts an
+// Impersonation while they already have one in effect.
+var ErrAlreadyImpersonating = fmt.Errorf("already impersonating someone")
+
+// ErrReadOnly is the error to use when a read only Impersonation tries to change
+// something.
+var ErrReadOnly = fmt.Errorf("impersonation is read only")
+
+// MaxLength is the longest an Impersonation lasts before it ends on its own.
+const MaxLength = time.Hour
+
+// Impersonation lets an admin use the API as another User to see what they see.
+// While it's in effect every request the admin makes is handled as the User.
+// Impersonations are read only unless AllowWrites is set. They end when the admin
+// ends them or at ExpiresOn, whichever comes first. IsActive is whether the
+// Impersonation is in effect at the time it was retrieved.
+type Impersonation struct {
+	ID          int        `json:"ID" db:"id"`
+	AdminID     int        `json:"AdminID" db:"admin_id"`
+	UserID      int        `json:"UserID" db:"user_id"`
+	Reason      string     `json:"Reason" db:"reason"`
+	AllowWrites bool       `json:"AllowWrites" db:"allow_writes"`
+	StartsOn    time.Time  `json:"StartsOn" db:"starts_on"`
+	ExpiresOn   time.Time  `json:"ExpiresOn" db:"expires_on"`
+	EndedOn     *time.Time `json:"EndedOn,omitempty" db:"ended_on"`
+	IsActive    bool       `json:"IsActive" db:"is_active"`
+	CreatedOn   time.Time  `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time  `json:"LastUpdated" db:"last_updated"`
+}
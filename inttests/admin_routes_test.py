@@ -1,6 +1,7 @@
 # Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
 
-import requests
+import requests, json
+from random import randint
 from base import TestBase
 
 class TestAdminRoutes(TestBase):
@@ -10,4 +11,38 @@ class TestAdminRoutes(TestBase):
         self.url = f"{self.base}/admin"
 
     def teardown_method(self, test_method):
-        super(TestAdminRoutes, self).teardown_method(test_method)
\ No newline at end of file
+        super(TestAdminRoutes, self).teardown_method(test_method)
+
+    def test_delete_user_deletes_their_characters_and_messages(self, create_channel_normal_user):
+        owner_cookies = self.get_authn_cookies_user_normal()
+        admin_cookies = self.get_authn_cookies_user_admin()
+
+        # Logging in for the first time creates the User
+        email = f"deleteme{randint(0, 1000000)}@fake.com"
+        cookies = self.get_authn_cookies(email)
+
+        r = requests.get(f"{self.url}/users?sort=createdOn&order=desc", headers=self.read_headers, cookies=admin_cookies)
+        assert 200 == r.status_code
+        user_id = next(u['ID'] for u in r.json() if u['Email'] == email)
+
+        # The Channel owner invites the User who then posts a Message
+        channel_id = create_channel_normal_user['ID']
+        data = json.dumps({"UserID": user_id})
+        r = requests.post(f"{self.base}/channels/{channel_id}/characters", data=data, headers=self.read_write_headers, cookies=owner_cookies)
+        assert 200 == r.status_code
+        character_id = r.json()['ID']
+
+        data = json.dumps({"CharacterID": character_id, "Content": "about to be deleted", "IsStory": True})
+        r = requests.post(f"{self.base}/channels/{channel_id}/messages", data=data, headers=self.read_write_headers, cookies=cookies)
+        assert 201 == r.status_code
+        message_id = r.json()['ID']
+
+        r = requests.delete(f"{self.url}/users/{user_id}", cookies=admin_cookies)
+        assert 204 == r.status_code
+
+        r = requests.get(f"{self.url}/users/{user_id}", headers=self.read_headers, cookies=admin_cookies)
+        assert 404 == r.status_code
+        r = requests.get(f"{self.url}/characters/{character_id}", headers=self.read_headers, cookies=admin_cookies)
+        assert 404 == r.status_code
+        r = requests.get(f"{self.url}/messages/{message_id}", headers=self.read_headers, cookies=admin_cookies)
+        assert 404 == r.status_code
@@ -81,7 +81,6 @@ class TestBase:
         cookies = self.get_authn_cookies_user_normal()
 
         data = json.dumps({
-            "OwnerID": 4,
             "DMID": 4,
             "Name": "test channel " + str(randint(0, 1000000)),
             "IsPrivate": False
@@ -1,6 +1,6 @@
 # Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
 
-import requests, json
+import requests, json, time
 from base import TestBase
 
 # TODO: Created, Delete
@@ -28,7 +28,8 @@ class TestChannelRoutes(TestBase):
         channel_id = create_channel_normal_user['ID']
         url = self.url + f'/{channel_id}'
 
-        data = create_channel_normal_user
+        # Only the fields that can be set are sent back
+        data = {k: create_channel_normal_user[k] for k in ('Name', 'Description', 'IsPrivate', 'DMID')}
         data['Topic'] = 'Updated topic'
         data = json.dumps(data)
 
@@ -36,6 +37,45 @@ class TestChannelRoutes(TestBase):
         assert 200 == r.status_code
         assert 'Updated topic' == r.json()['Topic']
 
+    def test_create_channel_with_taken_name(self,
+                                            create_channel_normal_user):
+        cookies = self.get_authn_cookies_user_normal()
+
+        data = json.dumps({
+            "DMID": 4,
+            "Name": create_channel_normal_user['Name'],
+            "IsPrivate": False
+        })
+
+        # Channel names are unique so the duplicate is a validation failure
+        r = requests.post(self.url, data=data, headers=self.read_write_headers, cookies=cookies)
+        assert 400 == r.status_code
+        assert 'Name' == r.json()['Fields'][0]['Field']
+
+    def test_create_channel_with_deleted_channels_name(self,
+                                                       create_channel_normal_user):
+        cookies = self.get_authn_cookies_user_normal()
+
+        channel_id = create_channel_normal_user['ID']
+        r = requests.delete(self.url + f'/{channel_id}', cookies=cookies)
+        assert 204 == r.status_code
+
+        data = json.dumps({
+            "DMID": 4,
+            "Name": create_channel_normal_user['Name'],
+            "IsPrivate": False
+        })
+
+        # Deleted Channels don't hold on to their names
+        r = requests.post(self.url, data=data, headers=self.read_write_headers, cookies=cookies)
+        assert 201 == r.status_code
+        assert channel_id != r.json()['ID']
+
+        # So the deleted Channel can't come back with the same name
+        r = requests.post(self.url + f'/{channel_id}/restore', headers=self.read_headers, cookies=cookies)
+        assert 400 == r.status_code
+        assert 'Name' == r.json()['Fields'][0]['Field']
+
     def test_get_channel(self,
                          create_channel_normal_user):
         cookies = self.get_authn_cookies_user_normal()
@@ -46,3 +86,75 @@ class TestChannelRoutes(TestBase):
         r = requests.get(url, cookies=cookies)
         assert 200 == r.status_code
         assert channel_id == r.json()['ID']
+
+    def test_restore_channel_within_window(self,
+                                           create_channel_normal_user):
+        cookies = self.get_authn_cookies_user_normal()
+
+        channel_id = create_channel_normal_user['ID']
+        url = self.url + f'/{channel_id}'
+
+        r = requests.delete(url, cookies=cookies)
+        assert 204 == r.status_code
+        r = requests.get(url, cookies=cookies)
+        assert 404 == r.status_code
+
+        r = requests.post(url + '/restore', headers=self.read_headers, cookies=cookies)
+        assert 200 == r.status_code
+        assert channel_id == r.json()['ID']
+
+        r = requests.get(url, cookies=cookies)
+        assert 200 == r.status_code
+
+    def test_restore_channel_after_window(self,
+                                          create_channel_normal_user):
+        cookies = self.get_authn_cookies_user_normal()
+
+        channel_id = create_channel_normal_user['ID']
+        url = self.url + f'/{channel_id}'
+
+        r = requests.delete(url, cookies=cookies)
+        assert 204 == r.status_code
+
+        # The integration config keeps the restore window short so it can pass here
+        time.sleep(4)
+
+        r = requests.post(url + '/restore', headers=self.read_headers, cookies=cookies)
+        assert 410 == r.status_code
+        r = requests.get(url, cookies=cookies)
+        assert 404 == r.status_code
+
+    def test_delete_channel_deletes_its_characters_and_messages(self,
+                                                                create_channel_normal_user):
+        cookies = self.get_authn_cookies_user_normal()
+        admin_cookies = self.get_authn_cookies_user_admin()
+
+        channel_id = create_channel_normal_user['ID']
+        url = self.url + f'/{channel_id}'
+
+        data = json.dumps({"UserID": 4})
+        r = requests.post(url + '/characters', data=data, headers=self.read_write_headers, cookies=cookies)
+        assert 200 == r.status_code
+        character_id = r.json()['ID']
+
+        data = json.dumps({"CharacterID": character_id, "Content": "deleted with the channel", "IsStory": True})
+        r = requests.post(url + '/messages', data=data, headers=self.read_write_headers, cookies=cookies)
+        assert 201 == r.status_code
+        message_id = r.json()['ID']
+
+        r = requests.delete(url, cookies=cookies)
+        assert 204 == r.status_code
+
+        r = requests.get(f"{self.base}/admin/characters/{character_id}", headers=self.read_headers, cookies=admin_cookies)
+        assert 404 == r.status_code
+        r = requests.get(f"{self.base}/admin/messages/{message_id}", headers=self.read_headers, cookies=admin_cookies)
+        assert 404 == r.status_code
+
+        # Restoring the Channel brings back everything that was deleted with it
+        r = requests.post(url + '/restore', headers=self.read_headers, cookies=cookies)
+        assert 200 == r.status_code
+
+        r = requests.get(url + f'/characters/{character_id}', headers=self.read_headers, cookies=cookies)
+        assert 200 == r.status_code
+        r = requests.get(url + f'/messages/{message_id}', headers=self.read_headers, cookies=cookies)
+        assert 200 == r.status_code
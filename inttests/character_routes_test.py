@@ -1,6 +1,7 @@
 # Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
 
 import requests, pytest, json
+from random import randint
 from base import TestBase
 
 # TODO: Update, Delete, Create, Get
@@ -25,9 +26,7 @@ class TestCharacterRoutes(TestBase):
         channel_id = create_channel_normal_user['ID']
 
         sample_data = {
-            "ChannelID": channel_id,
-            "UserID": 4,
-            "Name": "Character 1"
+            "UserID": 4
         }
 
         # Create a character and expect OK
@@ -37,7 +36,79 @@ class TestCharacterRoutes(TestBase):
         assert 200 == r.status_code
 
         # This time expect an error related to already having a character
-        sample_data["Name"] = "Character 2"
-        data = json.dumps(sample_data)
         r = requests.post(url, data=data, headers=self.read_write_headers, cookies=cookies)
-        assert 500 == r.status_code # TODO: Pass a 400 back for this scenario
+        assert 400 == r.status_code
+        assert 'UserID' == r.json()['Fields'][0]['Field']
+
+    def test_delete_character_deletes_their_messages(self,
+                                                     create_channel_normal_user):
+        cookies = self.get_authn_cookies_user_normal()
+
+        channel_id = create_channel_normal_user['ID']
+        url = self.url % channel_id
+
+        data = json.dumps({"UserID": 4})
+        r = requests.post(url, data=data, headers=self.read_write_headers, cookies=cookies)
+        assert 200 == r.status_code
+        character_id = r.json()['ID']
+
+        messages_url = f"{self.base}/channels/{channel_id}/messages"
+        data = json.dumps({"CharacterID": character_id, "Content": "deleted with the character", "IsStory": True})
+        r = requests.post(messages_url, data=data, headers=self.read_write_headers, cookies=cookies)
+        assert 201 == r.status_code
+        message_id = r.json()['ID']
+
+        r = requests.delete(f"{url}{character_id}", cookies=cookies)
+        assert 204 == r.status_code
+
+        r = requests.get(f"{url}{character_id}", headers=self.read_headers, cookies=cookies)
+        assert 404 == r.status_code
+        r = requests.get(f"{messages_url}/{message_id}", headers=self.read_headers, cookies=cookies)
+        assert 404 == r.status_code
+
+        # Restoring the Character brings back the Messages that were deleted with it
+        r = requests.post(f"{url}{character_id}/restore", headers=self.read_headers, cookies=cookies)
+        assert 200 == r.status_code
+
+        r = requests.get(f"{messages_url}/{message_id}", headers=self.read_headers, cookies=cookies)
+        assert 200 == r.status_code
+
+    def test_delete_character_from_another_channel(self,
+                                                   create_channel_normal_user):
+        owner_cookies = self.get_authn_cookies_user_normal()
+        admin_cookies = self.get_authn_cookies_user_admin()
+
+        # Logging in for the first time creates the User
+        email = f"otherowner{randint(0, 1000000)}@fake.com"
+        other_cookies = self.get_authn_cookies(email)
+
+        r = requests.get(f"{self.base}/admin/users?sort=createdOn&order=desc", headers=self.read_headers, cookies=admin_cookies)
+        assert 200 == r.status_code
+        other_id = next(u['ID'] for u in r.json() if u['Email'] == email)
+
+        # The other User owns a Channel with their own Character in it
+        data = json.dumps({
+            "DMID": other_id,
+            "Name": "other channel " + str(randint(0, 1000000)),
+            "IsPrivate": False
+        })
+        r = requests.post(f"{self.base}/channels", data=data, headers=self.read_write_headers, cookies=other_cookies)
+        assert 201 == r.status_code
+        other_channel_id = r.json()['ID']
+
+        data = json.dumps({"UserID": other_id})
+        r = requests.post(self.url % other_channel_id, data=data, headers=self.read_write_headers, cookies=other_cookies)
+        assert 200 == r.status_code
+        character_id = r.json()['ID']
+
+        # Owning a different Channel doesn't allow deleting the Character through it
+        channel_id = create_channel_normal_user['ID']
+        r = requests.delete(f"{self.url % channel_id}{character_id}", cookies=owner_cookies)
+        assert 404 == r.status_code
+
+        r = requests.get(f"{self.url % other_channel_id}{character_id}", headers=self.read_headers, cookies=other_cookies)
+        assert 200 == r.status_code
+
+        r = requests.get(f"{self.base}/admin/audit?action=character.deleted&targetType=character&targetID={character_id}", headers=self.read_headers, cookies=admin_cookies)
+        assert 200 == r.status_code
+        assert [] == r.json()
@@ -25,9 +25,6 @@ class TestUserRoutes(TestBase):
 
         data = json.dumps({
             'Username': 'regularuser',
-            'Email': 'regularuser@fake.com',
-            'IsAdmin': False,
-            'IsBanned': False,
             'Bio': 'updated bio'
         })
 
@@ -0,0 +1,67 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package logging sets up logrus and carries a logger scoped to the current request
+// in a context.Context. The request logger already has fields like the request id
+// and User id on it so every line logged while handling a request can be found
+// together.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+	log "github.com/sirupsen/logrus"
+)
+
+// The log formats that can be configured.
+const (
+	JSONFormat = "json"
+	TextFormat = "text"
+)
+
+type loggerKey struct{}
+
+// Configure sets the level and format of the standard logger from the logging config.
+// JSON is the default format so the logs can be searched.
+func Configure(c configs.LoggingConfiguration) error {
+	if c.Level != "" {
+		level, err := log.ParseLevel(c.Level)
+		if err != nil {
+			return err
+		}
+		log.SetLevel(level)
+	}
+
+	switch c.Format {
+	case "", JSONFormat:
+		log.SetFormatter(&log.JSONFormatter{})
+	case TextFormat:
+		log.SetFormatter(&log.TextFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %s", c.Format)
+	}
+
+	log.SetOutput(os.Stdout)
+	return nil
+}
+
+// WithLogger returns a context carrying the logger.
+func WithLogger(ctx context.Context, logger *log.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext gets the logger from the context. Contexts without one get the
+// standard logger so it's always safe to use.
+func FromContext(ctx context.Context) *log.Entry {
+	if logger, ok := ctx.Value(loggerKey{}).(*log.Entry); ok {
+		return logger
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+// AddFields adds the fields to the logger in the context.
+func AddFields(ctx context.Context, fields log.Fields) context.Context {
+	return WithLogger(ctx, FromContext(ctx).WithFields(fields))
+}
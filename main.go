@@ -3,27 +3,180 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/andrew-boutin/dndtextapi/announcements"
 	"github.com/andrew-boutin/dndtextapi/backends"
+	"github.com/andrew-boutin/dndtextapi/bridges/discord"
+	"github.com/andrew-boutin/dndtextapi/bridges/slack"
 	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/logging"
+	"github.com/andrew-boutin/dndtextapi/metrics"
 	"github.com/andrew-boutin/dndtextapi/middleware"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
+	"github.com/andrew-boutin/dndtextapi/retention"
+	"github.com/andrew-boutin/dndtextapi/tracing"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 func main() {
-	// Read in config
-	configuration := configs.LoadConfig()
+	// Exiting skips deferred calls so it waits until run has cleaned up
+	if err := run(); err != nil {
+		log.WithError(err).Error("Server stopped unexpectedly.")
+		os.Exit(1)
+	}
+}
+
+// run starts everything up and serves until the server fails or is told to shut
+// down. Whatever was started is stopped again before it returns.
+func run() error {
+	// Read in config from the config file, environment, and flags
+	configuration, err := configs.LoadConfig(os.Args[1:])
+	if err == configs.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load configuration.")
+	}
+
+	// Logs are JSON by default so they can be searched by request id
+	if err := logging.Configure(configuration.Logging); err != nil {
+		log.WithError(err).Fatal("Failed to configure logging.")
+	}
 
 	// Initialize backend
 	backend, err := backends.InitBackend(configuration.Backend)
 	if err != nil {
-		panic(err)
+		log.WithError(err).Fatal("Failed to initialize the backend.")
+	}
+	// Deferred first so the pool is closed after everything else has stopped
+	defer func() {
+		if err := backend.Close(); err != nil {
+			log.WithError(err).Error("Failed to close the backend.")
+		}
+	}()
+
+	// Wrap the backend so every call is timed when metrics are enabled
+	var registry *metrics.Registry
+	if configuration.Metrics.Enabled {
+		backend = backends.WithMetrics(backend)
+		registry = metrics.Default
+		if configuration.Metrics.SessionWindow > 0 {
+			metrics.ActiveSessions.SetWindow(configuration.Metrics.SessionWindow)
+		}
+	}
+
+	// Wrap the backend so every call gets a span when tracing is enabled
+	var tracer *tracing.Tracer
+	if configuration.Tracing.Enabled {
+		exporter, err := tracing.MakeExporter(configuration.Tracing)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create the trace exporter.")
+		}
+		tracer = tracing.NewTracer(configuration.Tracing.ServiceName, exporter, configuration.Tracing.FlushInterval)
+		tracer.Start()
+		defer tracer.Stop()
+		backend = backends.WithTracing(backend, tracer)
 	}
 
 	// Initalize authentication data
 	middleware.InitAuthentication(configuration.Authentication)
 
-	// Set up server
-	r := gin.Default()
-	middleware.RegisterMiddleware(r, backend)
-	r.Run(":8080")
+	// Initialize notifications - a nil notifier discards everything
+	var notifier *notifications.Notifier
+	if configuration.Notifications.Enabled {
+		sender := notifications.MakeSMTPSender(configuration.Notifications)
+		notifier = notifications.NewNotifier(sender, backend, configuration.Notifications.DigestInterval)
+		notifier.Start()
+		defer notifier.Stop()
+	}
+
+	// Initialize outgoing webhooks
+	dispatcher := webhooks.NewDispatcher(backend, configuration.Webhooks)
+
+	// Messages from the chat bridges get the same notifications and events as ones
+	// created through the API
+	announcer := announcements.NewAnnouncer(backend, notifier, dispatcher)
+
+	// Initialize the Slack bridge which relays new Messages back to Slack
+	var slackBridge *slack.Bridge
+	if configuration.Slack.Enabled {
+		slackBridge = slack.NewBridge(backend, announcer, configuration.Slack)
+		dispatcher.AddListener(slackBridge.Relay)
+	}
+
+	// Initialize the Discord bridge which polls linked Discord channels and relays
+	// new Messages back to Discord
+	if configuration.Discord.Enabled {
+		discordBridge := discord.NewBridge(backend, announcer, configuration.Discord)
+		dispatcher.AddListener(discordBridge.Relay)
+		discordBridge.Start()
+		defer discordBridge.Stop()
+	}
+
+	// Stopped along with the server below so deliveries get the shutdown timeout too
+	dispatcher.Start()
+
+	// Deleted Channels, Characters, and Messages are purged once they can't be
+	// restored anymore
+	purger := retention.NewPurger(backend, configuration.Retention)
+	purger.Start()
+	defer purger.Stop()
+
+	// Rate limits are kept in memory so they're per server
+	var limiter *ratelimit.Limiter
+	if configuration.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), configuration.RateLimit)
+	}
+
+	// Set up server - the access log and error handler middleware take the place
+	// of the default logger and recovery
+	r := gin.New()
+	middleware.RegisterMiddleware(r, backend, notifier, dispatcher, slackBridge, limiter, registry, tracer, configuration.Server, configuration.Retention)
+
+	srv := &http.Server{
+		Addr:         configuration.Server.Address,
+		Handler:      r,
+		ReadTimeout:  configuration.Server.ReadTimeout,
+		WriteTimeout: configuration.Server.WriteTimeout,
+		IdleTimeout:  configuration.Server.IdleTimeout,
+	}
+
+	serverErrs := make(chan error, 1)
+	go func() {
+		log.WithField("address", srv.Addr).Info("Server listening.")
+		serverErrs <- srv.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	// Everything is still shut down if the server fails so the workers and pool
+	// are cleaned up
+	var serverErr error
+	select {
+	case serverErr = <-serverErrs:
+	case sig := <-signals:
+		log.WithField("signal", sig.String()).Info("Shutting down.")
+	}
+
+	// Fail readiness checks first so no new traffic is sent while draining
+	middleware.MarkShuttingDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), configuration.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.WithError(err).Error("In-flight requests didn't finish before the shutdown timeout.")
+	}
+
+	// Webhook deliveries only get whatever is left of the shutdown timeout
+	dispatcher.Stop(ctx)
+	return serverErr
 }
@@ -12,14 +12,25 @@ var ErrMessageNotFound = fmt.Errorf("message not found")
 
 // Message contains message data
 type Message struct {
-	ID          int       `json:"ID" db:"id"`
-	Content     string    `json:"Content" db:"content"`
-	CharacterID int       `json:"CharacterID" db:"character_id"`
-	ChannelID   int       `json:"ChannelID" db:"channel_id"`
-	IsStory     bool      `json:"IsStory" db:"is_story"`
-	CreatedOn   time.Time `json:"CreatedOn" db:"created_on"`
-	LastUpdated time.Time `json:"LastUpdated" db:"last_updated"`
+	ID          int        `json:"ID" db:"id"`
+	Content     string     `json:"Content" db:"content"`
+	CharacterID int        `json:"CharacterID" db:"character_id"`
+	ChannelID   int        `json:"ChannelID" db:"channel_id"`
+	IsStory     bool       `json:"IsStory" db:"is_story"`
+	CreatedOn   time.Time  `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time  `json:"LastUpdated" db:"last_updated"`
+	DeletedOn   *time.Time `json:"DeletedOn,omitempty" db:"deleted_on"`
 }
 
 // MessageCollection is a collection of messages
 type MessageCollection []*Message
+
+// Page limits which Messages are retrieved so large Channels can be read
+// in chunks.
+type Page struct {
+	// AfterID only includes Messages with a larger ID
+	AfterID int
+
+	// Limit is the most Messages to include - 0 means no limit
+	Limit int
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Default is the Registry holding the application metrics.
+var Default = NewRegistry()
+
+// The application metrics.
+var (
+	// HTTPRequests counts requests by method, route template, and status code
+	HTTPRequests = Default.NewCounter("dndtext_http_requests_total", "HTTP requests handled.", "method", "route", "status")
+
+	// HTTPRequestDuration is how long requests take by method and route template
+	HTTPRequestDuration = Default.NewHistogram("dndtext_http_request_duration_seconds", "How long HTTP requests take in seconds.", DefaultBuckets, "method", "route")
+
+	// BackendCallDuration is how long each Backend method takes
+	BackendCallDuration = Default.NewHistogram("dndtext_backend_call_duration_seconds", "How long Backend calls take in seconds.", DefaultBuckets, "method")
+
+	// BackendErrors counts unexpected errors from each Backend method. Expected errors
+	// such as something not being found aren't counted.
+	BackendErrors = Default.NewCounter("dndtext_backend_errors_total", "Unexpected errors returned by Backend calls.", "method")
+
+	// MessagesCreated counts new Messages from every source
+	MessagesCreated = Default.NewCounter("dndtext_messages_created_total", "Messages created.")
+
+	// ChannelsCreated counts new Channels
+	ChannelsCreated = Default.NewCounter("dndtext_channels_created_total", "Channels created.")
+
+	// Logins counts Users logging in through Google
+	Logins = Default.NewCounter("dndtext_logins_total", "Successful logins.")
+
+	// ActiveSessions tracks the Users making requests with a session
+	ActiveSessions = NewSessionTracker(15 * time.Minute)
+)
+
+func init() {
+	Default.NewGaugeFunc("dndtext_active_sessions", "Users who made a request with a session recently.", func() float64 {
+		return float64(ActiveSessions.Count())
+	})
+}
+
+// SessionTracker counts the Users seen within a window of time. Sessions live in
+// cookies so the server can't list them. Users who recently made a request with
+// their session are as close as it gets.
+type SessionTracker struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[int]time.Time
+	now    func() time.Time
+}
+
+// NewSessionTracker creates a SessionTracker that counts Users seen within the window.
+func NewSessionTracker(window time.Duration) *SessionTracker {
+	return &SessionTracker{
+		window: window,
+		seen:   make(map[int]time.Time),
+		now:    time.Now,
+	}
+}
+
+// SetWindow changes how recently a User has to have been seen to be counted.
+func (s *SessionTracker) SetWindow(window time.Duration) {
+	s.mu.Lock()
+	s.window = window
+	s.mu.Unlock()
+}
+
+// Seen records that the User made a request with their session.
+func (s *SessionTracker) Seen(userID int) {
+	s.mu.Lock()
+	s.seen[userID] = s.now()
+	s.mu.Unlock()
+}
+
+// Count is the number of Users seen within the window. Users seen before the window
+// are forgotten.
+func (s *SessionTracker) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-s.window)
+	for userID, last := range s.seen {
+		if last.Before(cutoff) {
+			delete(s.seen, userID)
+		}
+	}
+	return len(s.seen)
+}
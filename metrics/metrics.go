@@ -0,0 +1,309 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package metrics keeps counters, gauges, and histograms and exposes them in the
+// Prometheus text format so they can be scraped from /metrics.
+//
+// Every metric has a fixed set of label names. Values are recorded by passing the
+// label values in the same order.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the histogram buckets in seconds used for latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// labelEscaper escapes label values the way the text format expects.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metric is anything that can be written out by a Registry.
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds metrics and writes them out.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.metrics {
+		if existing.name() == m.name() {
+			panic(fmt.Sprintf("metric %s registered twice", m.name()))
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all of the metrics in the Prometheus text format sorted by name.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	all := make([]metric, len(r.metrics))
+	copy(all, r.metrics)
+	r.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool { return all[i].name() < all[j].name() })
+	for _, m := range all {
+		m.write(w)
+	}
+}
+
+// ServeHTTP serves the metrics for scraping.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	r.Write(w)
+}
+
+// desc is what every metric has in common.
+type desc struct {
+	metricName string
+	help       string
+	labelNames []string
+}
+
+func (d desc) name() string {
+	return d.metricName
+}
+
+func (d desc) writeHeader(w io.Writer, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, strings.Replace(d.help, "\n", " ", -1))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, metricType)
+}
+
+// key joins the label values so they can be used as a map key.
+func (d desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values but got %d", d.metricName, len(d.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// labels formats the label pairs for the label values in key along with any extra pairs.
+func (d desc) labels(key string, extra ...string) string {
+	pairs := make([]string, 0, len(d.labelNames)+len(extra)/2)
+	if len(d.labelNames) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, d.labelNames[i]+`="`+labelEscaper.Replace(value)+`"`)
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+labelEscaper.Replace(extra[i+1])+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// values is a set of float values by label values.
+type values struct {
+	desc
+	mu   sync.Mutex
+	vals map[string]float64
+}
+
+func (v *values) add(delta float64, labelValues []string) {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	v.vals[key] += delta
+	v.mu.Unlock()
+}
+
+func (v *values) set(val float64, labelValues []string) {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	v.vals[key] = val
+	v.mu.Unlock()
+}
+
+func (v *values) writeValues(w io.Writer, metricType string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.writeHeader(w, metricType)
+	for _, key := range sortedKeys(v.vals) {
+		fmt.Fprintf(w, "%s%s %s\n", v.metricName, v.labels(key), formatFloat(v.vals[key]))
+	}
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	values
+}
+
+// NewCounter creates and registers a Counter.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{values{desc: desc{name, help, labelNames}, vals: make(map[string]float64)}}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the Counter.
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+// Add adds the amount to the Counter. The amount can't be negative.
+func (c *Counter) Add(amount float64, labelValues ...string) {
+	if amount < 0 {
+		panic(fmt.Sprintf("counter %s can't go down", c.metricName))
+	}
+	c.add(amount, labelValues)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.writeValues(w, "counter")
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	values
+}
+
+// NewGauge creates and registers a Gauge.
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{values{desc: desc{name, help, labelNames}, vals: make(map[string]float64)}}
+	r.register(g)
+	return g
+}
+
+// Set sets the Gauge to the value.
+func (g *Gauge) Set(val float64, labelValues ...string) {
+	g.set(val, labelValues)
+}
+
+// Add adds the amount, which can be negative, to the Gauge.
+func (g *Gauge) Add(amount float64, labelValues ...string) {
+	g.add(amount, labelValues)
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.writeValues(w, "gauge")
+}
+
+// GaugeFunc is a Gauge without labels whose value is looked up when it's written.
+type GaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc creates and registers a GaugeFunc.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{metricName: name, help: help}, fn: fn}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.writeHeader(w, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(g.fn()))
+}
+
+// Histogram counts observations in buckets along with their sum and count.
+type Histogram struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	counts map[string][]uint64
+	sums   map[string]float64
+}
+
+// NewHistogram creates and registers a Histogram. The buckets are the upper bounds
+// in increasing order.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name, help, labelNames},
+		buckets: buckets,
+		counts:  make(map[string][]uint64),
+		sums:    make(map[string]float64),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe records the value.
+func (h *Histogram) Observe(val float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts, ok := h.counts[key]
+	if !ok {
+		// The last count is the +Inf bucket
+		counts = make([]uint64, len(h.buckets)+1)
+		h.counts[key] = counts
+	}
+	for i, upper := range h.buckets {
+		if val <= upper {
+			counts[i]++
+		}
+	}
+	counts[len(h.buckets)]++
+	h.sums[key] += val
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w, "histogram")
+
+	keys := make([]string, 0, len(h.counts))
+	for key := range h.counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		counts := h.counts[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(key, "le", formatFloat(upper)), counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(key, "le", "+Inf"), counts[len(h.buckets)])
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labels(key), formatFloat(h.sums[key]))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labels(key), counts[len(h.buckets)])
+	}
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests handled.", "route")
+	sessions := r.NewGauge("sessions", "Sessions.")
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{.1, 1}, "route")
+
+	requests.Inc("/b")
+	requests.Add(2, `/a"`)
+	sessions.Set(3)
+	latency.Observe(.05, "/a")
+	latency.Observe(.5, "/a")
+	latency.Observe(5, "/a")
+
+	buf := &bytes.Buffer{}
+	r.Write(buf)
+
+	expected := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 5.55
+latency_seconds_count{route="/a"} 3
+# HELP requests_total Requests handled.
+# TYPE requests_total counter
+requests_total{route="/a\""} 2
+requests_total{route="/b"} 1
+# HELP sessions Sessions.
+# TYPE sessions gauge
+sessions 3
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLabelValuesMustMatch(t *testing.T) {
+	c := NewRegistry().NewCounter("requests_total", "Requests handled.", "route", "status")
+	assert.Panics(t, func() { c.Inc("/a") })
+}
+
+func TestSessionTracker(t *testing.T) {
+	now := time.Now()
+	s := NewSessionTracker(time.Minute)
+	s.now = func() time.Time { return now }
+
+	s.Seen(1)
+	s.Seen(2)
+	s.Seen(1)
+	assert.Equal(t, 2, s.Count())
+
+	now = now.Add(2 * time.Minute)
+	s.Seen(3)
+	assert.Equal(t, 1, s.Count())
+}
@@ -1,61 +1,113 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
 	"github.com/andrew-boutin/dndtextapi/channels"
 	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
 	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/requests"
 
 	"github.com/andrew-boutin/dndtextapi/users"
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
-// RegisterAdminRoutes adds the admin routes.
+const reportKey = "reportKey"
+
+// RegisterAdminRoutes adds the admin routes to the group, which is expected to be
+// mounted at /admin behind RequireAdminHandler.
 func RegisterAdminRoutes(g *gin.RouterGroup) {
 	// Routes to admin channels
-	g.GET("/admin/channels", ValidateHeaders(acceptHeader), AdminGetChannels)
-	g.GET("/admin/channels/:channelID", ValidateHeaders(acceptHeader), AdminGetChannel)
-	g.PUT("/admin/channels/:channelID", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateChannel)
-	g.DELETE("/admin/channels/:channelID", AdminDeleteChannel)
+	g.GET("/channels", ValidateHeaders(acceptHeader), AdminGetChannels)
+	g.GET("/channels/:channelID", ValidateHeaders(acceptHeader), AdminGetChannel)
+	g.PUT("/channels/:channelID", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateChannel)
+	g.DELETE("/channels/:channelID", AdminDeleteChannel)
 
 	// Routes to admin messages
-	g.GET("/admin/channels/:channelID/messages", ValidateHeaders(acceptHeader), LoadChannelFromPathID, AdminGetMessages)
-	g.GET("/admin/messages/:id", ValidateHeaders(acceptHeader), AdminGetMessage)
-	g.PUT("/admin/messages/:id", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateMessage)
-	g.DELETE("/admin/messages/:id", AdminDeleteMessage)
+	g.GET("/channels/:channelID/messages", ValidateHeaders(acceptHeader), LoadChannelFromPathID, AdminGetMessages)
+	g.GET("/messages/:id", ValidateHeaders(acceptHeader), AdminGetMessage)
+	g.PUT("/messages/:id", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateMessage)
+	g.DELETE("/messages/:id", AdminDeleteMessage)
 
 	// Routes to admin users
-	g.GET("/admin/users", ValidateHeaders(acceptHeader), AdminGetUsers)
-	g.GET("/admin/users/:id", ValidateHeaders(acceptHeader), AdminGetUser)
-	g.PUT("/admin/users/:id", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateUser)
-	g.DELETE("/admin/users/:id", AdminDeleteUser)
+	g.GET("/users", ValidateHeaders(acceptHeader), AdminGetUsers)
+	g.GET("/users/:id", ValidateHeaders(acceptHeader), AdminGetUser)
+	g.PUT("/users/:id", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateUser)
+	g.DELETE("/users/:id", AdminDeleteUser)
+	g.GET("/users/:id/bans", ValidateHeaders(acceptHeader), AdminGetUserBans)
+	g.POST("/users/:id/bans", ValidateHeaders(acceptHeader, contentTypeHeader), AdminBanUser)
+	g.POST("/bans/:id/lift", ValidateHeaders(acceptHeader), AdminLiftBan)
+	g.POST("/users/:id/impersonate", ValidateHeaders(acceptHeader, contentTypeHeader), AdminStartImpersonation)
 
 	// Routes to admin Characters
-	g.GET("/admin/channels/:channelID/characters", ValidateHeaders(acceptHeader), LoadChannelFromPathID, AdminGetCharacters)
-	g.GET("/admin/characters/:id", ValidateHeaders(acceptHeader), AdminGetCharacter)
-	g.PUT("/admin/characters/:id", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateCharacter)
-	g.DELETE("/admin/characters/:id", AdminDeleteCharacter)
+	g.GET("/channels/:channelID/characters", ValidateHeaders(acceptHeader), LoadChannelFromPathID, AdminGetCharacters)
+	g.GET("/characters/:id", ValidateHeaders(acceptHeader), AdminGetCharacter)
+	g.PUT("/characters/:id", ValidateHeaders(acceptHeader, contentTypeHeader), AdminUpdateCharacter)
+	g.DELETE("/characters/:id", AdminDeleteCharacter)
+
+	// Routes to admin deleted Channels, Characters, and Messages
+	g.GET("/deleted/channels", ValidateHeaders(acceptHeader), AdminGetDeletedChannels)
+	g.GET("/deleted/channels/:channelID/characters", ValidateHeaders(acceptHeader), AdminGetDeletedCharacters)
+	g.GET("/deleted/channels/:channelID/messages", ValidateHeaders(acceptHeader), AdminGetDeletedMessages)
+	g.POST("/channels/:channelID/restore", ValidateHeaders(acceptHeader), AdminRestoreChannel)
+	g.POST("/characters/:id/restore", ValidateHeaders(acceptHeader), AdminRestoreCharacter)
+	g.POST("/messages/:id/restore", ValidateHeaders(acceptHeader), AdminRestoreMessage)
+
+	// Routes to triage Reports
+	g.GET("/reports", ValidateHeaders(acceptHeader), AdminGetReports)
+	g.GET("/reports/:id", ValidateHeaders(acceptHeader), LoadReport, AdminGetReport)
+	g.POST("/reports/:id/assign", ValidateHeaders(acceptHeader, contentTypeHeader), LoadReport, RequireOpenReport, AdminAssignReport)
+	g.POST("/reports/:id/resolve", ValidateHeaders(acceptHeader, contentTypeHeader), LoadReport, RequireOpenReport, AdminResolveReport)
+	g.POST("/reports/:id/dismiss", ValidateHeaders(acceptHeader, contentTypeHeader), LoadReport, RequireOpenReport, AdminDismissReport)
+
+	// Routes to read the audit log and usage stats
+	g.GET("/audit", ValidateHeaders(acceptHeader), AdminGetAuditLog)
+	g.GET("/stats", ValidateHeaders(acceptHeader), AdminGetStats)
+
+	// Routes to do the same thing to many Users, Channels, or Messages at once
+	g.POST("/bulk/users/ban", ValidateHeaders(acceptHeader, contentTypeHeader), AdminBulkBanUsers)
+	g.POST("/bulk/users/delete", ValidateHeaders(acceptHeader, contentTypeHeader), AdminBulkDeleteUsers)
+	g.POST("/bulk/channels/delete", ValidateHeaders(acceptHeader, contentTypeHeader), AdminBulkDeleteChannels)
+	g.POST("/bulk/messages/delete", ValidateHeaders(acceptHeader, contentTypeHeader), AdminBulkDeleteMessages)
 }
 
 // RequireAdminHandler requires that the authenticated User be an admin or else
-// access is denied.
+// access is denied. Admins have to end any Impersonation first.
 func RequireAdminHandler(c *gin.Context) {
+	if GetImpersonator(c) != nil {
+		c.AbortWithError(http.StatusConflict, impersonations.ErrAlreadyImpersonating)
+		return
+	}
+
 	user := GetAuthenticatedUser(c)
 	if !user.IsAdmin {
-		log.Error("Non admin user attempted to access route that requires admin.")
+		GetLogger(c).Error("Non admin user attempted to access route that requires admin.")
 		c.AbortWithStatus(http.StatusForbidden)
 	}
 }
 
-// AdminGetChannels retrieves all of the Channels.
+// AdminGetChannels retrieves the Channels matching the optional `private` and
+// `owner` query parameters, ordered and paged by the optional `sort`, `order`,
+// `limit`, and `offset` query parameters.
 func AdminGetChannels(c *gin.Context) {
 	dbBackend := GetDBBackend(c)
 
-	allChannels, err := dbBackend.GetAllChannels(nil)
+	filter, err := channelFilterFromQuery(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	allChannels, err := dbBackend.GetChannels(c.Request.Context(), filter)
 	if err != nil {
-		log.WithError(err).Error("Failed to retrieve all channels.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve all channels.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -74,18 +126,18 @@ func AdminGetChannel(c *gin.Context) {
 		return
 	}
 
-	channel, err := dbBackend.GetChannel(channelID)
+	channel, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
 		if err == channels.ErrChannelNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to retrieve channel.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, channel)
+	respondWithETag(c, http.StatusOK, channel.LastUpdated, channel)
 }
 
 // AdminUpdateChannel updates the Channel matching the id
@@ -99,20 +151,42 @@ func AdminUpdateChannel(c *gin.Context) {
 		return
 	}
 
-	channel := &channels.Channel{}
-	err = c.Bind(channel)
+	req := &requests.Channel{}
+	err = requests.Decode(c.Request.Body, req)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	updatedChannel, err := dbBackend.UpdateChannel(channelID, channel)
+	existingChannel, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
+		if err == channels.ErrChannelNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up channel before update.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedChannel)
+	expected, ok := checkIfMatch(c, existingChannel.LastUpdated)
+	if !ok {
+		return
+	}
+
+	// Only the fields in the request change, the rest are kept
+	channel := *existingChannel
+	req.Apply(&channel)
+
+	updatedChannel, err := dbBackend.UpdateChannel(c.Request.Context(), channelID, &channel, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, audit.ChannelUpdated, audit.ChannelTarget, channelID, existingChannel, updatedChannel)
+
+	respondWithETag(c, http.StatusOK, updatedChannel.LastUpdated, updatedChannel)
 }
 
 // AdminDeleteChannel deletes the Channel matching the id
@@ -126,34 +200,45 @@ func AdminDeleteChannel(c *gin.Context) {
 		return
 	}
 
-	err = dbBackend.DeleteMessagesFromChannel(channelID)
+	// Look up the current version for the audit log and any precondition
+	existing, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete messages from channel.")
+		if err == channels.ErrChannelNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up channel before delete.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	err = dbBackend.DeleteCharactersFromChannel(channelID)
-	if err != nil {
-		log.WithError(err).Error("Failed to delete characters from channel.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+	expected, ok := checkIfMatch(c, existing.LastUpdated)
+	if !ok {
 		return
 	}
 
-	err = dbBackend.DeleteChannel(channelID)
+	err = deleteChannel(c, existing, expected)
 	if err != nil {
-		if err == channels.ErrChannelNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
-			return
-		}
-		log.WithError(err).Error("Failed to delete channel.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// deleteChannel deletes the Channel, along with its Characters and Messages, and
+// records it in the audit log. It's only deleted if it's still at the expected
+// version, if there is one.
+func deleteChannel(c *gin.Context, existing *channels.Channel, expected *time.Time) error {
+	err := GetDBBackend(c).DeleteChannel(c.Request.Context(), existing.ID, expected)
+	if err != nil {
+		return err
+	}
+
+	recordAudit(c, audit.ChannelDeleted, audit.ChannelTarget, existing.ID, existing, nil)
+	return nil
+}
+
 // AdminGetMessages retrieves all of the Messages
 // for the Channel matching the required query parameter
 // channelID.
@@ -161,9 +246,15 @@ func AdminGetMessages(c *gin.Context) {
 	dbBackend := GetDBBackend(c)
 	channel := c.MustGet(channelKey).(*channels.Channel)
 
-	allMessages, err := dbBackend.GetMessagesInChannel(channel.ID, nil)
+	page, err := PageFromQuery(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	allMessages, err := dbBackend.GetMessagesInChannel(c.Request.Context(), channel.ID, nil, page)
 	if err != nil {
-		log.WithError(err).Error("Failed to look up messages for channel.")
+		GetLogger(c).WithError(err).Error("Failed to look up messages for channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -182,18 +273,18 @@ func AdminGetMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := dbBackend.GetMessage(messageID)
+	message, err := dbBackend.GetMessage(c.Request.Context(), messageID)
 	if err != nil {
 		if err == messages.ErrMessageNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to retrieve message.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve message.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, message)
+	respondWithETag(c, http.StatusOK, message.LastUpdated, message)
 }
 
 // AdminUpdateMessage updates the Message matching the id
@@ -207,20 +298,39 @@ func AdminUpdateMessage(c *gin.Context) {
 		return
 	}
 
-	message := &messages.Message{}
-	err = c.Bind(message)
+	req := &requests.UpdateMessage{}
+	err = requests.Decode(c.Request.Body, req)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	updatedMessage, err := dbBackend.UpdateMessage(messageID, message)
+	// Look up the current version for the audit log and any precondition
+	existing, err := dbBackend.GetMessage(c.Request.Context(), messageID)
 	if err != nil {
+		if err == messages.ErrMessageNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up message before update.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedMessage)
+	expected, ok := checkIfMatch(c, existing.LastUpdated)
+	if !ok {
+		return
+	}
+
+	updatedMessage, err := dbBackend.UpdateMessage(c.Request.Context(), messageID, req.Message(), expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, audit.MessageUpdated, audit.MessageTarget, messageID, existing, updatedMessage)
+
+	respondWithETag(c, http.StatusOK, updatedMessage.LastUpdated, updatedMessage)
 }
 
 // AdminDeleteMessage deletes the Message matching the id
@@ -234,27 +344,59 @@ func AdminDeleteMessage(c *gin.Context) {
 		return
 	}
 
-	err = dbBackend.DeleteMessage(messageID)
+	// Look up the current version for the audit log and any precondition
+	existing, err := dbBackend.GetMessage(c.Request.Context(), messageID)
 	if err != nil {
 		if err == messages.ErrMessageNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to delete message.")
+		GetLogger(c).WithError(err).Error("Failed to look up message before delete.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
+	expected, ok := checkIfMatch(c, existing.LastUpdated)
+	if !ok {
+		return
+	}
+
+	err = deleteMessage(c, existing, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
 	c.Status(http.StatusNoContent)
 }
 
-// AdminGetUsers retrieves all of the Users.
+// deleteMessage deletes the Message and records it in the audit log. It's only
+// deleted if it's still at the expected version, if there is one.
+func deleteMessage(c *gin.Context, existing *messages.Message, expected *time.Time) error {
+	err := GetDBBackend(c).DeleteMessage(c.Request.Context(), existing.ID, expected)
+	if err != nil {
+		return err
+	}
+
+	recordAudit(c, audit.MessageDeleted, audit.MessageTarget, existing.ID, existing, nil)
+	return nil
+}
+
+// AdminGetUsers retrieves the Users matching the optional `admin`, `banned`,
+// `loggedInSince`, and `loggedInBefore` query parameters, ordered and paged by
+// the optional `sort`, `order`, `limit`, and `offset` query parameters.
 func AdminGetUsers(c *gin.Context) {
 	dbBackend := GetDBBackend(c)
 
-	allUsers, err := dbBackend.GetAllUsers()
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	allUsers, err := dbBackend.GetUsers(c.Request.Context(), filter)
 	if err != nil {
-		log.WithError(err).Error("Failed to retrieve all users.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve all users.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -273,20 +415,19 @@ func AdminGetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := dbBackend.GetUserByID(userID)
+	user, err := dbBackend.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
-		log.WithError(err).Error("Failed to retrieve user.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve user.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	respondWithETag(c, http.StatusOK, user.LastUpdated, user)
 }
 
 // AdminUpdateUser updates the User matching the id in the path
 // with the data from the request body.
 func AdminUpdateUser(c *gin.Context) {
-	// TODO: Make IsAdmin immutable everywhere in validation (db call doesn't update this so ok for now)
 	dbBackend := GetDBBackend(c)
 
 	userID, err := PathParamAsIntExtractor(c, idPathParam)
@@ -295,39 +436,46 @@ func AdminUpdateUser(c *gin.Context) {
 		return
 	}
 
-	user := &users.User{}
-	err = c.Bind(user)
+	req := &requests.UpdateUser{}
+	err = requests.Decode(c.Request.Body, req)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
 	// Look up the existing User so we can see if they're an admin or not
-	existingUser, err := dbBackend.GetUserByID(userID)
+	existingUser, err := dbBackend.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		if err == users.ErrUserNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to look up User before update.")
+		GetLogger(c).WithError(err).Error("Failed to look up User before update.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
 	// Prevent updates to other admins
 	if existingUser.IsAdmin {
-		log.WithError(err).Error("Admin attempted to update another admin user.")
-		c.AbortWithStatus(http.StatusForbidden)
+		GetLogger(c).Error("Admin attempted to update another admin user.")
+		c.AbortWithError(http.StatusForbidden, users.ErrUserIsAdmin)
+		return
+	}
+
+	expected, ok := checkIfMatch(c, existingUser.LastUpdated)
+	if !ok {
 		return
 	}
 
-	updatedUser, err := dbBackend.UpdateUser(userID, user)
+	updatedUser, err := dbBackend.UpdateUser(c.Request.Context(), userID, req.User(), expected)
 	if err != nil {
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedUser)
+	recordAudit(c, audit.UserUpdated, audit.UserTarget, userID, existingUser, updatedUser)
+
+	respondWithETag(c, http.StatusOK, updatedUser.LastUpdated, updatedUser)
 }
 
 // AdminDeleteUser deletes the User matching the id in the path.
@@ -341,50 +489,149 @@ func AdminDeleteUser(c *gin.Context) {
 	}
 
 	// Look up the existing User so we can make sure they're not an admin
-	existingUser, err := dbBackend.GetUserByID(userID)
+	existingUser, err := dbBackend.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		if err == users.ErrUserNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to retrieve user.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve user.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
+	expected, ok := checkIfMatch(c, existingUser.LastUpdated)
+	if !ok {
+		return
+	}
+
+	err = deleteUser(c, existingUser, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// deleteUser deletes the User along with their Messages and Characters and
+// records it in the audit log. Admins can't be deleted. The User is only deleted
+// if they're still at the expected version, if there is one.
+func deleteUser(c *gin.Context, existingUser *users.User, expected *time.Time) error {
+	dbBackend := GetDBBackend(c)
+
 	// Prevent deletion of another admin
 	if existingUser.IsAdmin {
-		log.WithError(err).Error("Admin attempted to delete another admin.")
-		c.AbortWithStatus(http.StatusForbidden)
+		GetLogger(c).Error("Admin attempted to delete another admin.")
+		return users.ErrUserIsAdmin
+	}
+
+	err := dbBackend.DeleteUser(c.Request.Context(), existingUser.ID, expected)
+	if err != nil {
+		return err
+	}
+
+	recordAudit(c, audit.UserDeleted, audit.UserTarget, existingUser.ID, existingUser, nil)
+	return nil
+}
+
+// AdminGetUserBans retrieves the history of Bans for the User matching the id in
+// the path, both site wide and from Channels.
+func AdminGetUserBans(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	userID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	err = dbBackend.DeleteMessagesFromUser(userID)
+	userBans, err := dbBackend.GetBansForUser(c.Request.Context(), userID)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete messages from user.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve bans for user.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	err = dbBackend.DeleteCharactersFromUser(userID)
+	c.JSON(http.StatusOK, userBans)
+}
+
+// AdminBanUser bans the User matching the id in the path from the whole site
+// using the reason and times from the request body.
+func AdminBanUser(c *gin.Context) {
+	userID, err := PathParamAsIntExtractor(c, idPathParam)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete characters from user.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	err = dbBackend.DeleteUser(userID)
+	req := &requests.CreateBan{}
+	err = requests.Decode(c.Request.Body, req)
 	if err != nil {
-		if err == users.ErrUserNotFound {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	newBan, err := banUser(c, userID, req)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newBan)
+}
+
+// banUser bans the User matching the id from the whole site on behalf of the
+// authenticated admin and records it in the audit log. Admins can't be banned.
+func banUser(c *gin.Context, userID int, req *requests.CreateBan) (*bans.Ban, error) {
+	dbBackend := GetDBBackend(c)
+
+	// Look up the existing User so we can make sure they're not an admin
+	existingUser, err := dbBackend.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prevent banning another admin
+	if existingUser.IsAdmin {
+		GetLogger(c).Error("Admin attempted to ban another admin.")
+		return nil, users.ErrUserIsAdmin
+	}
+
+	newBan, err := dbBackend.CreateBan(c.Request.Context(), req.Ban(userID, bans.SiteWide, GetAuthenticatedUser(c).ID))
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(c, audit.BanCreated, audit.BanTarget, newBan.ID, nil, newBan)
+	return newBan, nil
+}
+
+// AdminLiftBan ends the Ban matching the id in the path early, whether it's site
+// wide or from a Channel.
+func AdminLiftBan(c *gin.Context) {
+	admin := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	banID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Look up the Ban first so one that doesn't exist isn't reported as ended
+	existingBan, err := dbBackend.GetBan(c.Request.Context(), banID)
+	if err != nil {
+		if err == bans.ErrBanNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to retrieve user.")
+		GetLogger(c).WithError(err).Error("Failed to look up ban.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	liftBan(c, existingBan, admin.ID)
 }
 
 // AdminGetCharacters retrieves all of the Characters that
@@ -393,9 +640,9 @@ func AdminGetCharacters(c *gin.Context) {
 	dbBackend := GetDBBackend(c)
 	channel := c.MustGet(channelKey).(*channels.Channel)
 
-	allChars, err := dbBackend.GetCharactersInChannel(channel.ID)
+	allChars, err := dbBackend.GetCharactersInChannel(c.Request.Context(), channel.ID)
 	if err != nil {
-		log.WithError(err).Error("Failed to get characters from channel.")
+		GetLogger(c).WithError(err).Error("Failed to get characters from channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -414,18 +661,18 @@ func AdminGetCharacter(c *gin.Context) {
 		return
 	}
 
-	char, err := dbBackend.GetCharacter(charID)
+	char, err := dbBackend.GetCharacter(c.Request.Context(), charID)
 	if err != nil {
 		if err == characters.ErrCharacterNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to retrieve character.")
+		GetLogger(c).WithError(err).Error("Failed to retrieve character.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, char)
+	respondWithETag(c, http.StatusOK, char.LastUpdated, char)
 }
 
 // AdminUpdateCharacter updates the Character matching the id in
@@ -439,21 +686,39 @@ func AdminUpdateCharacter(c *gin.Context) {
 		return
 	}
 
-	char := &characters.Character{}
-	err = c.Bind(char)
+	req := &requests.UpdateCharacter{}
+	err = requests.Decode(c.Request.Body, req)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	updatedChar, err := dbBackend.UpdateCharacter(charID, char)
+	// Look up the current version for the audit log and any precondition
+	existing, err := dbBackend.GetCharacter(c.Request.Context(), charID)
 	if err != nil {
-		log.WithError(err).Error("Failed to update character.")
+		if err == characters.ErrCharacterNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up character before update.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedChar)
+	expected, ok := checkIfMatch(c, existing.LastUpdated)
+	if !ok {
+		return
+	}
+
+	updatedChar, err := dbBackend.UpdateCharacter(c.Request.Context(), charID, req.Character(), expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, audit.CharacterUpdated, audit.CharacterTarget, charID, existing, updatedChar)
+
+	respondWithETag(c, http.StatusOK, updatedChar.LastUpdated, updatedChar)
 }
 
 // AdminDeleteCharacter deletes the Character matching the id from
@@ -467,16 +732,505 @@ func AdminDeleteCharacter(c *gin.Context) {
 		return
 	}
 
-	err = dbBackend.DeleteCharacter(charID)
+	// Look up the current version for the audit log and any precondition
+	existing, err := dbBackend.GetCharacter(c.Request.Context(), charID)
 	if err != nil {
 		if err == characters.ErrCharacterNotFound {
-			c.AbortWithStatus(http.StatusNotFound)
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to delete character.")
+		GetLogger(c).WithError(err).Error("Failed to look up character before delete.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
+	expected, ok := checkIfMatch(c, existing.LastUpdated)
+	if !ok {
+		return
+	}
+
+	err = dbBackend.DeleteCharacter(c.Request.Context(), charID, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, audit.CharacterDeleted, audit.CharacterTarget, charID, existing, nil)
+
 	c.Status(http.StatusNoContent)
 }
+
+// AdminGetDeletedChannels retrieves all of the deleted Channels that haven't been
+// purged yet.
+func AdminGetDeletedChannels(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	deletedChannels, err := dbBackend.GetDeletedChannels(c.Request.Context())
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve deleted channels.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedChannels)
+}
+
+// AdminGetDeletedCharacters retrieves the deleted Characters that haven't been
+// purged yet from the Channel in the path, which can be deleted itself.
+func AdminGetDeletedCharacters(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	channelID, err := PathParamAsIntExtractor(c, channelIDPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	deletedCharacters, err := dbBackend.GetDeletedCharactersInChannel(c.Request.Context(), channelID)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve deleted characters.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedCharacters)
+}
+
+// AdminGetDeletedMessages retrieves the deleted Messages that haven't been purged
+// yet from the Channel in the path, which can be deleted itself.
+func AdminGetDeletedMessages(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	channelID, err := PathParamAsIntExtractor(c, channelIDPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	deletedMessages, err := dbBackend.GetDeletedMessagesInChannel(c.Request.Context(), channelID)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve deleted messages.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedMessages)
+}
+
+// AdminRestoreChannel brings back the deleted Channel matching the id in the path
+// along with everything deleted with it, no matter how long ago it was deleted.
+func AdminRestoreChannel(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	channelID, err := PathParamAsIntExtractor(c, channelIDPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Look up the deleted version for the audit log
+	deletedChannel, err := dbBackend.GetDeletedChannel(c.Request.Context(), channelID)
+	if err != nil {
+		if err == channels.ErrChannelNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up deleted channel.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	restoredChannel, err := dbBackend.RestoreChannel(c.Request.Context(), channelID)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, audit.ChannelRestored, audit.ChannelTarget, channelID, deletedChannel, restoredChannel)
+
+	respondWithETag(c, http.StatusOK, restoredChannel.LastUpdated, restoredChannel)
+}
+
+// AdminRestoreCharacter brings back the deleted Character matching the id in the
+// path along with the Messages deleted with it, no matter how long ago it was
+// deleted.
+func AdminRestoreCharacter(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	charID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Look up the deleted version for the audit log
+	deletedCharacter, err := dbBackend.GetDeletedCharacter(c.Request.Context(), charID)
+	if err != nil {
+		if err == characters.ErrCharacterNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up deleted character.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	restoredCharacter, err := dbBackend.RestoreCharacter(c.Request.Context(), charID)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, audit.CharacterRestored, audit.CharacterTarget, charID, deletedCharacter, restoredCharacter)
+
+	respondWithETag(c, http.StatusOK, restoredCharacter.LastUpdated, restoredCharacter)
+}
+
+// AdminRestoreMessage brings back the deleted Message matching the id in the path,
+// no matter how long ago it was deleted.
+func AdminRestoreMessage(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	messageID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Look up the deleted version for the audit log
+	deletedMessage, err := dbBackend.GetDeletedMessage(c.Request.Context(), messageID)
+	if err != nil {
+		if err == messages.ErrMessageNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up deleted message.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	restoredMessage, err := dbBackend.RestoreMessage(c.Request.Context(), messageID)
+	if err != nil {
+		if err == messages.ErrMessageNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to restore message.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(c, audit.MessageRestored, audit.MessageTarget, messageID, deletedMessage, restoredMessage)
+
+	respondWithETag(c, http.StatusOK, restoredMessage.LastUpdated, restoredMessage)
+}
+
+// AdminGetReports retrieves the Reports matching the optional `status`,
+// `targetType`, and `assignee` query parameters, oldest first.
+func AdminGetReports(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	filter := reports.Filter{
+		Status:     reports.Status(c.Query(statusQueryParam)),
+		TargetType: reports.TargetType(c.Query(targetTypeQueryParam)),
+	}
+	if filter.Status != "" && !reports.IsValidStatus(filter.Status) {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid %s query parameter %s", statusQueryParam, filter.Status))
+		return
+	}
+	if filter.TargetType != "" && !reports.IsValidTargetType(filter.TargetType) {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid %s query parameter %s", targetTypeQueryParam, filter.TargetType))
+		return
+	}
+
+	assigneeID, err := QueryParamAsIntExtractor(c, assigneeQueryParam)
+	if err == nil {
+		filter.AssigneeID = &assigneeID
+	} else if err != ErrQueryParamNotFound {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	allReports, err := dbBackend.GetReports(c.Request.Context(), filter)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve reports.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, allReports)
+}
+
+// LoadReport looks up the Report matching the id in the path and stores it in the
+// context for the handlers after it.
+func LoadReport(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	reportID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := dbBackend.GetReport(c.Request.Context(), reportID)
+	if err != nil {
+		if err == reports.ErrReportNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to retrieve report.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Set(reportKey, report)
+}
+
+// RequireOpenReport requires that the Report previously loaded from the path
+// hasn't been resolved or dismissed yet.
+func RequireOpenReport(c *gin.Context) {
+	report := c.MustGet(reportKey).(*reports.Report)
+	if !report.IsOpen() {
+		c.AbortWithError(http.StatusConflict, reports.ErrReportClosed)
+		return
+	}
+}
+
+// AdminGetReport retrieves the Report matching the id in the path.
+func AdminGetReport(c *gin.Context) {
+	report := c.MustGet(reportKey).(*reports.Report)
+	respondWithETag(c, http.StatusOK, report.LastUpdated, report)
+}
+
+// AdminAssignReport assigns the Report matching the id in the path to the admin
+// in the request body, or unassigns it.
+func AdminAssignReport(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	existingReport := c.MustGet(reportKey).(*reports.Report)
+
+	req := &requests.AssignReport{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Reports can only be assigned to admins
+	if req.AssigneeID != 0 {
+		assignee, err := dbBackend.GetUserByID(c.Request.Context(), req.AssigneeID)
+		if err != nil && err != users.ErrUserNotFound {
+			GetLogger(c).WithError(err).Error("Failed to look up assignee.")
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if err == users.ErrUserNotFound || !assignee.IsAdmin {
+			c.AbortWithError(http.StatusBadRequest, apierrors.FieldErrors{{Field: "AssigneeID", Message: "must be an admin"}})
+			return
+		}
+	}
+
+	expected, ok := checkIfMatch(c, existingReport.LastUpdated)
+	if !ok {
+		return
+	}
+
+	report := *existingReport
+	report.AssigneeID = req.AssigneeID
+	updateReport(c, audit.ReportAssigned, existingReport, &report, expected)
+}
+
+// AdminResolveReport resolves the Report matching the id in the path by taking the
+// action in the request body against the reported thing.
+func AdminResolveReport(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	existingReport := c.MustGet(reportKey).(*reports.Report)
+
+	req := &requests.ResolveReport{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	action := req.ReportAction()
+	if !action.CanTake(existingReport.TargetType) {
+		c.AbortWithError(http.StatusBadRequest, apierrors.FieldErrors{{Field: "Action", Message: fmt.Sprintf("can't be %s for a %s", action, existingReport.TargetType)}})
+		return
+	}
+
+	expected, ok := checkIfMatch(c, existingReport.LastUpdated)
+	if !ok {
+		return
+	}
+
+	if !takeReportAction(c, existingReport, req) {
+		return
+	}
+
+	report := *existingReport
+	report.Status = reports.ResolvedStatus
+	report.Action = action
+	report.Resolution = req.Resolution
+	report.ClosedBy = user.ID
+	updateReport(c, audit.ReportResolved, existingReport, &report, expected)
+}
+
+// AdminDismissReport closes the Report matching the id in the path without doing
+// anything about it.
+func AdminDismissReport(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	existingReport := c.MustGet(reportKey).(*reports.Report)
+
+	req := &requests.DismissReport{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	expected, ok := checkIfMatch(c, existingReport.LastUpdated)
+	if !ok {
+		return
+	}
+
+	report := *existingReport
+	report.Status = reports.DismissedStatus
+	report.Action = reports.NoAction
+	report.Resolution = req.Resolution
+	report.ClosedBy = user.ID
+	updateReport(c, audit.ReportDismissed, existingReport, &report, expected)
+}
+
+// updateReport saves the triaged Report, records the action in the audit log, and
+// responds with it. It's only saved if the Report is still at the expected version,
+// if there is one.
+func updateReport(c *gin.Context, action audit.Action, existingReport, report *reports.Report, expected *time.Time) {
+	updatedReport, err := GetDBBackend(c).UpdateReport(c.Request.Context(), report.ID, report, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, action, audit.ReportTarget, report.ID, existingReport, updatedReport)
+
+	respondWithETag(c, http.StatusOK, updatedReport.LastUpdated, updatedReport)
+}
+
+// listOrder is how an admin list of Users or Channels is ordered and paged.
+type listOrder struct {
+	Sort       string
+	Descending bool
+	Limit      int
+	Offset     int
+}
+
+// listOrderFromQuery builds the listOrder from the optional `sort`, `order`,
+// `limit`, and `offset` query parameters. Limits above the max are lowered to the
+// max and no limit at all gets the max.
+func listOrderFromQuery(c *gin.Context) (listOrder, error) {
+	order := listOrder{Sort: c.Query(sortQueryParam)}
+
+	switch dir := c.Query(orderQueryParam); dir {
+	case "", ascendingOrder:
+	case descendingOrder:
+		order.Descending = true
+	default:
+		return order, fmt.Errorf("invalid %s query parameter %s", orderQueryParam, dir)
+	}
+
+	ints := map[string]*int{
+		limitQueryParam:  &order.Limit,
+		offsetQueryParam: &order.Offset,
+	}
+	for name, dest := range ints {
+		val, err := QueryParamAsIntExtractor(c, name)
+		if err != nil && err != ErrQueryParamNotFound {
+			return order, err
+		}
+		*dest = val
+	}
+	if order.Limit <= 0 || order.Limit > maxPageLimit {
+		order.Limit = maxPageLimit
+	}
+	if order.Offset < 0 {
+		order.Offset = 0
+	}
+
+	return order, nil
+}
+
+// userFilterFromQuery builds the User Filter from the optional query parameters.
+func userFilterFromQuery(c *gin.Context) (users.Filter, error) {
+	order, err := listOrderFromQuery(c)
+	if err != nil {
+		return users.Filter{}, err
+	}
+
+	filter := users.Filter{
+		Sort:       users.SortField(order.Sort),
+		Descending: order.Descending,
+		Limit:      order.Limit,
+		Offset:     order.Offset,
+	}
+	if filter.Sort != "" && !users.IsValidSortField(filter.Sort) {
+		return filter, fmt.Errorf("invalid %s query parameter %s", sortQueryParam, filter.Sort)
+	}
+
+	bools := map[string]**bool{
+		adminQueryParam:  &filter.IsAdmin,
+		bannedQueryParam: &filter.IsBanned,
+	}
+	for name, dest := range bools {
+		val, err := OptionalBoolQueryParam(c, name)
+		if err != nil {
+			return filter, err
+		}
+		*dest = val
+	}
+
+	times := map[string]**time.Time{
+		loggedInSinceQueryParam:  &filter.LoggedInSince,
+		loggedInBeforeQueryParam: &filter.LoggedInBefore,
+	}
+	for name, dest := range times {
+		val, err := OptionalTimeQueryParam(c, name)
+		if err != nil {
+			return filter, err
+		}
+		*dest = val
+	}
+
+	return filter, nil
+}
+
+// channelFilterFromQuery builds the Channel Filter from the optional query
+// parameters.
+func channelFilterFromQuery(c *gin.Context) (channels.Filter, error) {
+	order, err := listOrderFromQuery(c)
+	if err != nil {
+		return channels.Filter{}, err
+	}
+
+	filter := channels.Filter{
+		Sort:       channels.SortField(order.Sort),
+		Descending: order.Descending,
+		Limit:      order.Limit,
+		Offset:     order.Offset,
+	}
+	if filter.Sort != "" && !channels.IsValidSortField(filter.Sort) {
+		return filter, fmt.Errorf("invalid %s query parameter %s", sortQueryParam, filter.Sort)
+	}
+
+	filter.IsPrivate, err = OptionalBoolQueryParam(c, privateQueryParam)
+	if err != nil {
+		return filter, err
+	}
+
+	ownerID, err := QueryParamAsIntExtractor(c, ownerQueryParam)
+	if err != nil && err != ErrQueryParamNotFound {
+		return filter, err
+	}
+	filter.OwnerID = ownerID
+
+	return filter, nil
+}
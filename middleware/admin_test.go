@@ -0,0 +1,133 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserFilterFromQuery(t *testing.T) {
+	since := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	yes, no := true, false
+
+	testIO := []struct {
+		desc      string
+		query     string
+		expected  users.Filter
+		expectErr bool
+	}{
+		{
+			desc:     "No filters.",
+			query:    "",
+			expected: users.Filter{Limit: maxPageLimit},
+		},
+		{
+			desc:  "All filters.",
+			query: "admin=false&banned=true&loggedInSince=2018-06-01T00:00:00Z&sort=lastLogin&order=desc&limit=10&offset=20",
+			expected: users.Filter{
+				IsAdmin:       &no,
+				IsBanned:      &yes,
+				LoggedInSince: &since,
+				Sort:          users.SortByLastLogin,
+				Descending:    true,
+				Limit:         10,
+				Offset:        20,
+			},
+		},
+		{
+			desc:      "Unknown sort.",
+			query:     "sort=email",
+			expectErr: true,
+		},
+		{
+			desc:      "Unknown order.",
+			query:     "order=up",
+			expectErr: true,
+		},
+		{
+			desc:      "Bad bool.",
+			query:     "banned=maybe",
+			expectErr: true,
+		},
+		{
+			desc:      "Bad time.",
+			query:     "loggedInBefore=yesterday",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/users?"+test.query, nil)
+
+			filter, err := userFilterFromQuery(c)
+			if test.expectErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, filter)
+		})
+	}
+}
+
+func TestChannelFilterFromQuery(t *testing.T) {
+	yes := true
+
+	testIO := []struct {
+		desc      string
+		query     string
+		expected  channels.Filter
+		expectErr bool
+	}{
+		{
+			desc:     "No filters.",
+			query:    "",
+			expected: channels.Filter{Limit: maxPageLimit},
+		},
+		{
+			desc:  "All filters.",
+			query: "private=true&owner=3&sort=createdOn&order=asc&limit=1000",
+			expected: channels.Filter{
+				IsPrivate: &yes,
+				OwnerID:   3,
+				Sort:      channels.SortByCreatedOn,
+				Limit:     maxPageLimit,
+			},
+		},
+		{
+			desc:      "Unknown sort.",
+			query:     "sort=topic",
+			expectErr: true,
+		},
+		{
+			desc:      "Bad owner.",
+			query:     "owner=me",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/channels?"+test.query, nil)
+
+			filter, err := channelFilterFromQuery(c)
+			if test.expectErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, filter)
+		})
+	}
+}
@@ -4,14 +4,15 @@ import (
 	"net/http"
 
 	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
 
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // RegisterAnonymousRoutes adds the anonymous routes
 func RegisterAnonymousRoutes(r *gin.Engine) {
 	g := r.Group("/public")
+	g.Use(RateLimitMiddleware(ratelimit.AnonymousGroup))
 	g.GET("/channels", ValidateHeaders(acceptHeader), GetPublicChannels)
 	g.GET("/channels/:channelID", ValidateHeaders(acceptHeader), GetPublicChannel)
 	g.GET("/channels/:channelID/messages", ValidateHeaders(acceptHeader), LoadChannelFromPathID, GetStoryMessagesInChannel)
@@ -22,9 +23,9 @@ func RegisterAnonymousRoutes(r *gin.Engine) {
 func GetPublicChannels(c *gin.Context) {
 	dbBackend := GetDBBackend(c)
 	isPrivate := false
-	publicChannels, err := dbBackend.GetAllChannels(&isPrivate)
+	publicChannels, err := dbBackend.GetAllChannels(c.Request.Context(), &isPrivate)
 	if err != nil {
-		log.WithError(err).Error("Failed to get public channels.")
+		GetLogger(c).WithError(err).Error("Failed to get public channels.")
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
@@ -42,25 +43,25 @@ func GetPublicChannel(c *gin.Context) {
 		return
 	}
 
-	channel, err := dbBackend.GetChannel(channelID)
+	channel, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
 		if err == channels.ErrChannelNotFound {
-			log.WithError(err).Error("Channel not found.")
-			c.AbortWithStatus(http.StatusNotFound)
+			GetLogger(c).WithError(err).Error("Channel not found.")
+			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to get channel.")
+		GetLogger(c).WithError(err).Error("Failed to get channel.")
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
 	if channel.IsPrivate {
-		log.WithError(err).Error("Anonymous user attempting to look up private channel denying access.")
+		GetLogger(c).WithError(err).Error("Anonymous user attempting to look up private channel denying access.")
 		c.AbortWithStatus(http.StatusForbidden)
 		return
 	}
 
-	c.JSON(http.StatusOK, channel)
+	respondWithETag(c, http.StatusOK, channel.LastUpdated, channel)
 }
 
 // GetStoryMessagesInChannel retrieves all of the story Messages from
@@ -71,15 +72,21 @@ func GetStoryMessagesInChannel(c *gin.Context) {
 	channel := c.MustGet(channelKey).(*channels.Channel)
 
 	if channel.IsPrivate {
-		log.Error("Anonymous User attempting to look up messages from private channel denying access.")
+		GetLogger(c).Error("Anonymous User attempting to look up messages from private channel denying access.")
 		c.AbortWithStatus(http.StatusForbidden)
 		return
 	}
 
+	page, err := PageFromQuery(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
 	onlyStoryMsgs := true
-	messages, err := dbBackend.GetMessagesInChannel(channel.ID, &onlyStoryMsgs)
+	messages, err := dbBackend.GetMessagesInChannel(c.Request.Context(), channel.ID, &onlyStoryMsgs, page)
 	if err != nil {
-		log.WithError(err).Error("Failed to get story messages for public channel.")
+		GetLogger(c).WithError(err).Error("Failed to get story messages for public channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
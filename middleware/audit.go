@@ -0,0 +1,121 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminGetAuditLog retrieves the audit log Entries matching the optional `actor`,
+// `action`, `targetType`, `targetID`, `since`, `until`, and `limit` query
+// parameters, newest first.
+func AdminGetAuditLog(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	filter, err := auditFilterFromQuery(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	entries, err := dbBackend.GetAuditEntries(c.Request.Context(), filter)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve audit log.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// auditFilterFromQuery builds the audit Filter from the optional query
+// parameters. Limits above the max are lowered to the max and no limit at all
+// gets the max.
+func auditFilterFromQuery(c *gin.Context) (audit.Filter, error) {
+	filter := audit.Filter{
+		Action:     audit.Action(c.Query(actionQueryParam)),
+		TargetType: audit.TargetType(c.Query(targetTypeQueryParam)),
+	}
+	if filter.TargetType != "" && !audit.IsValidTargetType(filter.TargetType) {
+		return filter, fmt.Errorf("invalid %s query parameter %s", targetTypeQueryParam, filter.TargetType)
+	}
+
+	ints := map[string]*int{
+		actorQueryParam:    &filter.ActorID,
+		targetIDQueryParam: &filter.TargetID,
+		limitQueryParam:    &filter.Limit,
+	}
+	for name, dest := range ints {
+		val, err := QueryParamAsIntExtractor(c, name)
+		if err != nil && err != ErrQueryParamNotFound {
+			return filter, err
+		}
+		*dest = val
+	}
+	if filter.Limit <= 0 || filter.Limit > maxPageLimit {
+		filter.Limit = maxPageLimit
+	}
+
+	times := map[string]**time.Time{
+		sinceQueryParam: &filter.Since,
+		untilQueryParam: &filter.Until,
+	}
+	for name, dest := range times {
+		val, err := OptionalTimeQueryParam(c, name)
+		if err != nil {
+			return filter, err
+		}
+		*dest = val
+	}
+
+	return filter, nil
+}
+
+// recordAudit adds an Entry to the audit log for the authenticated User taking
+// the action against the target. When an admin is impersonating the User the
+// admin is the actor. before and after are snapshots of the target on either side
+// of the change and are nil when there's nothing on that side. The change has
+// already happened by the time it's recorded so failures are only logged.
+func recordAudit(c *gin.Context, action audit.Action, targetType audit.TargetType, targetID int, before, after interface{}) {
+	entry := &audit.Entry{
+		ActorID:    GetAuthenticatedUser(c).ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     auditSnapshot(c, before),
+		After:      auditSnapshot(c, after),
+	}
+	if impersonator := GetImpersonator(c); impersonator != nil {
+		entry.ImpersonatingID = entry.ActorID
+		entry.ActorID = impersonator.ID
+	}
+
+	_, err := GetDBBackend(c).CreateAuditEntry(c.Request.Context(), entry)
+	if err != nil {
+		GetLogger(c).WithError(err).WithField("action", action).Error("Failed to record audit entry.")
+	}
+}
+
+// auditSnapshot converts the target into the JSON saved with the Entry. Nil
+// targets, including nil pointers, don't have a snapshot.
+func auditSnapshot(c *gin.Context, target interface{}) json.RawMessage {
+	if target == nil {
+		return nil
+	}
+
+	snapshot, err := json.Marshal(target)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to snapshot audit target.")
+		return nil
+	}
+	if string(snapshot) == "null" {
+		return nil
+	}
+	return snapshot
+}
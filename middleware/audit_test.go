@@ -0,0 +1,78 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditFilterFromQuery(t *testing.T) {
+	since := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testIO := []struct {
+		desc      string
+		query     string
+		expected  audit.Filter
+		expectErr bool
+	}{
+		{
+			desc:     "No filters.",
+			query:    "",
+			expected: audit.Filter{Limit: maxPageLimit},
+		},
+		{
+			desc:  "All filters.",
+			query: "actor=1&action=channel.deleted&targetType=channel&targetID=5&since=2018-06-01T00:00:00Z&limit=10",
+			expected: audit.Filter{
+				ActorID:    1,
+				Action:     audit.ChannelDeleted,
+				TargetType: audit.ChannelTarget,
+				TargetID:   5,
+				Since:      &since,
+				Limit:      10,
+			},
+		},
+		{
+			desc:     "Limit above the max.",
+			query:    "limit=1000",
+			expected: audit.Filter{Limit: maxPageLimit},
+		},
+		{
+			desc:      "Unknown target type.",
+			query:     "targetType=webhook",
+			expectErr: true,
+		},
+		{
+			desc:      "Bad time.",
+			query:     "until=yesterday",
+			expectErr: true,
+		},
+		{
+			desc:      "Bad actor.",
+			query:     "actor=me",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/audit?"+test.query, nil)
+
+			filter, err := auditFilterFromQuery(c)
+			if test.expectErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, filter)
+		})
+	}
+}
@@ -7,14 +7,19 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/andrew-boutin/dndtextapi/configs"
 
 	"github.com/andrew-boutin/dndtextapi/backends"
+	"github.com/andrew-boutin/dndtextapi/metrics"
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
+	"github.com/andrew-boutin/dndtextapi/tokens"
 	"github.com/andrew-boutin/dndtextapi/users"
 
 	"github.com/dchest/uniuri"
@@ -32,16 +37,22 @@ const (
 	// userContextKey is the key to look up the authenticated User in the Context with.
 	userContextKey = "USER_CONTEXT_KEY"
 
+	// tokenContextKey is the key to look up the Token the User authenticated with.
+	tokenContextKey = "TOKEN_CONTEXT_KEY"
+
 	cookieName = "dndtextapisession"
 
 	callbackQueryParam = "callback"
+
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
 )
 
 var googleAccountURL = "%s/oauth2/v2/userinfo?access_token="
 
 // googleOauthConfig is all of the config data required to authenticate a User with Google
 var googleOauthConfig = &oauth2.Config{
-	RedirectURL:  "http://localhost:8080/callback",
+	RedirectURL:  "", // Populated by config load
 	ClientID:     "", // Populated by config load
 	ClientSecret: "", // Populated by config load
 	Scopes: []string{
@@ -50,29 +61,29 @@ var googleOauthConfig = &oauth2.Config{
 	Endpoint: oauth2.Endpoint{}, // Populated by config load
 }
 
-// store is the session store used for authentication
+// store is the session store used for authentication. It's created from the
+// session secret by InitAuthentication.
 var store cookie.Store
 
-func init() {
-	// store = cookie.NewStore([]byte(randToken(64))) # Random is causing issues between restarts
-	store = cookie.NewStore([]byte("asdaskdhasdhgsajdgasdsadksakdhasidoajsdousahdopj")) // TODO: Make this from the config
-	store.Options(sessions.Options{
-		Path: "/",
-		// 1 week
-		MaxAge: 86400 * 7,
-	})
-}
-
 // InitAuthentication initializes authentication configuration that has
 // to be read in from config files
 func InitAuthentication(c configs.AuthenticationConfiguration) {
 	googleOauthConfig.ClientID = c.ID
 	googleOauthConfig.ClientSecret = c.Secret
+	googleOauthConfig.RedirectURL = c.RedirectURL
 	googleAccountURL = fmt.Sprintf(googleAccountURL, c.Accounts)
 	googleOauthConfig.Endpoint = oauth2.Endpoint{
 		AuthURL:  fmt.Sprintf("%s/o/oauth2/auth", c.Oauth2),
 		TokenURL: fmt.Sprintf("%s/o/oauth2/token", c.Oauth2),
 	}
+
+	// The secret comes from config so sessions survive restarts
+	store = cookie.NewStore([]byte(c.SessionSecret))
+	store.Options(sessions.Options{
+		Path: "/",
+		// 1 week
+		MaxAge: 86400 * 7,
+	})
 }
 
 // RegisterAuthenticationRoutes adds the authentication routes
@@ -80,8 +91,8 @@ func RegisterAuthenticationRoutes(r *gin.Engine) {
 	// Use the cookie store
 	r.Use(sessions.Sessions(cookieName, store))
 
-	r.GET("/login", LoginHandler)
-	r.GET("/callback", CallbackHandler)
+	r.GET("/login", RateLimitMiddleware(ratelimit.AnonymousGroup), LoginHandler)
+	r.GET("/callback", RateLimitMiddleware(ratelimit.AnonymousGroup), CallbackHandler)
 }
 
 // LoginHandler handles redirecting the User to Google for authentication. An
@@ -89,7 +100,7 @@ func RegisterAuthenticationRoutes(r *gin.Engine) {
 func LoginHandler(c *gin.Context) {
 	callbackFromQuery, err := QueryParamExtractor(c, callbackQueryParam)
 	if err != nil && err != ErrQueryParamNotFound {
-		log.WithError(err).Errorf("Error extracting optional query parameter %s", callbackFromQuery)
+		GetLogger(c).WithError(err).Errorf("Error extracting optional query parameter %s", callbackFromQuery)
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -130,14 +141,14 @@ func CallbackHandler(c *gin.Context) {
 	// Exchange the access code for an access token that we can make calls with
 	token, err := googleOauthConfig.Exchange(oauth2.NoContext, code)
 	if err != nil {
-		log.WithError(err).WithField("code", code).Error("Failed to get Google access token using access code.")
+		GetLogger(c).WithError(err).WithField("code", code).Error("Failed to get Google access token using access code.")
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
 	// Make sure the token is valid
 	if !token.Valid() {
-		log.WithField("token", token).Error("Google access token not valid.")
+		GetLogger(c).WithField("token", token).Error("Google access token not valid.")
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
@@ -145,7 +156,7 @@ func CallbackHandler(c *gin.Context) {
 	// Attempt to get the User info from Google using the access token
 	response, err := http.Get(googleAccountURL + token.AccessToken)
 	if err != nil {
-		log.WithError(err).Error("Failed to get Google User data.")
+		GetLogger(c).WithError(err).Error("Failed to get Google User data.")
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
@@ -153,22 +164,22 @@ func CallbackHandler(c *gin.Context) {
 	// Convert the response from the Google endpoint into userful data
 	googleUser, err := readInGoogleUser(response)
 	if err != nil {
-		log.WithError(err).Error("Failed to extract Google User data.")
+		GetLogger(c).WithError(err).Error("Failed to extract Google User data.")
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
 	// TODO: Could return some data to indicate a returning user or not
-	user, err := getOrCreateUser(dbBackend, googleUser)
+	user, err := getOrCreateUser(c.Request.Context(), dbBackend, googleUser)
 	if err != nil {
-		log.WithError(err).Error("Failed to either look up or create user.")
+		GetLogger(c).WithError(err).Error("Failed to either look up or create user.")
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
 	// Deny access if the User has been banned.
 	if user.IsBanned {
-		log.Error("Banned user attempted to access route.")
+		GetLogger(c).Error("Banned user attempted to access route.")
 		c.AbortWithStatus(http.StatusForbidden)
 		return
 	}
@@ -176,11 +187,12 @@ func CallbackHandler(c *gin.Context) {
 	// Create a session for the User and put it in the session store so we can check if they're authenticated later
 	err = createUserSession(c, user.Email)
 	if err != nil {
-		log.WithError(err).Error("Failed to create a user session.")
+		GetLogger(c).WithError(err).Error("Failed to create a user session.")
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
+	metrics.Logins.Inc()
 	c.Status(http.StatusNoContent)
 }
 
@@ -205,28 +217,35 @@ func createUserSession(c *gin.Context, email string) error {
 
 // getOrCreateUser attempts to lookup a User in the database and creates a new one if one
 // doesn't already exist.
-func getOrCreateUser(dbBackend backends.Backend, gu *users.GoogleUser) (user *users.User, err error) {
+func getOrCreateUser(ctx context.Context, dbBackend backends.Backend, gu *users.GoogleUser) (user *users.User, err error) {
 	// Attempt to look up the Google User in the database
-	user, err = dbBackend.GetUserByEmail(gu.Email)
+	user, err = dbBackend.GetUserByEmail(ctx, gu.Email)
 	if err == users.ErrUserNotFound {
 		// Create a new User in the database for this new Google profile
-		user, err = dbBackend.CreateUser(gu)
+		user, err = dbBackend.CreateUser(ctx, gu)
 	} else if err == nil {
 		// User already existed so update their last login time stamp
-		user, err = dbBackend.UpdateUserLastLogin(user)
+		user, err = dbBackend.UpdateUserLastLogin(ctx, user)
 	}
 	return
 }
 
 // AuthenticationMiddleware requires that the User is authenticated or else they
-// get access denied.
+// get access denied. Users can be authenticated by either a session or a Token.
 func AuthenticationMiddleware(c *gin.Context) {
+	// A bearer Token takes the place of a session for scripts and tools
+	authHeader := c.GetHeader(authorizationHeader)
+	if strings.HasPrefix(authHeader, bearerPrefix) {
+		authenticateToken(c, strings.TrimPrefix(authHeader, bearerPrefix))
+		return
+	}
+
 	// If there is a valid session entry for the User then they're still authenticated
 	session := sessions.Default(c)
 	emailAsInterface := session.Get(userSessionStoreKey) // TODO: Potentially have the User in the Session
 	if emailAsInterface == nil {
 		// User doesn't have a session so deny access
-		log.Error("No session data found denying access.")
+		GetLogger(c).Error("No session data found denying access.")
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
@@ -234,9 +253,9 @@ func AuthenticationMiddleware(c *gin.Context) {
 	// Look up the User and set in the Context so all future middleware can have access
 	email := emailAsInterface.(string)
 	dbBackend := GetDBBackend(c)
-	user, err := dbBackend.GetUserByEmail(email)
+	user, err := dbBackend.GetUserByEmail(c.Request.Context(), email)
 	if err != nil {
-		log.WithError(err).Errorf("Failed to look up user using session email data %s.", email)
+		GetLogger(c).WithError(err).Errorf("Failed to look up user using session email data %s.", email)
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
@@ -244,12 +263,49 @@ func AuthenticationMiddleware(c *gin.Context) {
 	// Deny access if the User has been banned here too since the ban could have happened
 	// while they have an active session
 	if user.IsBanned {
-		log.Error("Banned user attempted to access route.")
+		GetLogger(c).Error("Banned user attempted to access route.")
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	metrics.ActiveSessions.Seen(user.ID)
+	c.Set(userContextKey, user)
+	addLogFields(c, log.Fields{"user_id": user.ID})
+}
+
+// authenticateToken looks up the User who owns the Token value and sets them
+// as the authenticated User.
+func authenticateToken(c *gin.Context, value string) {
+	dbBackend := GetDBBackend(c)
+
+	token, err := dbBackend.GetTokenByHash(c.Request.Context(), tokens.Hash(value))
+	if err != nil {
+		if err == tokens.ErrTokenNotFound {
+			GetLogger(c).Error("Unknown token denying access.")
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up token.")
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := dbBackend.GetUserByID(c.Request.Context(), token.UserID)
+	if err != nil {
+		GetLogger(c).WithError(err).Errorf("Failed to look up user %d for token.", token.UserID)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if user.IsBanned {
+		GetLogger(c).Error("Banned user attempted to access route.")
 		c.AbortWithStatus(http.StatusForbidden)
 		return
 	}
 
 	c.Set(userContextKey, user)
+	c.Set(tokenContextKey, token)
+	addLogFields(c, log.Fields{"user_id": user.ID, "token_id": token.ID})
 }
 
 // GetAuthenticatedUser pulls out the authenticated User from the Context. Previous
@@ -0,0 +1,165 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/gin-gonic/gin"
+)
+
+const banKey = "banKey"
+
+// RegisterBansRoutes registers all of the Channel Ban routes with their
+// associated middleware. Only the Channel owner can manage Bans from their
+// Channel. Site wide Bans are with the rest of the admin routes.
+func RegisterBansRoutes(g *gin.RouterGroup) {
+	g.GET("/channels/:channelID/bans", ValidateHeaders(acceptHeader), LoadChannelFromPathID, RequireChannelOwner, GetChannelBans)
+	g.POST("/channels/:channelID/bans", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, RequireChannelOwner, CreateChannelBan)
+	g.POST("/channels/:channelID/bans/:id/lift", ValidateHeaders(acceptHeader), LoadChannelFromPathID, RequireChannelOwner, LoadChannelBan, LiftChannelBan)
+}
+
+// GetChannelBans retrieves the history of Bans from the Channel.
+func GetChannelBans(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	channelBans, err := dbBackend.GetBansInChannel(c.Request.Context(), channel.ID)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to look up bans for channel.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, channelBans)
+}
+
+// CreateChannelBan bans the User from the request body from the Channel. Banned
+// Users keep their Characters but can't see or post in the Channel until the Ban
+// ends.
+func CreateChannelBan(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	req := &requests.CreateChannelBan{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Owners can't lock themselves out of their own Channel
+	if req.UserID == user.ID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	_, err = dbBackend.GetUserByID(c.Request.Context(), req.UserID)
+	if err != nil {
+		if err == users.ErrUserNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up user before ban.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	newBan, err := dbBackend.CreateBan(c.Request.Context(), req.Ban(req.UserID, channel.ID, user.ID))
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to create ban.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(c, audit.BanCreated, audit.BanTarget, newBan.ID, nil, newBan)
+
+	c.JSON(http.StatusCreated, newBan)
+}
+
+// LiftChannelBan ends the Ban matching the id in the path early.
+func LiftChannelBan(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	ban := c.MustGet(banKey).(*bans.Ban)
+
+	liftBan(c, ban, user.ID)
+}
+
+// LoadChannelBan attempts to look up the Ban using the id in the path and stores
+// it in the Context. Bans from other Channels are treated as not found.
+func LoadChannelBan(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	banID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	ban, err := dbBackend.GetBan(c.Request.Context(), banID)
+	if err != nil {
+		if err == bans.ErrBanNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+
+		GetLogger(c).WithError(err).WithField("banID", banID).Error("Failed to look up ban.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if ban.ChannelID != channel.ID {
+		c.AbortWithError(http.StatusNotFound, bans.ErrBanNotFound)
+		return
+	}
+
+	c.Set(banKey, ban)
+}
+
+// liftBan lifts the Ban on behalf of the User, records it in the audit log, and
+// responds with it.
+func liftBan(c *gin.Context, ban *bans.Ban, liftedBy int) {
+	liftedBan, err := GetDBBackend(c).LiftBan(c.Request.Context(), ban.ID, liftedBy)
+	if err != nil {
+		if err == bans.ErrBanNotActive {
+			c.AbortWithError(http.StatusConflict, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to lift ban.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(c, audit.BanLifted, audit.BanTarget, ban.ID, ban, liftedBan)
+
+	c.JSON(http.StatusOK, liftedBan)
+}
+
+// checkNotBannedFromChannel makes sure the User doesn't have a Ban in effect for
+// the Channel. Admins are never kept out. It aborts and returns false if the User
+// is banned.
+func checkNotBannedFromChannel(c *gin.Context, user *users.User, channelID int) bool {
+	if user.IsAdmin {
+		return true
+	}
+
+	isBanned, err := GetDBBackend(c).IsUserBannedFromChannel(c.Request.Context(), user.ID, channelID)
+	if err != nil {
+		GetLogger(c).WithError(err).WithField("channelID", channelID).Error("Failed to check if user is banned from channel.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return false
+	}
+
+	if isBanned {
+		c.AbortWithError(http.StatusForbidden, bans.ErrBannedFromChannel)
+		return false
+	}
+	return true
+}
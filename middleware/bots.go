@@ -0,0 +1,132 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/bots"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/gin-gonic/gin"
+)
+
+const botIDPathParam = "botID"
+
+// RegisterBotsRoutes registers all of the Bot routes with their associated
+// middleware.
+func RegisterBotsRoutes(g *gin.RouterGroup) {
+	g.GET("/bots", ValidateHeaders(acceptHeader), GetBots)
+	g.POST("/bots", ValidateHeaders(acceptHeader, contentTypeHeader), CreateBot)
+	g.GET("/bots/:botID", ValidateHeaders(acceptHeader), GetBot)
+	g.DELETE("/bots/:botID", DeleteBot)
+}
+
+// GetBots retrieves all of the Bots so Users can find ones to use.
+func GetBots(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	allBots, err := dbBackend.GetAllBots(c.Request.Context())
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve all bots.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, allBots)
+}
+
+// GetBot retrieves the Bot matching the id in the path.
+func GetBot(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	botID, err := PathParamAsIntExtractor(c, botIDPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	bot, err := dbBackend.GetBot(c.Request.Context(), botID)
+	if err != nil {
+		if err == bots.ErrBotNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to retrieve bot.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, bot.LastUpdated, bot)
+}
+
+// CreateBot creates a new Bot owned by the authenticated User.
+func CreateBot(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	req := &requests.CreateBot{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	newBot, err := dbBackend.CreateBot(c.Request.Context(), req.Bot(user.ID))
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to create bot.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newBot)
+}
+
+// DeleteBot deletes the Bot matching the id in the path. Only the Bot owner
+// or an admin can delete it. Any Channels using the Bot are unlinked.
+func DeleteBot(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	botID, err := PathParamAsIntExtractor(c, botIDPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	bot, err := dbBackend.GetBot(c.Request.Context(), botID)
+	if err != nil {
+		if err == bots.ErrBotNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to retrieve bot.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if bot.OwnerID != user.ID && !user.IsAdmin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	expected, ok := checkIfMatch(c, bot.LastUpdated)
+	if !ok {
+		return
+	}
+
+	err = dbBackend.RemoveBotFromChannels(c.Request.Context(), botID)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to remove bot from channels.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	err = dbBackend.DeleteBot(c.Request.Context(), botID, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
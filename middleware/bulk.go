@@ -0,0 +1,116 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/bulk"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminBulkBanUsers bans each of the Users matching the IDs in the request body
+// from the whole site with the same reason and times.
+func AdminBulkBanUsers(c *gin.Context) {
+	req := &requests.BulkBan{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	runBulk(c, req.IDs, http.StatusCreated, func(userID int) error {
+		_, err := banUser(c, userID, &req.CreateBan)
+		return err
+	})
+}
+
+// AdminBulkDeleteUsers deletes each of the Users matching the IDs in the request
+// body along with their Messages and Characters.
+func AdminBulkDeleteUsers(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	req := &requests.BulkIDs{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	runBulk(c, req.IDs, http.StatusNoContent, func(userID int) error {
+		existingUser, err := dbBackend.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			return err
+		}
+		return deleteUser(c, existingUser, nil)
+	})
+}
+
+// AdminBulkDeleteChannels deletes each of the Channels matching the IDs in the
+// request body along with their Characters and Messages.
+func AdminBulkDeleteChannels(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	req := &requests.BulkIDs{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	runBulk(c, req.IDs, http.StatusNoContent, func(channelID int) error {
+		existing, err := dbBackend.GetChannel(c.Request.Context(), channelID)
+		if err != nil {
+			return err
+		}
+		return deleteChannel(c, existing, nil)
+	})
+}
+
+// AdminBulkDeleteMessages deletes each of the Messages matching the IDs in the
+// request body.
+func AdminBulkDeleteMessages(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	req := &requests.BulkIDs{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	runBulk(c, req.IDs, http.StatusNoContent, func(messageID int) error {
+		existing, err := dbBackend.GetMessage(c.Request.Context(), messageID)
+		if err != nil {
+			return err
+		}
+		return deleteMessage(c, existing, nil)
+	})
+}
+
+// runBulk does the action to each of the IDs in turn and responds with a Result
+// for each one. One failing doesn't stop the rest. IDs the action succeeds for get
+// the success status and the rest get the status for the error, the same as doing
+// it to just that ID would have.
+func runBulk(c *gin.Context, ids []int, success int, action func(id int) error) {
+	results := make(bulk.ResultCollection, len(ids))
+	for i, id := range ids {
+		result := &bulk.Result{ID: id, Status: success}
+
+		if err := action(id); err != nil {
+			result.Status = apierrors.StatusFor(err)
+			if result.Status >= http.StatusInternalServerError {
+				GetLogger(c).WithError(err).Errorf("Bulk action failed for %d.", id)
+			}
+
+			apiErr := apierrors.New(result.Status, err, GetRequestID(c))
+			result.Error = &apiErr
+		}
+
+		results[i] = result
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -3,11 +3,18 @@
 package middleware
 
 import (
+	"context"
+	"github.com/andrew-boutin/dndtextapi/logging"
 	"net/http"
 
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/audit"
 	"github.com/andrew-boutin/dndtextapi/backends"
+	"github.com/andrew-boutin/dndtextapi/bots"
 	"github.com/andrew-boutin/dndtextapi/channels"
-	log "github.com/sirupsen/logrus"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +26,9 @@ func RegisterChannelsRoutes(g *gin.RouterGroup) {
 	g.POST("/channels", ValidateHeaders(acceptHeader, contentTypeHeader), CreateChannel)
 	g.GET("/channels/:channelID", ValidateHeaders(acceptHeader), GetChannel)
 	g.PUT("/channels/:channelID", ValidateHeaders(acceptHeader, contentTypeHeader), UpdateChannel)
+	g.PATCH("/channels/:channelID", ValidateHeaders(acceptHeader, contentTypeHeader), PatchChannel)
 	g.DELETE("/channels/:channelID", DeleteChannel)
+	g.POST("/channels/:channelID/restore", ValidateHeaders(acceptHeader), RestoreChannel)
 }
 
 // GetChannels retrieves a list of Channels that the authenticated User has access
@@ -44,11 +53,11 @@ func GetChannels(c *gin.Context) {
 	var outChannels channels.ChannelCollection
 	switch level {
 	case ownerLevel:
-		outChannels, err = dbBackend.GetChannelsOwnedByUser(user.ID)
+		outChannels, err = dbBackend.GetChannelsOwnedByUser(c.Request.Context(), user.ID)
 	case memberLevel:
-		outChannels, err = GetChannelsUserIsMember(dbBackend, user.ID)
+		outChannels, err = GetChannelsUserIsMember(c.Request.Context(), dbBackend, user.ID)
 	default:
-		outChannels, err = GetChannelsUserCanAccess(dbBackend, user.ID)
+		outChannels, err = GetChannelsUserCanAccess(c.Request.Context(), dbBackend, user.ID)
 	}
 
 	if err != nil {
@@ -69,7 +78,7 @@ func GetChannel(c *gin.Context) {
 		return
 	}
 
-	channel, err := dbBackend.GetChannel(channelID)
+	channel, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
 		if err == channels.ErrChannelNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
@@ -79,10 +88,14 @@ func GetChannel(c *gin.Context) {
 		return
 	}
 
+	if !checkNotBannedFromChannel(c, user, channel.ID) {
+		return
+	}
+
 	// Private Channels require that the User is a member to access
 	var userInChannel bool
 	if channel.IsPrivate == true {
-		userInChannel, err = dbBackend.DoesUserHaveCharacterInChannel(user.ID, channelID)
+		userInChannel, err = dbBackend.DoesUserHaveCharacterInChannel(c.Request.Context(), user.ID, channelID)
 		if err != nil {
 			c.AbortWithError(http.StatusInternalServerError, err)
 			return
@@ -95,33 +108,39 @@ func GetChannel(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, channel)
+	respondWithETag(c, http.StatusOK, channel.LastUpdated, channel)
 }
 
 // CreateChannel creates a new channel using the data provided
 // in the request body.
 func CreateChannel(c *gin.Context) {
-	// TODO: Validation. Name not empty, can't set ID/OwnerID, etc.
 	user := GetAuthenticatedUser(c)
 	dbBackend := GetDBBackend(c)
 
-	channel := &channels.Channel{}
-	err := c.Bind(channel)
+	req := &requests.Channel{}
+	err := requests.Decode(c.Request.Body, req)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
 	// Set the authenticated User as the Channel owner
-	channel.OwnerID = user.ID
+	channel := &channels.Channel{OwnerID: user.ID}
+	req.Apply(channel)
 
-	createdChannel, err := dbBackend.CreateChannel(channel, user.ID)
+	err = validateChannelBot(c.Request.Context(), dbBackend, user, channel)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	createdChannel, err := dbBackend.CreateChannel(c.Request.Context(), channel, user.ID)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdChannel)
+	respondWithETag(c, http.StatusCreated, createdChannel.LastUpdated, createdChannel)
 }
 
 // DeleteChannel deletes the channel using the id from the request path.
@@ -131,18 +150,18 @@ func DeleteChannel(c *gin.Context) {
 
 	channelID, err := PathParamAsIntExtractor(c, channelIDPathParam)
 	if err != nil {
-		log.WithError(err).Error("Failed to get channel id from path.")
+		GetLogger(c).WithError(err).Error("Failed to get channel id from path.")
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	existingChannel, err := dbBackend.GetChannel(channelID)
+	existingChannel, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
 		if err == channels.ErrChannelNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to get existing channel.")
+		GetLogger(c).WithError(err).Error("Failed to get existing channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -153,34 +172,89 @@ func DeleteChannel(c *gin.Context) {
 		return
 	}
 
-	err = dbBackend.DeleteMessagesFromChannel(channelID)
+	expected, ok := checkIfMatch(c, existingChannel.LastUpdated)
+	if !ok {
+		return
+	}
+
+	// The Characters and Messages in the Channel are deleted along with it
+	err = dbBackend.DeleteChannel(c.Request.Context(), channelID, expected)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete messages from channel.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
-	err = dbBackend.DeleteCharactersFromChannel(channelID)
+	recordAudit(c, audit.ChannelDeleted, audit.ChannelTarget, channelID, existingChannel, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreChannel brings back the deleted channel using the id from the request path
+// along with the Characters and Messages that were deleted with it. Only the owner
+// can restore it and only within the restore window.
+func RestoreChannel(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	channelID, err := PathParamAsIntExtractor(c, channelIDPathParam)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete characters from channel.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	err = dbBackend.DeleteChannel(channelID)
+	deletedChannel, err := dbBackend.GetDeletedChannel(c.Request.Context(), channelID)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete channel.")
+		if err == channels.ErrChannelNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to get deleted channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	if deletedChannel.OwnerID != user.ID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	if !checkRestoreWindow(c, deletedChannel.DeletedOn) {
+		return
+	}
+
+	restoredChannel, err := dbBackend.RestoreChannel(c.Request.Context(), channelID)
+	if err != nil {
+		// Another Channel might have taken the name since it was deleted
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	recordAudit(c, audit.ChannelRestored, audit.ChannelTarget, channelID, deletedChannel, restoredChannel)
+
+	respondWithETag(c, http.StatusOK, restoredChannel.LastUpdated, restoredChannel)
 }
 
 // UpdateChannel updates the specified channel from the id in the request
 // path using the data in the request body.
 func UpdateChannel(c *gin.Context) {
-	// TODO: Validation. Name not empty, can't set ID/OwnerID, etc.
+	updateChannel(c, func(existing *channels.Channel) (*requests.Channel, error) {
+		req := &requests.Channel{}
+		return req, requests.Decode(c.Request.Body, req)
+	})
+}
+
+// PatchChannel updates only the fields of the specified channel that are in the
+// JSON merge patch in the request body.
+func PatchChannel(c *gin.Context) {
+	updateChannel(c, func(existing *channels.Channel) (*requests.Channel, error) {
+		req := requests.ChannelFrom(existing)
+		return req, requests.MergePatch(c.Request.Body, req)
+	})
+}
+
+// updateChannel updates the Channel from the path with the request that decode
+// reads from the body.
+func updateChannel(c *gin.Context, decode func(existing *channels.Channel) (*requests.Channel, error)) {
 	user := GetAuthenticatedUser(c)
 	dbBackend := GetDBBackend(c)
 
@@ -190,14 +264,7 @@ func UpdateChannel(c *gin.Context) {
 		return
 	}
 
-	channel := &channels.Channel{}
-	err = c.Bind(channel)
-	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
-		return
-	}
-
-	existingChannel, err := dbBackend.GetChannel(channelID)
+	existingChannel, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
 		if err == channels.ErrChannelNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
@@ -213,29 +280,56 @@ func UpdateChannel(c *gin.Context) {
 		return
 	}
 
-	updatedChannel, err := dbBackend.UpdateChannel(channelID, channel)
+	// Someone else may have changed it since the User last looked
+	expected, ok := checkIfMatch(c, existingChannel.LastUpdated)
+	if !ok {
+		return
+	}
+
+	req, err := decode(existingChannel)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedChannel)
+	// Only the fields in the request change, the rest are kept
+	channel := *existingChannel
+	req.Apply(&channel)
+
+	// The Bot only has to be checked again when the link changes
+	if channel.BotID != existingChannel.BotID || channel.BotChannel != existingChannel.BotChannel {
+		err = validateChannelBot(c.Request.Context(), dbBackend, user, &channel)
+		if err != nil {
+			c.AbortWithError(apierrors.StatusFor(err), err)
+			return
+		}
+	}
+
+	updatedChannel, err := dbBackend.UpdateChannel(c.Request.Context(), channelID, &channel, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	GetWebhookDispatcher(c).Dispatch(channelID, webhooks.ChannelUpdatedEvent, updatedChannel)
+
+	respondWithETag(c, http.StatusOK, updatedChannel.LastUpdated, updatedChannel)
 }
 
 // GetChannelsUserIsMember finds all of the Channels that the User is a member of which
 // means any Channel that the User has a Character in or owns.
-func GetChannelsUserIsMember(dbBackend backends.Backend, userID int) (channels.ChannelCollection, error) {
+func GetChannelsUserIsMember(ctx context.Context, dbBackend backends.Backend, userID int) (channels.ChannelCollection, error) {
 	// User is considered a member of any Channel that they own
-	ownedChannels, err := dbBackend.GetChannelsOwnedByUser(userID)
+	ownedChannels, err := dbBackend.GetChannelsOwnedByUser(ctx, userID)
 	if err != nil {
-		log.WithError(err).Error("Failed to look up channels owned by user.")
+		logging.FromContext(ctx).WithError(err).Error("Failed to look up channels owned by user.")
 		return nil, err
 	}
 
 	// Find all Channels that the User has a Character in
-	charChannels, err := dbBackend.GetChannelsUserHasCharacterIn(userID, nil)
+	charChannels, err := dbBackend.GetChannelsUserHasCharacterIn(ctx, userID, nil)
 	if err != nil {
-		log.WithError(err).Error("Failed to look up channels that user has a character in.")
+		logging.FromContext(ctx).WithError(err).Error("Failed to look up channels that user has a character in.")
 		return nil, err
 	}
 
@@ -244,20 +338,20 @@ func GetChannelsUserIsMember(dbBackend backends.Backend, userID int) (channels.C
 
 // GetChannelsUserCanAccess finds all Channels that a User has access to. This includes all public Channels,
 // any private Channels that they have a Character in, and also any Channels that they own.
-func GetChannelsUserCanAccess(dbBackend backends.Backend, userID int) (channels.ChannelCollection, error) {
+func GetChannelsUserCanAccess(ctx context.Context, dbBackend backends.Backend, userID int) (channels.ChannelCollection, error) {
 	// Look up all public channels
 	isPrivate := false
-	publicChannels, err := dbBackend.GetAllChannels(&isPrivate)
+	publicChannels, err := dbBackend.GetAllChannels(ctx, &isPrivate)
 	if err != nil {
-		log.WithError(err).Error("Failed to look up public channels.")
+		logging.FromContext(ctx).WithError(err).Error("Failed to look up public channels.")
 		return nil, err
 	}
 
 	// Look up private Channels that the User has a Character in
 	isPrivate = true
-	charChannels, err := dbBackend.GetChannelsUserHasCharacterIn(userID, &isPrivate)
+	charChannels, err := dbBackend.GetChannelsUserHasCharacterIn(ctx, userID, &isPrivate)
 	if err != nil {
-		log.WithError(err).Error("Failed to look up channels that user has a character in.")
+		logging.FromContext(ctx).WithError(err).Error("Failed to look up channels that user has a character in.")
 		return nil, err
 	}
 
@@ -265,9 +359,9 @@ func GetChannelsUserCanAccess(dbBackend backends.Backend, userID int) (channels.
 	outChannels := append(publicChannels, charChannels...)
 
 	// Look up channels owned by User
-	ownedChannels, err := dbBackend.GetChannelsOwnedByUser(userID)
+	ownedChannels, err := dbBackend.GetChannelsOwnedByUser(ctx, userID)
 	if err != nil {
-		log.WithError(err).Error("Failed to look up channels owned by user.")
+		logging.FromContext(ctx).WithError(err).Error("Failed to look up channels owned by user.")
 		return nil, err
 	}
 
@@ -276,6 +370,34 @@ func GetChannelsUserCanAccess(dbBackend backends.Backend, userID int) (channels.
 	return outChannels, nil
 }
 
+// validateChannelBot requires that the Channel's bot fields are either both blank
+// or both filled out with a Bot that exists and that the User owns. Admins can link
+// any Bot. Problems with the request are FieldErrors so the error's status can be
+// used as is.
+func validateChannelBot(ctx context.Context, dbBackend backends.Backend, user *users.User, channel *channels.Channel) error {
+	if channel.BotID == 0 && channel.BotChannel == "" {
+		return nil
+	}
+
+	if channel.BotID == 0 || channel.BotChannel == "" {
+		return apierrors.FieldErrors{{Field: "BotChannel", Message: "must be set along with BotID or both be blank"}}
+	}
+
+	bot, err := dbBackend.GetBot(ctx, channel.BotID)
+	if err != nil {
+		if err == bots.ErrBotNotFound {
+			return apierrors.FieldErrors{{Field: "BotID", Message: "must be an existing Bot"}}
+		}
+		logging.FromContext(ctx).WithError(err).Error("Failed to look up bot for channel.")
+		return err
+	}
+
+	if bot.OwnerID != user.ID && !user.IsAdmin {
+		return bots.ErrNotBotOwner
+	}
+	return nil
+}
+
 func concatUniqueChannels(cca channels.ChannelCollection, ccb channels.ChannelCollection) (newCC channels.ChannelCollection) {
 	m := make(map[int]*channels.Channel)
 	tmpCC := append(cca, ccb...)
@@ -3,13 +3,17 @@
 package middleware
 
 import (
-	"errors"
 	"net/http"
 
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
 	"github.com/andrew-boutin/dndtextapi/channels"
 	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // RegisterCharactersRoutes registers all of the character routes with their
@@ -18,8 +22,11 @@ func RegisterCharactersRoutes(g *gin.RouterGroup) {
 	g.GET("/channels/:channelID/characters", ValidateHeaders(acceptHeader), LoadChannelFromPathID, GetCharacters)
 	g.POST("/channels/:channelID/characters", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, CreateCharacter)
 	g.GET("/channels/:channelID/characters/:id", ValidateHeaders(acceptHeader), LoadChannelFromPathID, LoadCharacter, GetCharacter)
-	g.PUT("/channels/:channelID/characters/:id", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, UpdateCharacter)
+	g.PUT("/channels/:channelID/characters/:id", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, LoadCharacter, UpdateCharacter)
+	g.PATCH("/channels/:channelID/characters/:id", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, LoadCharacter, PatchCharacter)
 	g.DELETE("/channels/:channelID/characters/:id", LoadChannelFromPathID, LoadCharacter, DeleteCharacter)
+	g.POST("/channels/:channelID/characters/:id/turn", LoadChannelFromPathID, LoadCharacter, NotifyCharacterTurn)
+	g.POST("/channels/:channelID/characters/:id/restore", ValidateHeaders(acceptHeader), LoadChannelFromPathID, RestoreCharacter)
 }
 
 // GetCharacters retrieves all of the Characters in the Channel from the path. The
@@ -31,9 +38,9 @@ func GetCharacters(c *gin.Context) {
 
 	// If the User isn't the Channel owner then they have to have a Character in the Channel
 	if user.ID != channel.OwnerID {
-		isUserInChannel, err := dbBackend.DoesUserHaveCharacterInChannel(user.ID, channel.ID)
+		isUserInChannel, err := dbBackend.DoesUserHaveCharacterInChannel(c.Request.Context(), user.ID, channel.ID)
 		if err != nil {
-			log.WithError(err).Error("Failed to look up if user is in channel.")
+			GetLogger(c).WithError(err).Error("Failed to look up if user is in channel.")
 			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
@@ -44,9 +51,9 @@ func GetCharacters(c *gin.Context) {
 		}
 	}
 
-	charactersInChannel, err := dbBackend.GetCharactersInChannel(channel.ID)
+	charactersInChannel, err := dbBackend.GetCharactersInChannel(c.Request.Context(), channel.ID)
 	if err != nil {
-		log.WithError(err).Error("Failed to look up characters for channel.")
+		GetLogger(c).WithError(err).Error("Failed to look up characters for channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -57,13 +64,13 @@ func GetCharacters(c *gin.Context) {
 // CreateCharacter allows the Channel owner to create a new Character. This
 // is how Users are invited to a Channel.
 func CreateCharacter(c *gin.Context) {
-	// TODO: Can't fill in name
 	user := GetAuthenticatedUser(c)
 	dbBackend := GetDBBackend(c)
 	channel := c.MustGet(channelKey).(*channels.Channel)
 
-	character := &characters.Character{}
-	err := c.Bind(character)
+	// The invited User fills in the name when they accept
+	req := &requests.CreateCharacter{}
+	err := requests.Decode(c.Request.Body, req)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
@@ -74,14 +81,33 @@ func CreateCharacter(c *gin.Context) {
 		return
 	}
 
-	newCharacter, err := dbBackend.CreateCharacter(character)
+	// Users banned from the Channel can't be invited back until the Ban ends
+	isBanned, err := dbBackend.IsUserBannedFromChannel(c.Request.Context(), req.UserID, channel.ID)
 	if err != nil {
-		log.WithError(err).Error("Failed to create character.")
+		GetLogger(c).WithError(err).Error("Failed to check if invited user is banned from channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
+	if isBanned {
+		c.AbortWithError(http.StatusForbidden, bans.ErrBannedFromChannel)
+		return
+	}
+
+	newCharacter, err := dbBackend.CreateCharacter(c.Request.Context(), req.Character(channel.ID))
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	// Let the invited User know they have a Character waiting for them
+	GetNotifier(c).Notify(notifications.Notification{
+		Kind:        notifications.InvitationKind,
+		UserID:      newCharacter.UserID,
+		ChannelID:   channel.ID,
+		ChannelName: channel.Name,
+	})
 
-	c.JSON(http.StatusOK, newCharacter)
+	respondWithETag(c, http.StatusOK, newCharacter.LastUpdated, newCharacter)
 }
 
 // GetCharacter retrieves a single character using the id from the path. Anyone with a
@@ -94,9 +120,9 @@ func GetCharacter(c *gin.Context) {
 
 	// If the User isn't the Channel owner then they have to have a Character in the Channel
 	if user.ID != channel.OwnerID {
-		isUserInChannel, err := dbBackend.DoesUserHaveCharacterInChannel(user.ID, channel.ID)
+		isUserInChannel, err := dbBackend.DoesUserHaveCharacterInChannel(c.Request.Context(), user.ID, channel.ID)
 		if err != nil {
-			log.WithError(err).Error("Failed to look up if user is in channel.")
+			GetLogger(c).WithError(err).Error("Failed to look up if user is in channel.")
 			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
@@ -107,14 +133,31 @@ func GetCharacter(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, character)
+	respondWithETag(c, http.StatusOK, character.LastUpdated, character)
 }
 
 // UpdateCharacter allows the Character owner to update their Character. The Character
 // is determined by the ID in the path and the data used for updating comes from the
 // request body.
 func UpdateCharacter(c *gin.Context) {
-	// TODO: Now name is required
+	updateCharacter(c, func(existing *characters.Character) (*requests.UpdateCharacter, error) {
+		req := &requests.UpdateCharacter{}
+		return req, requests.Decode(c.Request.Body, req)
+	})
+}
+
+// PatchCharacter allows the Character owner to update only the fields of their
+// Character that are in the JSON merge patch in the request body.
+func PatchCharacter(c *gin.Context) {
+	updateCharacter(c, func(existing *characters.Character) (*requests.UpdateCharacter, error) {
+		req := requests.UpdateCharacterFrom(existing)
+		return req, requests.MergePatch(c.Request.Body, req)
+	})
+}
+
+// updateCharacter updates the Character from the path with the request that decode
+// reads from the body.
+func updateCharacter(c *gin.Context, decode func(existing *characters.Character) (*requests.UpdateCharacter, error)) {
 	user := GetAuthenticatedUser(c)
 	dbBackend := GetDBBackend(c)
 	existingCharacter := c.MustGet(characterKey).(*characters.Character)
@@ -125,26 +168,30 @@ func UpdateCharacter(c *gin.Context) {
 		return
 	}
 
-	character := &characters.Character{}
-	err := c.Bind(character)
-	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+	// Someone else may have changed it since the User last looked
+	expected, ok := checkIfMatch(c, existingCharacter.LastUpdated)
+	if !ok {
 		return
 	}
 
-	if character.Name == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("name is required"))
+	req, err := decode(existingCharacter)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	updatedCharacter, err := dbBackend.UpdateCharacter(existingCharacter.ID, character)
+	updatedCharacter, err := dbBackend.UpdateCharacter(c.Request.Context(), existingCharacter.ID, req.Character(), expected)
 	if err != nil {
-		log.WithError(err).Error("Failed to update existing character.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedCharacter)
+	// Naming the Character for the first time is how a User accepts the invitation
+	if existingCharacter.Name == "" {
+		GetWebhookDispatcher(c).Dispatch(existingCharacter.ChannelID, webhooks.CharacterJoinedEvent, updatedCharacter)
+	}
+
+	respondWithETag(c, http.StatusOK, updatedCharacter.LastUpdated, updatedCharacter)
 }
 
 // DeleteCharacter allows either the Channel owner or the Character owner to delete
@@ -161,46 +208,134 @@ func DeleteCharacter(c *gin.Context) {
 		return
 	}
 
-	err := dbBackend.DeleteMessagesFromCharacter(character.ID)
+	expected, ok := checkIfMatch(c, character.LastUpdated)
+	if !ok {
+		return
+	}
+
+	// The Character's Messages are deleted along with it
+	err := dbBackend.DeleteCharacter(c.Request.Context(), character.ID, expected)
 	if err != nil {
-		log.WithError(err).WithField("characterID", character.ID).Error("Failed to delete messages from character.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	// Only the Channel owner removing someone else's Character is recorded
+	if user.ID != character.UserID {
+		recordAudit(c, audit.CharacterDeleted, audit.CharacterTarget, character.ID, character, nil)
+	}
+
+	GetWebhookDispatcher(c).Dispatch(character.ChannelID, webhooks.CharacterLeftEvent, character)
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreCharacter brings back the deleted Character along with the Messages that
+// were deleted with it. Either the Channel owner or the Character owner can restore
+// it within the restore window.
+func RestoreCharacter(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	characterID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	err = dbBackend.DeleteCharacter(character.ID)
+	character, err := dbBackend.GetDeletedCharacter(c.Request.Context(), characterID)
 	if err != nil {
-		log.WithError(err).WithField("characterID", character.ID).Error("Failed to delete character.")
+		if err == characters.ErrCharacterNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).WithField("characterID", characterID).Error("Failed to get deleted character.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	// The Character has to be from the Channel in the path
+	if character.ChannelID != channel.ID {
+		c.AbortWithError(http.StatusNotFound, characters.ErrCharacterNotFound)
+		return
+	}
+
+	if user.ID != channel.OwnerID && user.ID != character.UserID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	if !checkRestoreWindow(c, character.DeletedOn) {
+		return
+	}
+
+	restoredCharacter, err := dbBackend.RestoreCharacter(c.Request.Context(), characterID)
+	if err != nil {
+		// The User might have been given a new Character since
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	if user.ID != character.UserID {
+		recordAudit(c, audit.CharacterRestored, audit.CharacterTarget, characterID, character, restoredCharacter)
+	}
+
+	respondWithETag(c, http.StatusOK, restoredCharacter.LastUpdated, restoredCharacter)
+}
+
+// NotifyCharacterTurn allows the Channel owner or DM to let the User who owns
+// the Character know that it's their turn.
+func NotifyCharacterTurn(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+	character := c.MustGet(characterKey).(*characters.Character)
+
+	if user.ID != channel.OwnerID && user.ID != channel.DMID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	GetNotifier(c).Notify(notifications.Notification{
+		Kind:        notifications.TurnKind,
+		UserID:      character.UserID,
+		ChannelID:   channel.ID,
+		ChannelName: channel.Name,
+	})
+
+	c.Status(http.StatusAccepted)
 }
 
 // LoadCharacter attempts to lookup the Character using the Character ID in the path
-// and stores it in the context so the later middleware doesn't have to do it.
+// and stores it in the context so the later middleware doesn't have to do it. The
+// Character has to be in the Channel that was already loaded from the path.
 func LoadCharacter(c *gin.Context) {
 	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
 
 	characterID, err := PathParamAsIntExtractor(c, idPathParam)
 	if err != nil {
-		log.WithError(err).Error("Failed to get character id from path.")
+		GetLogger(c).WithError(err).Error("Failed to get character id from path.")
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	character, err := dbBackend.GetCharacter(characterID)
+	character, err := dbBackend.GetCharacter(c.Request.Context(), characterID)
 	if err != nil {
 		if err == characters.ErrCharacterNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
 
-		log.WithError(err).WithField("characterID", characterID).Error("Failed look up character.")
+		GetLogger(c).WithError(err).WithField("characterID", characterID).Error("Failed look up character.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
+	if character.ChannelID != channel.ID {
+		c.AbortWithError(http.StatusNotFound, characters.ErrCharacterNotFound)
+		return
+	}
+
 	c.Set(characterKey, character)
 }
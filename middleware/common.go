@@ -6,11 +6,20 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/andrew-boutin/dndtextapi/backends"
+	"github.com/andrew-boutin/dndtextapi/bridges/slack"
 	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/metrics"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
+	"github.com/andrew-boutin/dndtextapi/tracing"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -19,12 +28,15 @@ const (
 	acceptHeader      = "accept"
 
 	// Context keys
-	dbBackendKey = "dbBackendKey"
-	channelKey   = "channelKey"
-	characterKey = "characterKey"
+	dbBackendKey         = "dbBackendKey"
+	notifierKey          = "notifierKey"
+	webhookDispatcherKey = "webhookDispatcherKey"
+	channelKey           = "channelKey"
+	characterKey         = "characterKey"
 
 	// Other
 	applicationJSONHeaderVal = "application/json"
+	mergePatchHeaderVal      = "application/merge-patch+json"
 	anyMedia                 = "*/*"
 	idPathParam              = "id"
 	channelIDPathParam       = "channelID"
@@ -42,6 +54,49 @@ const (
 	levelQueryParam = "level"
 	ownerLevel      = "owner"
 	memberLevel     = "member"
+
+	// afterQueryParam and limitQueryParam page through Messages.
+	afterQueryParam = "after"
+	limitQueryParam = "limit"
+	maxPageLimit    = 500
+
+	// statusQueryParam, targetTypeQueryParam, and assigneeQueryParam filter
+	// Reports. An assignee of 0 is unassigned.
+	statusQueryParam     = "status"
+	targetTypeQueryParam = "targetType"
+	assigneeQueryParam   = "assignee"
+
+	// actorQueryParam, actionQueryParam, targetIDQueryParam, sinceQueryParam, and
+	// untilQueryParam filter the audit log along with targetTypeQueryParam and
+	// limitQueryParam. Times are RFC 3339.
+	actorQueryParam    = "actor"
+	actionQueryParam   = "action"
+	targetIDQueryParam = "targetID"
+	sinceQueryParam    = "since"
+	untilQueryParam    = "until"
+
+	// sortQueryParam, orderQueryParam, and offsetQueryParam order and page the
+	// admin lists of Users and Channels along with limitQueryParam. The order can
+	// be `asc` or `desc`.
+	sortQueryParam   = "sort"
+	orderQueryParam  = "order"
+	offsetQueryParam = "offset"
+	ascendingOrder   = "asc"
+	descendingOrder  = "desc"
+
+	// adminQueryParam, bannedQueryParam, loggedInSinceQueryParam, and
+	// loggedInBeforeQueryParam filter the admin list of Users. privateQueryParam
+	// and ownerQueryParam filter the admin list of Channels.
+	adminQueryParam          = "admin"
+	bannedQueryParam         = "banned"
+	loggedInSinceQueryParam  = "loggedInSince"
+	loggedInBeforeQueryParam = "loggedInBefore"
+	privateQueryParam        = "private"
+	ownerQueryParam          = "owner"
+
+	// weeksQueryParam and topQueryParam pick how much the admin Stats cover.
+	weeksQueryParam = "weeks"
+	topQueryParam   = "top"
 )
 
 var acceptHeaderValsAllowed = []string{applicationJSONHeaderVal, anyMedia}
@@ -62,30 +117,85 @@ var (
 
 	// ErrQueryParamNotInt
 	ErrQueryParamNotInt = fmt.Errorf("expected query parameter to be an integer")
+
+	// ErrQueryParamNotBool is the error to use when a parameter in the query
+	// string is expected to be `true` or `false` but isn't.
+	ErrQueryParamNotBool = fmt.Errorf("expected query parameter to be true or false")
+
+	// ErrQueryParamNotTime is the error to use when a parameter in the query
+	// string is expected to be an RFC 3339 time but isn't.
+	ErrQueryParamNotTime = fmt.Errorf("expected query parameter to be an RFC 3339 time")
 )
 
 // RegisterMiddleware handles registering all common middleware
 // and registering all of the various route groups.
-func RegisterMiddleware(r *gin.Engine, backend backends.Backend) {
+func RegisterMiddleware(r *gin.Engine, backend backends.Backend, notifier *notifications.Notifier, dispatcher *webhooks.Dispatcher, slackBridge *slack.Bridge, limiter *ratelimit.Limiter, registry *metrics.Registry, tracer *tracing.Tracer, server configs.ServerConfiguration, retention configs.RetentionConfiguration) {
 	// TODO: Is it possible to register a middleware at the beginning of all PUT/GET etc. routes?
-	r.Use(ContextInjectionMiddleware(backend))
+	r.Use(RequestIDMiddleware)
+
+	// Health checks are polled constantly so they're registered before the access
+	// log to keep them out of it
+	RegisterHealthRoutes(r, backend)
+
+	r.Use(AccessLogMiddleware(r))
+
+	// CORS is off unless origins are configured
+	if len(server.CORSOrigins) > 0 {
+		r.Use(CORSMiddleware(server.CORSOrigins))
+	}
+
+	requireIfMatch = server.RequireIfMatch
+	restoreWindow = retention.Window
+
+	// Tracing is optional
+	if tracer != nil {
+		r.Use(TracingMiddleware(r, tracer))
+	}
+
+	// Metrics are optional and are served before everything else so scraping them
+	// doesn't need authentication
+	if registry != nil {
+		r.Use(MetricsMiddleware(r))
+		RegisterMetricsRoutes(r, registry)
+	}
+
+	r.Use(ErrorHandler)
+	r.Use(ContextInjectionMiddleware(backend, notifier, dispatcher, limiter))
 
 	RegisterAnonymousRoutes(r)
 
 	RegisterAuthenticationRoutes(r)
 
-	authorized := r.Group("/")
-	authorized.Use(AuthenticationMiddleware)
-
-	RegisterChannelsRoutes(authorized)
-	RegisterUsersRoutes(authorized)
-	RegisterMessagesRoutes(authorized)
-	RegisterCharactersRoutes(authorized)
+	// The Slack bridge is optional
+	if slackBridge != nil {
+		RegisterSlackRoutes(r, slackBridge)
+	}
 
-	// Set up all of the admin only routes
-	admin := authorized.Group("/") // TODO: want this to be `/admin`
-	admin.Use(RequireAdminHandler)
+	authorized := r.Group("/")
+	authorized.Use(AuthenticationMiddleware, ImpersonationMiddleware)
+
+	// The admin routes have their own rate limit so they're kept out of this group
+	members := authorized.Group("/")
+	members.Use(RateLimitMiddleware(ratelimit.AuthenticatedGroup))
+
+	RegisterChannelsRoutes(members)
+	RegisterUsersRoutes(members)
+	RegisterMessagesRoutes(members)
+	RegisterCharactersRoutes(members)
+	RegisterWebhooksRoutes(members)
+	RegisterBotsRoutes(members)
+	RegisterTokensRoutes(members)
+	RegisterReportsRoutes(members)
+	RegisterBansRoutes(members)
+	RegisterImpersonationRoutes(members)
+
+	// Set up all of the admin only routes under /admin
+	admin := authorized.Group("/admin")
+	admin.Use(RequireAdminHandler, RateLimitMiddleware(ratelimit.AdminGroup))
 	RegisterAdminRoutes(admin)
+
+	// Has to come last since it documents all of the routes above
+	RegisterOpenAPIRoutes(r)
 }
 
 // GetDBBackend pulls the db backend out of the context that
@@ -94,12 +204,22 @@ func GetDBBackend(c *gin.Context) backends.Backend {
 	return c.MustGet(dbBackendKey).(backends.Backend)
 }
 
+// GetNotifier pulls the notifier out of the context that was previously
+// injected. The notifier is nil when notifications are disabled which is
+// still safe to use.
+func GetNotifier(c *gin.Context) *notifications.Notifier {
+	return c.MustGet(notifierKey).(*notifications.Notifier)
+}
+
 // ContextInjectionMiddleware injects various data into the context
 // so that it will be available throughout the rest of the middleware
 // that executes on the route.
-func ContextInjectionMiddleware(backend backends.Backend) gin.HandlerFunc {
+func ContextInjectionMiddleware(backend backends.Backend, notifier *notifications.Notifier, dispatcher *webhooks.Dispatcher, limiter *ratelimit.Limiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Set(dbBackendKey, backend)
+		c.Set(notifierKey, notifier)
+		c.Set(webhookDispatcherKey, dispatcher)
+		c.Set(rateLimiterKey, limiter)
 	}
 }
 
@@ -119,14 +239,16 @@ func ValidateHeaders(headers ...string) gin.HandlerFunc {
 					acceptVal := c.NegotiateFormat(acceptHeaderValsAllowed...)
 					if acceptVal == "" {
 						// TODO: 415 Unsupported Media Type?
-						log.WithField(acceptHeader, val).Error("Invalid header value.")
+						GetLogger(c).WithField(acceptHeader, val).Error("Invalid header value.")
 						c.AbortWithError(http.StatusBadRequest, c.Error(fmt.Errorf("invalid %s header value %s", acceptHeader, val)))
 						return
 					}
 				case contentTypeHeader:
-					if val != applicationJSONHeaderVal {
+					// PATCH bodies are merge patches but plain JSON is accepted too
+					isMergePatch := c.Request.Method == http.MethodPatch && val == mergePatchHeaderVal
+					if val != applicationJSONHeaderVal && !isMergePatch {
 						// TODO: 415 Unsupported Media Type?
-						log.WithField(contentTypeHeader, val).Error("Invalid header value.")
+						GetLogger(c).WithField(contentTypeHeader, val).Error("Invalid header value.")
 						c.AbortWithError(http.StatusBadRequest, c.Error(fmt.Errorf("invalid %s header value %s", contentTypeHeader, val)))
 						return
 					}
@@ -196,6 +318,61 @@ func QueryParamAsIntExtractor(c *gin.Context, name string) (int, error) {
 	return pInt, nil
 }
 
+// OptionalBoolQueryParam extracts the query parameter from the gin.Context by using
+// the given name and returns it as a bool, or nil if it isn't there.
+func OptionalBoolQueryParam(c *gin.Context, name string) (*bool, error) {
+	pStr, err := QueryParamExtractor(c, name)
+	if err == ErrQueryParamNotFound {
+		return nil, nil
+	}
+
+	pBool, err := strconv.ParseBool(pStr)
+	if err != nil {
+		return nil, ErrQueryParamNotBool
+	}
+
+	return &pBool, nil
+}
+
+// OptionalTimeQueryParam extracts the query parameter from the gin.Context by using
+// the given name and returns it as a time, or nil if it isn't there.
+func OptionalTimeQueryParam(c *gin.Context, name string) (*time.Time, error) {
+	pStr, err := QueryParamExtractor(c, name)
+	if err == ErrQueryParamNotFound {
+		return nil, nil
+	}
+
+	pTime, err := time.Parse(time.RFC3339, pStr)
+	if err != nil {
+		return nil, ErrQueryParamNotTime
+	}
+
+	return &pTime, nil
+}
+
+// PageFromQuery builds the Message Page from the optional `after` and `limit`
+// query parameters. Limits above the max are lowered to the max.
+func PageFromQuery(c *gin.Context) (*messages.Page, error) {
+	page := &messages.Page{}
+
+	after, err := QueryParamAsIntExtractor(c, afterQueryParam)
+	if err != nil && err != ErrQueryParamNotFound {
+		return nil, err
+	}
+	page.AfterID = after
+
+	limit, err := QueryParamAsIntExtractor(c, limitQueryParam)
+	if err != nil && err != ErrQueryParamNotFound {
+		return nil, err
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	page.Limit = limit
+
+	return page, nil
+}
+
 // LoadChannelFromPathID attempts to lookup the Channel using the Channel ID in the path and stores it
 // in the context so the later middleware doesn't have to do it.
 func LoadChannelFromPathID(c *gin.Context) {
@@ -203,22 +380,29 @@ func LoadChannelFromPathID(c *gin.Context) {
 
 	channelID, err := PathParamAsIntExtractor(c, channelIDPathParam)
 	if err != nil {
-		log.WithError(err).Error("Failed to get channel id from path.")
+		GetLogger(c).WithError(err).Error("Failed to get channel id from path.")
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	channel, err := dbBackend.GetChannel(channelID)
+	channel, err := dbBackend.GetChannel(c.Request.Context(), channelID)
 	if err != nil {
 		if err == channels.ErrChannelNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
 
-		log.WithError(err).WithField("channelID", channelID).Error("Failed look up channel.")
+		GetLogger(c).WithError(err).WithField("channelID", channelID).Error("Failed look up channel.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
+	// Users banned from the Channel are kept out. Anonymous routes have no User.
+	if user, ok := c.Get(userContextKey); ok {
+		if !checkNotBannedFromChannel(c, user.(*users.User), channel.ID) {
+			return
+		}
+	}
+
 	c.Set(channelKey, channel)
 }
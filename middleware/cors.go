@@ -0,0 +1,67 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	originHeader           = "Origin"
+	varyHeader             = "Vary"
+	allowOriginHeader      = "Access-Control-Allow-Origin"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	exposeHeadersHeader    = "Access-Control-Expose-Headers"
+	maxAgeHeader           = "Access-Control-Max-Age"
+	requestMethodHeader    = "Access-Control-Request-Method"
+
+	anyOrigin = "*"
+)
+
+var (
+	corsAllowedMethods = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, ", ")
+	corsAllowedHeaders = strings.Join([]string{authorizationHeader, "Content-Type", requestIDHeader, ifMatchHeader, ifNoneMatchHeader}, ", ")
+	corsExposedHeaders = strings.Join([]string{requestIDHeader, etagHeader, retryAfterHeader, rateLimitLimitHeader, rateLimitRemainingHeader, rateLimitResetHeader, impersonatingHeader, impersonatedByHeader}, ", ")
+)
+
+// CORSMiddleware lets browsers on the allowed origins call the API. Requests from
+// a specific origin can include the session cookie but requests allowed by `*`
+// can't. Preflight requests are answered here since there aren't OPTIONS routes.
+func CORSMiddleware(origins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader(originHeader)
+		if origin == "" {
+			return
+		}
+
+		c.Writer.Header().Add(varyHeader, originHeader)
+		switch {
+		case allowed[origin]:
+			c.Header(allowOriginHeader, origin)
+			c.Header(allowCredentialsHeader, "true")
+		case allowed[anyOrigin]:
+			c.Header(allowOriginHeader, anyOrigin)
+		default:
+			// The browser blocks the response without the allow header
+			return
+		}
+		c.Header(exposeHeadersHeader, corsExposedHeaders)
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader(requestMethodHeader) != "" {
+			c.Header(allowMethodsHeader, corsAllowedMethods)
+			c.Header(allowHeadersHeader, corsAllowedHeaders)
+			c.Header(maxAgeHeader, "600")
+			c.AbortWithStatus(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	type testIO struct {
+		desc                string
+		origins             []string
+		method              string
+		origin              string
+		expectedStatus      int
+		expectedAllow       string
+		expectedCredentials string
+	}
+
+	tests := []testIO{
+		{desc: "No origin", origins: []string{"https://dndtext.example.com"}, method: http.MethodGet, expectedStatus: http.StatusOK},
+		{desc: "Allowed origin", origins: []string{"https://dndtext.example.com"}, method: http.MethodGet, origin: "https://dndtext.example.com", expectedStatus: http.StatusOK, expectedAllow: "https://dndtext.example.com", expectedCredentials: "true"},
+		{desc: "Other origin", origins: []string{"https://dndtext.example.com"}, method: http.MethodGet, origin: "https://evil.example.com", expectedStatus: http.StatusOK},
+		{desc: "Any origin", origins: []string{"*"}, method: http.MethodGet, origin: "https://evil.example.com", expectedStatus: http.StatusOK, expectedAllow: "*"},
+		{desc: "Preflight", origins: []string{"https://dndtext.example.com/"}, method: http.MethodOptions, origin: "https://dndtext.example.com", expectedStatus: http.StatusNoContent, expectedAllow: "https://dndtext.example.com", expectedCredentials: "true"},
+		{desc: "Preflight from other origin", origins: []string{"https://dndtext.example.com"}, method: http.MethodOptions, origin: "https://evil.example.com", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CORSMiddleware(test.origins))
+			r.GET("/channels", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/channels", nil)
+			if test.origin != "" {
+				req.Header.Set(originHeader, test.origin)
+			}
+			if test.method == http.MethodOptions {
+				req.Header.Set(requestMethodHeader, http.MethodGet)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, test.expectedAllow, w.Header().Get(allowOriginHeader))
+			assert.Equal(t, test.expectedCredentials, w.Header().Get(allowCredentialsHeader))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/dchest/uniuri"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// requestIDHeader carries the request id in both the request and response.
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "requestIDKey"
+
+	requestIDLength = 16
+
+	// maxRequestIDLength is the longest request id a caller can send
+	maxRequestIDLength = 64
+)
+
+// validRequestID is what a request id sent by a caller has to look like so it's
+// safe to put in the logs and response headers.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// RequestIDMiddleware uses the request id sent by the caller, or makes a new one,
+// and echoes it back so requests can be traced through logs and error responses.
+// Request ids that are too long or have anything other than letters, digits, dots,
+// dashes, and underscores are replaced with a new one.
+func RequestIDMiddleware(c *gin.Context) {
+	requestID := c.GetHeader(requestIDHeader)
+	if len(requestID) > maxRequestIDLength || !validRequestID.MatchString(requestID) {
+		requestID = uniuri.NewLen(requestIDLength)
+	}
+
+	c.Set(requestIDKey, requestID)
+	c.Header(requestIDHeader, requestID)
+}
+
+// GetRequestID pulls the request id out of the context.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// errorWriter holds off on writing error status codes so the error envelope
+// can still set headers after a handler aborts.
+type errorWriter struct {
+	gin.ResponseWriter
+}
+
+func (w *errorWriter) WriteHeaderNow() {
+	if w.Status() >= http.StatusBadRequest {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// ErrorHandler renders every error response that a handler didn't write a body for
+// using the JSON error envelope. Handlers keep using AbortWithStatus and AbortWithError.
+// Details of server errors, including panics, are logged but never sent back.
+func ErrorHandler(c *gin.Context) {
+	c.Writer = &errorWriter{c.Writer}
+
+	defer func() {
+		if r := recover(); r != nil {
+			GetLogger(c).WithField("panic", r).Error("Recovered from panic.")
+			c.AbortWithStatus(http.StatusInternalServerError)
+			renderError(c)
+		}
+	}()
+
+	c.Next()
+	renderError(c)
+}
+
+// renderError writes the error envelope if the response is an error without a body.
+// Errors recorded with c.Error without an error status get the status for the error.
+func renderError(c *gin.Context) {
+	if c.Writer.Written() {
+		return
+	}
+
+	var err error
+	if last := c.Errors.Last(); last != nil {
+		err = last.Err
+	}
+
+	status := c.Writer.Status()
+	if status < http.StatusBadRequest {
+		if err == nil {
+			return
+		}
+		status = apierrors.StatusFor(err)
+	}
+
+	if status >= http.StatusInternalServerError && err != nil {
+		GetLogger(c).WithError(err).Error("Request failed with server error.")
+	}
+
+	c.JSON(status, apierrors.Response{Error: apierrors.New(status, err, GetRequestID(c))})
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	testIO := []struct {
+		desc           string
+		handler        gin.HandlerFunc
+		expectedStatus int
+		expected       apierrors.Error
+	}{
+		{
+			desc:           "Status without an error.",
+			handler:        func(c *gin.Context) { c.AbortWithStatus(http.StatusForbidden) },
+			expectedStatus: http.StatusForbidden,
+			expected:       apierrors.Error{Code: apierrors.ForbiddenCode, Message: "forbidden"},
+		},
+		{
+			desc:           "Sentinel error.",
+			handler:        func(c *gin.Context) { c.AbortWithError(http.StatusNotFound, users.ErrUserNotFound) },
+			expectedStatus: http.StatusNotFound,
+			expected:       apierrors.Error{Code: "user_not_found", Message: users.ErrUserNotFound.Error()},
+		},
+		{
+			desc:           "Sentinel error without a status.",
+			handler:        func(c *gin.Context) { c.Error(users.ErrUserNotFound) },
+			expectedStatus: http.StatusNotFound,
+			expected:       apierrors.Error{Code: "user_not_found", Message: users.ErrUserNotFound.Error()},
+		},
+		{
+			desc: "Field errors.",
+			handler: func(c *gin.Context) {
+				c.AbortWithError(http.StatusBadRequest, apierrors.FieldErrors{{Field: "Name", Message: "is required"}})
+			},
+			expectedStatus: http.StatusBadRequest,
+			expected: apierrors.Error{
+				Code:    apierrors.ValidationFailedCode,
+				Message: "request body failed validation",
+				Fields:  []apierrors.FieldError{{Field: "Name", Message: "is required"}},
+			},
+		},
+		{
+			desc: "Server error details are hidden.",
+			handler: func(c *gin.Context) {
+				c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("pq: connection refused"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expected:       apierrors.Error{Code: apierrors.InternalErrorCode, Message: "internal server error"},
+		},
+		{
+			desc:           "Panics become server errors.",
+			handler:        func(c *gin.Context) { panic("boom") },
+			expectedStatus: http.StatusInternalServerError,
+			expected:       apierrors.Error{Code: apierrors.InternalErrorCode, Message: "internal server error"},
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			r := gin.New()
+			r.Use(RequestIDMiddleware, ErrorHandler)
+			r.GET("/", test.handler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(requestIDHeader, "abc")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, "abc", w.Header().Get(requestIDHeader))
+
+			resp := apierrors.Response{}
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+			test.expected.RequestID = "abc"
+			assert.Equal(t, test.expected, resp.Error)
+		})
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	testIO := []struct {
+		desc      string
+		requestID string
+	}{
+		{desc: "None sent", requestID: ""},
+		{desc: "Too long", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+		{desc: "Bad characters", requestID: "abc\ninjected log line"},
+		{desc: "Spaces", requestID: "abc def"},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			r := gin.New()
+			r.Use(RequestIDMiddleware)
+			r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, GetRequestID(c)) })
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header[requestIDHeader] = []string{test.requestID}
+			r.ServeHTTP(w, req)
+
+			assert.Len(t, w.Body.String(), requestIDLength)
+			assert.NotEqual(t, test.requestID, w.Body.String())
+			assert.Equal(t, w.Body.String(), w.Header().Get(requestIDHeader))
+		})
+	}
+}
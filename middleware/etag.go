@@ -0,0 +1,92 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	etagHeader        = "ETag"
+	ifMatchHeader     = "If-Match"
+	ifNoneMatchHeader = "If-None-Match"
+
+	anyETag = "*"
+)
+
+// requireIfMatch makes changes without an If-Match header fail instead of going
+// through unconditionally. It's set from the server config.
+var requireIfMatch bool
+
+// etag is the entity tag for the version of a resource last updated at the time.
+// Every update changes the time so it changes the tag too.
+func etag(lastUpdated time.Time) string {
+	return `"` + strconv.FormatInt(lastUpdated.UnixNano(), 36) + `"`
+}
+
+// etagsMatch is whether the header value, a list of entity tags or `*`, has the
+// tag. Weak tags never match since the comparison has to be strong.
+func etagsMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == anyETag || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithETag writes the resource as JSON along with its ETag. GET requests
+// whose If-None-Match has the ETag get a 304 without a body instead.
+func respondWithETag(c *gin.Context, status int, lastUpdated time.Time, obj interface{}) {
+	tag := etag(lastUpdated)
+	c.Header(etagHeader, tag)
+
+	ifNoneMatch := c.GetHeader(ifNoneMatchHeader)
+	if c.Request.Method == http.MethodGet && ifNoneMatch != "" && etagsMatch(ifNoneMatch, tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(status, obj)
+}
+
+// hasPrecondition is whether checkIfMatch has anything to check. Handlers that
+// don't otherwise need the current resource only look it up when this is true.
+func hasPrecondition(c *gin.Context) bool {
+	return requireIfMatch || c.GetHeader(ifMatchHeader) != ""
+}
+
+// checkIfMatch makes sure the caller is changing the version of the resource they
+// last saw. It aborts with a 412 if the If-Match header doesn't have the current
+// ETag, or a 428 if the header is required but missing, and returns false.
+// Otherwise it returns the LastUpdated time the change has to be made at so the
+// Backend can make sure nothing changed in between. That's nil when there isn't a
+// version to hold the change to.
+func checkIfMatch(c *gin.Context, lastUpdated time.Time) (*time.Time, bool) {
+	ifMatch := c.GetHeader(ifMatchHeader)
+	if ifMatch == "" {
+		if requireIfMatch {
+			c.AbortWithError(http.StatusPreconditionRequired, apierrors.ErrPreconditionRequired)
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if !etagsMatch(ifMatch, etag(lastUpdated)) {
+		c.AbortWithError(http.StatusPreconditionFailed, apierrors.ErrPreconditionFailed)
+		return nil, false
+	}
+
+	// `*` matches whatever version there is
+	if strings.TrimSpace(ifMatch) == anyETag {
+		return nil, true
+	}
+	return &lastUpdated, true
+}
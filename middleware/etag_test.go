@@ -0,0 +1,115 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespondWithETag(t *testing.T) {
+	lastUpdated := time.Date(2018, 3, 4, 5, 6, 7, 8000, time.UTC)
+	current := etag(lastUpdated)
+
+	type testIO struct {
+		desc        string
+		method      string
+		ifNoneMatch string
+		expected    int
+	}
+
+	tests := []testIO{
+		{desc: "No header", method: http.MethodGet, expected: http.StatusOK},
+		{desc: "Current version", method: http.MethodGet, ifNoneMatch: current, expected: http.StatusNotModified},
+		{desc: "Current version in a list", method: http.MethodGet, ifNoneMatch: `"old", ` + current, expected: http.StatusNotModified},
+		{desc: "Any version", method: http.MethodGet, ifNoneMatch: "*", expected: http.StatusNotModified},
+		{desc: "Old version", method: http.MethodGet, ifNoneMatch: `"old"`, expected: http.StatusOK},
+		{desc: "Not a GET", method: http.MethodPut, ifNoneMatch: current, expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			r := gin.New()
+			r.Handle(test.method, "/channels/1", func(c *gin.Context) {
+				respondWithETag(c, http.StatusOK, lastUpdated, gin.H{"ID": 1})
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/channels/1", nil)
+			if test.ifNoneMatch != "" {
+				req.Header.Set(ifNoneMatchHeader, test.ifNoneMatch)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expected, w.Code)
+			assert.Equal(t, current, w.Header().Get(etagHeader))
+			if test.expected == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	defer func() { requireIfMatch = false }()
+
+	lastUpdated := time.Date(2018, 3, 4, 5, 6, 7, 8000, time.UTC)
+	current := etag(lastUpdated)
+
+	type testIO struct {
+		desc     string
+		ifMatch  string
+		required bool
+		expected int
+
+		// versioned is whether the change has to be made to the current version
+		versioned bool
+	}
+
+	tests := []testIO{
+		{desc: "No header", expected: http.StatusNoContent},
+		{desc: "No header when required", required: true, expected: http.StatusPreconditionRequired},
+		{desc: "Current version", ifMatch: current, expected: http.StatusNoContent, versioned: true},
+		{desc: "One of the versions", ifMatch: `"other", ` + current, expected: http.StatusNoContent, versioned: true},
+		{desc: "Any version", ifMatch: "*", required: true, expected: http.StatusNoContent},
+		{desc: "Old version", ifMatch: etag(lastUpdated.Add(-time.Second)), expected: http.StatusPreconditionFailed},
+		{desc: "Weak version", ifMatch: "W/" + current, expected: http.StatusPreconditionFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			requireIfMatch = test.required
+
+			var version *time.Time
+			r := gin.New()
+			r.Use(ErrorHandler)
+			r.PUT("/channels/1", func(c *gin.Context) {
+				expected, ok := checkIfMatch(c, lastUpdated)
+				if !ok {
+					return
+				}
+				version = expected
+				c.Status(http.StatusNoContent)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/channels/1", nil)
+			if test.ifMatch != "" {
+				req.Header.Set(ifMatchHeader, test.ifMatch)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expected, w.Code)
+			if test.versioned {
+				assert.Equal(t, &lastUpdated, version)
+			} else {
+				assert.Nil(t, version)
+			}
+		})
+	}
+}
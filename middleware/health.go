@@ -0,0 +1,63 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout is how long the readiness check waits on the backend.
+const readinessTimeout = 2 * time.Second
+
+// shuttingDown is set once the server starts shutting down so it stops getting
+// new traffic while in-flight requests finish.
+var shuttingDown int32
+
+// HealthChecker is anything that can report whether it's able to serve requests.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// MarkShuttingDown makes the readiness check fail from now on.
+func MarkShuttingDown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+// RegisterHealthRoutes adds the liveness and readiness routes used by the
+// orchestrator. They don't need authentication.
+func RegisterHealthRoutes(r *gin.Engine, checker HealthChecker) {
+	r.GET("/healthz", LivenessHandler)
+	r.GET("/readyz", ReadinessHandler(checker))
+}
+
+// LivenessHandler reports that the process is up.
+func LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadinessHandler reports whether the server should get traffic. It isn't ready
+// while shutting down or when the backend can't be reached.
+func ReadinessHandler(checker HealthChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if atomic.LoadInt32(&shuttingDown) == 1 {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		if err := checker.HealthCheck(ctx); err != nil {
+			GetLogger(c).WithError(err).Warn("Readiness check failed.")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "backend unavailable"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHealthChecker struct {
+	err error
+}
+
+func (f fakeHealthChecker) HealthCheck(ctx context.Context) error {
+	return f.err
+}
+
+func TestHealthRoutes(t *testing.T) {
+	defer atomic.StoreInt32(&shuttingDown, 0)
+
+	type testIO struct {
+		desc         string
+		path         string
+		err          error
+		shuttingDown bool
+		expected     int
+	}
+
+	tests := []testIO{
+		{desc: "Liveness", path: "/healthz", expected: http.StatusOK},
+		{desc: "Liveness ignores the backend", path: "/healthz", err: fmt.Errorf("down"), expected: http.StatusOK},
+		{desc: "Liveness while shutting down", path: "/healthz", shuttingDown: true, expected: http.StatusOK},
+		{desc: "Ready", path: "/readyz", expected: http.StatusOK},
+		{desc: "Backend down", path: "/readyz", err: fmt.Errorf("down"), expected: http.StatusServiceUnavailable},
+		{desc: "Shutting down", path: "/readyz", shuttingDown: true, expected: http.StatusServiceUnavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			atomic.StoreInt32(&shuttingDown, 0)
+			if test.shuttingDown {
+				MarkShuttingDown()
+			}
+
+			r := gin.New()
+			RegisterHealthRoutes(r, fakeHealthChecker{err: test.err})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+			assert.Equal(t, test.expected, w.Code)
+		})
+	}
+}
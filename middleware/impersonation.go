@@ -0,0 +1,187 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// impersonatingHeader and impersonatedByHeader are on every response to a
+	// request made while impersonating, with the IDs of the User and the admin.
+	impersonatingHeader  = "X-Impersonating"
+	impersonatedByHeader = "X-Impersonated-By"
+
+	// impersonatorContextKey is the key to look up the admin doing the
+	// impersonating in the Context with.
+	impersonatorContextKey = "IMPERSONATOR_CONTEXT_KEY"
+	impersonationKey       = "impersonationKey"
+
+	endImpersonationPath = "/impersonation/end"
+)
+
+// RegisterImpersonationRoutes registers the route admins end an Impersonation
+// with. It's made as the impersonated User so it can't be with the admin routes.
+func RegisterImpersonationRoutes(g *gin.RouterGroup) {
+	g.POST(endImpersonationPath, ValidateHeaders(acceptHeader), EndImpersonation)
+}
+
+// ImpersonationMiddleware handles the request as the User the authenticated admin
+// is impersonating, if they are, and records it in the audit log. Read only
+// Impersonations can't change anything. It has to come after the
+// AuthenticationMiddleware.
+func ImpersonationMiddleware(c *gin.Context) {
+	admin := GetAuthenticatedUser(c)
+	if !admin.IsAdmin {
+		return
+	}
+
+	dbBackend := GetDBBackend(c)
+	impersonation, err := dbBackend.GetActiveImpersonation(c.Request.Context(), admin.ID)
+	if err != nil {
+		if err == impersonations.ErrNotImpersonating {
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up impersonation.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	user, err := dbBackend.GetUserByID(c.Request.Context(), impersonation.UserID)
+	if err != nil {
+		GetLogger(c).WithError(err).Errorf("Failed to look up impersonated user %d.", impersonation.UserID)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// Make it obvious to the admin that they aren't seeing things as themselves
+	c.Header(impersonatingHeader, strconv.Itoa(user.ID))
+	c.Header(impersonatedByHeader, strconv.Itoa(admin.ID))
+
+	c.Set(userContextKey, user)
+	c.Set(impersonatorContextKey, admin)
+	c.Set(impersonationKey, impersonation)
+	addLogFields(c, log.Fields{"user_id": user.ID, "impersonator_id": admin.ID})
+
+	if impersonationAllows(impersonation, c.Request.Method, c.Request.URL.Path) {
+		c.Next()
+	} else {
+		c.AbortWithError(http.StatusForbidden, impersonations.ErrReadOnly)
+	}
+
+	recordAudit(c, audit.ImpersonationRequest, audit.ImpersonationTarget, impersonation.ID, nil, map[string]interface{}{
+		"RequestID": GetRequestID(c),
+		"Method":    c.Request.Method,
+		"Path":      c.Request.URL.Path,
+		"Status":    c.Writer.Status(),
+	})
+}
+
+// impersonationAllows determines if the request to the path can be made during
+// the Impersonation. Read only Impersonations can only read, but any Impersonation
+// can be ended. The end route doesn't have path parameters so the request's path
+// is the same as the route.
+func impersonationAllows(impersonation *impersonations.Impersonation, method, path string) bool {
+	if impersonation.AllowWrites || (method == http.MethodPost && path == endImpersonationPath) {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// GetImpersonator pulls the admin doing the impersonating out of the Context. It's
+// nil when the request isn't made while impersonating.
+func GetImpersonator(c *gin.Context) *users.User {
+	impersonator, ok := c.Get(impersonatorContextKey)
+	if !ok {
+		return nil
+	}
+	return impersonator.(*users.User)
+}
+
+// AdminStartImpersonation starts an Impersonation of the User matching the id in
+// the path by the authenticated admin. Admins can't be impersonated.
+func AdminStartImpersonation(c *gin.Context) {
+	admin := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	userID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	req := &requests.StartImpersonation{}
+	err = requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	existingUser, err := dbBackend.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		if err == users.ErrUserNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up user before impersonation.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// Prevent impersonating another admin, which would get around the admin checks
+	if existingUser.IsAdmin {
+		GetLogger(c).Error("Admin attempted to impersonate another admin.")
+		c.AbortWithError(http.StatusForbidden, users.ErrUserIsAdmin)
+		return
+	}
+
+	newImpersonation, err := dbBackend.CreateImpersonation(c.Request.Context(), req.Impersonation(admin.ID, userID))
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to create impersonation.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(c, audit.ImpersonationStarted, audit.ImpersonationTarget, newImpersonation.ID, nil, newImpersonation)
+
+	c.JSON(http.StatusCreated, newImpersonation)
+}
+
+// EndImpersonation ends the Impersonation the admin making the request has in
+// effect so they're back to being themselves.
+func EndImpersonation(c *gin.Context) {
+	value, ok := c.Get(impersonationKey)
+	if !ok {
+		c.AbortWithError(http.StatusConflict, impersonations.ErrNotImpersonating)
+		return
+	}
+	impersonation := value.(*impersonations.Impersonation)
+
+	endedImpersonation, err := GetDBBackend(c).EndImpersonation(c.Request.Context(), impersonation.ID)
+	if err != nil {
+		if err == impersonations.ErrNotImpersonating {
+			c.AbortWithError(http.StatusConflict, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to end impersonation.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	recordAudit(c, audit.ImpersonationEnded, audit.ImpersonationTarget, impersonation.ID, impersonation, endedImpersonation)
+
+	c.JSON(http.StatusOK, endedImpersonation)
+}
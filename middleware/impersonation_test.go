@@ -0,0 +1,66 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonationAllows(t *testing.T) {
+	testIO := []struct {
+		desc        string
+		allowWrites bool
+		method      string
+		path        string
+		expected    bool
+	}{
+		{
+			desc:     "Read only can read.",
+			method:   http.MethodGet,
+			path:     "/channels/3",
+			expected: true,
+		},
+		{
+			desc:     "Read only can't write.",
+			method:   http.MethodPost,
+			path:     "/channels/3/messages",
+			expected: false,
+		},
+		{
+			desc:     "Read only can't delete.",
+			method:   http.MethodDelete,
+			path:     "/channels/3",
+			expected: false,
+		},
+		{
+			desc:     "Read only can end the impersonation.",
+			method:   http.MethodPost,
+			path:     endImpersonationPath,
+			expected: true,
+		},
+		{
+			desc:     "Read only can't write under the end path.",
+			method:   http.MethodPost,
+			path:     endImpersonationPath + "/3",
+			expected: false,
+		},
+		{
+			desc:        "Allowing writes can write.",
+			allowWrites: true,
+			method:      http.MethodPut,
+			path:        "/channels/3",
+			expected:    true,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			impersonation := &impersonations.Impersonation{AllowWrites: test.allowWrites}
+			assert.Equal(t, test.expected, impersonationAllows(impersonation, test.method, test.path))
+		})
+	}
+}
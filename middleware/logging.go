@@ -0,0 +1,67 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/logging"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetLogger pulls the logger for the request out of the context. It has the request
+// id, route, and authenticated User on it. Requests that didn't go through the
+// AccessLogMiddleware get the standard logger.
+func GetLogger(c *gin.Context) *log.Entry {
+	return logging.FromContext(c.Request.Context())
+}
+
+// addLogFields adds the fields to the request's logger so everything logged for
+// the rest of the request has them.
+func addLogFields(c *gin.Context, fields log.Fields) {
+	c.Request = c.Request.WithContext(logging.AddFields(c.Request.Context(), fields))
+}
+
+// AccessLogMiddleware gives the request its own logger and logs a single line once
+// the request is done. It has to run after the RequestIDMiddleware and before the
+// ErrorHandler so the final status code is logged.
+func AccessLogMiddleware(r *gin.Engine) gin.HandlerFunc {
+	matcher := newRouteMatcher(r)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		addLogFields(c, log.Fields{
+			"request_id": GetRequestID(c),
+			"method":     c.Request.Method,
+			"route":      matcher.route(c),
+		})
+
+		c.Next()
+
+		status := c.Writer.Status()
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+
+		entry := GetLogger(c).WithFields(log.Fields{
+			"path":       c.Request.URL.Path,
+			"status":     status,
+			"latency_ms": float64(time.Since(start)) / float64(time.Millisecond),
+			"bytes":      size,
+			"client_ip":  c.ClientIP(),
+			"user_agent": c.Request.UserAgent(),
+		})
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request handled.")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request handled.")
+		default:
+			entry.Info("Request handled.")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFormatter(&log.TextFormatter{})
+	}()
+
+	r := gin.New()
+	r.Use(RequestIDMiddleware, AccessLogMiddleware(r), ErrorHandler)
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Set(userContextKey, &users.User{ID: 7})
+		addLogFields(c, log.Fields{"user_id": 7})
+		GetLogger(c).Info("Looking up user.")
+		c.AbortWithStatus(http.StatusNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(requestIDHeader, "abc123")
+	r.ServeHTTP(w, req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	handlerLine := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &handlerLine))
+	assert.Equal(t, "abc123", handlerLine["request_id"])
+	assert.Equal(t, "/users/:id", handlerLine["route"])
+	assert.Equal(t, float64(7), handlerLine["user_id"])
+
+	accessLine := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &accessLine))
+	assert.Equal(t, "Request handled.", accessLine["msg"])
+	assert.Equal(t, "warning", accessLine["level"])
+	assert.Equal(t, "abc123", accessLine["request_id"])
+	assert.Equal(t, float64(7), accessLine["user_id"])
+	assert.Equal(t, "/users/7", accessLine["path"])
+	assert.Equal(t, float64(http.StatusNotFound), accessLine["status"])
+	assert.Contains(t, accessLine, "latency_ms")
+}
@@ -5,12 +5,16 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/andrew-boutin/dndtextapi/announcements"
+	"github.com/andrew-boutin/dndtextapi/apierrors"
 	"github.com/andrew-boutin/dndtextapi/characters"
-	log "github.com/sirupsen/logrus"
 
 	"github.com/andrew-boutin/dndtextapi/channels"
 
 	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,15 +22,17 @@ import (
 // associated middleware.
 func RegisterMessagesRoutes(g *gin.RouterGroup) {
 	g.GET("/channels/:channelID/messages", ValidateHeaders(acceptHeader), LoadChannelFromPathID, GetMessages)
-	g.POST("/channels/:channelID/messages", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, CreateMessage)
+	g.POST("/channels/:channelID/messages", RateLimitMiddleware(ratelimit.MessagesGroup), ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, CreateMessage)
 	g.GET("/channels/:channelID/messages/:id", ValidateHeaders(acceptHeader), LoadChannelFromPathID, GetMessage)
 	g.PUT("/channels/:channelID/messages/:id", ValidateHeaders(acceptHeader, contentTypeHeader), UpdateMessage)
+	g.PATCH("/channels/:channelID/messages/:id", ValidateHeaders(acceptHeader, contentTypeHeader), PatchMessage)
 	g.DELETE("/channels/:channelID/messages/:id", DeleteMessage)
+	g.POST("/channels/:channelID/messages/:id/restore", ValidateHeaders(acceptHeader), LoadChannelFromPathID, RestoreMessage)
 }
 
 // GetMessages retrieves a list of Messages from the designated Channel. The query
 // parameter msgType is optional and can be used to filter which Messages are
-// retrieved.
+// retrieved. The optional `after` and `limit` query parameters page through them.
 func GetMessages(c *gin.Context) {
 	user := GetAuthenticatedUser(c)
 	dbBackend := GetDBBackend(c)
@@ -45,7 +51,7 @@ func GetMessages(c *gin.Context) {
 	// Accessing any meta Messages on public Channels also requires membership
 	var isMember bool
 	if channel.IsPrivate || msgType != storyMsgType {
-		isMember, err = dbBackend.DoesUserHaveCharacterInChannel(user.ID, channel.ID)
+		isMember, err = dbBackend.DoesUserHaveCharacterInChannel(c.Request.Context(), user.ID, channel.ID)
 		if err != nil {
 			c.AbortWithError(http.StatusInternalServerError, err)
 			return
@@ -58,17 +64,23 @@ func GetMessages(c *gin.Context) {
 		}
 	}
 
+	page, err := PageFromQuery(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
 	var onlyStoryMsgs bool
 	var outMessages messages.MessageCollection
 	switch msgType {
 	case storyMsgType:
 		onlyStoryMsgs = true
-		outMessages, err = dbBackend.GetMessagesInChannel(channel.ID, &onlyStoryMsgs)
+		outMessages, err = dbBackend.GetMessagesInChannel(c.Request.Context(), channel.ID, &onlyStoryMsgs, page)
 	case metaMsgType:
 		onlyStoryMsgs = false
-		outMessages, err = dbBackend.GetMessagesInChannel(channel.ID, &onlyStoryMsgs)
+		outMessages, err = dbBackend.GetMessagesInChannel(c.Request.Context(), channel.ID, &onlyStoryMsgs, page)
 	default:
-		outMessages, err = dbBackend.GetMessagesInChannel(channel.ID, nil)
+		outMessages, err = dbBackend.GetMessagesInChannel(c.Request.Context(), channel.ID, nil, page)
 	}
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
@@ -91,7 +103,7 @@ func GetMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := dbBackend.GetMessage(messageID)
+	message, err := dbBackend.GetMessage(c.Request.Context(), messageID)
 	if err != nil {
 		if err == messages.ErrMessageNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
@@ -105,7 +117,7 @@ func GetMessage(c *gin.Context) {
 	// that the User is a member
 	var isMember bool
 	if channel.IsPrivate || !message.IsStory {
-		isMember, err = dbBackend.DoesUserHaveCharacterInChannel(user.ID, channel.ID)
+		isMember, err = dbBackend.DoesUserHaveCharacterInChannel(c.Request.Context(), user.ID, channel.ID)
 		if err != nil {
 			c.AbortWithError(http.StatusInternalServerError, err)
 			return
@@ -118,7 +130,7 @@ func GetMessage(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, message)
+	respondWithETag(c, http.StatusOK, message.LastUpdated, message)
 }
 
 // CreateMessage creates a new Message using the data in the
@@ -127,23 +139,22 @@ func CreateMessage(c *gin.Context) {
 	user := GetAuthenticatedUser(c)
 	channel := c.MustGet(channelKey).(*channels.Channel)
 
-	// TODO: Validation - content not empty, can't set user/channel ids, etc.
 	dbBackend := GetDBBackend(c)
-	message := &messages.Message{}
-	err := c.Bind(message)
+	req := &requests.CreateMessage{}
+	err := requests.Decode(c.Request.Body, req)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
 	// User must own the Character that the Message is for
-	char, err := dbBackend.GetCharacter(message.CharacterID)
+	char, err := dbBackend.GetCharacter(c.Request.Context(), req.CharacterID)
 	if err != nil {
 		if err == characters.ErrCharacterNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to look up message.")
+		GetLogger(c).WithError(err).Error("Failed to look up message.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -159,17 +170,23 @@ func CreateMessage(c *gin.Context) {
 		return
 	}
 
-	message.ChannelID = channel.ID
-
-	createdMessage, err := dbBackend.CreateMessage(message)
+	createdMessage, err := dbBackend.CreateMessage(c.Request.Context(), req.Message(channel.ID))
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
+	GetAnnouncer(c).MessageCreated(c.Request.Context(), channel, char, createdMessage)
+
 	c.JSON(http.StatusCreated, createdMessage)
 }
 
+// GetAnnouncer creates the Announcer for new Messages out of the notifier and
+// webhook dispatcher in the context.
+func GetAnnouncer(c *gin.Context) *announcements.Announcer {
+	return announcements.NewAnnouncer(GetDBBackend(c), GetNotifier(c), GetWebhookDispatcher(c))
+}
+
 // DeleteMessage deletes the message matching the ID in the path.
 func DeleteMessage(c *gin.Context) {
 	user := GetAuthenticatedUser(c)
@@ -182,25 +199,25 @@ func DeleteMessage(c *gin.Context) {
 		return
 	}
 
-	message, err := dbBackend.GetMessage(messageID)
+	message, err := dbBackend.GetMessage(c.Request.Context(), messageID)
 	if err != nil {
 		if err == messages.ErrMessageNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to look up message.")
+		GetLogger(c).WithError(err).Error("Failed to look up message.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
 	// Look up the Character the Message is from so we can check if this User owns it
-	char, err := dbBackend.GetCharacter(message.CharacterID)
+	char, err := dbBackend.GetCharacter(c.Request.Context(), message.CharacterID)
 	if err != nil {
 		if err == characters.ErrCharacterNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to look up character.")
+		GetLogger(c).WithError(err).Error("Failed to look up character.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -215,18 +232,108 @@ func DeleteMessage(c *gin.Context) {
 		}
 	}
 
-	err = dbBackend.DeleteMessage(messageID)
+	expected, ok := checkIfMatch(c, message.LastUpdated)
+	if !ok {
+		return
+	}
+
+	err = dbBackend.DeleteMessage(c.Request.Context(), messageID, expected)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
+	GetWebhookDispatcher(c).Dispatch(message.ChannelID, webhooks.MessageDeletedEvent, message)
+
 	c.Status(http.StatusNoContent)
 }
 
+// RestoreMessage brings back the deleted Message using the ID from the path. The
+// User who created it or the Channel owner can restore it within the restore
+// window. Messages from a deleted Character come back with the Character instead.
+func RestoreMessage(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	messageID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	message, err := dbBackend.GetDeletedMessage(c.Request.Context(), messageID)
+	if err != nil {
+		if err == messages.ErrMessageNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up deleted message.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// The Message has to be from the Channel in the path
+	if message.ChannelID != channel.ID {
+		c.AbortWithError(http.StatusNotFound, messages.ErrMessageNotFound)
+		return
+	}
+
+	char, err := dbBackend.GetCharacter(c.Request.Context(), message.CharacterID)
+	if err != nil {
+		if err == characters.ErrCharacterNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up character.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if char.UserID != user.ID && channel.OwnerID != user.ID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	if !checkRestoreWindow(c, message.DeletedOn) {
+		return
+	}
+
+	restoredMessage, err := dbBackend.RestoreMessage(c.Request.Context(), messageID)
+	if err != nil {
+		if err == messages.ErrMessageNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to restore message.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, restoredMessage.LastUpdated, restoredMessage)
+}
+
 // UpdateMessage updates the Message using the ID from the path with
 // the data from the request body.
 func UpdateMessage(c *gin.Context) {
+	updateMessage(c, func(existing *messages.Message) (*requests.UpdateMessage, error) {
+		req := &requests.UpdateMessage{}
+		return req, requests.Decode(c.Request.Body, req)
+	})
+}
+
+// PatchMessage updates the Message using the ID from the path with the JSON
+// merge patch in the request body.
+func PatchMessage(c *gin.Context) {
+	updateMessage(c, func(existing *messages.Message) (*requests.UpdateMessage, error) {
+		req := requests.UpdateMessageFrom(existing)
+		return req, requests.MergePatch(c.Request.Body, req)
+	})
+}
+
+// updateMessage updates the Message from the path with the request that decode
+// reads from the body.
+func updateMessage(c *gin.Context, decode func(existing *messages.Message) (*requests.UpdateMessage, error)) {
 	user := GetAuthenticatedUser(c)
 	dbBackend := GetDBBackend(c)
 
@@ -236,25 +343,25 @@ func UpdateMessage(c *gin.Context) {
 		return
 	}
 
-	existingMessage, err := dbBackend.GetMessage(messageID)
+	existingMessage, err := dbBackend.GetMessage(c.Request.Context(), messageID)
 	if err != nil {
 		if err == messages.ErrMessageNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to look up message.")
+		GetLogger(c).WithError(err).Error("Failed to look up message.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
 	// Look up the Character the Message is from so we can check if this User owns it
-	char, err := dbBackend.GetCharacter(existingMessage.CharacterID)
+	char, err := dbBackend.GetCharacter(c.Request.Context(), existingMessage.CharacterID)
 	if err != nil {
 		if err == characters.ErrCharacterNotFound {
 			c.AbortWithError(http.StatusNotFound, err)
 			return
 		}
-		log.WithError(err).Error("Failed to look up character.")
+		GetLogger(c).WithError(err).Error("Failed to look up character.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
@@ -265,18 +372,29 @@ func UpdateMessage(c *gin.Context) {
 		return
 	}
 
-	message := &messages.Message{}
-	err = c.Bind(message)
+	if !checkNotBannedFromChannel(c, user, existingMessage.ChannelID) {
+		return
+	}
+
+	// Someone else may have changed it since the User last looked
+	expected, ok := checkIfMatch(c, existingMessage.LastUpdated)
+	if !ok {
+		return
+	}
+
+	req, err := decode(existingMessage)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	updatedMessage, err := dbBackend.UpdateMessage(messageID, message)
+	updatedMessage, err := dbBackend.UpdateMessage(c.Request.Context(), messageID, req.Message(), expected)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedMessage)
+	GetWebhookDispatcher(c).Dispatch(updatedMessage.ChannelID, webhooks.MessageEditedEvent, updatedMessage)
+
+	respondWithETag(c, http.StatusOK, updatedMessage.LastUpdated, updatedMessage)
 }
@@ -0,0 +1,91 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute is the route label for requests that didn't match a route so
+// random paths can't create new label values.
+const unmatchedRoute = "unmatched"
+
+// RegisterMetricsRoutes adds the route that Prometheus scrapes.
+func RegisterMetricsRoutes(r *gin.Engine, registry *metrics.Registry) {
+	r.GET("/metrics", gin.WrapH(registry))
+}
+
+// MetricsMiddleware records how many requests each route handles, their status
+// codes, and how long they take. It has to run before the ErrorHandler so the
+// final status code is recorded.
+func MetricsMiddleware(r *gin.Engine) gin.HandlerFunc {
+	matcher := newRouteMatcher(r)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		method := c.Request.Method
+		route := matcher.route(c)
+
+		metrics.HTTPRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
+
+// routeMatcher finds the route that a request matched.
+type routeMatcher struct {
+	engine *gin.Engine
+	once   sync.Once
+	routes map[string]bool
+}
+
+func newRouteMatcher(r *gin.Engine) *routeMatcher {
+	return &routeMatcher{engine: r}
+}
+
+// route is the route the request matched, such as `/channels/:channelID`, or
+// unmatchedRoute if there wasn't one.
+func (m *routeMatcher) route(c *gin.Context) string {
+	// Every route has been registered by the time requests come in
+	m.once.Do(func() {
+		m.routes = make(map[string]bool)
+		for _, info := range m.engine.Routes() {
+			m.routes[info.Method+" "+info.Path] = true
+		}
+	})
+
+	route := routeTemplate(c.Request.URL.Path, c.Params)
+	if !m.routes[c.Request.Method+" "+route] {
+		return unmatchedRoute
+	}
+	return route
+}
+
+// routeTemplate turns the path back into the route it matched, such as
+// `/channels/:channelID`, by swapping the path parameter values for their names.
+// The parameters are in the same order as they show up in the path.
+func routeTemplate(path string, params gin.Params) string {
+	if len(params) == 0 {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	next := 0
+	for i, segment := range segments {
+		if next >= len(params) {
+			break
+		}
+		if segment == params[next].Value {
+			segments[i] = ":" + params[next].Key
+			next++
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	testIO := []struct {
+		desc     string
+		path     string
+		params   gin.Params
+		expected string
+	}{
+		{
+			desc:     "No parameters.",
+			path:     "/channels",
+			expected: "/channels",
+		},
+		{
+			desc:     "One parameter.",
+			path:     "/channels/5",
+			params:   gin.Params{{Key: "channelID", Value: "5"}},
+			expected: "/channels/:channelID",
+		},
+		{
+			desc:     "Parameters with the same value.",
+			path:     "/channels/5/messages/5",
+			params:   gin.Params{{Key: "channelID", Value: "5"}, {Key: "id", Value: "5"}},
+			expected: "/channels/:channelID/messages/:id",
+		},
+		{
+			desc:     "Value that matches a static segment.",
+			path:     "/users/tokens/tokens/7",
+			params:   gin.Params{{Key: "id", Value: "tokens"}, {Key: "tokenID", Value: "7"}},
+			expected: "/users/:id/tokens/:tokenID",
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, routeTemplate(test.path, test.params))
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/bots"
+	"github.com/andrew-boutin/dndtextapi/bulk"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/openapi"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/stats"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	openAPITitle   = "DnD Text API"
+	openAPIVersion = "1.0.0"
+)
+
+// Query parameters shared by multiple routes.
+var (
+	levelQuery    = openapi.QueryParam(levelQueryParam, "Filter Channels by `owner` or `member`")
+	msgTypeQuery  = openapi.QueryParam(msgTypeQueryParam, "Filter Messages by `story` or `meta`")
+	callbackQuery = openapi.QueryParam(callbackQueryParam, "Callback URL to use after logging in")
+	reportsQuery  = []openapi.Parameter{
+		openapi.QueryParam(statusQueryParam, "Filter Reports by `open`, `resolved`, or `dismissed`"),
+		openapi.QueryParam(targetTypeQueryParam, "Filter Reports by `message`, `character`, `channel`, or `user`"),
+		openapi.QueryParam(assigneeQueryParam, "Filter Reports by the admin they're assigned to, 0 for unassigned"),
+	}
+	auditQuery = []openapi.Parameter{
+		openapi.QueryParam(actorQueryParam, "Filter Entries by the User that took the action"),
+		openapi.QueryParam(actionQueryParam, "Filter Entries by action, like `channel.deleted`"),
+		openapi.QueryParam(targetTypeQueryParam, "Filter Entries by `channel`, `message`, `character`, `user`, `ban`, or `report`"),
+		openapi.QueryParam(targetIDQueryParam, "Filter Entries by the ID of the target"),
+		openapi.QueryParam(sinceQueryParam, "Only include Entries at or after this RFC 3339 time"),
+		openapi.QueryParam(untilQueryParam, "Only include Entries before this RFC 3339 time"),
+		openapi.QueryParam(limitQueryParam, "Most Entries to include"),
+	}
+	listQuery = []openapi.Parameter{
+		openapi.QueryParam(orderQueryParam, "Order by `asc` or `desc`"),
+		openapi.QueryParam(limitQueryParam, "Most to include"),
+		openapi.QueryParam(offsetQueryParam, "How many to skip"),
+	}
+	adminUsersQuery = append([]openapi.Parameter{
+		openapi.QueryParam(adminQueryParam, "Filter Users by whether they're an admin"),
+		openapi.QueryParam(bannedQueryParam, "Filter Users by whether they're banned"),
+		openapi.QueryParam(loggedInSinceQueryParam, "Only include Users that logged in at or after this RFC 3339 time"),
+		openapi.QueryParam(loggedInBeforeQueryParam, "Only include Users that last logged in before this RFC 3339 time"),
+		openapi.QueryParam(sortQueryParam, "Sort Users by `id`, `username`, `lastLogin`, or `createdOn`"),
+	}, listQuery...)
+	adminChannelsQuery = append([]openapi.Parameter{
+		openapi.QueryParam(privateQueryParam, "Filter Channels by whether they're private"),
+		openapi.QueryParam(ownerQueryParam, "Filter Channels by the User that owns them"),
+		openapi.QueryParam(sortQueryParam, "Sort Channels by `id`, `name`, `createdOn`, or `lastUpdated`"),
+	}, listQuery...)
+	statsQuery = []openapi.Parameter{
+		openapi.QueryParam(weeksQueryParam, "How many weeks of Channel creation to include, 12 by default"),
+		openapi.QueryParam(topQueryParam, "How many of the most active Channels to include, 10 by default"),
+	}
+	pageQuery = []openapi.Parameter{
+		openapi.QueryParam(afterQueryParam, "Only include Messages with a larger ID"),
+		openapi.QueryParam(limitQueryParam, "Most Messages to include"),
+	}
+)
+
+// routeDocs documents every route the API registers. A new route needs an entry
+// here or it won't show up in the OpenAPI document.
+var routeDocs = []openapi.Route{
+	// Documentation
+	{Method: http.MethodGet, Path: "/openapi.json", Tag: "docs", Summary: "Get this OpenAPI document"},
+
+	// Health
+	{Method: http.MethodGet, Path: "/healthz", Tag: "health", Summary: "Check that the server is up"},
+	{Method: http.MethodGet, Path: "/readyz", Tag: "health", Summary: "Check that the server can take traffic"},
+
+	// Metrics
+	{Method: http.MethodGet, Path: "/metrics", Tag: "metrics", Summary: "Get the Prometheus metrics"},
+
+	// Anonymous
+	{Method: http.MethodGet, Path: "/public/channels", Tag: "public", Summary: "Get public Channels", Response: channels.ChannelCollection{}},
+	{Method: http.MethodGet, Path: "/public/channels/:channelID", Tag: "public", Summary: "Get a public Channel", Response: channels.Channel{}},
+	{Method: http.MethodGet, Path: "/public/channels/:channelID/messages", Tag: "public", Summary: "Get story Messages from a public Channel", Query: pageQuery, Response: messages.MessageCollection{}},
+
+	// Authentication
+	{Method: http.MethodGet, Path: "/login", Tag: "authentication", Summary: "Log in through Google", Query: []openapi.Parameter{callbackQuery}, Status: http.StatusTemporaryRedirect},
+	{Method: http.MethodGet, Path: "/callback", Tag: "authentication", Summary: "Google login callback", Status: http.StatusNoContent},
+
+	// Slack
+	{Method: http.MethodPost, Path: "/slack/events", Tag: "slack", Summary: "Receive Slack Events API requests"},
+
+	// Channels
+	{Method: http.MethodGet, Path: "/channels", Tag: "channels", Summary: "Get Channels", Query: []openapi.Parameter{levelQuery}, Response: channels.ChannelCollection{}},
+	{Method: http.MethodPost, Path: "/channels", Tag: "channels", Summary: "Create a Channel", Request: requests.Channel{}, Response: channels.Channel{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/channels/:channelID", Tag: "channels", Summary: "Get a Channel", Response: channels.Channel{}},
+	{Method: http.MethodPut, Path: "/channels/:channelID", Tag: "channels", Summary: "Update a Channel", Request: requests.Channel{}, Response: channels.Channel{}},
+	{Method: http.MethodPatch, Path: "/channels/:channelID", Tag: "channels", Summary: "Update some fields of a Channel", Request: requests.Channel{}, Response: channels.Channel{}},
+	{Method: http.MethodDelete, Path: "/channels/:channelID", Tag: "channels", Summary: "Delete a Channel", Status: http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/channels/:channelID/restore", Tag: "channels", Summary: "Restore a deleted Channel", Response: channels.Channel{}},
+
+	// Users
+	{Method: http.MethodGet, Path: "/users/:id", Tag: "users", Summary: "Get a User - other Users only get the public profile", Response: users.SelfUser{}},
+	{Method: http.MethodPut, Path: "/users/:id", Tag: "users", Summary: "Update a User", Request: requests.UpdateUser{}, Response: users.SelfUser{}},
+	{Method: http.MethodPatch, Path: "/users/:id", Tag: "users", Summary: "Update some fields of a User", Request: requests.UpdateUser{}, Response: users.SelfUser{}},
+	{Method: http.MethodDelete, Path: "/users/:id", Tag: "users", Summary: "Delete a User", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/users/:id/notifications", Tag: "users", Summary: "Get notification preferences", Response: notifications.Preferences{}},
+	{Method: http.MethodPut, Path: "/users/:id/notifications", Tag: "users", Summary: "Update notification preferences", Request: requests.NotificationPreferences{}, Response: notifications.Preferences{}},
+
+	// Tokens
+	{Method: http.MethodGet, Path: "/users/:id/tokens", Tag: "tokens", Summary: "Get your API Tokens", Response: tokens.TokenCollection{}},
+	{Method: http.MethodPost, Path: "/users/:id/tokens", Tag: "tokens", Summary: "Create an API Token", Request: requests.CreateToken{}, Response: tokens.Token{}, Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/users/:id/tokens/:tokenID", Tag: "tokens", Summary: "Revoke an API Token", Status: http.StatusNoContent},
+
+	// Messages
+	{Method: http.MethodGet, Path: "/channels/:channelID/messages", Tag: "messages", Summary: "Get Messages in a Channel", Query: append([]openapi.Parameter{msgTypeQuery}, pageQuery...), Response: messages.MessageCollection{}},
+	{Method: http.MethodPost, Path: "/channels/:channelID/messages", Tag: "messages", Summary: "Create a Message", Request: requests.CreateMessage{}, Response: messages.Message{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/channels/:channelID/messages/:id", Tag: "messages", Summary: "Get a Message", Response: messages.Message{}},
+	{Method: http.MethodPut, Path: "/channels/:channelID/messages/:id", Tag: "messages", Summary: "Update a Message", Request: requests.UpdateMessage{}, Response: messages.Message{}},
+	{Method: http.MethodPatch, Path: "/channels/:channelID/messages/:id", Tag: "messages", Summary: "Update some fields of a Message", Request: requests.UpdateMessage{}, Response: messages.Message{}},
+	{Method: http.MethodDelete, Path: "/channels/:channelID/messages/:id", Tag: "messages", Summary: "Delete a Message", Status: http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/channels/:channelID/messages/:id/restore", Tag: "messages", Summary: "Restore a deleted Message", Response: messages.Message{}},
+
+	// Characters
+	{Method: http.MethodGet, Path: "/channels/:channelID/characters", Tag: "characters", Summary: "Get Characters in a Channel", Response: characters.CharacterCollection{}},
+	{Method: http.MethodPost, Path: "/channels/:channelID/characters", Tag: "characters", Summary: "Invite a User to a Channel", Request: requests.CreateCharacter{}, Response: characters.Character{}},
+	{Method: http.MethodGet, Path: "/channels/:channelID/characters/:id", Tag: "characters", Summary: "Get a Character", Response: characters.Character{}},
+	{Method: http.MethodPut, Path: "/channels/:channelID/characters/:id", Tag: "characters", Summary: "Update a Character", Request: requests.UpdateCharacter{}, Response: characters.Character{}},
+	{Method: http.MethodPatch, Path: "/channels/:channelID/characters/:id", Tag: "characters", Summary: "Update some fields of a Character", Request: requests.UpdateCharacter{}, Response: characters.Character{}},
+	{Method: http.MethodDelete, Path: "/channels/:channelID/characters/:id", Tag: "characters", Summary: "Delete a Character", Status: http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/channels/:channelID/characters/:id/turn", Tag: "characters", Summary: "Notify a Character that it's their turn", Status: http.StatusAccepted},
+	{Method: http.MethodPost, Path: "/channels/:channelID/characters/:id/restore", Tag: "characters", Summary: "Restore a deleted Character", Response: characters.Character{}},
+
+	// Webhooks
+	{Method: http.MethodGet, Path: "/channels/:channelID/webhooks", Tag: "webhooks", Summary: "Get Webhooks for a Channel", Response: webhooks.WebhookCollection{}},
+	{Method: http.MethodPost, Path: "/channels/:channelID/webhooks", Tag: "webhooks", Summary: "Create a Webhook", Request: requests.CreateWebhook{}, Response: webhooks.Webhook{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/channels/:channelID/webhooks/:id", Tag: "webhooks", Summary: "Get a Webhook", Response: webhooks.Webhook{}},
+	{Method: http.MethodPut, Path: "/channels/:channelID/webhooks/:id", Tag: "webhooks", Summary: "Update a Webhook", Request: requests.UpdateWebhook{}, Response: webhooks.Webhook{}},
+	{Method: http.MethodDelete, Path: "/channels/:channelID/webhooks/:id", Tag: "webhooks", Summary: "Delete a Webhook", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/channels/:channelID/webhooks/:id/deliveries", Tag: "webhooks", Summary: "Get recent Webhook deliveries", Response: webhooks.DeliveryCollection{}},
+
+	// Bans
+	{Method: http.MethodGet, Path: "/channels/:channelID/bans", Tag: "bans", Summary: "Get the Ban history for a Channel", Response: bans.BanCollection{}},
+	{Method: http.MethodPost, Path: "/channels/:channelID/bans", Tag: "bans", Summary: "Ban a User from a Channel", Request: requests.CreateChannelBan{}, Response: bans.Ban{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/channels/:channelID/bans/:id/lift", Tag: "bans", Summary: "Lift a Channel Ban early", Response: bans.Ban{}},
+	{Method: http.MethodPost, Path: "/impersonation/end", Tag: "impersonation", Summary: "End the admin's Impersonation", Response: impersonations.Impersonation{}},
+
+	// Bots
+	{Method: http.MethodGet, Path: "/bots", Tag: "bots", Summary: "Get Bots", Response: bots.BotCollection{}},
+	{Method: http.MethodPost, Path: "/bots", Tag: "bots", Summary: "Create a Bot", Request: requests.CreateBot{}, Response: bots.Bot{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/bots/:botID", Tag: "bots", Summary: "Get a Bot", Response: bots.Bot{}},
+	{Method: http.MethodDelete, Path: "/bots/:botID", Tag: "bots", Summary: "Delete a Bot", Status: http.StatusNoContent},
+
+	// Reports
+	{Method: http.MethodGet, Path: "/reports", Tag: "reports", Summary: "Get your Reports", Response: reports.ReportCollection{}},
+	{Method: http.MethodPost, Path: "/reports", Tag: "reports", Summary: "Report a Message, Character, Channel, or User", Request: requests.CreateReport{}, Response: reports.Report{}, Status: http.StatusCreated},
+
+	// Admin
+	{Method: http.MethodGet, Path: "/admin/channels", Tag: "admin", Summary: "Get all Channels", Query: adminChannelsQuery, Response: channels.ChannelCollection{}},
+	{Method: http.MethodGet, Path: "/admin/channels/:channelID", Tag: "admin", Summary: "Get any Channel", Response: channels.Channel{}},
+	{Method: http.MethodPut, Path: "/admin/channels/:channelID", Tag: "admin", Summary: "Update any Channel", Request: requests.Channel{}, Response: channels.Channel{}},
+	{Method: http.MethodDelete, Path: "/admin/channels/:channelID", Tag: "admin", Summary: "Delete any Channel", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/admin/channels/:channelID/messages", Tag: "admin", Summary: "Get all Messages in a Channel", Query: pageQuery, Response: messages.MessageCollection{}},
+	{Method: http.MethodGet, Path: "/admin/messages/:id", Tag: "admin", Summary: "Get any Message", Response: messages.Message{}},
+	{Method: http.MethodPut, Path: "/admin/messages/:id", Tag: "admin", Summary: "Update any Message", Request: requests.UpdateMessage{}, Response: messages.Message{}},
+	{Method: http.MethodDelete, Path: "/admin/messages/:id", Tag: "admin", Summary: "Delete any Message", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/admin/users", Tag: "admin", Summary: "Get all Users", Query: adminUsersQuery, Response: users.UserCollection{}},
+	{Method: http.MethodGet, Path: "/admin/users/:id", Tag: "admin", Summary: "Get any User", Response: users.User{}},
+	{Method: http.MethodPut, Path: "/admin/users/:id", Tag: "admin", Summary: "Update any User", Request: requests.UpdateUser{}, Response: users.User{}},
+	{Method: http.MethodDelete, Path: "/admin/users/:id", Tag: "admin", Summary: "Delete any User", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/admin/users/:id/bans", Tag: "admin", Summary: "Get the Ban history for any User", Response: bans.BanCollection{}},
+	{Method: http.MethodPost, Path: "/admin/users/:id/bans", Tag: "admin", Summary: "Ban any User from the whole site", Request: requests.CreateBan{}, Response: bans.Ban{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/admin/bans/:id/lift", Tag: "admin", Summary: "Lift any Ban early", Response: bans.Ban{}},
+	{Method: http.MethodPost, Path: "/admin/users/:id/impersonate", Tag: "admin", Summary: "Start impersonating a User", Request: requests.StartImpersonation{}, Response: impersonations.Impersonation{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/admin/channels/:channelID/characters", Tag: "admin", Summary: "Get all Characters in a Channel", Response: characters.CharacterCollection{}},
+	{Method: http.MethodGet, Path: "/admin/characters/:id", Tag: "admin", Summary: "Get any Character", Response: characters.Character{}},
+	{Method: http.MethodPut, Path: "/admin/characters/:id", Tag: "admin", Summary: "Update any Character", Request: requests.UpdateCharacter{}, Response: characters.Character{}},
+	{Method: http.MethodDelete, Path: "/admin/characters/:id", Tag: "admin", Summary: "Delete any Character", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/admin/deleted/channels", Tag: "admin", Summary: "Get deleted Channels", Response: channels.ChannelCollection{}},
+	{Method: http.MethodGet, Path: "/admin/deleted/channels/:channelID/characters", Tag: "admin", Summary: "Get deleted Characters in a Channel", Response: characters.CharacterCollection{}},
+	{Method: http.MethodGet, Path: "/admin/deleted/channels/:channelID/messages", Tag: "admin", Summary: "Get deleted Messages in a Channel", Response: messages.MessageCollection{}},
+	{Method: http.MethodPost, Path: "/admin/channels/:channelID/restore", Tag: "admin", Summary: "Restore any deleted Channel", Response: channels.Channel{}},
+	{Method: http.MethodPost, Path: "/admin/characters/:id/restore", Tag: "admin", Summary: "Restore any deleted Character", Response: characters.Character{}},
+	{Method: http.MethodPost, Path: "/admin/messages/:id/restore", Tag: "admin", Summary: "Restore any deleted Message", Response: messages.Message{}},
+	{Method: http.MethodGet, Path: "/admin/reports", Tag: "admin", Summary: "Get Reports", Query: reportsQuery, Response: reports.ReportCollection{}},
+	{Method: http.MethodGet, Path: "/admin/reports/:id", Tag: "admin", Summary: "Get a Report", Response: reports.Report{}},
+	{Method: http.MethodPost, Path: "/admin/reports/:id/assign", Tag: "admin", Summary: "Assign a Report to an admin", Request: requests.AssignReport{}, Response: reports.Report{}},
+	{Method: http.MethodPost, Path: "/admin/reports/:id/resolve", Tag: "admin", Summary: "Resolve a Report, optionally deleting or banning what was reported", Request: requests.ResolveReport{}, Response: reports.Report{}},
+	{Method: http.MethodPost, Path: "/admin/reports/:id/dismiss", Tag: "admin", Summary: "Dismiss a Report", Request: requests.DismissReport{}, Response: reports.Report{}},
+	{Method: http.MethodGet, Path: "/admin/audit", Tag: "admin", Summary: "Get the audit log", Query: auditQuery, Response: audit.EntryCollection{}},
+	{Method: http.MethodGet, Path: "/admin/stats", Tag: "admin", Summary: "Get the usage stats", Query: statsQuery, Response: stats.Stats{}},
+	{Method: http.MethodPost, Path: "/admin/bulk/users/ban", Tag: "admin", Summary: "Ban many Users", Request: requests.BulkBan{}, Response: bulk.ResultCollection{}},
+	{Method: http.MethodPost, Path: "/admin/bulk/users/delete", Tag: "admin", Summary: "Delete many Users", Request: requests.BulkIDs{}, Response: bulk.ResultCollection{}},
+	{Method: http.MethodPost, Path: "/admin/bulk/channels/delete", Tag: "admin", Summary: "Delete many Channels", Request: requests.BulkIDs{}, Response: bulk.ResultCollection{}},
+	{Method: http.MethodPost, Path: "/admin/bulk/messages/delete", Tag: "admin", Summary: "Delete many Messages", Request: requests.BulkIDs{}, Response: bulk.ResultCollection{}},
+}
+
+// RegisterOpenAPIRoutes adds the route serving the OpenAPI document. It needs to be
+// registered after every other route since the document is built from the routes
+// registered with the engine.
+func RegisterOpenAPIRoutes(r *gin.Engine) {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+	)
+
+	r.GET("/openapi.json", ValidateHeaders(acceptHeader), func(c *gin.Context) {
+		once.Do(func() {
+			doc = BuildOpenAPIDocument(r.Routes())
+		})
+		c.JSON(http.StatusOK, doc)
+	})
+}
+
+// BuildOpenAPIDocument creates the OpenAPI document for the registered routes using
+// the route documentation. Registered routes without documentation are left out.
+func BuildOpenAPIDocument(routes gin.RoutesInfo) *openapi.Document {
+	docsByRoute := make(map[string]openapi.Route, len(routeDocs))
+	for _, route := range routeDocs {
+		docsByRoute[route.Method+" "+route.Path] = route
+	}
+
+	doc := openapi.NewDocument(openAPITitle, openAPIVersion)
+	for _, info := range routes {
+		route, ok := docsByRoute[info.Method+" "+info.Path]
+		if !ok {
+			log.WithField("method", info.Method).WithField("path", info.Path).Warn("Route is missing from the OpenAPI documentation.")
+			continue
+		}
+		doc.AddRoute(route)
+	}
+
+	return doc
+}
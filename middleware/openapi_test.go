@@ -0,0 +1,53 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/bridges/slack"
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/metrics"
+	"github.com/andrew-boutin/dndtextapi/openapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestEngine registers every route including the optional ones.
+func newTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterMiddleware(r, nil, nil, nil, slack.NewBridge(nil, nil, configs.SlackConfiguration{}), nil, metrics.NewRegistry(), nil, configs.ServerConfiguration{}, configs.RetentionConfiguration{})
+	return r
+}
+
+func TestOpenAPIDocumentCoversRoutes(t *testing.T) {
+	r := newTestEngine()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	doc := &openapi.Document{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), doc))
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	for _, route := range r.Routes() {
+		assert.True(t, doc.HasOperation(route.Method, route.Path), "%s %s is missing from the OpenAPI document", route.Method, route.Path)
+	}
+}
+
+func TestOpenAPIDocsMatchRoutes(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, route := range newTestEngine().Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	for _, route := range routeDocs {
+		assert.True(t, registered[route.Method+" "+route.Path], "%s %s is documented but not registered", route.Method, route.Path)
+	}
+}
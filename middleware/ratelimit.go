@@ -0,0 +1,81 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	rateLimiterKey = "rateLimiterKey"
+
+	// Rate limit headers
+	retryAfterHeader         = "Retry-After"
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// GetRateLimiter pulls the rate limiter out of the context that was previously
+// injected. It's nil when rate limiting is disabled.
+func GetRateLimiter(c *gin.Context) *ratelimit.Limiter {
+	limiter, _ := c.MustGet(rateLimiterKey).(*ratelimit.Limiter)
+	return limiter
+}
+
+// RateLimitMiddleware is a gin.HandlerFunc wrapper that takes a token from the
+// caller's bucket for the route group and denies the request when it's empty.
+// Callers are identified by their Token, then their User, then their IP. The
+// X-Forwarded-For header only counts when the request came from a trusted proxy.
+func RateLimitMiddleware(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := GetRateLimiter(c)
+		if limiter == nil {
+			return
+		}
+
+		result, limited, err := limiter.Take(group, rateLimitKey(c, limiter))
+		if err != nil {
+			// Don't take the whole API down with the store
+			GetLogger(c).WithError(err).WithField("group", group).Error("Failed to check rate limit.")
+			return
+		}
+		if !limited {
+			return
+		}
+
+		c.Header(rateLimitLimitHeader, strconv.Itoa(result.Limit))
+		c.Header(rateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		c.Header(rateLimitResetHeader, seconds(result.Reset))
+
+		if !result.Allowed {
+			c.Header(retryAfterHeader, seconds(result.RetryAfter))
+			c.AbortWithError(http.StatusTooManyRequests, ratelimit.ErrRateLimited)
+		}
+	}
+}
+
+// rateLimitKey identifies the caller. Anonymous callers are identified by the IP
+// the limiter works out for them.
+func rateLimitKey(c *gin.Context, limiter *ratelimit.Limiter) string {
+	if token, ok := c.Get(tokenContextKey); ok {
+		return fmt.Sprintf("token:%d", token.(*tokens.Token).ID)
+	}
+	if _, ok := c.Get(userContextKey); ok {
+		return fmt.Sprintf("user:%d", GetAuthenticatedUser(c).ID)
+	}
+	return "ip:" + limiter.ClientIP(c.Request)
+}
+
+// seconds rounds the duration up to whole seconds for the headers.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/andrew-boutin/dndtextapi/ratelimit"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), configs.RateLimitConfiguration{
+		Anonymous: configs.RateLimit{Requests: 1, Per: time.Minute},
+	})
+
+	r := gin.New()
+	r.Use(ErrorHandler, ContextInjectionMiddleware(nil, nil, nil, limiter))
+	r.GET("/", func(c *gin.Context) {
+		switch c.Query("as") {
+		case "user":
+			c.Set(userContextKey, &users.User{ID: 1})
+		case "token":
+			c.Set(userContextKey, &users.User{ID: 1})
+			c.Set(tokenContextKey, &tokens.Token{ID: 1})
+		}
+	}, RateLimitMiddleware(ratelimit.AnonymousGroup), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	get := func(as string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/?as="+as, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "1", w.Header().Get(rateLimitLimitHeader))
+	assert.Equal(t, "0", w.Header().Get(rateLimitRemainingHeader))
+	assert.Equal(t, "60", w.Header().Get(rateLimitResetHeader))
+
+	w = get("")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get(retryAfterHeader))
+	assert.Contains(t, w.Body.String(), "rate_limited")
+
+	// Users and Tokens have their own buckets apart from the IP
+	assert.Equal(t, http.StatusNoContent, get("user").Code)
+	assert.Equal(t, http.StatusNoContent, get("token").Code)
+	assert.Equal(t, http.StatusTooManyRequests, get("token").Code)
+}
+
+func TestRateLimitMiddlewareDisabled(t *testing.T) {
+	r := gin.New()
+	r.Use(ContextInjectionMiddleware(nil, nil, nil, nil))
+	r.GET("/", RateLimitMiddleware(ratelimit.AnonymousGroup), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "", w.Header().Get(rateLimitLimitHeader))
+	}
+}
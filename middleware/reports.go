@@ -0,0 +1,215 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/audit"
+	"github.com/andrew-boutin/dndtextapi/bans"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/messages"
+	"github.com/andrew-boutin/dndtextapi/reports"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterReportsRoutes registers the routes Users report abuse through. The
+// admin routes for working through Reports are with the rest of the admin routes.
+func RegisterReportsRoutes(g *gin.RouterGroup) {
+	g.GET("/reports", ValidateHeaders(acceptHeader), GetReports)
+	g.POST("/reports", ValidateHeaders(acceptHeader, contentTypeHeader), CreateReport)
+}
+
+// GetReports retrieves the Reports the authenticated User made.
+func GetReports(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	userReports, err := dbBackend.GetReports(c.Request.Context(), reports.Filter{ReporterID: user.ID})
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve reports for user.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, userReports)
+}
+
+// CreateReport reports a Message, Character, Channel, or User to the admins. Users
+// can only report things they're able to see.
+func CreateReport(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	req := &requests.CreateReport{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	report := req.Report(user.ID)
+	if !loadReportTarget(c, user, report) {
+		return
+	}
+
+	newReport, err := dbBackend.CreateReport(c.Request.Context(), report)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to create report.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newReport)
+}
+
+// loadReportTarget makes sure the reported thing exists and that the User can see
+// it, then fills in the User responsible for it. It aborts and returns false if
+// the thing can't be reported.
+func loadReportTarget(c *gin.Context, user *users.User, report *reports.Report) bool {
+	dbBackend := GetDBBackend(c)
+	ctx := c.Request.Context()
+
+	var (
+		channelID int
+		isStory   = true
+		err       error
+	)
+
+	switch report.TargetType {
+	case reports.MessageTarget:
+		var message *messages.Message
+		message, err = dbBackend.GetMessage(ctx, report.TargetID)
+		if err == nil {
+			var char *characters.Character
+			char, err = dbBackend.GetCharacter(ctx, message.CharacterID)
+			if err == nil {
+				channelID, isStory, report.TargetUserID = message.ChannelID, message.IsStory, char.UserID
+			}
+		}
+	case reports.CharacterTarget:
+		var char *characters.Character
+		char, err = dbBackend.GetCharacter(ctx, report.TargetID)
+		if err == nil {
+			channelID, report.TargetUserID = char.ChannelID, char.UserID
+		}
+	case reports.ChannelTarget:
+		channelID = report.TargetID
+	case reports.UserTarget:
+		var target *users.User
+		target, err = dbBackend.GetUserByID(ctx, report.TargetID)
+		if err == nil {
+			report.TargetUserID = target.ID
+		}
+	}
+
+	if err == nil && channelID != 0 {
+		var channel *channels.Channel
+		channel, err = dbBackend.GetChannel(ctx, channelID)
+		if err == nil {
+			if report.TargetType == reports.ChannelTarget {
+				report.TargetUserID = channel.OwnerID
+			}
+
+			var canSee bool
+			canSee, err = canSeeInChannel(c, user, channel, isStory)
+			if err == nil && !canSee {
+				c.AbortWithStatus(http.StatusForbidden)
+				return false
+			}
+		}
+	}
+
+	if err != nil {
+		switch err {
+		case messages.ErrMessageNotFound, characters.ErrCharacterNotFound, channels.ErrChannelNotFound, users.ErrUserNotFound:
+			c.AbortWithError(http.StatusNotFound, err)
+		default:
+			GetLogger(c).WithError(err).Error("Failed to look up reported thing.")
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
+// canSeeInChannel determines if the User can see what's in the Channel. Members
+// see everything while everyone else only sees the story in public Channels.
+func canSeeInChannel(c *gin.Context, user *users.User, channel *channels.Channel, isStory bool) (bool, error) {
+	if channel.OwnerID == user.ID {
+		return true, nil
+	}
+	if !channel.IsPrivate && isStory {
+		return true, nil
+	}
+	return GetDBBackend(c).DoesUserHaveCharacterInChannel(c.Request.Context(), user.ID, channel.ID)
+}
+
+// takeReportAction does what resolving the Report calls for to the reported thing.
+// Deleting something that's already gone is left as is. Banning bans the User
+// responsible for the reported thing from the whole site, with the resolution, or
+// the reason for the Report if there isn't one, as the reason. Admins can't be
+// banned. It aborts and returns false if the action couldn't be taken.
+func takeReportAction(c *gin.Context, report *reports.Report, req *requests.ResolveReport) bool {
+	admin := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+	ctx := c.Request.Context()
+
+	action := req.ReportAction()
+
+	var err error
+	switch action {
+	case reports.DeleteAction:
+		switch report.TargetType {
+		case reports.MessageTarget:
+			err = dbBackend.DeleteMessage(ctx, report.TargetID, nil)
+		case reports.CharacterTarget:
+			err = dbBackend.DeleteCharacter(ctx, report.TargetID, nil)
+		case reports.ChannelTarget:
+			err = dbBackend.DeleteChannel(ctx, report.TargetID, nil)
+		}
+		if err == messages.ErrMessageNotFound || err == characters.ErrCharacterNotFound || err == channels.ErrChannelNotFound {
+			err = nil
+		}
+	case reports.BanAction:
+		var target *users.User
+		target, err = dbBackend.GetUserByID(ctx, report.TargetUserID)
+		if err == nil {
+			if target.IsAdmin {
+				GetLogger(c).Error("Admin attempted to ban another admin through a report.")
+				c.AbortWithStatus(http.StatusForbidden)
+				return false
+			}
+
+			reason := req.Resolution
+			if reason == "" {
+				reason = report.Reason
+			}
+			var newBan *bans.Ban
+			newBan, err = dbBackend.CreateBan(ctx, &bans.Ban{
+				UserID:    target.ID,
+				ChannelID: bans.SiteWide,
+				Reason:    reason,
+				IssuedBy:  admin.ID,
+				ExpiresOn: req.BanExpiresOn,
+			})
+			if err == nil {
+				recordAudit(c, audit.BanCreated, audit.BanTarget, newBan.ID, nil, newBan)
+			}
+		}
+		if err == users.ErrUserNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return false
+		}
+	}
+
+	if err != nil {
+		GetLogger(c).WithError(err).WithField("action", action).Error("Failed to take action for report.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
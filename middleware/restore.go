@@ -0,0 +1,26 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/gin-gonic/gin"
+)
+
+// restoreWindow is how long after something is deleted its owner can restore it.
+// It's set from the retention config.
+var restoreWindow time.Duration
+
+// checkRestoreWindow makes sure whatever was deleted at the time can still be
+// restored by its owner. It aborts with a 410 if the window has passed and returns
+// false. Admins can restore anything that hasn't been purged so they skip this.
+func checkRestoreWindow(c *gin.Context, deletedOn *time.Time) bool {
+	if deletedOn != nil && time.Since(*deletedOn) > restoreWindow {
+		c.AbortWithError(http.StatusGone, apierrors.ErrRestoreWindowExpired)
+		return false
+	}
+	return true
+}
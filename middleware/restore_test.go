@@ -0,0 +1,46 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRestoreWindow(t *testing.T) {
+	restoreWindow = time.Hour
+	defer func() { restoreWindow = 0 }()
+
+	recently := time.Now().Add(-time.Minute)
+	longAgo := time.Now().Add(-2 * time.Hour)
+
+	type testIO struct {
+		desc      string
+		deletedOn *time.Time
+		ok        bool
+	}
+
+	tests := []testIO{
+		{desc: "Inside the window", deletedOn: &recently, ok: true},
+		{desc: "Outside the window", deletedOn: &longAgo, ok: false},
+		{desc: "Not deleted", deletedOn: nil, ok: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+			assert.Equal(t, test.ok, checkRestoreWindow(c, test.deletedOn))
+			if !test.ok {
+				assert.Equal(t, http.StatusGone, c.Writer.Status())
+				assert.Equal(t, apierrors.ErrRestoreWindowExpired, c.Errors.Last().Err)
+			}
+		})
+	}
+}
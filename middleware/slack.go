@@ -0,0 +1,55 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/bridges/slack"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSlackRoutes adds the route that receives Slack Events API requests. These
+// requests come from Slack instead of a User so they're verified using the Slack
+// signature instead of a session.
+func RegisterSlackRoutes(r *gin.Engine, bridge *slack.Bridge) {
+	r.POST("/slack/events", SlackEventsHandler(bridge))
+}
+
+// SlackEventsHandler verifies that the request came from Slack and hands the
+// event off to the Slack bridge.
+func SlackEventsHandler(bridge *slack.Bridge) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		timestamp := c.GetHeader(slack.TimestampHeader)
+		signature := c.GetHeader(slack.SignatureHeader)
+		err = bridge.VerifyRequest(timestamp, signature, body, time.Now())
+		if err != nil {
+			GetLogger(c).WithError(err).Error("Rejected slack event request.")
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		challenge, err := bridge.HandleEvent(c.Request.Context(), body)
+		if err != nil {
+			GetLogger(c).WithError(err).Error("Failed to handle slack event.")
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+
+		// Slack expects the challenge echoed back when verifying the URL
+		if challenge != "" {
+			c.JSON(http.StatusOK, gin.H{"challenge": challenge})
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/stats"
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatsWeeks is the most weeks of Channel creation the Stats can cover.
+const maxStatsWeeks = 104
+
+// AdminGetStats retrieves the Stats for the admin dashboard covering the number
+// of weeks in the optional `weeks` query parameter and including the number of
+// most active Channels in the optional `top` query parameter.
+func AdminGetStats(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+
+	opts, err := statsOptionsFromQuery(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	siteStats, err := dbBackend.GetStats(c.Request.Context(), opts)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to retrieve stats.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, siteStats)
+}
+
+// statsOptionsFromQuery builds the stats Options from the optional query
+// parameters. Missing values get the defaults and ones above the max are lowered
+// to the max.
+func statsOptionsFromQuery(c *gin.Context) (stats.Options, error) {
+	opts := stats.Options{}
+
+	ints := map[string]*int{
+		weeksQueryParam: &opts.Weeks,
+		topQueryParam:   &opts.Top,
+	}
+	for name, dest := range ints {
+		val, err := QueryParamAsIntExtractor(c, name)
+		if err != nil && err != ErrQueryParamNotFound {
+			return opts, err
+		}
+		*dest = val
+	}
+
+	if opts.Weeks <= 0 {
+		opts.Weeks = stats.DefaultWeeks
+	} else if opts.Weeks > maxStatsWeeks {
+		opts.Weeks = maxStatsWeeks
+	}
+	if opts.Top <= 0 {
+		opts.Top = stats.DefaultTop
+	} else if opts.Top > maxPageLimit {
+		opts.Top = maxPageLimit
+	}
+
+	return opts, nil
+}
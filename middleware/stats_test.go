@@ -0,0 +1,58 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/stats"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsOptionsFromQuery(t *testing.T) {
+	testIO := []struct {
+		desc      string
+		query     string
+		expected  stats.Options
+		expectErr bool
+	}{
+		{
+			desc:     "Defaults.",
+			query:    "",
+			expected: stats.Options{Weeks: stats.DefaultWeeks, Top: stats.DefaultTop},
+		},
+		{
+			desc:     "Both given.",
+			query:    "weeks=4&top=3",
+			expected: stats.Options{Weeks: 4, Top: 3},
+		},
+		{
+			desc:     "Above the max.",
+			query:    "weeks=1000&top=1000",
+			expected: stats.Options{Weeks: maxStatsWeeks, Top: maxPageLimit},
+		},
+		{
+			desc:      "Bad weeks.",
+			query:     "weeks=all",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/admin/stats?"+test.query, nil)
+
+			opts, err := statsOptionsFromQuery(c)
+			if test.expectErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, opts)
+		})
+	}
+}
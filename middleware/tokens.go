@@ -0,0 +1,118 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+	"github.com/gin-gonic/gin"
+)
+
+const tokenIDPathParam = "tokenID"
+
+// RegisterTokensRoutes registers all of the Token routes with their associated
+// middleware. Users can only manage their own Tokens.
+func RegisterTokensRoutes(g *gin.RouterGroup) {
+	g.GET("/users/:id/tokens", ValidateHeaders(acceptHeader), RequireSelf, GetTokens)
+	g.POST("/users/:id/tokens", ValidateHeaders(acceptHeader, contentTypeHeader), RequireSelf, CreateToken)
+	g.DELETE("/users/:id/tokens/:tokenID", RequireSelf, DeleteToken)
+}
+
+// RequireSelf requires that the User id in the path is the authenticated User
+// or else access is denied.
+func RequireSelf(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+
+	userIDFromPath, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if userIDFromPath != user.ID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+}
+
+// GetTokens retrieves the authenticated User's Tokens. Token values are never
+// included.
+func GetTokens(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	userTokens, err := dbBackend.GetTokensForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to look up tokens for user.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, userTokens)
+}
+
+// CreateToken creates a new Token for the authenticated User. The response is
+// the only time the Token value is available.
+func CreateToken(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	req := &requests.CreateToken{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	token := req.Token(user.ID)
+	token.Generate()
+
+	newToken, err := dbBackend.CreateToken(c.Request.Context(), token)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to create token.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newToken)
+}
+
+// DeleteToken revokes one of the authenticated User's Tokens.
+func DeleteToken(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	tokenID, err := PathParamAsIntExtractor(c, tokenIDPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	token, err := dbBackend.GetToken(c.Request.Context(), tokenID)
+	if err != nil {
+		if err == tokens.ErrTokenNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		GetLogger(c).WithError(err).Error("Failed to look up token.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	// Don't reveal other Users' Tokens exist
+	if token.UserID != user.ID {
+		c.AbortWithError(http.StatusNotFound, tokens.ErrTokenNotFound)
+		return
+	}
+
+	err = dbBackend.DeleteToken(c.Request.Context(), tokenID)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to delete token.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/tracing"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// TracingMiddleware starts a span for the request that every span started with
+// c.Request.Context(), such as the Backend calls, is a child of. A trace started by
+// the caller is continued if they sent a traceparent header. It has to run before
+// the ErrorHandler so the final status code is recorded.
+func TracingMiddleware(r *gin.Engine, tracer *tracing.Tracer) gin.HandlerFunc {
+	matcher := newRouteMatcher(r)
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if parent, ok := tracing.ParseTraceparent(c.GetHeader(tracing.TraceparentHeader)); ok {
+			ctx = tracing.ContextWithRemoteParent(ctx, parent)
+		}
+
+		ctx, span := tracer.StartSpan(ctx, c.Request.Method, tracing.KindServer)
+		defer span.Finish()
+
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.target", c.Request.URL.Path)
+		span.SetAttribute("http.request_id", GetRequestID(c))
+		c.Request = c.Request.WithContext(ctx)
+		addLogFields(c, log.Fields{"trace_id": span.TraceID.String(), "span_id": span.SpanID.String()})
+
+		c.Next()
+
+		// The route isn't known until the request has been routed
+		route := matcher.route(c)
+		status := c.Writer.Status()
+		span.SetName(c.Request.Method + " " + route)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.RecordError(errorForStatus(c, status))
+		}
+	}
+}
+
+// errorForStatus is the error that caused the request to fail or the status text if
+// there isn't one.
+func errorForStatus(c *gin.Context, status int) error {
+	if last := c.Errors.Last(); last != nil {
+		return last.Err
+	}
+	return fmt.Errorf("%s", http.StatusText(status))
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingExporter keeps the spans it's given.
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (e *recordingExporter) Export(serviceName string, spans []*tracing.Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := tracing.NewTracer("test", exporter, time.Hour)
+	tracer.Start()
+
+	var handlerSpan *tracing.Span
+	r := gin.New()
+	r.Use(TracingMiddleware(r, tracer), ErrorHandler)
+	r.GET("/channels/:channelID", func(c *gin.Context) {
+		_, handlerSpan = tracer.StartSpan(c.Request.Context(), "handler", tracing.KindInternal)
+		handlerSpan.Finish()
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/channels/5", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(w, req)
+	tracer.Stop()
+
+	assert.Len(t, exporter.spans, 2)
+	requestSpan := exporter.spans[1]
+	assert.Equal(t, "GET /channels/:channelID", requestSpan.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requestSpan.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", requestSpan.ParentSpanID.String())
+	assert.Equal(t, http.StatusInternalServerError, requestSpan.Attributes["http.status_code"])
+	assert.NotEmpty(t, requestSpan.Error)
+	assert.Equal(t, requestSpan.SpanID, handlerSpan.ParentSpanID)
+}
@@ -4,10 +4,12 @@ package middleware
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/requests"
 	"github.com/andrew-boutin/dndtextapi/users"
 	"github.com/gin-gonic/gin"
-	log "github.com/sirupsen/logrus"
 )
 
 // RegisterUsersRoutes registers all of the Users routes with their
@@ -15,39 +17,68 @@ import (
 func RegisterUsersRoutes(g *gin.RouterGroup) {
 	g.GET("/users/:id", ValidateHeaders(acceptHeader), GetUser)
 	g.PUT("/users/:id", ValidateHeaders(acceptHeader, contentTypeHeader), UpdateUser)
+	g.PATCH("/users/:id", ValidateHeaders(acceptHeader, contentTypeHeader), PatchUser)
 	g.DELETE("/users/:id", DeleteUser)
+	g.GET("/users/:id/notifications", ValidateHeaders(acceptHeader), GetNotificationPreferences)
+	g.PUT("/users/:id/notifications", ValidateHeaders(acceptHeader, contentTypeHeader), UpdateNotificationPreferences)
 }
 
-// GetUser retrieves the User matching the id in the path.
+// GetUser retrieves the User matching the id in the path. Users get their own
+// full profile and only the public profile of other Users.
 func GetUser(c *gin.Context) {
 	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
 
 	userIDFromPath, err := PathParamAsIntExtractor(c, idPathParam)
 	if err != nil {
-		log.WithError(err).Error("Failed to get User id from path.")
+		GetLogger(c).WithError(err).Error("Failed to get User id from path.")
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	// Users are only allowed to retrieve themselves
+	requestedUser := user
 	if userIDFromPath != user.ID {
-		log.Error("User attempted to get a User other than themselves.")
-		c.AbortWithStatus(http.StatusForbidden)
-		return
+		requestedUser, err = dbBackend.GetUserByID(c.Request.Context(), userIDFromPath)
+		if err != nil {
+			if err == users.ErrUserNotFound {
+				c.AbortWithError(http.StatusNotFound, err)
+				return
+			}
+			GetLogger(c).WithError(err).Error("Failed to look up user.")
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, user)
+	respondWithETag(c, http.StatusOK, requestedUser.LastUpdated, requestedUser.ViewFor(user))
 }
 
 // UpdateUser allows a User to update some of their own User data.
 func UpdateUser(c *gin.Context) {
-	// TODO: Validate only some fields attempted to be updated like bio
+	updateUser(c, func(existing *users.User) (*requests.UpdateUser, error) {
+		req := &requests.UpdateUser{}
+		return req, requests.Decode(c.Request.Body, req)
+	})
+}
+
+// PatchUser allows a User to update only the fields of their own User data that
+// are in the JSON merge patch in the request body.
+func PatchUser(c *gin.Context) {
+	updateUser(c, func(existing *users.User) (*requests.UpdateUser, error) {
+		req := requests.UpdateUserFrom(existing)
+		return req, requests.MergePatch(c.Request.Body, req)
+	})
+}
+
+// updateUser updates the authenticated User with the request that decode reads
+// from the body.
+func updateUser(c *gin.Context, decode func(existing *users.User) (*requests.UpdateUser, error)) {
 	user := GetAuthenticatedUser(c)
 	dbBackend := GetDBBackend(c)
 
 	userIDFromPath, err := PathParamAsIntExtractor(c, idPathParam)
 	if err != nil {
-		log.WithError(err).Error("Failed to get user id from path.")
+		GetLogger(c).WithError(err).Error("Failed to get user id from path.")
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
@@ -58,23 +89,26 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// The User may have changed their profile from somewhere else
+	expected, ok := checkIfMatch(c, user.LastUpdated)
+	if !ok {
+		return
+	}
+
 	// Get the provided User data out of the request body
-	userBody := &users.User{}
-	err = c.Bind(userBody)
+	req, err := decode(user)
 	if err != nil {
-		log.WithError(err).Error("Issue reading user from request body.")
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	updatedUser, err := dbBackend.UpdateUser(user.ID, userBody)
+	updatedUser, err := dbBackend.UpdateUser(c.Request.Context(), user.ID, req.User(), expected)
 	if err != nil {
-		log.WithError(err).Error("Failed to update user.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(apierrors.StatusFor(err), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedUser)
+	respondWithETag(c, http.StatusOK, updatedUser.LastUpdated, updatedUser.SelfView())
 }
 
 // DeleteUser allows a User to delete their own User data.
@@ -94,25 +128,96 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	err = dbBackend.DeleteMessagesFromUser(userIDFromPath)
+	expected, ok := checkIfMatch(c, user.LastUpdated)
+	if !ok {
+		return
+	}
+
+	// The User's Messages and Characters are deleted along with them
+	err = dbBackend.DeleteUser(c.Request.Context(), user.ID, expected)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete messages from user.")
-		c.AbortWithStatus(http.StatusInternalServerError)
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetNotificationPreferences retrieves the notification preferences for the User
+// matching the id in the path.
+func GetNotificationPreferences(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	userIDFromPath, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Users are only allowed to see their own preferences
+	if userIDFromPath != user.ID {
+		c.AbortWithStatus(http.StatusForbidden)
 		return
 	}
 
-	err = dbBackend.DeleteCharactersFromUser(userIDFromPath)
+	prefs, err := dbBackend.GetNotificationPreferences(c.Request.Context(), user.ID)
 	if err != nil {
-		log.WithError(err).Error("Failed to delete characters from user.")
+		GetLogger(c).WithError(err).Error("Failed to look up notification preferences.")
 		c.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	err = dbBackend.DeleteUser(user.ID)
+	respondWithETag(c, http.StatusOK, prefs.LastUpdated, prefs)
+}
+
+// UpdateNotificationPreferences allows a User to choose which notification
+// emails they receive.
+func UpdateNotificationPreferences(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	dbBackend := GetDBBackend(c)
+
+	userIDFromPath, err := PathParamAsIntExtractor(c, idPathParam)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	// Users are only allowed to update their own preferences
+	if userIDFromPath != user.ID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	req := &requests.NotificationPreferences{}
+	err = requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	// Only look up the current preferences when there's a version to compare against
+	var expected *time.Time
+	if hasPrecondition(c) {
+		existingPrefs, err := dbBackend.GetNotificationPreferences(c.Request.Context(), user.ID)
+		if err != nil {
+			GetLogger(c).WithError(err).Error("Failed to look up notification preferences.")
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		var ok bool
+		expected, ok = checkIfMatch(c, existingPrefs.LastUpdated)
+		if !ok {
+			return
+		}
+	}
+
+	updatedPrefs, err := dbBackend.UpdateNotificationPreferences(c.Request.Context(), user.ID, req.Preferences(user.ID), expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, updatedPrefs.LastUpdated, updatedPrefs)
 }
@@ -0,0 +1,215 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/channels"
+	"github.com/andrew-boutin/dndtextapi/requests"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	"github.com/dchest/uniuri"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	webhookKey = "webhookKey"
+
+	webhookSecretLength = 32
+)
+
+// RegisterWebhooksRoutes registers all of the Webhook routes with their
+// associated middleware. Only the Channel owner can manage Webhooks.
+func RegisterWebhooksRoutes(g *gin.RouterGroup) {
+	g.GET("/channels/:channelID/webhooks", ValidateHeaders(acceptHeader), LoadChannelFromPathID, RequireChannelOwner, GetWebhooks)
+	g.POST("/channels/:channelID/webhooks", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, RequireChannelOwner, CreateWebhook)
+	g.GET("/channels/:channelID/webhooks/:id", ValidateHeaders(acceptHeader), LoadChannelFromPathID, RequireChannelOwner, LoadWebhook, GetWebhook)
+	g.PUT("/channels/:channelID/webhooks/:id", ValidateHeaders(acceptHeader, contentTypeHeader), LoadChannelFromPathID, RequireChannelOwner, LoadWebhook, UpdateWebhook)
+	g.DELETE("/channels/:channelID/webhooks/:id", LoadChannelFromPathID, RequireChannelOwner, LoadWebhook, DeleteWebhook)
+	g.GET("/channels/:channelID/webhooks/:id/deliveries", ValidateHeaders(acceptHeader), LoadChannelFromPathID, RequireChannelOwner, LoadWebhook, GetWebhookDeliveries)
+}
+
+// GetWebhookDispatcher pulls the Webhook dispatcher out of the context that
+// was previously injected.
+func GetWebhookDispatcher(c *gin.Context) *webhooks.Dispatcher {
+	return c.MustGet(webhookDispatcherKey).(*webhooks.Dispatcher)
+}
+
+// RequireChannelOwner requires that the authenticated User owns the Channel
+// previously loaded from the path or else access is denied.
+func RequireChannelOwner(c *gin.Context) {
+	user := GetAuthenticatedUser(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	if channel.OwnerID != user.ID {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+}
+
+// GetWebhooks retrieves all of the Webhooks registered for the Channel.
+func GetWebhooks(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	channelWebhooks, err := dbBackend.GetWebhooksInChannel(c.Request.Context(), channel.ID)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to look up webhooks for channel.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, channelWebhooks)
+}
+
+// CreateWebhook registers a new Webhook for the Channel using the URL and Events
+// from the request body. The signing secret is generated for the owner.
+func CreateWebhook(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	req := &requests.CreateWebhook{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !checkWebhookURL(c, req.URL) {
+		return
+	}
+
+	webhook := req.Webhook(channel.ID)
+	webhook.Secret = uniuri.NewLen(webhookSecretLength)
+
+	newWebhook, err := dbBackend.CreateWebhook(c.Request.Context(), webhook)
+	if err != nil {
+		GetLogger(c).WithError(err).Error("Failed to create webhook.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newWebhook)
+}
+
+// GetWebhook retrieves the Webhook matching the id in the path.
+func GetWebhook(c *gin.Context) {
+	webhook := c.MustGet(webhookKey).(*webhooks.Webhook)
+	respondWithETag(c, http.StatusOK, webhook.LastUpdated, webhook)
+}
+
+// UpdateWebhook updates the URL, Events, and disabled state of the Webhook
+// matching the id in the path. Re-enabling a Webhook clears its failures.
+func UpdateWebhook(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	existingWebhook := c.MustGet(webhookKey).(*webhooks.Webhook)
+
+	expected, ok := checkIfMatch(c, existingWebhook.LastUpdated)
+	if !ok {
+		return
+	}
+
+	req := &requests.UpdateWebhook{}
+	err := requests.Decode(c.Request.Body, req)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !checkWebhookURL(c, req.URL) {
+		return
+	}
+
+	updatedWebhook, err := dbBackend.UpdateWebhook(c.Request.Context(), existingWebhook.ID, req.Webhook(), expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, updatedWebhook.LastUpdated, updatedWebhook)
+}
+
+// DeleteWebhook deletes the Webhook matching the id in the path.
+func DeleteWebhook(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	webhook := c.MustGet(webhookKey).(*webhooks.Webhook)
+
+	expected, ok := checkIfMatch(c, webhook.LastUpdated)
+	if !ok {
+		return
+	}
+
+	err := dbBackend.DeleteWebhook(c.Request.Context(), webhook.ID, expected)
+	if err != nil {
+		c.AbortWithError(apierrors.StatusFor(err), err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries retrieves the delivery log for the Webhook matching the
+// id in the path.
+func GetWebhookDeliveries(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	webhook := c.MustGet(webhookKey).(*webhooks.Webhook)
+
+	deliveries, err := dbBackend.GetWebhookDeliveries(c.Request.Context(), webhook.ID)
+	if err != nil {
+		GetLogger(c).WithError(err).WithField("webhookID", webhook.ID).Error("Failed to look up webhook deliveries.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// LoadWebhook attempts to lookup the Webhook using the id in the path and stores
+// it in the context so the later middleware doesn't have to do it. The Webhook
+// has to belong to the Channel from the path.
+func LoadWebhook(c *gin.Context) {
+	dbBackend := GetDBBackend(c)
+	channel := c.MustGet(channelKey).(*channels.Channel)
+
+	webhookID, err := PathParamAsIntExtractor(c, idPathParam)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	webhook, err := dbBackend.GetWebhook(c.Request.Context(), webhookID)
+	if err != nil {
+		if err == webhooks.ErrWebhookNotFound {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+
+		GetLogger(c).WithError(err).WithField("webhookID", webhookID).Error("Failed to look up webhook.")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if webhook.ChannelID != channel.ID {
+		c.AbortWithError(http.StatusNotFound, webhooks.ErrWebhookNotFound)
+		return
+	}
+
+	c.Set(webhookKey, webhook)
+}
+
+// checkWebhookURL makes sure the Webhook URL doesn't point at a private address.
+// It aborts and returns false if it does or its host couldn't be looked up.
+func checkWebhookURL(c *gin.Context, rawURL string) bool {
+	err := GetWebhookDispatcher(c).CheckURL(c.Request.Context(), rawURL)
+	if err == nil {
+		return true
+	}
+
+	message := "must have a host that can be looked up"
+	if err == webhooks.ErrPrivateAddress {
+		message = "must not be a loopback or private address"
+	}
+	c.AbortWithError(http.StatusBadRequest, apierrors.FieldErrors{{Field: "URL", Message: message}})
+	return false
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/characters"
+)
+
+// Kind is the type of event a Notification is for.
+type Kind string
+
+// The kinds of Notifications that can be sent.
+const (
+	// MentionKind is used when a User's Character is mentioned in a Message.
+	MentionKind Kind = "mention"
+
+	// InvitationKind is used when a User is given a Character in a Channel.
+	InvitationKind Kind = "invitation"
+
+	// TurnKind is used when the DM lets a User know it's their turn.
+	TurnKind Kind = "turn"
+)
+
+// Notification is a single event that a User should be told about.
+type Notification struct {
+	Kind        Kind
+	UserID      int
+	ChannelID   int
+	ChannelName string
+	Text        string
+	CreatedOn   time.Time
+}
+
+// String gives a single line human readable description of the Notification.
+func (n Notification) String() string {
+	switch n.Kind {
+	case MentionKind:
+		return fmt.Sprintf("You were mentioned in %s: %s", n.ChannelName, n.Text)
+	case InvitationKind:
+		return fmt.Sprintf("You were invited to join %s.", n.ChannelName)
+	case TurnKind:
+		return fmt.Sprintf("It's your turn in %s.", n.ChannelName)
+	default:
+		return n.Text
+	}
+}
+
+// Preferences holds which Notifications a User wants to receive.
+type Preferences struct {
+	UserID      int       `json:"UserID" db:"user_id"`
+	Mentions    bool      `json:"Mentions" db:"mentions"`
+	Invitations bool      `json:"Invitations" db:"invitations"`
+	Turns       bool      `json:"Turns" db:"turns"`
+	CreatedOn   time.Time `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time `json:"LastUpdated" db:"last_updated"`
+}
+
+// DefaultPreferences are used for Users that haven't saved any
+// Preferences yet.
+func DefaultPreferences(userID int) *Preferences {
+	return &Preferences{
+		UserID:      userID,
+		Mentions:    true,
+		Invitations: true,
+		Turns:       true,
+	}
+}
+
+// Allows determines if the Preferences allow Notifications of the given Kind.
+func (p *Preferences) Allows(kind Kind) bool {
+	switch kind {
+	case MentionKind:
+		return p.Mentions
+	case InvitationKind:
+		return p.Invitations
+	case TurnKind:
+		return p.Turns
+	default:
+		return false
+	}
+}
+
+// FindMentions finds all of the Characters that are mentioned in the content using
+// the `@name` format. Characters that haven't picked a name yet can't be mentioned.
+func FindMentions(content string, chars characters.CharacterCollection) characters.CharacterCollection {
+	lowerContent := strings.ToLower(content)
+
+	mentioned := make(characters.CharacterCollection, 0)
+	for _, char := range chars {
+		if char.Name == "" {
+			continue
+		}
+
+		if strings.Contains(lowerContent, "@"+strings.ToLower(char.Name)) {
+			mentioned = append(mentioned, char)
+		}
+	}
+	return mentioned
+}
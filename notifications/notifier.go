@@ -0,0 +1,194 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/users"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	digestSubject = "DnD Text activity digest"
+
+	// maxDigestAttempts is how many flushes in a row a User's digest can fail to
+	// send before their Notifications are dropped
+	maxDigestAttempts = 5
+)
+
+// Store defines the data the Notifier needs to look up when it's
+// time to send out a digest.
+type Store interface {
+	GetUserByID(context.Context, int) (*users.User, error)
+	GetNotificationPreferences(context.Context, int) (*Preferences, error)
+}
+
+// Notifier collects Notifications for Users and periodically sends each
+// User a single digest email instead of one email per event. A nil Notifier
+// is valid and discards everything so notifications can be turned off.
+type Notifier struct {
+	sender   Sender
+	store    Store
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[int][]Notification
+
+	// failures are how many flushes in a row each User's digest failed to send
+	failures map[int]int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewNotifier creates a Notifier that sends digests with the given Sender
+// every interval.
+func NewNotifier(sender Sender, store Store, interval time.Duration) *Notifier {
+	return &Notifier{
+		sender:   sender,
+		store:    store,
+		interval: interval,
+		pending:  make(map[int][]Notification),
+		failures: make(map[int]int),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Notify queues up the Notification to go out in the User's next digest.
+func (n *Notifier) Notify(notification Notification) {
+	if n == nil {
+		return
+	}
+
+	if notification.CreatedOn.IsZero() {
+		notification.CreatedOn = time.Now()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending[notification.UserID] = append(n.pending[notification.UserID], notification)
+}
+
+// Start begins sending digests in the background every interval.
+func (n *Notifier) Start() {
+	if n == nil {
+		return
+	}
+
+	go func() {
+		defer close(n.done)
+		ticker := time.NewTicker(n.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				n.Flush()
+			case <-n.stop:
+				// Don't drop anything that was waiting on the next tick
+				n.Flush()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops sending digests after sending out anything still pending.
+func (n *Notifier) Stop() {
+	if n == nil {
+		return
+	}
+
+	close(n.stop)
+	<-n.done
+}
+
+// Flush sends a digest to every User with pending Notifications that their
+// Preferences allow. Digests that fail to send stay pending for the next flush
+// until they've failed maxDigestAttempts times.
+func (n *Notifier) Flush() {
+	n.mu.Lock()
+	toSend := n.pending
+	n.pending = make(map[int][]Notification)
+	n.mu.Unlock()
+
+	for userID, userNotifications := range toSend {
+		err := n.sendDigest(userID, userNotifications)
+		n.recordDigest(userID, userNotifications, err)
+	}
+}
+
+// recordDigest keeps track of how sending the User's digest went. Failed
+// Notifications go back in front of any that came in since so they're sent in
+// order. Users that no longer exist are never retried.
+func (n *Notifier) recordDigest(userID int, userNotifications []Notification, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err == nil {
+		delete(n.failures, userID)
+		return
+	}
+
+	logger := log.WithError(err).WithField("userID", userID)
+	n.failures[userID]++
+	if err == users.ErrUserNotFound || n.failures[userID] >= maxDigestAttempts {
+		logger.WithField("notifications", len(userNotifications)).Error("Failed to send notification digest, dropping it.")
+		delete(n.failures, userID)
+		return
+	}
+
+	logger.Warn("Failed to send notification digest, retrying next flush.")
+	n.pending[userID] = append(userNotifications, n.pending[userID]...)
+}
+
+// sendDigest sends the User a single email with all of the Notifications
+// that they want to receive.
+func (n *Notifier) sendDigest(userID int, userNotifications []Notification) error {
+	ctx := context.Background()
+	user, err := n.store.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Banned Users don't get anything
+	if user.IsBanned {
+		return nil
+	}
+
+	prefs, err := n.store.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	allowed := make([]Notification, 0, len(userNotifications))
+	for _, notification := range userNotifications {
+		if prefs.Allows(notification.Kind) {
+			allowed = append(allowed, notification)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	return n.sender.Send(user.Email, digestSubject, buildDigestBody(user, allowed))
+}
+
+// buildDigestBody builds the plain text body of a digest email.
+func buildDigestBody(user *users.User, userNotifications []Notification) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Hi %s,\n\n", user.Username)
+	fmt.Fprintf(&buf, "Here's what happened since we last wrote:\n\n")
+	for _, notification := range userNotifications {
+		fmt.Fprintf(&buf, "- [%s] %s\n", notification.CreatedOn.Format(time.Kitchen), notification)
+	}
+	buf.WriteString("\nYou can change which emails you get from your notification preferences.\n")
+	return buf.String()
+}
@@ -0,0 +1,233 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/characters"
+	"github.com/andrew-boutin/dndtextapi/users"
+	"github.com/stretchr/testify/assert"
+)
+
+type sentEmail struct {
+	to, subject, body string
+}
+
+type fakeSender struct {
+	sent     []sentEmail
+	failures int
+}
+
+// Send fails until it's run out of failures.
+func (s *fakeSender) Send(to, subject, body string) error {
+	if s.failures > 0 {
+		s.failures--
+		return fmt.Errorf("smtp unavailable")
+	}
+	s.sent = append(s.sent, sentEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+type fakeStore struct {
+	users map[int]*users.User
+	prefs map[int]*Preferences
+}
+
+func (s fakeStore) GetUserByID(ctx context.Context, id int) (*users.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, users.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s fakeStore) GetNotificationPreferences(ctx context.Context, id int) (*Preferences, error) {
+	prefs, ok := s.prefs[id]
+	if !ok {
+		return DefaultPreferences(id), nil
+	}
+	return prefs, nil
+}
+
+func TestFlush(t *testing.T) {
+	store := fakeStore{
+		users: map[int]*users.User{
+			1: {ID: 1, Username: "one", Email: "one@fake.com"},
+			2: {ID: 2, Username: "two", Email: "two@fake.com"},
+			3: {ID: 3, Username: "banned", Email: "banned@fake.com", IsBanned: true},
+		},
+		prefs: map[int]*Preferences{
+			2: {UserID: 2, Mentions: false, Invitations: true, Turns: false},
+		},
+	}
+
+	testIO := []struct {
+		desc          string
+		notifications []Notification
+		expectedTo    []string
+		expectedLines []string
+	}{
+		{
+			desc:          "Nothing pending sends nothing",
+			notifications: []Notification{},
+			expectedTo:    []string{},
+		},
+		{
+			desc: "Multiple notifications are batched into a single digest",
+			notifications: []Notification{
+				{Kind: MentionKind, UserID: 1, ChannelName: "tavern", Text: "hi @one"},
+				{Kind: TurnKind, UserID: 1, ChannelName: "tavern"},
+			},
+			expectedTo:    []string{"one@fake.com"},
+			expectedLines: []string{"You were mentioned in tavern: hi @one", "It's your turn in tavern."},
+		},
+		{
+			desc: "Preferences filter out unwanted kinds",
+			notifications: []Notification{
+				{Kind: MentionKind, UserID: 2, ChannelName: "tavern", Text: "hi @two"},
+				{Kind: InvitationKind, UserID: 2, ChannelName: "dungeon"},
+			},
+			expectedTo:    []string{"two@fake.com"},
+			expectedLines: []string{"You were invited to join dungeon."},
+		},
+		{
+			desc: "Nothing sent when every notification is filtered out",
+			notifications: []Notification{
+				{Kind: TurnKind, UserID: 2, ChannelName: "tavern"},
+			},
+			expectedTo: []string{},
+		},
+		{
+			desc: "Banned users get nothing",
+			notifications: []Notification{
+				{Kind: TurnKind, UserID: 3, ChannelName: "tavern"},
+			},
+			expectedTo: []string{},
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			sender := &fakeSender{}
+			notifier := NewNotifier(sender, store, time.Minute)
+			for _, notification := range test.notifications {
+				notifier.Notify(notification)
+			}
+
+			notifier.Flush()
+
+			to := []string{}
+			for _, email := range sender.sent {
+				to = append(to, email.to)
+				assert.Equal(t, digestSubject, email.subject)
+			}
+			assert.Equal(t, test.expectedTo, to)
+
+			for _, line := range test.expectedLines {
+				assert.True(t, strings.Contains(sender.sent[0].body, line), line)
+			}
+
+			// Everything pending was handled so a second flush sends nothing new
+			sentCount := len(sender.sent)
+			notifier.Flush()
+			assert.Equal(t, sentCount, len(sender.sent))
+		})
+	}
+}
+
+func TestFlushRetries(t *testing.T) {
+	store := fakeStore{
+		users: map[int]*users.User{
+			1: {ID: 1, Username: "one", Email: "one@fake.com"},
+		},
+	}
+
+	testIO := []struct {
+		desc          string
+		failures      int
+		expectedSent  int
+		expectedLines []string
+	}{
+		{
+			desc:          "Failed digests are sent on a later flush along with anything new",
+			failures:      2,
+			expectedSent:  1,
+			expectedLines: []string{"It's your turn in tavern.", "You were invited to join dungeon."},
+		},
+		{
+			desc:     "Digests are dropped after too many failures",
+			failures: maxDigestAttempts,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			sender := &fakeSender{failures: test.failures}
+			notifier := NewNotifier(sender, store, time.Minute)
+			notifier.Notify(Notification{Kind: TurnKind, UserID: 1, ChannelName: "tavern"})
+			notifier.Flush()
+			notifier.Notify(Notification{Kind: InvitationKind, UserID: 1, ChannelName: "dungeon"})
+
+			for i := 0; i <= maxDigestAttempts; i++ {
+				notifier.Flush()
+			}
+
+			assert.Equal(t, test.expectedSent, len(sender.sent))
+			for _, line := range test.expectedLines {
+				assert.True(t, strings.Contains(sender.sent[0].body, line), line)
+			}
+			assert.Equal(t, 0, len(notifier.pending))
+			assert.Equal(t, 0, len(notifier.failures))
+		})
+	}
+}
+
+func TestFindMentions(t *testing.T) {
+	chars := characters.CharacterCollection{
+		&characters.Character{ID: 1, Name: "Gandalf"},
+		&characters.Character{ID: 2, Name: "Frodo"},
+		&characters.Character{ID: 3, Name: ""},
+	}
+
+	testIO := []struct {
+		desc     string
+		content  string
+		expected []int
+	}{
+		{
+			desc:     "No mentions",
+			content:  "Gandalf walks in",
+			expected: []int{},
+		},
+		{
+			desc:     "Single mention ignoring case",
+			content:  "@gandalf what now?",
+			expected: []int{1},
+		},
+		{
+			desc:     "Multiple mentions",
+			content:  "@Frodo and @Gandalf run",
+			expected: []int{1, 2},
+		},
+		{
+			desc:     "Characters without a name can't be mentioned",
+			content:  "@ hello",
+			expected: []int{},
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			ids := []int{}
+			for _, char := range FindMentions(test.content, chars) {
+				ids = append(ids, char.ID)
+			}
+			assert.Equal(t, test.expected, ids)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+)
+
+// Sender defines the functionality expected of a transport that
+// delivers emails.
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPSender is a Sender that delivers emails through an SMTP server.
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// MakeSMTPSender creates an SMTPSender using the notifications configuration. Auth
+// is only used when a username is configured so local SMTP stand-ins work as is.
+func MakeSMTPSender(c configs.NotificationsConfiguration) SMTPSender {
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+
+	return SMTPSender{
+		addr: fmt.Sprintf("%s:%d", c.Host, c.Port),
+		from: c.From,
+		auth: auth,
+	}
+}
+
+// Send sends a plain text email to the given address.
+func (s SMTPSender) Send(to, subject, body string) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{to}, buildEmail(s.from, to, subject, body))
+}
+
+// buildEmail builds the raw email message including headers.
+func buildEmail(from, to, subject, body string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", subject)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return buf.Bytes()
+}
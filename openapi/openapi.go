@@ -0,0 +1,268 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package openapi builds OpenAPI 3 documents describing the API from the registered
+// routes and the model structs that they send and receive.
+package openapi
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI specification version that documents are written against.
+const Version = "3.0.0"
+
+// Document is the root of an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	// schemaTypes is the type each component schema was made from
+	schemaTypes map[string]reflect.Type
+}
+
+// Info describes the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components holds the schemas that operations reference.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem holds the operations for a single path keyed by lowercase HTTP method.
+type PathItem map[string]*Operation
+
+// Operation describes a single method on a path.
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body sent with an operation.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response from an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema for a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON schema used to describe the models.
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+}
+
+// Route documents a single registered route. Request and Response are
+// example values of the model types, such as channels.Channel{}, and can be nil.
+type Route struct {
+	Method   string
+	Path     string
+	Summary  string
+	Tag      string
+	Query    []Parameter
+	Request  interface{}
+	Response interface{}
+
+	// Status is the success status code and defaults to 200
+	Status int
+}
+
+// NewDocument creates an empty Document.
+func NewDocument(title, version string) *Document {
+	return &Document{
+		OpenAPI:     Version,
+		Info:        Info{Title: title, Version: version},
+		Paths:       make(map[string]PathItem),
+		Components:  Components{Schemas: make(map[string]*Schema)},
+		schemaTypes: make(map[string]reflect.Type),
+	}
+}
+
+// QueryParam creates an optional string query Parameter.
+func QueryParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// AddRoute adds the Route as an operation in the Document. Gin style path parameters
+// such as `:channelID` are converted to `{channelID}` and documented as integers.
+func (d *Document) AddRoute(route Route) {
+	path, params := convertPath(route.Path)
+
+	op := &Operation{
+		Summary:    route.Summary,
+		Parameters: append(params, route.Query...),
+		Responses:  make(map[string]*Response),
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+
+	if route.Request != nil {
+		// PATCH bodies are JSON merge patches of the Request
+		contentType := "application/json"
+		if route.Method == http.MethodPatch {
+			contentType = "application/merge-patch+json"
+		}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{contentType: {Schema: d.SchemaFor(route.Request)}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp := &Response{Description: http.StatusText(status)}
+	if route.Response != nil {
+		resp.Content = map[string]MediaType{"application/json": {Schema: d.SchemaFor(route.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(route.Method)] = op
+}
+
+// HasOperation checks if the Document contains the method for the Gin style path.
+func (d *Document) HasOperation(method, path string) bool {
+	converted, _ := convertPath(path)
+	_, ok := d.Paths[converted][strings.ToLower(method)]
+	return ok
+}
+
+// SchemaFor creates the Schema for the value's type. Structs are added to the
+// Document components and referenced by name.
+func (d *Document) SchemaFor(v interface{}) *Schema {
+	return d.schemaForType(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (d *Document) schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaForType(t.Elem())}
+	case reflect.Map, reflect.Interface:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		name := d.schemaName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// Reserve the name first in case the struct refers to itself
+			schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			d.Components.Schemas[name] = schema
+			d.schemaTypes[name] = t
+			d.addProperties(schema, t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	return &Schema{}
+}
+
+// schemaName is the component name for the struct. Structs from different packages
+// with the same name, like requests.Channel and channels.Channel, are told apart
+// by prefixing the package name.
+func (d *Document) schemaName(t reflect.Type) string {
+	name := t.Name()
+	if existing, ok := d.schemaTypes[name]; ok && existing != t {
+		name = strings.Title(path.Base(t.PkgPath())) + name
+	}
+	return name
+}
+
+// addProperties adds the exported fields of the struct to the schema using
+// their json names.
+func (d *Document) addProperties(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		// Embedded structs are flattened the same way encoding/json does
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			d.addProperties(schema, field.Type)
+			continue
+		}
+
+		name := field.Name
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			name = tag
+		}
+
+		schema.Properties[name] = d.schemaForType(field.Type)
+	}
+}
+
+// convertPath converts a Gin style path into an OpenAPI path along with
+// its path Parameters.
+func convertPath(path string) (string, []Parameter) {
+	params := make([]Parameter, 0)
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "integer"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
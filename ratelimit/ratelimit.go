@@ -0,0 +1,189 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package ratelimit limits how often callers can make requests using token buckets.
+// Every caller gets a bucket per route group that holds up to Burst tokens and refills
+// at a steady rate. Each request takes a token and requests are denied while the
+// bucket is empty.
+//
+// Buckets live in a Store. MemoryStore keeps them in process which is fine for a single
+// server. Running more than one server needs a shared Store, such as one backed by
+// Redis, so callers can't get around the limits by hitting different servers.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+)
+
+// ErrRateLimited is the error to use when a request is denied for going over the limit.
+var ErrRateLimited = fmt.Errorf("rate limit exceeded")
+
+// forwardedForHeader lists the addresses a request was forwarded through by proxies.
+const forwardedForHeader = "X-Forwarded-For"
+
+// The route groups that have their own limits.
+const (
+	AnonymousGroup     = "anonymous"
+	AuthenticatedGroup = "authenticated"
+	AdminGroup         = "admin"
+	MessagesGroup      = "messages"
+)
+
+// Limit is the size of a bucket and how quickly it refills.
+type Limit struct {
+	// Rate is how many tokens are added per second
+	Rate float64
+
+	// Burst is the most tokens the bucket can hold
+	Burst int
+}
+
+// Result is the state of the bucket after trying to take a token.
+type Result struct {
+	// Allowed is whether a token was taken
+	Allowed bool
+
+	// Limit is the size of the bucket
+	Limit int
+
+	// Remaining is how many whole tokens are left
+	Remaining int
+
+	// RetryAfter is how long until a token is available when the request wasn't allowed
+	RetryAfter time.Duration
+
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store holds the buckets. Taking a token has to be atomic for each key since
+// requests come in concurrently.
+type Store interface {
+	// Take attempts to take a token from the bucket for the key.
+	Take(key string, limit Limit) (Result, error)
+}
+
+// Limiter applies the Limit for each route group using the Store.
+type Limiter struct {
+	store          Store
+	limits         map[string]Limit
+	trustedProxies []*net.IPNet
+}
+
+// NewLimiter creates a Limiter from the rate limit config.
+func NewLimiter(store Store, c configs.RateLimitConfiguration) *Limiter {
+	return &Limiter{
+		store: store,
+		limits: map[string]Limit{
+			AnonymousGroup:     limitFromConfig(c.Anonymous),
+			AuthenticatedGroup: limitFromConfig(c.Authenticated),
+			AdminGroup:         limitFromConfig(c.Admin),
+			MessagesGroup:      limitFromConfig(c.Messages),
+		},
+		trustedProxies: parseProxies(c.TrustedProxies),
+	}
+}
+
+// parseProxies converts the trusted proxy IPs and CIDRs into networks. A single IP
+// is a network of just itself. Anything that doesn't parse is skipped since the
+// config was already validated.
+func parseProxies(proxies []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+
+		ip := net.ParseIP(proxy)
+		if ip == nil {
+			continue
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks
+}
+
+// isTrusted determines if the IP is one of the trusted proxies.
+func (l *Limiter) isTrusted(ip net.IP) bool {
+	for _, network := range l.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP identifies the IP the request came from. The X-Forwarded-For header is
+// only used when the request came from a trusted proxy, in which case the closest
+// address in it that isn't a trusted proxy is the client.
+func (l *Limiter) ClientIP(r *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(remoteIP)
+	if ip == nil || !l.isTrusted(ip) {
+		return remoteIP
+	}
+
+	forwarded := strings.Split(r.Header.Get(forwardedForHeader), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		hopIP := net.ParseIP(hop)
+		if hopIP == nil {
+			break
+		}
+		remoteIP = hop
+		if !l.isTrusted(hopIP) {
+			break
+		}
+	}
+	return remoteIP
+}
+
+// limitFromConfig converts the number of requests allowed per period into a Limit.
+// A burst of 0 defaults to allowing all of the requests at once.
+func limitFromConfig(c configs.RateLimit) Limit {
+	if c.Requests <= 0 || c.Per <= 0 {
+		return Limit{}
+	}
+
+	burst := c.Burst
+	if burst <= 0 {
+		burst = c.Requests
+	}
+	return Limit{Rate: float64(c.Requests) / c.Per.Seconds(), Burst: burst}
+}
+
+// Take attempts to take a token for the caller identified by key in the route group.
+// Groups without a Limit are always allowed and the bool is false.
+func (l *Limiter) Take(group, key string) (Result, bool, error) {
+	limit, ok := l.limits[group]
+	if !ok || limit.Rate <= 0 {
+		return Result{Allowed: true}, false, nil
+	}
+
+	result, err := l.store.Take(group+":"+key, limit)
+	return result, true, err
+}
+
+// durationFor is how long it takes the bucket to refill the tokens.
+func durationFor(tokens float64, limit Limit) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / limit.Rate * float64(time.Second)))
+}
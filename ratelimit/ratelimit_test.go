@@ -0,0 +1,144 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	// 1 token a second with room for 2
+	limit := Limit{Rate: 1, Burst: 2}
+
+	result, _ := store.Take("a", limit)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, result)
+
+	result, _ = store.Take("a", limit)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}, result)
+
+	// Empty until a token is added
+	result, _ = store.Take("a", limit)
+	assert.Equal(t, Result{Allowed: false, Limit: 2, Remaining: 0, RetryAfter: time.Second, Reset: 2 * time.Second}, result)
+
+	// Other keys have their own bucket
+	result, _ = store.Take("b", limit)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	result, _ = store.Take("a", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	result, _ = store.Take("a", limit)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1}
+
+	store.Take("old", limit)
+	now = now.Add(time.Minute)
+	for i := 0; i < sweepEvery; i++ {
+		store.Take("new", limit)
+	}
+
+	_, ok := store.buckets["old"]
+	assert.False(t, ok)
+	_, ok = store.buckets["new"]
+	assert.True(t, ok)
+}
+
+func TestLimiterTake(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), configs.RateLimitConfiguration{
+		Anonymous: configs.RateLimit{Requests: 60, Per: time.Minute},
+		Messages:  configs.RateLimit{Requests: 1, Per: time.Minute},
+	})
+
+	result, limited, err := limiter.Take(AnonymousGroup, "ip:1.2.3.4")
+	assert.Nil(t, err)
+	assert.True(t, limited)
+	assert.Equal(t, 60, result.Limit)
+
+	// Groups share keys without sharing buckets
+	result, _, _ = limiter.Take(MessagesGroup, "ip:1.2.3.4")
+	assert.True(t, result.Allowed)
+	result, _, _ = limiter.Take(MessagesGroup, "ip:1.2.3.4")
+	assert.False(t, result.Allowed)
+
+	// Groups without a limit are always allowed
+	result, limited, _ = limiter.Take(AdminGroup, "user:1")
+	assert.False(t, limited)
+	assert.True(t, result.Allowed)
+}
+
+func TestClientIP(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), configs.RateLimitConfiguration{
+		TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"},
+	})
+
+	testIO := []struct {
+		desc         string
+		remoteAddr   string
+		forwardedFor string
+		expected     string
+	}{
+		{
+			desc:       "Direct request",
+			remoteAddr: "1.2.3.4:5678",
+			expected:   "1.2.3.4",
+		},
+		{
+			desc:         "Forwarded for header from an untrusted caller is ignored",
+			remoteAddr:   "1.2.3.4:5678",
+			forwardedFor: "5.6.7.8",
+			expected:     "1.2.3.4",
+		},
+		{
+			desc:         "Through a trusted proxy",
+			remoteAddr:   "10.0.0.1:5678",
+			forwardedFor: "5.6.7.8",
+			expected:     "5.6.7.8",
+		},
+		{
+			desc:         "Through a chain of trusted proxies",
+			remoteAddr:   "10.0.0.1:5678",
+			forwardedFor: "5.6.7.8, 192.168.1.1, 10.0.0.2",
+			expected:     "5.6.7.8",
+		},
+		{
+			desc:         "Addresses the client added themselves are skipped",
+			remoteAddr:   "192.168.1.1:5678",
+			forwardedFor: "9.9.9.9, 5.6.7.8",
+			expected:     "5.6.7.8",
+		},
+		{
+			desc:       "Trusted proxy without the header",
+			remoteAddr: "10.0.0.1:5678",
+			expected:   "10.0.0.1",
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.forwardedFor != "" {
+				req.Header.Set(forwardedForHeader, test.forwardedFor)
+			}
+			assert.Equal(t, test.expected, limiter.ClientIP(req))
+		})
+	}
+}
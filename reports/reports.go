@@ -0,0 +1,145 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package reports
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrReportNotFound is the error to use when the Report is not found.
+var ErrReportNotFound = fmt.Errorf("report not found")
+
+// ErrReportClosed is the error to use when changing a Report that was already
+// resolved or dismissed.
+var ErrReportClosed = fmt.Errorf("report has already been closed")
+
+// TargetType is the kind of thing a Report is about.
+type TargetType string
+
+// The kinds of things that can be reported.
+const (
+	MessageTarget   TargetType = "message"
+	CharacterTarget TargetType = "character"
+	ChannelTarget   TargetType = "channel"
+	UserTarget      TargetType = "user"
+)
+
+// AllTargetTypes are all of the kinds of things that can be reported.
+var AllTargetTypes = []TargetType{
+	MessageTarget,
+	CharacterTarget,
+	ChannelTarget,
+	UserTarget,
+}
+
+// Status is where a Report is in the queue.
+type Status string
+
+// The Statuses a Report goes through. Reports start open and are closed by
+// either resolving or dismissing them.
+const (
+	OpenStatus      Status = "open"
+	ResolvedStatus  Status = "resolved"
+	DismissedStatus Status = "dismissed"
+)
+
+// AllStatuses are all of the Statuses a Report can have.
+var AllStatuses = []Status{
+	OpenStatus,
+	ResolvedStatus,
+	DismissedStatus,
+}
+
+// Action is what was done about the reported thing when the Report was resolved.
+type Action string
+
+// The Actions a resolution can take.
+const (
+	NoAction     Action = "none"
+	DeleteAction Action = "delete"
+	BanAction    Action = "ban"
+)
+
+// AllActions are all of the Actions a resolution can take.
+var AllActions = []Action{
+	NoAction,
+	DeleteAction,
+	BanAction,
+}
+
+// Report is a User letting the admins know that a Message, Character, Channel,
+// or User is abusive. TargetUserID is the User responsible for the reported
+// thing, found when the Report is created, so they can be banned.
+type Report struct {
+	ID           int        `json:"ID" db:"id"`
+	ReporterID   int        `json:"ReporterID" db:"reporter_id"`
+	TargetType   TargetType `json:"TargetType" db:"target_type"`
+	TargetID     int        `json:"TargetID" db:"target_id"`
+	TargetUserID int        `json:"TargetUserID" db:"target_user_id"`
+	Reason       string     `json:"Reason" db:"reason"`
+	Status       Status     `json:"Status" db:"status"`
+	AssigneeID   int        `json:"AssigneeID" db:"assignee_id"`
+	Action       Action     `json:"Action" db:"action"`
+	Resolution   string     `json:"Resolution" db:"resolution"`
+	ClosedBy     int        `json:"ClosedBy" db:"closed_by"`
+	ClosedOn     *time.Time `json:"ClosedOn,omitempty" db:"closed_on"`
+	CreatedOn    time.Time  `json:"CreatedOn" db:"created_on"`
+	LastUpdated  time.Time  `json:"LastUpdated" db:"last_updated"`
+}
+
+// ReportCollection is a collection of Reports.
+type ReportCollection []*Report
+
+// Filter limits which Reports are retrieved. Empty fields don't filter anything.
+// An AssigneeID of 0 only matches unassigned Reports.
+type Filter struct {
+	Status     Status
+	TargetType TargetType
+	AssigneeID *int
+	ReporterID int
+}
+
+// IsOpen determines if the Report still needs to be triaged.
+func (r *Report) IsOpen() bool {
+	return r.Status == OpenStatus
+}
+
+// IsValidTargetType determines if the TargetType is one that can be reported.
+func IsValidTargetType(t TargetType) bool {
+	for _, known := range AllTargetTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidStatus determines if the Status exists.
+func IsValidStatus(s Status) bool {
+	for _, known := range AllStatuses {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidAction determines if the Action exists.
+func IsValidAction(a Action) bool {
+	for _, known := range AllActions {
+		if a == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTake determines if the Action can be taken against the TargetType. Users
+// can be banned but not deleted through a Report.
+func (a Action) CanTake(t TargetType) bool {
+	if a == DeleteAction {
+		return t != UserTarget
+	}
+	return IsValidAction(a)
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package reports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidTargetType(t *testing.T) {
+	testIO := []struct {
+		desc       string
+		targetType TargetType
+		expected   bool
+	}{
+		{desc: "Messages can be reported", targetType: MessageTarget, expected: true},
+		{desc: "Characters can be reported", targetType: CharacterTarget, expected: true},
+		{desc: "Channels can be reported", targetType: ChannelTarget, expected: true},
+		{desc: "Users can be reported", targetType: UserTarget, expected: true},
+		{desc: "Unknown types can't be reported", targetType: "webhook", expected: false},
+		{desc: "Empty type can't be reported", targetType: "", expected: false},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, IsValidTargetType(test.targetType))
+		})
+	}
+}
+
+func TestCanTake(t *testing.T) {
+	testIO := []struct {
+		desc       string
+		action     Action
+		targetType TargetType
+		expected   bool
+	}{
+		{desc: "Nothing can be done about anything", action: NoAction, targetType: UserTarget, expected: true},
+		{desc: "Messages can be deleted", action: DeleteAction, targetType: MessageTarget, expected: true},
+		{desc: "Channels can be deleted", action: DeleteAction, targetType: ChannelTarget, expected: true},
+		{desc: "Users can't be deleted", action: DeleteAction, targetType: UserTarget, expected: false},
+		{desc: "The User behind a Character can be banned", action: BanAction, targetType: CharacterTarget, expected: true},
+		{desc: "Users can be banned", action: BanAction, targetType: UserTarget, expected: true},
+		{desc: "Unknown actions can't be taken", action: "warn", targetType: UserTarget, expected: false},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.action.CanTake(test.targetType))
+		})
+	}
+}
+
+func TestIsOpen(t *testing.T) {
+	assert.True(t, (&Report{Status: OpenStatus}).IsOpen())
+	assert.False(t, (&Report{Status: ResolvedStatus}).IsOpen())
+	assert.False(t, (&Report{Status: DismissedStatus}).IsOpen())
+}
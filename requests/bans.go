@@ -0,0 +1,62 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/bans"
+)
+
+// CreateBan is the body for banning a User from the site. The Ban starts right
+// away unless StartsOn is given and lasts until it's lifted unless ExpiresOn is.
+type CreateBan struct {
+	Reason    string     `json:"Reason" validate:"required,max=500"`
+	StartsOn  *time.Time `json:"StartsOn"`
+	ExpiresOn *time.Time `json:"ExpiresOn"`
+}
+
+// Validate makes sure the Ban doesn't end before it starts.
+func (r *CreateBan) Validate() apierrors.FieldErrors {
+	return validateBanExpiry(r.StartsOn, r.ExpiresOn)
+}
+
+// Ban creates the Ban of the User issued by the given User. The ChannelID is
+// bans.SiteWide for site wide Bans.
+func (r *CreateBan) Ban(userID, channelID, issuedBy int) *bans.Ban {
+	ban := &bans.Ban{
+		UserID:    userID,
+		ChannelID: channelID,
+		Reason:    r.Reason,
+		IssuedBy:  issuedBy,
+		ExpiresOn: r.ExpiresOn,
+	}
+	if r.StartsOn != nil {
+		ban.StartsOn = *r.StartsOn
+	}
+	return ban
+}
+
+// CreateChannelBan is the body for a Channel owner banning a User from their
+// Channel.
+type CreateChannelBan struct {
+	UserID int `json:"UserID" validate:"required,min=1"`
+	CreateBan
+}
+
+// validateBanExpiry makes sure the expiry, if there is one, is after the start,
+// which is now if there isn't one.
+func validateBanExpiry(startsOn, expiresOn *time.Time) apierrors.FieldErrors {
+	fieldErrs := apierrors.FieldErrors{}
+
+	start := time.Now()
+	if startsOn != nil {
+		start = *startsOn
+	}
+	if expiresOn != nil && !expiresOn.After(start) {
+		fieldErrs = append(fieldErrs, apierrors.FieldError{Field: "ExpiresOn", Message: "must be after the Ban starts"})
+	}
+
+	return fieldErrs
+}
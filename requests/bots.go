@@ -0,0 +1,34 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"github.com/andrew-boutin/dndtextapi/bots"
+	"github.com/andrew-boutin/dndtextapi/tokens"
+)
+
+// CreateBot is the body for creating a Bot. The owner is the User creating it.
+type CreateBot struct {
+	Workspace string `json:"Workspace" validate:"required,max=200"`
+}
+
+// Bot creates the Bot owned by the User.
+func (r *CreateBot) Bot(ownerID int) *bots.Bot {
+	return &bots.Bot{
+		Workspace: r.Workspace,
+		OwnerID:   ownerID,
+	}
+}
+
+// CreateToken is the body for creating an API Token. The value is generated.
+type CreateToken struct {
+	Name string `json:"Name" validate:"required,max=80"`
+}
+
+// Token creates the Token for the User.
+func (r *CreateToken) Token(userID int) *tokens.Token {
+	return &tokens.Token{
+		UserID: userID,
+		Name:   r.Name,
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"fmt"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+)
+
+// BulkIDs is the body for doing the same thing to many things at once.
+type BulkIDs struct {
+	IDs []int `json:"IDs" validate:"required,max=100"`
+}
+
+// Validate makes sure every ID could exist and none are repeated.
+func (r *BulkIDs) Validate() apierrors.FieldErrors {
+	fieldErrs := apierrors.FieldErrors{}
+
+	seen := map[int]bool{}
+	for i, id := range r.IDs {
+		field := fmt.Sprintf("IDs[%d]", i)
+		if id < 1 {
+			fieldErrs = append(fieldErrs, apierrors.FieldError{Field: field, Message: "must be at least 1"})
+		} else if seen[id] {
+			fieldErrs = append(fieldErrs, apierrors.FieldError{Field: field, Message: "is repeated"})
+		}
+		seen[id] = true
+	}
+
+	return fieldErrs
+}
+
+// BulkBan is the body for banning many Users from the site at once. Every Ban
+// has the same reason and times.
+type BulkBan struct {
+	BulkIDs
+	CreateBan
+}
+
+// Validate checks both the IDs and the Ban.
+func (r *BulkBan) Validate() apierrors.FieldErrors {
+	return append(r.BulkIDs.Validate(), r.CreateBan.Validate()...)
+}
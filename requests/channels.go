@@ -0,0 +1,42 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import "github.com/andrew-boutin/dndtextapi/channels"
+
+// Channel is the body for creating and updating Channels. The owner is always
+// the User that created the Channel.
+type Channel struct {
+	Name        string `json:"Name" validate:"required,max=30"`
+	Description string `json:"Description"`
+	Topic       string `json:"Topic"`
+	IsPrivate   bool   `json:"IsPrivate"`
+	DMID        int    `json:"DMID" validate:"min=0"`
+	BotID       int    `json:"BotID" validate:"min=0"`
+	BotChannel  string `json:"BotChannel" validate:"max=80"`
+}
+
+// Apply copies the request onto the Channel.
+func (r *Channel) Apply(c *channels.Channel) {
+	c.Name = r.Name
+	c.Description = r.Description
+	c.Topic = r.Topic
+	c.IsPrivate = r.IsPrivate
+	c.DMID = r.DMID
+	c.BotID = r.BotID
+	c.BotChannel = r.BotChannel
+}
+
+// ChannelFrom creates the request with the Channel's current values so it can be
+// patched.
+func ChannelFrom(c *channels.Channel) *Channel {
+	return &Channel{
+		Name:        c.Name,
+		Description: c.Description,
+		Topic:       c.Topic,
+		IsPrivate:   c.IsPrivate,
+		DMID:        c.DMID,
+		BotID:       c.BotID,
+		BotChannel:  c.BotChannel,
+	}
+}
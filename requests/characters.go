@@ -0,0 +1,45 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import "github.com/andrew-boutin/dndtextapi/characters"
+
+// CreateCharacter is the body for inviting a User to a Channel. The invited User
+// names the Character when they accept.
+type CreateCharacter struct {
+	UserID int `json:"UserID" validate:"required,min=1"`
+}
+
+// Character creates the Character in the Channel.
+func (r *CreateCharacter) Character(channelID int) *characters.Character {
+	return &characters.Character{
+		ChannelID: channelID,
+		UserID:    r.UserID,
+	}
+}
+
+// UpdateCharacter is the body for updating a Character.
+type UpdateCharacter struct {
+	Name        string `json:"Name" validate:"required,max=30"`
+	Description string `json:"Description"`
+	BotUsername string `json:"BotUsername" validate:"max=80"`
+}
+
+// Character creates the Character with the updated fields.
+func (r *UpdateCharacter) Character() *characters.Character {
+	return &characters.Character{
+		Name:        r.Name,
+		Description: r.Description,
+		BotUsername: r.BotUsername,
+	}
+}
+
+// UpdateCharacterFrom creates the request with the Character's current values so
+// it can be patched.
+func UpdateCharacterFrom(c *characters.Character) *UpdateCharacter {
+	return &UpdateCharacter{
+		Name:        c.Name,
+		Description: c.Description,
+		BotUsername: c.BotUsername,
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/impersonations"
+)
+
+// StartImpersonation is the body for an admin starting to impersonate a User. The
+// Impersonation is read only unless AllowWrites is set.
+type StartImpersonation struct {
+	Reason      string `json:"Reason" validate:"required,max=500"`
+	AllowWrites bool   `json:"AllowWrites"`
+}
+
+// Impersonation creates the Impersonation of the User by the admin. It lasts for
+// the longest an Impersonation can.
+func (r *StartImpersonation) Impersonation(adminID, userID int) *impersonations.Impersonation {
+	return &impersonations.Impersonation{
+		AdminID:     adminID,
+		UserID:      userID,
+		Reason:      r.Reason,
+		AllowWrites: r.AllowWrites,
+		ExpiresOn:   time.Now().Add(impersonations.MaxLength),
+	}
+}
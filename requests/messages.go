@@ -0,0 +1,38 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import "github.com/andrew-boutin/dndtextapi/messages"
+
+// CreateMessage is the body for creating a Message. The Channel comes from the path.
+type CreateMessage struct {
+	CharacterID int    `json:"CharacterID" validate:"required,min=1"`
+	Content     string `json:"Content" validate:"required,max=200"`
+	IsStory     bool   `json:"IsStory"`
+}
+
+// Message creates the Message for the Channel.
+func (r *CreateMessage) Message(channelID int) *messages.Message {
+	return &messages.Message{
+		CharacterID: r.CharacterID,
+		ChannelID:   channelID,
+		Content:     r.Content,
+		IsStory:     r.IsStory,
+	}
+}
+
+// UpdateMessage is the body for updating a Message. Only the content can change.
+type UpdateMessage struct {
+	Content string `json:"Content" validate:"required,max=200"`
+}
+
+// Message creates the Message with the updated content.
+func (r *UpdateMessage) Message() *messages.Message {
+	return &messages.Message{Content: r.Content}
+}
+
+// UpdateMessageFrom creates the request with the Message's current values so it
+// can be patched.
+func UpdateMessageFrom(m *messages.Message) *UpdateMessage {
+	return &UpdateMessage{Content: m.Content}
+}
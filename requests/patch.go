@@ -0,0 +1,58 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// MergePatch applies the JSON merge patch (RFC 7396) in the body to the request,
+// which has to already hold the current values. Fields in the patch replace the
+// current values, null clears them, and everything else is left alone. Fields the
+// request doesn't have, such as IDs, can't be patched. The result is validated
+// the same as a full request.
+func MergePatch(body io.Reader, req interface{}) error {
+	b, raw, err := readObject(body)
+	if err != nil {
+		return err
+	}
+
+	fieldErrs := unknownFields(raw, req)
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+
+	// Decoding skips nulls so they're cleared first. None of the requests have
+	// nested objects so clearing is as deep as merging needs to go.
+	v := reflect.ValueOf(req).Elem()
+	for key, value := range raw {
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			field := fieldByJSONName(v, key)
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+
+	return unmarshalAndValidate(b, req)
+}
+
+// fieldByJSONName finds the field that decoding would put the key in. The key has
+// to be one that jsonFields found.
+func fieldByJSONName(v reflect.Value, key string) reflect.Value {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous {
+			if found := fieldByJSONName(v.Field(i), key); found.IsValid() {
+				return found
+			}
+			continue
+		}
+
+		if strings.EqualFold(jsonName(field), key) {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
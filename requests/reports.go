@@ -0,0 +1,86 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/reports"
+)
+
+// CreateReport is the body for reporting a Message, Character, Channel, or User.
+type CreateReport struct {
+	TargetType string `json:"TargetType" validate:"required"`
+	TargetID   int    `json:"TargetID" validate:"required,min=1"`
+	Reason     string `json:"Reason" validate:"required,max=500"`
+}
+
+// Validate makes sure the target is something that can be reported.
+func (r *CreateReport) Validate() apierrors.FieldErrors {
+	fieldErrs := apierrors.FieldErrors{}
+
+	// Empty types are already reported by the validate tags
+	if r.TargetType != "" && !reports.IsValidTargetType(reports.TargetType(r.TargetType)) {
+		fieldErrs = append(fieldErrs, apierrors.FieldError{Field: "TargetType", Message: "must be one of message, character, channel, or user"})
+	}
+
+	return fieldErrs
+}
+
+// Report creates the Report from the User.
+func (r *CreateReport) Report(reporterID int) *reports.Report {
+	return &reports.Report{
+		ReporterID: reporterID,
+		TargetType: reports.TargetType(r.TargetType),
+		TargetID:   r.TargetID,
+		Reason:     r.Reason,
+	}
+}
+
+// AssignReport is the body for assigning a Report to an admin. An AssigneeID of 0
+// unassigns it.
+type AssignReport struct {
+	AssigneeID int `json:"AssigneeID" validate:"min=0"`
+}
+
+// ResolveReport is the body for resolving a Report. The Action is taken against
+// the reported thing and defaults to doing nothing. Bans last until BanExpiresOn,
+// or until they're lifted if it isn't given.
+type ResolveReport struct {
+	Action       string     `json:"Action"`
+	Resolution   string     `json:"Resolution" validate:"max=500"`
+	BanExpiresOn *time.Time `json:"BanExpiresOn"`
+}
+
+// Validate makes sure the Action exists and only bans have an expiry.
+func (r *ResolveReport) Validate() apierrors.FieldErrors {
+	fieldErrs := apierrors.FieldErrors{}
+
+	if r.Action != "" && !reports.IsValidAction(reports.Action(r.Action)) {
+		fieldErrs = append(fieldErrs, apierrors.FieldError{Field: "Action", Message: "must be one of none, delete, or ban"})
+	}
+
+	if r.BanExpiresOn != nil {
+		if r.ReportAction() != reports.BanAction {
+			fieldErrs = append(fieldErrs, apierrors.FieldError{Field: "BanExpiresOn", Message: "can only be set when the Action is ban"})
+		} else if expiryErrs := validateBanExpiry(nil, r.BanExpiresOn); len(expiryErrs) > 0 {
+			fieldErrs = append(fieldErrs, apierrors.FieldError{Field: "BanExpiresOn", Message: expiryErrs[0].Message})
+		}
+	}
+
+	return fieldErrs
+}
+
+// ReportAction is the Action to take, which is none if the request didn't have one.
+func (r *ResolveReport) ReportAction() reports.Action {
+	if r.Action == "" {
+		return reports.NoAction
+	}
+	return reports.Action(r.Action)
+}
+
+// DismissReport is the body for dismissing a Report without doing anything.
+type DismissReport struct {
+	Resolution string `json:"Resolution" validate:"max=500"`
+}
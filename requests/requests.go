@@ -0,0 +1,222 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package requests has the request bodies that routes accept. Each one only has
+// the fields a client is allowed to set, and the validate struct tags describe what
+// makes a value acceptable. Length limits match the columns in schema.sql so bad
+// data gets a field error instead of a database error.
+//
+// The validate rules are separated by commas. `required` means the value can't be
+// empty. `min=N` and `max=N` limit the number of characters in strings, the number
+// of items in slices, and the value of numbers.
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+)
+
+// validator is implemented by requests with rules that need more than the
+// validate tags.
+type validator interface {
+	Validate() apierrors.FieldErrors
+}
+
+// Decode reads the JSON body into the request and validates it. Fields that the
+// request doesn't have can't be set and are reported along with any invalid
+// values as apierrors.FieldErrors.
+func Decode(body io.Reader, req interface{}) error {
+	b, raw, err := readObject(body)
+	if err != nil {
+		return err
+	}
+
+	fieldErrs := unknownFields(raw, req)
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+
+	return unmarshalAndValidate(b, req)
+}
+
+// readObject reads the body which has to be a JSON object. The object is returned
+// both as is and split up by key.
+func readObject(body io.Reader) ([]byte, map[string]json.RawMessage, error) {
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := map[string]json.RawMessage{}
+	err = json.Unmarshal(b, &raw)
+	if err != nil || raw == nil {
+		return nil, nil, fmt.Errorf("request body must be a JSON object")
+	}
+	return b, raw, nil
+}
+
+// unknownFields reports the keys that the request doesn't have a field for.
+func unknownFields(raw map[string]json.RawMessage, req interface{}) apierrors.FieldErrors {
+	fieldErrs := apierrors.FieldErrors{}
+
+	allowed := jsonFields(reflect.TypeOf(req).Elem())
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !allowed[strings.ToLower(key)] {
+			fieldErrs = append(fieldErrs, apierrors.FieldError{Field: key, Message: "can't be set"})
+		}
+	}
+	return fieldErrs
+}
+
+// unmarshalAndValidate decodes the JSON into the request and validates the result.
+func unmarshalAndValidate(b []byte, req interface{}) error {
+	err := json.Unmarshal(b, req)
+	if err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return apierrors.FieldErrors{{Field: typeErr.Field, Message: "has the wrong type"}}
+		}
+		return err
+	}
+
+	fieldErrs := Validate(req)
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
+
+// Validate checks the request against its validate tags and any additional rules
+// it has.
+func Validate(req interface{}) apierrors.FieldErrors {
+	fieldErrs := validateStruct(reflect.Indirect(reflect.ValueOf(req)))
+	if v, ok := req.(validator); ok {
+		fieldErrs = append(fieldErrs, v.Validate()...)
+	}
+	return fieldErrs
+}
+
+func validateStruct(v reflect.Value) apierrors.FieldErrors {
+	fieldErrs := apierrors.FieldErrors{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous {
+			fieldErrs = append(fieldErrs, validateStruct(v.Field(i))...)
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			msg := checkRule(rule, v.Field(i))
+			if msg != "" {
+				fieldErrs = append(fieldErrs, apierrors.FieldError{Field: jsonName(field), Message: msg})
+				break
+			}
+		}
+	}
+	return fieldErrs
+}
+
+// checkRule returns why the value breaks the rule, or nothing if it doesn't.
+func checkRule(rule string, v reflect.Value) string {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	if name == "required" {
+		if isEmpty(v) {
+			return "is required"
+		}
+		return ""
+	}
+
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		panic(fmt.Sprintf("invalid validate rule %q", rule))
+	}
+
+	size, unit := sizeOf(v)
+	switch name {
+	case "min":
+		if size < n {
+			return fmt.Sprintf("must be at least %d%s", n, unit)
+		}
+	case "max":
+		if size > n {
+			return fmt.Sprintf("must be at most %d%s", n, unit)
+		}
+	default:
+		panic(fmt.Sprintf("unknown validate rule %q", rule))
+	}
+	return ""
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.Interface() == reflect.Zero(v.Type()).Interface()
+}
+
+// sizeOf is what min and max compare against. Strings are measured in
+// characters to match the varchar limits.
+func sizeOf(v reflect.Value) (int, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), " characters"
+	case reflect.Slice, reflect.Map:
+		return v.Len(), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), ""
+	}
+	panic(fmt.Sprintf("min and max aren't supported for %s", v.Kind()))
+}
+
+// jsonFields finds the names of all of the fields the request can be decoded into.
+// Names are lowercase since decoding matches them without case.
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			for name := range jsonFields(field.Type) {
+				fields[name] = true
+			}
+			continue
+		}
+
+		name := jsonName(field)
+		if name != "-" {
+			fields[strings.ToLower(name)] = true
+		}
+	}
+	return fields
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,209 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	testIO := []struct {
+		desc        string
+		body        string
+		req         interface{}
+		expected    interface{}
+		expectedErr error
+	}{
+		{
+			desc:     "Valid body.",
+			body:     `{"CharacterID": 1, "Content": "hello", "IsStory": true}`,
+			req:      &CreateMessage{},
+			expected: &CreateMessage{CharacterID: 1, Content: "hello", IsStory: true},
+		},
+		{
+			desc:     "Field names match without case.",
+			body:     `{"content": "hello"}`,
+			req:      &UpdateMessage{},
+			expected: &UpdateMessage{Content: "hello"},
+		},
+		{
+			desc: "Fields that can't be set.",
+			body: `{"Name": "my channel", "OwnerID": 2, "ID": 5}`,
+			req:  &Channel{},
+			expectedErr: apierrors.FieldErrors{
+				{Field: "ID", Message: "can't be set"},
+				{Field: "OwnerID", Message: "can't be set"},
+			},
+		},
+		{
+			desc:        "Admin flag can't be set.",
+			body:        `{"Username": "me", "IsAdmin": true}`,
+			req:         &UpdateUser{},
+			expectedErr: apierrors.FieldErrors{{Field: "IsAdmin", Message: "can't be set"}},
+		},
+		{
+			desc: "Required fields.",
+			body: `{"Content": "   "}`,
+			req:  &CreateMessage{},
+			expectedErr: apierrors.FieldErrors{
+				{Field: "CharacterID", Message: "is required"},
+				{Field: "Content", Message: "is required"},
+			},
+		},
+		{
+			desc:        "Too long.",
+			body:        `{"Name": "` + strings.Repeat("a", 31) + `"}`,
+			req:         &Channel{},
+			expectedErr: apierrors.FieldErrors{{Field: "Name", Message: "must be at most 30 characters"}},
+		},
+		{
+			desc:     "Length is in characters.",
+			body:     `{"Name": "` + strings.Repeat("é", 30) + `"}`,
+			req:      &Channel{},
+			expected: &Channel{Name: strings.Repeat("é", 30)},
+		},
+		{
+			desc:        "Too small.",
+			body:        `{"Name": "my channel", "DMID": -1}`,
+			req:         &Channel{},
+			expectedErr: apierrors.FieldErrors{{Field: "DMID", Message: "must be at least 0"}},
+		},
+		{
+			desc:        "Wrong type.",
+			body:        `{"UserID": "2"}`,
+			req:         &CreateCharacter{},
+			expectedErr: apierrors.FieldErrors{{Field: "UserID", Message: "has the wrong type"}},
+		},
+		{
+			desc: "Additional rules.",
+			body: `{"URL": "ftp://example.com", "Events": ["message.created", "nope"]}`,
+			req:  &CreateWebhook{},
+			expectedErr: apierrors.FieldErrors{
+				{Field: "URL", Message: "must be an http or https URL"},
+				{Field: "Events", Message: "unknown webhook event"},
+			},
+		},
+		{
+			desc:        "Unknown report target.",
+			body:        `{"TargetType": "webhook", "TargetID": 1, "Reason": "spam"}`,
+			req:         &CreateReport{},
+			expectedErr: apierrors.FieldErrors{{Field: "TargetType", Message: "must be one of message, character, channel, or user"}},
+		},
+		{
+			desc:     "Embedded fields.",
+			body:     `{"UserID": 2, "Reason": "spam"}`,
+			req:      &CreateChannelBan{},
+			expected: &CreateChannelBan{UserID: 2, CreateBan: CreateBan{Reason: "spam"}},
+		},
+		{
+			desc:        "Ban that ends before it starts.",
+			body:        `{"Reason": "spam", "StartsOn": "2018-06-02T00:00:00Z", "ExpiresOn": "2018-06-01T00:00:00Z"}`,
+			req:         &CreateBan{},
+			expectedErr: apierrors.FieldErrors{{Field: "ExpiresOn", Message: "must be after the Ban starts"}},
+		},
+		{
+			desc:     "Bulk ban.",
+			body:     `{"IDs": [2, 3], "Reason": "spam"}`,
+			req:      &BulkBan{},
+			expected: &BulkBan{BulkIDs: BulkIDs{IDs: []int{2, 3}}, CreateBan: CreateBan{Reason: "spam"}},
+		},
+		{
+			desc: "Bad and repeated bulk IDs.",
+			body: `{"IDs": [2, 0, 2]}`,
+			req:  &BulkIDs{},
+			expectedErr: apierrors.FieldErrors{
+				{Field: "IDs[1]", Message: "must be at least 1"},
+				{Field: "IDs[2]", Message: "is repeated"},
+			},
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			err := Decode(strings.NewReader(test.body), test.req)
+			assert.Equal(t, test.expectedErr, err)
+			if test.expectedErr == nil {
+				assert.Equal(t, test.expected, test.req)
+			}
+		})
+	}
+}
+
+func TestDecodeNotAnObject(t *testing.T) {
+	err := Decode(strings.NewReader(`["hello"]`), &UpdateMessage{})
+	assert.NotNil(t, err)
+	_, isFieldErrs := err.(apierrors.FieldErrors)
+	assert.False(t, isFieldErrs)
+}
+
+func TestMergePatch(t *testing.T) {
+	testIO := []struct {
+		desc        string
+		body        string
+		req         interface{}
+		expected    interface{}
+		expectedErr error
+	}{
+		{
+			desc:     "Only patched fields change.",
+			body:     `{"Topic": "the dragon's lair"}`,
+			req:      &Channel{Name: "my channel", Description: "a campaign", Topic: "the tavern", DMID: 3},
+			expected: &Channel{Name: "my channel", Description: "a campaign", Topic: "the dragon's lair", DMID: 3},
+		},
+		{
+			desc:     "Empty patch.",
+			body:     `{}`,
+			req:      &UpdateUser{Username: "gandalf", Bio: "a wizard"},
+			expected: &UpdateUser{Username: "gandalf", Bio: "a wizard"},
+		},
+		{
+			desc:     "Null clears a field.",
+			body:     `{"bio": null}`,
+			req:      &UpdateUser{Username: "gandalf", Bio: "a wizard"},
+			expected: &UpdateUser{Username: "gandalf"},
+		},
+		{
+			desc:        "Null clears a required field.",
+			body:        `{"Name": null}`,
+			req:         &UpdateCharacter{Name: "Gandalf"},
+			expectedErr: apierrors.FieldErrors{{Field: "Name", Message: "is required"}},
+		},
+		{
+			desc: "Immutable fields can't be patched.",
+			body: `{"OwnerID": 2, "Topic": "the tavern"}`,
+			req:  &Channel{Name: "my channel"},
+			expectedErr: apierrors.FieldErrors{
+				{Field: "OwnerID", Message: "can't be set"},
+			},
+		},
+		{
+			desc:        "Patched values are validated.",
+			body:        `{"Content": "` + strings.Repeat("a", 201) + `"}`,
+			req:         &UpdateMessage{Content: "hello"},
+			expectedErr: apierrors.FieldErrors{{Field: "Content", Message: "must be at most 200 characters"}},
+		},
+		{
+			desc:        "Patch has to be an object.",
+			body:        `null`,
+			req:         &UpdateMessage{Content: "hello"},
+			expectedErr: fmt.Errorf("request body must be a JSON object"),
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			err := MergePatch(strings.NewReader(test.body), test.req)
+			if test.expectedErr != nil {
+				assert.Equal(t, test.expectedErr, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, test.req)
+		})
+	}
+}
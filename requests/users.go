@@ -0,0 +1,49 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"github.com/andrew-boutin/dndtextapi/notifications"
+	"github.com/andrew-boutin/dndtextapi/users"
+)
+
+// UpdateUser is the body for updating a User. The email and admin fields come
+// from elsewhere and can't be changed.
+type UpdateUser struct {
+	Username string `json:"Username" validate:"required,max=30"`
+	Bio      string `json:"Bio" validate:"max=200"`
+}
+
+// User creates the User with the updated fields.
+func (r *UpdateUser) User() *users.User {
+	return &users.User{
+		Username: r.Username,
+		Bio:      r.Bio,
+	}
+}
+
+// UpdateUserFrom creates the request with the User's current values so it can be
+// patched.
+func UpdateUserFrom(u *users.User) *UpdateUser {
+	return &UpdateUser{
+		Username: u.Username,
+		Bio:      u.Bio,
+	}
+}
+
+// NotificationPreferences is the body for updating which notifications a User gets.
+type NotificationPreferences struct {
+	Mentions    bool `json:"Mentions"`
+	Invitations bool `json:"Invitations"`
+	Turns       bool `json:"Turns"`
+}
+
+// Preferences creates the Preferences for the User.
+func (r *NotificationPreferences) Preferences(userID int) *notifications.Preferences {
+	return &notifications.Preferences{
+		UserID:      userID,
+		Mentions:    r.Mentions,
+		Invitations: r.Invitations,
+		Turns:       r.Turns,
+	}
+}
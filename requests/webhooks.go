@@ -0,0 +1,74 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package requests
+
+import (
+	"net/url"
+
+	"github.com/andrew-boutin/dndtextapi/apierrors"
+	"github.com/andrew-boutin/dndtextapi/webhooks"
+	"github.com/lib/pq"
+)
+
+// CreateWebhook is the body for registering a Webhook. The secret is generated.
+type CreateWebhook struct {
+	URL    string   `json:"URL" validate:"required,max=500"`
+	Events []string `json:"Events"`
+}
+
+// Validate makes sure the URL can be sent to and the Events exist.
+func (r *CreateWebhook) Validate() apierrors.FieldErrors {
+	return validateWebhook(r.URL, r.Events)
+}
+
+// Webhook creates the Webhook for the Channel.
+func (r *CreateWebhook) Webhook(channelID int) *webhooks.Webhook {
+	return &webhooks.Webhook{
+		ChannelID: channelID,
+		URL:       r.URL,
+		Events:    eventsArray(r.Events),
+	}
+}
+
+// UpdateWebhook is the body for updating a Webhook.
+type UpdateWebhook struct {
+	URL        string   `json:"URL" validate:"required,max=500"`
+	Events     []string `json:"Events"`
+	IsDisabled bool     `json:"IsDisabled"`
+}
+
+// Validate makes sure the URL can be sent to and the Events exist.
+func (r *UpdateWebhook) Validate() apierrors.FieldErrors {
+	return validateWebhook(r.URL, r.Events)
+}
+
+// Webhook creates the Webhook with the updated fields.
+func (r *UpdateWebhook) Webhook() *webhooks.Webhook {
+	return &webhooks.Webhook{
+		URL:        r.URL,
+		Events:     eventsArray(r.Events),
+		IsDisabled: r.IsDisabled,
+	}
+}
+
+// eventsArray never returns nil so the events column isn't set to null.
+func eventsArray(events []string) pq.StringArray {
+	return append(pq.StringArray{}, events...)
+}
+
+func validateWebhook(rawURL string, events []string) apierrors.FieldErrors {
+	fieldErrs := apierrors.FieldErrors{}
+
+	// Empty URLs are already reported by the validate tags
+	u, err := url.Parse(rawURL)
+	if rawURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		fieldErrs = append(fieldErrs, apierrors.FieldError{Field: "URL", Message: "must be an http or https URL"})
+	}
+
+	err = (&webhooks.Webhook{Events: events}).ValidateEvents()
+	if err != nil {
+		fieldErrs = append(fieldErrs, apierrors.FieldError{Field: "Events", Message: err.Error()})
+	}
+
+	return fieldErrs
+}
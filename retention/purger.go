@@ -0,0 +1,80 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package retention permanently removes deleted Channels, Characters, and Messages
+// once they can't be restored anymore.
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+	log "github.com/sirupsen/logrus"
+)
+
+// Store defines the data the Purger needs to clean up.
+type Store interface {
+	PurgeDeleted(context.Context, time.Time) error
+}
+
+// Purger periodically purges everything that was deleted longer ago than the
+// retention window.
+type Purger struct {
+	store    Store
+	window   time.Duration
+	interval time.Duration
+
+	// now is swapped out in tests
+	now func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPurger creates a Purger that purges from the Store using the retention
+// configuration.
+func NewPurger(store Store, c configs.RetentionConfiguration) *Purger {
+	return &Purger{
+		store:    store,
+		window:   c.Window,
+		interval: c.PurgeInterval,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins purging in the background every interval. The first purge happens
+// right away so a server that restarts often still gets around to it.
+func (p *Purger) Start() {
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		p.Purge()
+		for {
+			select {
+			case <-ticker.C:
+				p.Purge()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops purging, waiting for a purge that's already running to finish.
+func (p *Purger) Stop() {
+	close(p.stop)
+	<-p.done
+}
+
+// Purge permanently deletes everything that was deleted before the start of the
+// retention window.
+func (p *Purger) Purge() {
+	err := p.store.PurgeDeleted(context.Background(), p.now().Add(-p.window))
+	if err != nil {
+		log.WithError(err).Error("Failed to purge deleted Channels, Characters, and Messages.")
+	}
+}
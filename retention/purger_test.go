@@ -0,0 +1,81 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package retention
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	befores []time.Time
+	err     error
+}
+
+func (s *fakeStore) PurgeDeleted(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.befores = append(s.befores, before)
+	return s.err
+}
+
+func (s *fakeStore) calls() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.befores...)
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testIO := []struct {
+		desc     string
+		window   time.Duration
+		err      error
+		expected time.Time
+	}{
+		{
+			desc:     "Purges everything deleted before the window",
+			window:   24 * time.Hour,
+			expected: time.Date(2018, 5, 31, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "Store errors are only logged",
+			window:   time.Hour,
+			err:      fmt.Errorf("database down"),
+			expected: time.Date(2018, 6, 1, 11, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			store := &fakeStore{err: test.err}
+			p := NewPurger(store, configs.RetentionConfiguration{Window: test.window, PurgeInterval: time.Hour})
+			p.now = func() time.Time { return now }
+
+			p.Purge()
+			assert.Equal(t, []time.Time{test.expected}, store.calls())
+		})
+	}
+}
+
+func TestStartPurgesRightAway(t *testing.T) {
+	store := &fakeStore{}
+	p := NewPurger(store, configs.RetentionConfiguration{Window: time.Hour, PurgeInterval: time.Hour})
+
+	p.Start()
+	deadline := time.Now().Add(time.Second)
+	for len(store.calls()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	p.Stop()
+
+	assert.Len(t, store.calls(), 1)
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package stats
+
+import "time"
+
+const (
+	// ActivityWindow is how far back Messages count towards the top Channels.
+	ActivityWindow = 7 * 24 * time.Hour
+
+	// DormantAfter is how long a Channel has to go without a Message to be
+	// dormant. Channels newer than this are never dormant.
+	DormantAfter = 30 * 24 * time.Hour
+
+	// DefaultWeeks is how many weeks of Channel creation are included by default.
+	DefaultWeeks = 12
+
+	// DefaultTop is how many of the most active Channels are included by default.
+	DefaultTop = 10
+)
+
+// Options picks how much history the Stats cover. Weeks is how many weeks of
+// Channel creation to include, counting this one, and Top is how many of the most
+// active Channels to include.
+type Options struct {
+	Weeks int
+	Top   int
+}
+
+// Stats summarizes how the site is being used for the admins. Deleted Channels
+// and Messages aren't counted. MessagesPerChannel has every Channel, most Messages
+// first. TopChannels only counts the Messages from the ActivityWindow. Dormant
+// Channels are longest dormant first.
+type Stats struct {
+	ActiveUsers        ActiveUsers        `json:"ActiveUsers"`
+	Channels           ChannelCounts      `json:"Channels"`
+	ChannelsPerWeek    []*WeekCount       `json:"ChannelsPerWeek"`
+	MessagesPerChannel []*ChannelActivity `json:"MessagesPerChannel"`
+	TopChannels        []*ChannelActivity `json:"TopChannels"`
+	DormantChannels    []*ChannelActivity `json:"DormantChannels"`
+	GeneratedOn        time.Time          `json:"GeneratedOn"`
+}
+
+// ActiveUsers are how many Users have logged in over the last day, week, and 30
+// days.
+type ActiveUsers struct {
+	Day   int `json:"Day" db:"day"`
+	Week  int `json:"Week" db:"week"`
+	Month int `json:"Month" db:"month"`
+}
+
+// ChannelCounts are how many Channels there are of each kind.
+type ChannelCounts struct {
+	Public  int `json:"Public" db:"public"`
+	Private int `json:"Private" db:"private"`
+}
+
+// WeekCount is how many Channels were created in the week starting on WeekOf,
+// including ones that were deleted since.
+type WeekCount struct {
+	WeekOf   time.Time `json:"WeekOf" db:"week_of"`
+	Channels int       `json:"Channels" db:"channels"`
+}
+
+// ChannelActivity is how many Messages a Channel has and when the last one was
+// posted. LastMessageOn is missing for Channels without any Messages.
+type ChannelActivity struct {
+	ChannelID     int        `json:"ChannelID" db:"channel_id"`
+	Name          string     `json:"Name" db:"name"`
+	Messages      int        `json:"Messages" db:"messages"`
+	LastMessageOn *time.Time `json:"LastMessageOn,omitempty" db:"last_message_on"`
+}
+
+// StartOfWeek finds the Monday at midnight UTC that starts the week the time is in,
+// the same as Postgres's date_trunc('week').
+func StartOfWeek(t time.Time) time.Time {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartOfWeek(t *testing.T) {
+	monday := time.Date(2018, 6, 4, 0, 0, 0, 0, time.UTC)
+
+	testIO := []struct {
+		desc     string
+		in       time.Time
+		expected time.Time
+	}{
+		{
+			desc:     "Monday at midnight.",
+			in:       monday,
+			expected: monday,
+		},
+		{
+			desc:     "Later in the week.",
+			in:       time.Date(2018, 6, 7, 15, 30, 0, 0, time.UTC),
+			expected: monday,
+		},
+		{
+			desc:     "Sunday is the end of the week.",
+			in:       time.Date(2018, 6, 10, 23, 59, 0, 0, time.UTC),
+			expected: monday,
+		},
+		{
+			desc:     "Week that starts in the previous month.",
+			in:       time.Date(2018, 7, 1, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2018, 6, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc:     "Other time zones are converted to UTC first.",
+			in:       time.Date(2018, 6, 4, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			expected: time.Date(2018, 5, 28, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, StartOfWeek(test.in))
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package tokens
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/dchest/uniuri"
+)
+
+// ErrTokenNotFound is the error to use when the Token is not found.
+var ErrTokenNotFound = fmt.Errorf("token not found")
+
+// valueLength is the number of characters in a generated Token value.
+const valueLength = 40
+
+// Token is a personal API token that lets scripts and tools act as a User
+// without going through the Google login. Only the hash of the value is
+// stored so the value is only ever seen when the Token is created.
+type Token struct {
+	ID          int       `json:"ID" db:"id"`
+	UserID      int       `json:"UserID" db:"user_id"`
+	Name        string    `json:"Name" db:"name"`
+	Hash        string    `json:"-" db:"token_hash"`
+	Value       string    `json:"Value,omitempty" db:"-"`
+	CreatedOn   time.Time `json:"CreatedOn" db:"created_on"`
+	LastUpdated time.Time `json:"LastUpdated" db:"last_updated"`
+}
+
+// TokenCollection is a collection of Tokens.
+type TokenCollection []*Token
+
+// Generate fills in a new random value for the Token along with its hash.
+func (t *Token) Generate() {
+	t.Value = uniuri.NewLen(valueLength)
+	t.Hash = Hash(t.Value)
+}
+
+// Hash hashes the Token value for storage and lookup.
+func Hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
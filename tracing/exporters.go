@@ -0,0 +1,255 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+)
+
+// Exporters that can be configured.
+const (
+	StdoutExporter = "stdout"
+	OTLPExporter   = "otlp"
+)
+
+// Exporter sends finished spans somewhere they can be looked at.
+type Exporter interface {
+	Export(serviceName string, spans []*Span) error
+}
+
+// MakeExporter creates the Exporter from the tracing config.
+func MakeExporter(c configs.TracingConfiguration) (Exporter, error) {
+	switch c.Exporter {
+	case StdoutExporter:
+		return NewWriterExporter(os.Stdout), nil
+	case OTLPExporter:
+		return NewOTLPHTTPExporter(c.Endpoint, 10*time.Second), nil
+	}
+	return nil, fmt.Errorf("unknown trace exporter %s", c.Exporter)
+}
+
+// WriterExporter writes each span as a line of JSON.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter creates a WriterExporter that writes to w.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// spanLine is how a span is written by the WriterExporter.
+type spanLine struct {
+	Service      string                 `json:"service"`
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Start        time.Time              `json:"start"`
+	DurationMS   float64                `json:"durationMs"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Export writes the spans.
+func (e *WriterExporter) Export(serviceName string, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+	for _, span := range spans {
+		line := spanLine{
+			Service:    serviceName,
+			Name:       span.Name,
+			Kind:       span.Kind.String(),
+			TraceID:    span.TraceID.String(),
+			SpanID:     span.SpanID.String(),
+			Start:      span.Start,
+			DurationMS: float64(span.Duration()) / float64(time.Millisecond),
+			Attributes: span.Attributes,
+			Error:      span.Error,
+		}
+		if span.ParentSpanID.IsValid() {
+			line.ParentSpanID = span.ParentSpanID.String()
+		}
+
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OTLPHTTPExporter sends spans to an OpenTelemetry collector using the JSON
+// encoding of OTLP over HTTP.
+type OTLPHTTPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOTLPHTTPExporter creates an OTLPHTTPExporter that posts to the endpoint, which
+// is usually http://localhost:4318/v1/traces for a local collector.
+func NewOTLPHTTPExporter(endpoint string, timeout time.Duration) *OTLPHTTPExporter {
+	return &OTLPHTTPExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// The OTLP JSON request body. Only the parts that are used are defined.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// otlpStatusError is the OTLP status code for a failed span.
+const otlpStatusError = 2
+
+// instrumentationScope names what created the spans.
+const instrumentationScope = "github.com/andrew-boutin/dndtextapi/tracing"
+
+// Export posts the spans to the collector.
+func (e *OTLPHTTPExporter) Export(serviceName string, spans []*Span) error {
+	body, err := json.Marshal(makeOTLPRequest(serviceName, spans))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("trace collector responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// makeOTLPRequest converts the spans into the OTLP request body.
+func makeOTLPRequest(serviceName string, spans []*Span) otlpRequest {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.TraceID.String(),
+			SpanID:            span.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+		}
+		if span.ParentSpanID.IsValid() {
+			s.ParentSpanID = span.ParentSpanID.String()
+		}
+		if span.Error != "" {
+			s.Status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+		}
+		otlpSpans = append(otlpSpans, s)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: otlpAttributes(map[string]interface{}{"service.name": serviceName}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: instrumentationScope},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+// otlpAttributes converts span attributes. Types OTLP doesn't have are sent as strings.
+func otlpAttributes(attributes map[string]interface{}) []otlpAttribute {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]otlpAttribute, 0, len(attributes))
+	for _, key := range keys {
+		v := otlpValue{}
+		switch typed := attributes[key].(type) {
+		case string:
+			v.StringValue = &typed
+		case int:
+			s := strconv.Itoa(typed)
+			v.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(typed, 10)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &typed
+		case bool:
+			v.BoolValue = &typed
+		default:
+			s := fmt.Sprint(typed)
+			v.StringValue = &s
+		}
+		out = append(out, otlpAttribute{Key: key, Value: v})
+	}
+	return out
+}
@@ -0,0 +1,354 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+// Package tracing records spans for requests and the work they do so slow requests
+// can be broken down. Spans follow the OpenTelemetry model and are exported in
+// batches either as JSON lines or to an OpenTelemetry collector over OTLP/HTTP.
+//
+// The span for the current operation is carried in a context.Context. Starting a
+// span with a context that already has one makes the new span its child. Incoming
+// requests can continue a trace started elsewhere through the W3C traceparent header.
+//
+// A nil Tracer and a nil Span are valid and do nothing so tracing can be turned off.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TraceparentHeader is the W3C header that carries the trace across services.
+const TraceparentHeader = "traceparent"
+
+// SpanKind describes the relationship between the span and what it's talking to.
+// The values match OpenTelemetry.
+type SpanKind int
+
+// The kinds of spans.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// String is the name of the SpanKind.
+func (k SpanKind) String() string {
+	switch k {
+	case KindServer:
+		return "server"
+	case KindClient:
+		return "client"
+	}
+	return "internal"
+}
+
+// TraceID identifies every span in a trace.
+type TraceID [16]byte
+
+// String is the hex encoding of the TraceID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid is false for the all zero TraceID.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String is the hex encoding of the SpanID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid is false for the all zero SpanID.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext is what's needed to make a child of a span, including one from
+// another service.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// Traceparent formats the SpanContext as a W3C traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID)
+}
+
+// ParseTraceparent reads a W3C traceparent header value. The bool is false if the
+// value isn't valid.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	sc := SpanContext{}
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	if len(parts[1]) != 2*len(sc.TraceID) || len(parts[2]) != 2*len(sc.SpanID) {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+
+	return sc, sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Span is a single timed operation.
+type Span struct {
+	Name         string
+	Kind         SpanKind
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+
+	// Error describes what went wrong if the operation failed
+	Error string
+
+	tracer *Tracer
+	mu     sync.Mutex
+	ended  bool
+}
+
+// SpanContext is the context needed to make children of the Span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.TraceID, SpanID: s.SpanID}
+}
+
+// SetName changes the name of the Span. It's useful when the best name isn't
+// known until the operation is done.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.Name = name
+	s.mu.Unlock()
+}
+
+// SetAttribute adds the key and value to the Span. Values should be strings,
+// ints, floats, or bools.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError marks the Span as failed because of the error.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.Error = err.Error()
+	s.mu.Unlock()
+}
+
+// Finish ends the Span and hands it off to be exported. Finishing more than once
+// does nothing.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.enqueue(s)
+}
+
+// Duration is how long the Span took.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+type spanContextKey struct{}
+
+type remoteParentKey struct{}
+
+// FromContext gets the Span for the current operation. It's nil if there isn't one.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithSpan makes the Span the current operation.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// ContextWithRemoteParent makes spans started with the context children of a span
+// in another service.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteParentKey{}, sc)
+}
+
+// Tracer starts spans and exports them in batches in the background.
+type Tracer struct {
+	serviceName   string
+	exporter      Exporter
+	flushInterval time.Duration
+
+	queue chan *Span
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// queueSize is how many finished spans can wait to be exported. Spans are dropped
+// when the queue is full so tracing never holds up a request.
+const queueSize = 2048
+
+// batchSize is the most spans sent to the Exporter at once.
+const batchSize = 256
+
+// NewTracer creates a Tracer that exports spans for the service every flush interval.
+func NewTracer(serviceName string, exporter Exporter, flushInterval time.Duration) *Tracer {
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
+	return &Tracer{
+		serviceName:   serviceName,
+		exporter:      exporter,
+		flushInterval: flushInterval,
+		queue:         make(chan *Span, queueSize),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// StartSpan starts a Span that's a child of the current one in the context, if there
+// is one, and returns a context with the new Span as the current one.
+func (t *Tracer) StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		SpanID:     newSpanID(),
+		Start:      time.Now(),
+		Attributes: make(map[string]interface{}),
+		tracer:     t,
+	}
+
+	if parent := FromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+	} else if remote, ok := ctx.Value(remoteParentKey{}).(SpanContext); ok {
+		span.TraceID = remote.TraceID
+		span.ParentSpanID = remote.SpanID
+	} else {
+		span.TraceID = newTraceID()
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Start exports finished spans in the background until Stop is called.
+func (t *Tracer) Start() {
+	go func() {
+		defer close(t.done)
+
+		ticker := time.NewTicker(t.flushInterval)
+		defer ticker.Stop()
+
+		batch := make([]*Span, 0, batchSize)
+		for {
+			select {
+			case span := <-t.queue:
+				batch = append(batch, span)
+				if len(batch) >= batchSize {
+					batch = t.export(batch)
+				}
+			case <-ticker.C:
+				batch = t.export(batch)
+			case <-t.stop:
+				// Export whatever is left before shutting down
+				for {
+					select {
+					case span := <-t.queue:
+						batch = append(batch, span)
+					default:
+						t.export(batch)
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop exports the remaining spans and stops the background export.
+func (t *Tracer) Stop() {
+	close(t.stop)
+	<-t.done
+}
+
+// enqueue queues the finished Span for export.
+func (t *Tracer) enqueue(span *Span) {
+	select {
+	case t.queue <- span:
+	default:
+		log.WithField("span", span.Name).Warn("Trace queue is full dropping span.")
+	}
+}
+
+// export sends the batch to the Exporter and returns an empty batch to reuse.
+func (t *Tracer) export(batch []*Span) []*Span {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	err := t.exporter.Export(t.serviceName, batch)
+	if err != nil {
+		log.WithError(err).WithField("spans", len(batch)).Error("Failed to export spans.")
+	}
+	return make([]*Span, 0, batchSize)
+}
+
+func newTraceID() (id TraceID) {
+	randomBytes(id[:])
+	return
+}
+
+func newSpanID() (id SpanID) {
+	randomBytes(id[:])
+	return
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		// Only happens if the OS can't provide randomness at all
+		panic(err)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeExporter keeps the spans it's given.
+type fakeExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *fakeExporter) Export(serviceName string, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestParseTraceparent(t *testing.T) {
+	testIO := []struct {
+		desc    string
+		value   string
+		isValid bool
+	}{
+		{desc: "Valid.", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", isValid: true},
+		{desc: "Empty.", value: ""},
+		{desc: "Invalid version.", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{desc: "Short trace id.", value: "00-4bf92f3577b34da6-00f067aa0ba902b7-01"},
+		{desc: "Not hex.", value: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01"},
+		{desc: "Zero span id.", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			sc, ok := ParseTraceparent(test.value)
+			assert.Equal(t, test.isValid, ok)
+			if test.isValid {
+				assert.Equal(t, test.value, sc.Traceparent())
+			}
+		})
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	exporter := &fakeExporter{}
+	tracer := NewTracer("test", exporter, time.Hour)
+	tracer.Start()
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteParent(context.Background(), remote)
+
+	ctx, parent := tracer.StartSpan(ctx, "parent", KindServer)
+	_, child := tracer.StartSpan(ctx, "child", KindClient)
+	child.RecordError(fmt.Errorf("boom"))
+	child.Finish()
+	parent.Finish()
+	parent.Finish()
+
+	// Stopping exports everything that's left
+	tracer.Stop()
+
+	assert.Equal(t, []*Span{child, parent}, exporter.spans)
+	assert.Equal(t, remote.TraceID, parent.TraceID)
+	assert.Equal(t, remote.SpanID, parent.ParentSpanID)
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.Equal(t, parent.SpanID, child.ParentSpanID)
+	assert.Equal(t, "boom", child.Error)
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.StartSpan(context.Background(), "nothing", KindInternal)
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+
+	// None of these should panic
+	span.SetAttribute("key", "value")
+	span.RecordError(fmt.Errorf("boom"))
+	span.Finish()
+}
+
+func TestWriterExporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	span := &Span{Name: "GET /channels", Kind: KindServer, Attributes: map[string]interface{}{"http.status_code": 200}}
+
+	assert.Nil(t, NewWriterExporter(buf).Export("test", []*Span{span}))
+
+	line := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "test", line["service"])
+	assert.Equal(t, "GET /channels", line["name"])
+	assert.Equal(t, "server", line["kind"])
+	assert.NotContains(t, line, "parentSpanId")
+}
+
+func TestOTLPHTTPExporter(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	span := &Span{
+		Name:         "Backend.GetChannel",
+		Kind:         KindClient,
+		TraceID:      TraceID{1},
+		SpanID:       SpanID{2},
+		ParentSpanID: SpanID{3},
+		Start:        time.Unix(1, 0),
+		End:          time.Unix(2, 0),
+		Attributes:   map[string]interface{}{"b": 1, "a": true},
+		Error:        "boom",
+	}
+	assert.Nil(t, NewOTLPHTTPExporter(server.URL, time.Second).Export("test", []*Span{span}))
+
+	expected := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"test"}}]},` +
+		`"scopeSpans":[{"scope":{"name":"github.com/andrew-boutin/dndtextapi/tracing"},"spans":[{` +
+		`"traceId":"01000000000000000000000000000000","spanId":"0200000000000000","parentSpanId":"0300000000000000",` +
+		`"name":"Backend.GetChannel","kind":3,"startTimeUnixNano":"1000000000","endTimeUnixNano":"2000000000",` +
+		`"attributes":[{"key":"a","value":{"boolValue":true}},{"key":"b","value":{"intValue":"1"}}],` +
+		`"status":{"code":2,"message":"boom"}}]}]}]}`
+	assert.Equal(t, expected, string(body))
+}
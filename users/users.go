@@ -10,6 +10,9 @@ import (
 // ErrUserNotFound is the error to use when the User is not found.
 var ErrUserNotFound = fmt.Errorf("user not found")
 
+// ErrUserIsAdmin is the error to use when an admin tries to change another admin.
+var ErrUserIsAdmin = fmt.Errorf("admins can't be changed by other admins")
+
 // User holds User info
 type User struct {
 	ID          int       `json:"ID" db:"id"`
@@ -36,6 +39,95 @@ func (uc UserCollection) GetUserIDs() []int {
 	return ids
 }
 
+// PublicUser is the public profile of a User that any other User can see.
+type PublicUser struct {
+	ID        int       `json:"ID"`
+	Username  string    `json:"Username"`
+	Bio       string    `json:"Bio"`
+	CreatedOn time.Time `json:"CreatedOn"`
+}
+
+// SelfUser is what a User sees about themselves. Admin only fields such as
+// IsAdmin and IsBanned are left out - the full User is only used under /admin.
+type SelfUser struct {
+	PublicUser
+	Email       string    `json:"Email"`
+	LastLogin   time.Time `json:"LastLogin"`
+	LastUpdated time.Time `json:"LastUpdated"`
+}
+
+// PublicView creates the public profile of the User.
+func (u *User) PublicView() *PublicUser {
+	return &PublicUser{
+		ID:        u.ID,
+		Username:  u.Username,
+		Bio:       u.Bio,
+		CreatedOn: u.CreatedOn,
+	}
+}
+
+// SelfView creates the view of the User for themselves.
+func (u *User) SelfView() *SelfUser {
+	return &SelfUser{
+		PublicUser:  *u.PublicView(),
+		Email:       u.Email,
+		LastLogin:   u.LastLogin,
+		LastUpdated: u.LastUpdated,
+	}
+}
+
+// ViewFor picks the view of the User that the viewer is allowed to see outside
+// of /admin. Users see everything about themselves and only the public profile
+// of everyone else, even when they're an admin.
+func (u *User) ViewFor(viewer *User) interface{} {
+	if viewer != nil && viewer.ID == u.ID {
+		return u.SelfView()
+	}
+	return u.PublicView()
+}
+
+// SortField is what Users can be listed in order of.
+type SortField string
+
+// The SortFields Users can be listed by.
+const (
+	SortByID        SortField = "id"
+	SortByUsername  SortField = "username"
+	SortByLastLogin SortField = "lastLogin"
+	SortByCreatedOn SortField = "createdOn"
+)
+
+// AllSortFields are all of the SortFields Users can be listed by.
+var AllSortFields = []SortField{
+	SortByID,
+	SortByUsername,
+	SortByLastLogin,
+	SortByCreatedOn,
+}
+
+// Filter limits which Users are retrieved and what order they're in. Empty
+// fields don't filter anything and Users are in order of ID by default.
+type Filter struct {
+	IsAdmin        *bool
+	IsBanned       *bool
+	LoggedInSince  *time.Time
+	LoggedInBefore *time.Time
+	Sort           SortField
+	Descending     bool
+	Limit          int
+	Offset         int
+}
+
+// IsValidSortField determines if Users can be listed by the SortField.
+func IsValidSortField(s SortField) bool {
+	for _, known := range AllSortFields {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
 // GoogleUser has all of the fields that we expect to come back from querying Google for User data.
 type GoogleUser struct {
 	ID            string `json:"id"`
@@ -4,6 +4,7 @@ package users
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -38,3 +39,52 @@ func TestGetUserIDs(t *testing.T) {
 		})
 	}
 }
+
+func TestViewFor(t *testing.T) {
+	now := time.Now()
+	user := &User{
+		ID:          1,
+		Username:    "user",
+		Email:       "user@fake.com",
+		Bio:         "bio",
+		IsAdmin:     true,
+		IsBanned:    true,
+		LastLogin:   now,
+		CreatedOn:   now,
+		LastUpdated: now,
+	}
+	public := &PublicUser{ID: 1, Username: "user", Bio: "bio", CreatedOn: now}
+
+	testIO := []struct {
+		desc     string
+		viewer   *User
+		expected interface{}
+	}{
+		{
+			desc:     "Users see everything but admin fields about themselves",
+			viewer:   &User{ID: 1},
+			expected: &SelfUser{PublicUser: *public, Email: "user@fake.com", LastLogin: now, LastUpdated: now},
+		},
+		{
+			desc:     "Other Users see the public profile",
+			viewer:   &User{ID: 2},
+			expected: public,
+		},
+		{
+			desc:     "Admins see the public profile outside of admin routes",
+			viewer:   &User{ID: 3, IsAdmin: true},
+			expected: public,
+		},
+		{
+			desc:     "Anonymous viewers see the public profile",
+			viewer:   nil,
+			expected: public,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			assert.Equal(t, test.expected, user.ViewFor(test.viewer))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// ErrPrivateAddress is the error to use when a Webhook URL points at a loopback,
+// private, or otherwise internal address that shouldn't be sent requests.
+var ErrPrivateAddress = fmt.Errorf("webhook url resolves to a private address")
+
+// privateNetworks are the address ranges Webhooks aren't allowed to be sent to so
+// they can't be used to reach the server itself or anything on its network.
+var privateNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// isPrivate determines if the IP is in one of the private networks or isn't a
+// unicast address at all.
+func isPrivate(ip net.IP) bool {
+	if ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicIPs looks up the host and makes sure every address it resolves to is
+// public. A host that only resolves to some private addresses is still rejected
+// so the address that ends up being used doesn't matter.
+func publicIPs(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		if isPrivate(addr.IP) {
+			return nil, ErrPrivateAddress
+		}
+	}
+	return addrs, nil
+}
+
+// CheckURL makes sure the Webhook URL can be sent to. It's checked when the
+// Webhook is saved so owners find out right away, and the address is checked
+// again on every delivery in case the host starts resolving somewhere else.
+func (d *Dispatcher) CheckURL(ctx context.Context, rawURL string) error {
+	if d == nil || d.allowPrivate {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	_, err = publicIPs(ctx, u.Hostname())
+	return err
+}
+
+// dialPublic connects to the address only if the host resolves to public
+// addresses. The resolved address is dialed directly so the host can't resolve
+// somewhere else between the check and the connection.
+func dialPublic(timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs, err := publicIPs(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		return dialer.DialContext(ctx, network, net.JoinHostPort(addrs[0].IP.String(), port))
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Headers sent along with every delivery
+	eventHeader     = "X-DnDText-Event"
+	signatureHeader = "X-DnDText-Signature"
+	deliveryHeader  = "X-DnDText-Delivery"
+
+	queueSize = 256
+)
+
+// Store defines the data the Dispatcher needs in order to deliver Events
+// and keep track of how the deliveries went.
+type Store interface {
+	GetWebhooksInChannel(context.Context, int) (WebhookCollection, error)
+	CreateWebhookDelivery(context.Context, *Delivery) (*Delivery, error)
+	RecordWebhookSuccess(context.Context, int) error
+	RecordWebhookFailure(context.Context, int, int) error
+}
+
+// Listener is told about every dispatched Event in the background so other parts
+// of the app, such as the chat bridges, can react to what happens in Channels.
+type Listener func(channelID int, event Event, data interface{})
+
+// job is a single Event waiting to be sent out.
+type job struct {
+	channelID int
+	payload   Payload
+}
+
+// Dispatcher sends Events to all of the Webhooks in a Channel that are subscribed
+// to them. Deliveries happen in the background and are retried with backoff. A nil
+// Dispatcher is valid and discards everything.
+type Dispatcher struct {
+	store          Store
+	client         *http.Client
+	workers        int
+	maxAttempts    int
+	initialBackoff time.Duration
+	disableAfter   int
+	allowPrivate   bool
+
+	listeners []Listener
+
+	queue chan job
+	wg    sync.WaitGroup
+
+	// mu guards stopped so nothing is sent on the queue after it's closed
+	mu      sync.RWMutex
+	stopped bool
+
+	// ctx is canceled when Stop gives up on whatever hasn't been delivered yet
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewDispatcher creates a Dispatcher using the webhooks configuration.
+// Unless private addresses are allowed, deliveries are only ever sent to public
+// addresses.
+func NewDispatcher(store Store, c configs.WebhooksConfiguration) *Dispatcher {
+	client := &http.Client{Timeout: c.Timeout}
+	if !c.AllowPrivate {
+		client.Transport = &http.Transport{DialContext: dialPublic(c.Timeout)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		store:          store,
+		client:         client,
+		workers:        c.Workers,
+		maxAttempts:    c.MaxAttempts,
+		initialBackoff: c.InitialBackoff,
+		disableAfter:   c.DisableAfter,
+		allowPrivate:   c.AllowPrivate,
+		queue:          make(chan job, queueSize),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// AddListener adds a Listener to be told about every Event. Listeners have to
+// be added before Start is called.
+func (d *Dispatcher) AddListener(listener Listener) {
+	if d == nil {
+		return
+	}
+	d.listeners = append(d.listeners, listener)
+}
+
+// Start starts up the workers that deliver Events.
+func (d *Dispatcher) Start() {
+	if d == nil {
+		return
+	}
+
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for j := range d.queue {
+				// Once Stop gives up the rest of the queue is only drained
+				if d.ctx.Err() != nil {
+					continue
+				}
+				d.process(j)
+			}
+		}()
+	}
+}
+
+// Stop waits for everything already queued to be delivered until the context is
+// done. After that any deliveries left are given up on without counting against
+// their Webhooks. Events dispatched after it's called are dropped.
+func (d *Dispatcher) Stop(ctx context.Context) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	if !d.stopped {
+		d.stopped = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		log.Warn("Webhook deliveries didn't finish in time giving up on the rest.")
+		d.cancel()
+		<-finished
+	}
+	d.cancel()
+}
+
+// Dispatch queues up the Event to be sent to the Channel's Webhooks. The data is
+// whatever the Event is about such as the Message that was created.
+func (d *Dispatcher) Dispatch(channelID int, event Event, data interface{}) {
+	if d == nil {
+		return
+	}
+
+	j := job{
+		channelID: channelID,
+		payload: Payload{
+			Event:     event,
+			ChannelID: channelID,
+			Timestamp: time.Now(),
+			Data:      data,
+		},
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		log.WithField("event", event).WithField("channelID", channelID).Warn("Webhook dispatcher is stopped dropping event.")
+		return
+	}
+
+	// Never hold up the request that caused the Event
+	select {
+	case d.queue <- j:
+	default:
+		log.WithField("event", event).WithField("channelID", channelID).Error("Webhook queue is full dropping event.")
+	}
+}
+
+// process tells the Listeners about the job and then sends it to every enabled
+// Webhook in the Channel subscribed to it.
+func (d *Dispatcher) process(j job) {
+	for _, listener := range d.listeners {
+		listener(j.channelID, j.payload.Event, j.payload.Data)
+	}
+
+	ctx := context.Background()
+	hooks, err := d.store.GetWebhooksInChannel(ctx, j.channelID)
+	if err != nil {
+		log.WithError(err).WithField("channelID", j.channelID).Error("Failed to look up webhooks for channel.")
+		return
+	}
+
+	body, err := json.Marshal(j.payload)
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal webhook payload.")
+		return
+	}
+
+	for _, hook := range hooks {
+		if hook.IsDisabled || !hook.IsSubscribed(j.payload.Event) {
+			continue
+		}
+		d.deliver(ctx, hook, j.payload.Event, body)
+	}
+}
+
+// deliver sends the body to the Webhook retrying with exponential backoff. Every
+// attempt is recorded. Once the Webhook has failed too many deliveries in a row
+// it gets disabled.
+func (d *Dispatcher) deliver(ctx context.Context, hook *Webhook, event Event, body []byte) {
+	backoff := d.initialBackoff
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		statusCode, err := d.send(hook, event, body)
+		if err != nil && d.ctx.Err() != nil {
+			log.WithField("webhookID", hook.ID).Warn("Gave up on webhook delivery while stopping.")
+			return
+		}
+
+		delivery := &Delivery{
+			WebhookID:  hook.ID,
+			Event:      string(event),
+			Payload:    string(body),
+			Attempt:    attempt,
+			StatusCode: statusCode,
+			Succeeded:  err == nil,
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		_, logErr := d.store.CreateWebhookDelivery(ctx, delivery)
+		if logErr != nil {
+			log.WithError(logErr).WithField("webhookID", hook.ID).Error("Failed to record webhook delivery.")
+		}
+
+		if err == nil {
+			logErr = d.store.RecordWebhookSuccess(ctx, hook.ID)
+			if logErr != nil {
+				log.WithError(logErr).WithField("webhookID", hook.ID).Error("Failed to record webhook success.")
+			}
+			return
+		}
+
+		if attempt < d.maxAttempts {
+			select {
+			case <-time.After(backoff):
+			case <-d.ctx.Done():
+				log.WithField("webhookID", hook.ID).Warn("Gave up on webhook delivery while stopping.")
+				return
+			}
+			backoff *= 2
+		}
+	}
+
+	log.WithField("webhookID", hook.ID).Error("Webhook delivery failed after all attempts.")
+	err := d.store.RecordWebhookFailure(ctx, hook.ID, d.disableAfter)
+	if err != nil {
+		log.WithError(err).WithField("webhookID", hook.ID).Error("Failed to record webhook failure.")
+	}
+}
+
+// send makes a single signed request to the Webhook. Any non 2xx response
+// counts as a failure.
+func (d *Dispatcher) send(hook *Webhook, event Event, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(d.ctx)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(eventHeader, string(event))
+	req.Header.Set(deliveryHeader, strconv.FormatInt(time.Now().UnixNano(), 10))
+	req.Header.Set(signatureHeader, Sign(hook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrWebhookNotFound is the error to use when the Webhook is not found.
+var ErrWebhookNotFound = fmt.Errorf("webhook not found")
+
+// ErrUnknownEvent is the error to use when a Webhook subscribes to an Event
+// that doesn't exist.
+var ErrUnknownEvent = fmt.Errorf("unknown webhook event")
+
+// Event is something that happened in a Channel that Webhooks can subscribe to.
+type Event string
+
+// The Events that Webhooks can subscribe to.
+const (
+	MessageCreatedEvent  Event = "message.created"
+	MessageEditedEvent   Event = "message.edited"
+	MessageDeletedEvent  Event = "message.deleted"
+	CharacterJoinedEvent Event = "character.joined"
+	CharacterLeftEvent   Event = "character.left"
+	ChannelUpdatedEvent  Event = "channel.updated"
+)
+
+// AllEvents are all of the Events that Webhooks can subscribe to.
+var AllEvents = []Event{
+	MessageCreatedEvent,
+	MessageEditedEvent,
+	MessageDeletedEvent,
+	CharacterJoinedEvent,
+	CharacterLeftEvent,
+	ChannelUpdatedEvent,
+}
+
+// Webhook is a URL that a Channel owner registered to be sent Events from
+// their Channel.
+type Webhook struct {
+	ID           int            `json:"ID" db:"id"`
+	ChannelID    int            `json:"ChannelID" db:"channel_id"`
+	URL          string         `json:"URL" db:"url"`
+	Secret       string         `json:"Secret" db:"secret"`
+	Events       pq.StringArray `json:"Events" db:"events"`
+	IsDisabled   bool           `json:"IsDisabled" db:"is_disabled"`
+	FailureCount int            `json:"FailureCount" db:"failure_count"`
+	CreatedOn    time.Time      `json:"CreatedOn" db:"created_on"`
+	LastUpdated  time.Time      `json:"LastUpdated" db:"last_updated"`
+}
+
+// WebhookCollection is a collection of Webhooks.
+type WebhookCollection []*Webhook
+
+// IsSubscribed determines if the Webhook wants to be sent the Event.
+func (w *Webhook) IsSubscribed(event Event) bool {
+	for _, e := range w.Events {
+		if Event(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateEvents makes sure every Event the Webhook subscribes to exists.
+func (w *Webhook) ValidateEvents() error {
+	for _, e := range w.Events {
+		found := false
+		for _, known := range AllEvents {
+			if Event(e) == known {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return ErrUnknownEvent
+		}
+	}
+	return nil
+}
+
+// Delivery is a record of a single attempt to send an Event to a Webhook.
+type Delivery struct {
+	ID         int       `json:"ID" db:"id"`
+	WebhookID  int       `json:"WebhookID" db:"webhook_id"`
+	Event      string    `json:"Event" db:"event"`
+	Payload    string    `json:"Payload" db:"payload"`
+	Attempt    int       `json:"Attempt" db:"attempt"`
+	StatusCode int       `json:"StatusCode" db:"status_code"`
+	Error      string    `json:"Error" db:"error"`
+	Succeeded  bool      `json:"Succeeded" db:"succeeded"`
+	CreatedOn  time.Time `json:"CreatedOn" db:"created_on"`
+}
+
+// DeliveryCollection is a collection of Deliveries.
+type DeliveryCollection []*Delivery
+
+// Payload is the JSON body sent to Webhooks.
+type Payload struct {
+	Event     Event       `json:"Event"`
+	ChannelID int         `json:"ChannelID"`
+	Timestamp time.Time   `json:"Timestamp"`
+	Data      interface{} `json:"Data"`
+}
+
+// Sign creates the HMAC SHA256 signature for the body using the secret. Receivers
+// can compute the same value to verify that the request came from us.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,238 @@
+// Copyright (C) 2018, Baking Bits Studios - All Rights Reserved
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrew-boutin/dndtextapi/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	mu         sync.Mutex
+	hooks      WebhookCollection
+	deliveries []*Delivery
+	successes  int
+	failures   int
+}
+
+func (s *fakeStore) GetWebhooksInChannel(ctx context.Context, channelID int) (WebhookCollection, error) {
+	return s.hooks, nil
+}
+
+func (s *fakeStore) CreateWebhookDelivery(ctx context.Context, d *Delivery) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, d)
+	return d, nil
+}
+
+func (s *fakeStore) RecordWebhookSuccess(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.successes++
+	return nil
+}
+
+func (s *fakeStore) RecordWebhookFailure(ctx context.Context, id, disableAfter int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	// Known HMAC SHA256 of "body" using the key "secret"
+	expected := "sha256=dc46983557fea127b43af721467eb9b3fde2338fe3e14f51952aa8478c13d355"
+	assert.Equal(t, expected, Sign("secret", []byte("body")))
+	assert.NotEqual(t, expected, Sign("other", []byte("body")))
+}
+
+func TestDispatch(t *testing.T) {
+	testIO := []struct {
+		desc               string
+		failuresBeforeOK   int
+		events             []string
+		disabled           bool
+		blockPrivate       bool
+		expectedRequests   int
+		expectedDeliveries int
+		expectedSuccesses  int
+		expectedFailures   int
+	}{
+		{
+			desc:               "Successful delivery on the first attempt",
+			events:             []string{string(MessageCreatedEvent)},
+			expectedRequests:   1,
+			expectedDeliveries: 1,
+			expectedSuccesses:  1,
+		},
+		{
+			desc:               "Failed attempts are retried",
+			failuresBeforeOK:   2,
+			events:             []string{string(MessageCreatedEvent)},
+			expectedRequests:   3,
+			expectedDeliveries: 3,
+			expectedSuccesses:  1,
+		},
+		{
+			desc:               "Giving up records a failure",
+			failuresBeforeOK:   10,
+			events:             []string{string(MessageCreatedEvent)},
+			expectedRequests:   3,
+			expectedDeliveries: 3,
+			expectedFailures:   1,
+		},
+		{
+			desc:   "Unsubscribed events aren't sent",
+			events: []string{string(ChannelUpdatedEvent)},
+		},
+		{
+			desc:     "Disabled webhooks aren't sent anything",
+			events:   []string{string(MessageCreatedEvent)},
+			disabled: true,
+		},
+		{
+			desc:               "Private addresses aren't sent anything",
+			events:             []string{string(MessageCreatedEvent)},
+			blockPrivate:       true,
+			expectedDeliveries: 3,
+			expectedFailures:   1,
+		},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.desc, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				requests++
+
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.Equal(t, Sign("secret", body), r.Header.Get(signatureHeader))
+				assert.Equal(t, string(MessageCreatedEvent), r.Header.Get(eventHeader))
+
+				payload := Payload{}
+				assert.Nil(t, json.Unmarshal(body, &payload))
+				assert.Equal(t, 1, payload.ChannelID)
+
+				if requests <= test.failuresBeforeOK {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			store := &fakeStore{
+				hooks: WebhookCollection{
+					{ID: 1, ChannelID: 1, URL: server.URL, Secret: "secret", Events: test.events, IsDisabled: test.disabled},
+				},
+			}
+			dispatcher := NewDispatcher(store, configs.WebhooksConfiguration{
+				Workers:        1,
+				Timeout:        time.Second,
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				DisableAfter:   5,
+				AllowPrivate:   !test.blockPrivate,
+			})
+			dispatcher.Start()
+			dispatcher.Dispatch(1, MessageCreatedEvent, map[string]string{"Content": "hello"})
+			dispatcher.Stop(context.Background())
+
+			assert.Equal(t, test.expectedRequests, requests)
+			assert.Equal(t, test.expectedDeliveries, len(store.deliveries))
+			assert.Equal(t, test.expectedSuccesses, store.successes)
+			assert.Equal(t, test.expectedFailures, store.failures)
+		})
+	}
+}
+
+func TestDispatchAfterStop(t *testing.T) {
+	dispatcher := NewDispatcher(&fakeStore{}, configs.WebhooksConfiguration{Workers: 1, MaxAttempts: 1})
+	dispatcher.Start()
+	dispatcher.Stop(context.Background())
+
+	assert.NotPanics(t, func() {
+		dispatcher.Dispatch(1, MessageCreatedEvent, nil)
+		dispatcher.Stop(context.Background())
+	})
+}
+
+func TestStopGivesUpAtDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{
+		hooks: WebhookCollection{
+			{ID: 1, ChannelID: 1, URL: server.URL, Secret: "secret", Events: []string{string(MessageCreatedEvent)}},
+		},
+	}
+	dispatcher := NewDispatcher(store, configs.WebhooksConfiguration{
+		Workers:        1,
+		Timeout:        time.Second,
+		MaxAttempts:    5,
+		InitialBackoff: time.Minute,
+		DisableAfter:   5,
+		AllowPrivate:   true,
+	})
+	dispatcher.Start()
+	dispatcher.Dispatch(1, MessageCreatedEvent, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	dispatcher.Stop(ctx)
+
+	// The backoff isn't waited out and giving up isn't the Webhook's fault
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Equal(t, 0, store.failures)
+}
+
+func TestIsPrivate(t *testing.T) {
+	testIO := []struct {
+		ip       string
+		expected bool
+	}{
+		{ip: "127.0.0.1", expected: true},
+		{ip: "10.1.2.3", expected: true},
+		{ip: "172.16.0.1", expected: true},
+		{ip: "192.168.1.1", expected: true},
+		{ip: "169.254.169.254", expected: true},
+		{ip: "0.0.0.0", expected: true},
+		{ip: "::1", expected: true},
+		{ip: "fd00::1", expected: true},
+		{ip: "::ffff:127.0.0.1", expected: true},
+		{ip: "8.8.8.8", expected: false},
+		{ip: "2001:4860:4860::8888", expected: false},
+	}
+
+	for _, test := range testIO {
+		t.Run(test.ip, func(t *testing.T) {
+			assert.Equal(t, test.expected, isPrivate(net.ParseIP(test.ip)))
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	dispatcher := NewDispatcher(&fakeStore{}, configs.WebhooksConfiguration{})
+	assert.Equal(t, ErrPrivateAddress, dispatcher.CheckURL(context.Background(), "http://127.0.0.1:8080/hook"))
+	assert.Nil(t, dispatcher.CheckURL(context.Background(), "https://93.184.216.34/hook"))
+
+	allowing := NewDispatcher(&fakeStore{}, configs.WebhooksConfiguration{AllowPrivate: true})
+	assert.Nil(t, allowing.CheckURL(context.Background(), "http://127.0.0.1:8080/hook"))
+}